@@ -0,0 +1,207 @@
+package bluetooth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// CompanyIDApple is the Bluetooth SIG company identifier of Apple, used for iBeacon
+	CompanyIDApple uint16 = 0x004C
+
+	iBeaconType   = 0x02
+	iBeaconLength = 0x15
+
+	eddystoneFrameUID = 0x00
+	eddystoneFrameURL = 0x10
+	eddystoneFrameTLM = 0x20
+)
+
+// EddystoneServiceUUID is the UUID of the service data, which carries the Eddystone frames
+var EddystoneServiceUUID = mustParseUUID("FEAA")
+
+// IBeacon is the content of an iBeacon advertisement
+type IBeacon struct {
+	UUID    UUID
+	Major   uint16
+	Minor   uint16
+	TxPower int8 // calibrated signal strength at 1 m
+}
+
+// EddystoneUID is the content of an Eddystone-UID frame
+type EddystoneUID struct {
+	TxPower   int8 // calibrated signal strength at 0 m
+	Namespace [10]byte
+	Instance  [6]byte
+}
+
+// EddystoneURL is the content of an Eddystone-URL frame
+type EddystoneURL struct {
+	TxPower int8 // calibrated signal strength at 0 m
+	URL     string
+}
+
+// EddystoneTLM is the content of an unencrypted Eddystone-TLM frame
+type EddystoneTLM struct {
+	Version          uint8
+	BatteryVoltage   uint16  // in mV, 0 if not supported
+	Temperature      float64 // in °C, -128 if not supported
+	AdvertisingCount uint32  // since power-up or reboot
+	Uptime           time.Duration
+}
+
+// ManufacturerData is the content of the manufacturer specific data type of an advertisement
+type ManufacturerData struct {
+	CompanyID uint16
+	Data      []byte
+}
+
+var eddystoneURLSchemes = []string{"http://www.", "https://www.", "http://", "https://"}
+
+var eddystoneURLExpansions = []string{
+	".com/", ".org/", ".edu/", ".net/", ".info/", ".biz/", ".gov/",
+	".com", ".org", ".edu", ".net", ".info", ".biz", ".gov",
+}
+
+// ParseManufacturerData parses the payload of the manufacturer specific data type (0xFF) of an advertisement,
+// which starts with the company identifier in little endian
+func ParseManufacturerData(payload []byte) (ManufacturerData, error) {
+	if len(payload) < 2 {
+		return ManufacturerData{}, fmt.Errorf("%w: manufacturer data needs at least 2 bytes, got %d",
+			ErrInvalidLength, len(payload))
+	}
+
+	return ManufacturerData{
+		CompanyID: binary.LittleEndian.Uint16(payload),
+		Data:      append([]byte(nil), payload[2:]...),
+	}, nil
+}
+
+// ParseIBeacon parses the manufacturer data of Apple, without the company identifier, as iBeacon
+func ParseIBeacon(data []byte) (IBeacon, error) {
+	if len(data) != 2+iBeaconLength {
+		return IBeacon{}, fmt.Errorf("%w: iBeacon needs %d bytes, got %d", ErrInvalidLength, 2+iBeaconLength,
+			len(data))
+	}
+	if data[0] != iBeaconType || data[1] != iBeaconLength {
+		return IBeacon{}, fmt.Errorf("%w: no iBeacon (type 0x%02x, length 0x%02x)", ErrInvalidData, data[0], data[1])
+	}
+
+	u, err := uuid.FromBytes(data[2:18])
+	if err != nil {
+		return IBeacon{}, fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+
+	return IBeacon{
+		UUID:    UUID{UUID: u},
+		Major:   binary.BigEndian.Uint16(data[18:20]),
+		Minor:   binary.BigEndian.Uint16(data[20:22]),
+		TxPower: int8(data[22]),
+	}, nil
+}
+
+// ParseEddystoneUID parses the Eddystone service data as UID frame
+func ParseEddystoneUID(data []byte) (EddystoneUID, error) {
+	// the two reserved bytes at the end are optional
+	if err := checkEddystoneFrame(data, eddystoneFrameUID, 18); err != nil {
+		return EddystoneUID{}, err
+	}
+
+	frame := EddystoneUID{TxPower: int8(data[1])}
+	copy(frame.Namespace[:], data[2:12])
+	copy(frame.Instance[:], data[12:18])
+
+	return frame, nil
+}
+
+// ParseEddystoneURL parses the Eddystone service data as URL frame and expands the encoded URL
+func ParseEddystoneURL(data []byte) (EddystoneURL, error) {
+	if err := checkEddystoneFrame(data, eddystoneFrameURL, 3); err != nil {
+		return EddystoneURL{}, err
+	}
+	if int(data[2]) >= len(eddystoneURLSchemes) {
+		return EddystoneURL{}, fmt.Errorf("%w: unknown Eddystone URL scheme 0x%02x", ErrInvalidData, data[2])
+	}
+
+	var url strings.Builder
+	url.WriteString(eddystoneURLSchemes[data[2]])
+	for _, b := range data[3:] {
+		switch {
+		case int(b) < len(eddystoneURLExpansions):
+			url.WriteString(eddystoneURLExpansions[b])
+		case b > 0x20 && b < 0x7f:
+			url.WriteByte(b)
+		default:
+			return EddystoneURL{}, fmt.Errorf("%w: invalid Eddystone URL character 0x%02x", ErrInvalidData, b)
+		}
+	}
+
+	return EddystoneURL{TxPower: int8(data[1]), URL: url.String()}, nil
+}
+
+// ParseEddystoneTLM parses the Eddystone service data as unencrypted TLM frame
+func ParseEddystoneTLM(data []byte) (EddystoneTLM, error) {
+	if err := checkEddystoneFrame(data, eddystoneFrameTLM, 14); err != nil {
+		return EddystoneTLM{}, err
+	}
+	if data[1] != 0x00 {
+		return EddystoneTLM{}, fmt.Errorf("%w: unsupported Eddystone TLM version 0x%02x", ErrInvalidData, data[1])
+	}
+
+	return EddystoneTLM{
+		Version:        data[1],
+		BatteryVoltage: binary.BigEndian.Uint16(data[2:4]),
+		// signed 8.8 fixed point
+		Temperature:      float64(int16(binary.BigEndian.Uint16(data[4:6]))) / 256,
+		AdvertisingCount: binary.BigEndian.Uint32(data[6:10]),
+		Uptime:           time.Duration(binary.BigEndian.Uint32(data[10:14])) * 100 * time.Millisecond,
+	}, nil
+}
+
+// IBeacon returns the iBeacon content of the advertisement, if it is an iBeacon
+func (a Advertisement) IBeacon() (IBeacon, bool) {
+	data, ok := a.ManufacturerData[CompanyIDApple]
+	if !ok {
+		return IBeacon{}, false
+	}
+
+	beacon, err := ParseIBeacon(data)
+	return beacon, err == nil
+}
+
+// EddystoneUID returns the content of the Eddystone-UID frame of the advertisement, if any
+func (a Advertisement) EddystoneUID() (EddystoneUID, bool) {
+	frame, err := ParseEddystoneUID(a.ServiceData[EddystoneServiceUUID])
+	return frame, err == nil
+}
+
+// EddystoneURL returns the content of the Eddystone-URL frame of the advertisement, if any
+func (a Advertisement) EddystoneURL() (EddystoneURL, bool) {
+	frame, err := ParseEddystoneURL(a.ServiceData[EddystoneServiceUUID])
+	return frame, err == nil
+}
+
+// EddystoneTLM returns the content of the Eddystone-TLM frame of the advertisement, if any
+func (a Advertisement) EddystoneTLM() (EddystoneTLM, bool) {
+	frame, err := ParseEddystoneTLM(a.ServiceData[EddystoneServiceUUID])
+	return frame, err == nil
+}
+
+func checkEddystoneFrame(data []byte, frameType byte, minLength int) error {
+	if len(data) < 1 {
+		return fmt.Errorf("%w: empty Eddystone frame", ErrInvalidLength)
+	}
+	if data[0] != frameType {
+		return fmt.Errorf("%w: Eddystone frame type 0x%02x, expected 0x%02x", ErrInvalidData, data[0], frameType)
+	}
+	if len(data) < minLength {
+		return fmt.Errorf("%w: Eddystone frame 0x%02x needs at least %d bytes, got %d", ErrInvalidLength,
+			frameType, minLength, len(data))
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package bluetooth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testIBeaconData = []byte{
+	0x02, 0x15,
+	0xf7, 0x82, 0x6d, 0xa6, 0x4f, 0xa2, 0x4e, 0x98, 0x80, 0x24, 0xbc, 0x5b, 0x71, 0xe0, 0x89, 0x3e,
+	0x00, 0x01, // major
+	0x01, 0x02, // minor
+	0xc5, // -59 dBm
+}
+
+func TestParseManufacturerData(t *testing.T) {
+	// act
+	got, err := ParseManufacturerData([]byte{0x4c, 0x00, 0x02, 0x15})
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, ManufacturerData{CompanyID: CompanyIDApple, Data: []byte{0x02, 0x15}}, got)
+	_, err = ParseManufacturerData([]byte{0x4c})
+	require.ErrorIs(t, err, ErrInvalidLength)
+}
+
+func TestParseIBeacon(t *testing.T) {
+	tests := map[string]struct {
+		data    []byte
+		want    IBeacon
+		wantErr error
+	}{
+		"ibeacon": {
+			data: testIBeaconData,
+			want: IBeacon{
+				UUID:    mustParseUUID("f7826da6-4fa2-4e98-8024-bc5b71e0893e"),
+				Major:   1,
+				Minor:   258,
+				TxPower: -59,
+			},
+		},
+		"error_length": {
+			data:    testIBeaconData[:22],
+			wantErr: ErrInvalidLength,
+		},
+		"error_type": {
+			data:    append([]byte{0x03}, testIBeaconData[1:]...),
+			wantErr: ErrInvalidData,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// act
+			got, err := ParseIBeacon(tc.data)
+			// assert
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseEddystoneUID(t *testing.T) {
+	// arrange
+	data := []byte{
+		0x00, 0xee,
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a,
+		0x11, 0x12, 0x13, 0x14, 0x15, 0x16,
+		0x00, 0x00,
+	}
+	// act
+	got, err := ParseEddystoneUID(data)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, EddystoneUID{
+		TxPower:   -18,
+		Namespace: [10]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a},
+		Instance:  [6]byte{0x11, 0x12, 0x13, 0x14, 0x15, 0x16},
+	}, got)
+	_, err = ParseEddystoneUID(data[:17])
+	require.ErrorIs(t, err, ErrInvalidLength)
+	_, err = ParseEddystoneUID([]byte{0x10, 0xee, 0x00})
+	require.ErrorIs(t, err, ErrInvalidData)
+}
+
+func TestParseEddystoneURL(t *testing.T) {
+	tests := map[string]struct {
+		data    []byte
+		want    string
+		wantErr error
+	}{
+		"expansion": {
+			data: append([]byte{0x10, 0xeb, 0x03}, append([]byte("gobot"), 0x01, 'x', 0x00)...),
+			want: "https://gobot.org/x.com/",
+		},
+		"plain": {
+			data: append([]byte{0x10, 0xeb, 0x00}, []byte("example.io")...),
+			want: "http://www.example.io",
+		},
+		"error_scheme": {
+			data:    []byte{0x10, 0xeb, 0x04},
+			wantErr: ErrInvalidData,
+		},
+		"error_character": {
+			data:    []byte{0x10, 0xeb, 0x02, 0x20},
+			wantErr: ErrInvalidData,
+		},
+		"error_length": {
+			data:    []byte{0x10, 0xeb},
+			wantErr: ErrInvalidLength,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// act
+			got, err := ParseEddystoneURL(tc.data)
+			// assert
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, EddystoneURL{TxPower: -21, URL: tc.want}, got)
+		})
+	}
+}
+
+func TestParseEddystoneTLM(t *testing.T) {
+	// arrange
+	data := []byte{
+		0x20, 0x00,
+		0x0b, 0xb8, // 3000 mV
+		0xfe, 0x80, // -1.5 °C
+		0x00, 0x00, 0x01, 0x00, // 256 advertisements
+		0x00, 0x00, 0x00, 0x64, // 10 s
+	}
+	// act
+	got, err := ParseEddystoneTLM(data)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, EddystoneTLM{
+		BatteryVoltage:   3000,
+		Temperature:      -1.5,
+		AdvertisingCount: 256,
+		Uptime:           10 * time.Second,
+	}, got)
+	_, err = ParseEddystoneTLM(append([]byte{0x20, 0x01}, data[2:]...))
+	require.ErrorIs(t, err, ErrInvalidData)
+	_, err = ParseEddystoneTLM(data[:13])
+	require.ErrorIs(t, err, ErrInvalidLength)
+}
+
+func TestAdvertisementBeacons(t *testing.T) {
+	// arrange
+	adv := Advertisement{
+		ManufacturerData: map[uint16][]byte{CompanyIDApple: testIBeaconData},
+		ServiceData:      map[UUID][]byte{EddystoneServiceUUID: append([]byte{0x10, 0x00, 0x01}, []byte("gobot.io")...)},
+	}
+	// act & assert
+	beacon, ok := adv.IBeacon()
+	assert.True(t, ok)
+	assert.Equal(t, uint16(258), beacon.Minor)
+	url, ok := adv.EddystoneURL()
+	assert.True(t, ok)
+	assert.Equal(t, "https://www.gobot.io", url.URL)
+	_, ok = adv.EddystoneUID()
+	assert.False(t, ok)
+	_, ok = adv.EddystoneTLM()
+	assert.False(t, ok)
+	_, ok = Advertisement{}.IBeacon()
+	assert.False(t, ok)
+}
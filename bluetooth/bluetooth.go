@@ -121,6 +121,8 @@ type ScanParams struct {
 	Window           time.Duration
 	ActiveScan       bool
 	FilterDuplicates bool
+	DuplicateWindow  time.Duration // unchanged advertisements are reported again after the window, 0 for never
+	Filter           ScanFilter
 }
 
 // DefaultScanParams returns default scan parameters
@@ -197,6 +199,12 @@ type Characteristic interface {
 	Descriptors() []Descriptor
 }
 
+// OffsetCharacteristic is implemented by characteristics, which can read and write a part of a long value
+type OffsetCharacteristic interface {
+	ReadWithOffset(ctx context.Context, offset uint16) ([]byte, error)
+	WriteWithOffset(ctx context.Context, data []byte, offset uint16) error
+}
+
 // Service represents a GATT service
 type Service interface {
 	UUID() UUID
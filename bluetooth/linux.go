@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"os"
 	"slices"
 	"strings"
 	"sync"
@@ -30,9 +31,10 @@ const (
 
 // linuxManager implements Manager for Linux using BlueZ
 type linuxManager struct {
-	conn     *dbus.Conn
-	mu       sync.RWMutex
-	adapters map[dbus.ObjectPath]*linuxAdapter
+	conn          *dbus.Conn
+	mu            sync.RWMutex
+	adapters      map[dbus.ObjectPath]*linuxAdapter
+	notifications *linuxNotifications
 }
 
 // linuxAdapter implements Adapter for Linux
@@ -55,10 +57,15 @@ type linuxCentral struct {
 
 // linuxPeripheral implements Peripheral for Linux
 type linuxPeripheral struct {
-	adapter     *linuxAdapter
-	advertising bool
-	services    map[dbus.ObjectPath]*linuxPeripheralService
-	mu          sync.RWMutex
+	adapter      *linuxAdapter
+	advertising  bool
+	registered   bool // GATT application registered at BlueZ
+	services     map[dbus.ObjectPath]*linuxPeripheralService
+	onConnect    func(Device)
+	onDisconnect func(Device)
+	connSignals  chan *dbus.Signal
+	advMu        sync.Mutex // serializes start and stop of advertising
+	mu           sync.RWMutex
 }
 
 // linuxDevice implements Device for Linux
@@ -68,6 +75,9 @@ type linuxDevice struct {
 	properties map[string]dbus.Variant
 	services   map[dbus.ObjectPath]*linuxService
 	connected  bool
+	filter     []UUID
+	watch      *linuxDeviceWatch
+	mtu        uint16 // negotiated ATT MTU, 0 if not known
 	mu         sync.RWMutex
 }
 
@@ -89,6 +99,8 @@ type linuxCharacteristic struct {
 	properties  map[string]dbus.Variant
 	descriptors map[dbus.ObjectPath]*linuxDescriptor
 	subscribed  bool
+	writer      *os.File   // acquired for writes without response
+	acquireMu   sync.Mutex // serializes the acquiring of the writer
 	mu          sync.RWMutex
 }
 
@@ -121,6 +133,8 @@ type linuxPeripheralCharacteristic struct {
 	onWrite       func([]byte) error
 	onSubscribe   func()
 	onUnsubscribe func()
+	props         *prop.Properties // set while exported at D-Bus
+	notifying     bool
 	mu            sync.RWMutex
 }
 
@@ -131,10 +145,7 @@ func getPlatformManager() (Manager, error) {
 		return nil, fmt.Errorf("failed to connect to system bus: %w", err)
 	}
 
-	manager := &linuxManager{
-		conn:     conn,
-		adapters: make(map[dbus.ObjectPath]*linuxAdapter),
-	}
+	manager := newLinuxManager(conn)
 
 	if err := manager.discoverAdapters(); err != nil {
 		return nil, fmt.Errorf("failed to discover adapters: %w", err)
@@ -143,6 +154,14 @@ func getPlatformManager() (Manager, error) {
 	return manager, nil
 }
 
+func newLinuxManager(conn *dbus.Conn) *linuxManager {
+	return &linuxManager{
+		conn:          conn,
+		adapters:      make(map[dbus.ObjectPath]*linuxAdapter),
+		notifications: newLinuxNotifications(conn),
+	}
+}
+
 func (m *linuxManager) discoverAdapters() error {
 	obj := m.conn.Object(bluezService, bluezObjectPath)
 
@@ -292,6 +311,8 @@ func (c *linuxCentral) Disable(ctx context.Context) error {
 	return c.adapter.SetPowerState(false)
 }
 
+// Scan discovers devices until the timeout of the parameters is elapsed or the context is done. The filter of the
+// parameters is pushed down to BlueZ as discovery filter, the manufacturer IDs are only checked locally.
 func (c *linuxCentral) Scan(ctx context.Context, params ScanParams, callback func(Advertisement)) error {
 	c.mu.Lock()
 	if c.scanning {
@@ -309,20 +330,27 @@ func (c *linuxCentral) Scan(ctx context.Context, params ScanParams, callback fun
 
 	obj := c.adapter.manager.conn.Object(bluezService, c.adapter.path)
 
-	// Set scan filter properties if needed
-	propsIface, _ := prop.Export(c.adapter.manager.conn, c.adapter.path, prop.Map{})
-	if params.FilterDuplicates {
-		propsIface.Set(adapterInterface, "DuplicateData", dbus.MakeVariant(false))
+	call := obj.CallWithContext(ctx, adapterInterface+".SetDiscoveryFilter", 0, discoveryFilter(params))
+	if call.Err != nil {
+		return fmt.Errorf("failed to set discovery filter: %w", call.Err)
 	}
+	// an empty filter resets the discovery filter of this client
+	defer func() { _ = obj.Call(adapterInterface+".SetDiscoveryFilter", 0, map[string]dbus.Variant{}).Err }()
+
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// listen before starting, so the first advertisements are not missed
+	signalChan := c.watchDeviceDiscovery()
 
 	// Start discovery
 	err := obj.Call(adapterInterface+".StartDiscovery", 0).Err
 	if err != nil {
+		c.stopWatchDeviceDiscovery(signalChan)
 		return fmt.Errorf("failed to start discovery: %w", err)
 	}
 
-	// Set up signal monitoring for device discovery
-	go c.monitorDeviceDiscovery(ctx, callback)
+	go c.monitorDeviceDiscovery(scanCtx, signalChan, scanCallback(params, callback))
 
 	// Wait for timeout or context cancellation
 	timer := time.NewTimer(params.Timeout)
@@ -336,21 +364,53 @@ func (c *linuxCentral) Scan(ctx context.Context, params ScanParams, callback fun
 	}
 }
 
-func (c *linuxCentral) monitorDeviceDiscovery(ctx context.Context, callback func(Advertisement)) {
-	// Set up D-Bus signal monitoring for device discovery
+// discoveryFilter returns the discovery filter of BlueZ for the scan parameters
+func discoveryFilter(params ScanParams) map[string]dbus.Variant {
+	filter := map[string]dbus.Variant{
+		"Transport": dbus.MakeVariant("le"),
+		// duplicates need to be reported by BlueZ, if they are suppressed only within a window
+		"DuplicateData": dbus.MakeVariant(!params.FilterDuplicates || params.DuplicateWindow > 0),
+	}
+
+	if len(params.Filter.ServiceUUIDs) > 0 {
+		uuids := make([]string, 0, len(params.Filter.ServiceUUIDs))
+		for _, u := range params.Filter.ServiceUUIDs {
+			uuids = append(uuids, u.String())
+		}
+		filter["UUIDs"] = dbus.MakeVariant(uuids)
+	}
+	if params.Filter.MinRSSI != 0 {
+		filter["RSSI"] = dbus.MakeVariant(params.Filter.MinRSSI)
+	}
+	if params.Filter.NamePrefix != "" {
+		filter["Pattern"] = dbus.MakeVariant(params.Filter.NamePrefix)
+	}
+
+	return filter
+}
+
+// watchDeviceDiscovery subscribes to the InterfacesAdded and PropertiesChanged signals of devices
+func (c *linuxCentral) watchDeviceDiscovery() chan *dbus.Signal {
 	signalChan := make(chan *dbus.Signal, 10)
 	c.adapter.manager.conn.Signal(signalChan)
-	
-	// Subscribe to InterfacesAdded and PropertiesChanged signals
+
 	c.adapter.manager.conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0,
 		"type='signal',interface='org.freedesktop.DBus.ObjectManager',member='InterfacesAdded'")
 	c.adapter.manager.conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0,
 		"type='signal',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged',arg0='org.bluez.Device1'")
-	
-	defer func() {
-		c.adapter.manager.conn.RemoveSignal(signalChan)
-		close(signalChan)
-	}()
+
+	return signalChan
+}
+
+func (c *linuxCentral) stopWatchDeviceDiscovery(signalChan chan *dbus.Signal) {
+	c.adapter.manager.conn.RemoveSignal(signalChan)
+	close(signalChan)
+}
+
+func (c *linuxCentral) monitorDeviceDiscovery(ctx context.Context, signalChan chan *dbus.Signal,
+	callback func(Advertisement),
+) {
+	defer c.stopWatchDeviceDiscovery(signalChan)
 
 	for {
 		select {
@@ -360,7 +420,7 @@ func (c *linuxCentral) monitorDeviceDiscovery(ctx context.Context, callback func
 			if sig == nil {
 				continue
 			}
-			
+
 			switch sig.Name {
 			case "org.freedesktop.DBus.ObjectManager.InterfacesAdded":
 				c.handleInterfacesAdded(sig, callback)
@@ -630,6 +690,10 @@ func (d *linuxDevice) Disconnect(ctx context.Context) error {
 		return fmt.Errorf("failed to disconnect device: %w", call.Err)
 	}
 
+	d.central.adapter.manager.notifications.removeDevice(d.path)
+	d.stopWatch()
+	d.releaseAcquired()
+
 	d.mu.Lock()
 	d.connected = false
 	d.mu.Unlock()
@@ -660,30 +724,6 @@ func (d *linuxDevice) GetService(uuid UUID) (Service, error) {
 	return nil, ErrServiceNotFound
 }
 
-func (d *linuxDevice) DiscoverServices(ctx context.Context, uuids []UUID) error {
-	// Discover GATT services via D-Bus
-	obj := d.central.adapter.manager.conn.Object(bluezService, d.path)
-
-	call := obj.CallWithContext(ctx, deviceInterface+".Connect", 0)
-	if call.Err != nil {
-		return fmt.Errorf("failed to connect for service discovery: %w", call.Err)
-	}
-
-	d.mu.Lock()
-	d.connected = true
-	d.mu.Unlock()
-
-	return nil
-}
-
-func (d *linuxDevice) RequestMTU(ctx context.Context, mtu uint16) error {
-	return ErrOperationNotSupported
-}
-
-func (d *linuxDevice) GetMTU() uint16 {
-	return 23 // Default ATT MTU
-}
-
 // linuxService implementation
 func (s *linuxService) UUID() UUID {
 	s.mu.RLock()
@@ -773,6 +813,8 @@ func (c *linuxCharacteristic) Properties() CharacteristicProperty {
 	return 0
 }
 
+// Read returns the complete value of the characteristic, BlueZ uses "read blob" requests for values longer than
+// the MTU allows in one response
 func (c *linuxCharacteristic) Read(ctx context.Context) ([]byte, error) {
 	obj := c.service.device.central.adapter.manager.conn.Object(bluezService, c.path)
 
@@ -790,6 +832,8 @@ func (c *linuxCharacteristic) Read(ctx context.Context) ([]byte, error) {
 	return value, nil
 }
 
+// Write writes the complete value of the characteristic with response, BlueZ uses prepared writes for data longer
+// than the MTU allows in one request
 func (c *linuxCharacteristic) Write(ctx context.Context, data []byte) error {
 	obj := c.service.device.central.adapter.manager.conn.Object(bluezService, c.path)
 
@@ -801,7 +845,18 @@ func (c *linuxCharacteristic) Write(ctx context.Context, data []byte) error {
 	return nil
 }
 
+// WriteWithoutResponse writes the data by the file descriptor acquired from BlueZ, if offered for the
+// characteristic, otherwise by a D-Bus call. The data must fit into the negotiated MTU, see GetMTU.
 func (c *linuxCharacteristic) WriteWithoutResponse(ctx context.Context, data []byte) error {
+	if f, err := c.acquireWrite(ctx); err == nil {
+		if _, err := f.Write(data); err != nil {
+			// BlueZ closes the file descriptor e.g. on disconnect, so it is acquired again on next write
+			c.releaseWrite()
+			return fmt.Errorf("failed to write characteristic without response: %w", err)
+		}
+		return nil
+	}
+
 	obj := c.service.device.central.adapter.manager.conn.Object(bluezService, c.path)
 
 	options := map[string]dbus.Variant{
@@ -816,11 +871,43 @@ func (c *linuxCharacteristic) WriteWithoutResponse(ctx context.Context, data []b
 	return nil
 }
 
+// Subscribe starts the notifications or indications of the characteristic. The callback is called with each
+// new value, until Unsubscribe is called or the device is disconnected. The notifications are received by the file
+// descriptor acquired from BlueZ, if offered for the characteristic, otherwise by D-Bus signals. The callback of an
+// active subscription is just replaced.
 func (c *linuxCharacteristic) Subscribe(ctx context.Context, callback func([]byte)) error {
-	obj := c.service.device.central.adapter.manager.conn.Object(bluezService, c.path)
+	if callback == nil {
+		return fmt.Errorf("a callback is mandatory for notifications")
+	}
+
+	manager := c.service.device.central.adapter.manager
+
+	// listen before starting, so the first notifications are not missed
+	if err := manager.notifications.add(c, c.service.device.path, callback); err != nil {
+		return err
+	}
 
+	c.mu.RLock()
+	subscribed := c.subscribed
+	c.mu.RUnlock()
+	if subscribed {
+		return nil
+	}
+
+	if f, mtu, err := c.acquireNotify(ctx); err == nil {
+		manager.notifications.attach(c.path, f, mtu)
+
+		c.mu.Lock()
+		c.subscribed = true
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	obj := manager.conn.Object(bluezService, c.path)
 	call := obj.CallWithContext(ctx, gattCharInterface+".StartNotify", 0)
 	if call.Err != nil {
+		manager.notifications.remove(c.path)
 		return fmt.Errorf("failed to start notifications: %w", call.Err)
 	}
 
@@ -831,18 +918,27 @@ func (c *linuxCharacteristic) Subscribe(ctx context.Context, callback func([]byt
 	return nil
 }
 
+// Unsubscribe stops the notifications or indications of the characteristic, the callback is not called
+// anymore, even if stopping fails at BlueZ
 func (c *linuxCharacteristic) Unsubscribe(ctx context.Context) error {
-	obj := c.service.device.central.adapter.manager.conn.Object(bluezService, c.path)
+	manager := c.service.device.central.adapter.manager
+	acquired := manager.notifications.remove(c.path)
+
+	c.mu.Lock()
+	c.subscribed = false
+	c.mu.Unlock()
 
+	// BlueZ stops the acquired notifications, when the file descriptor is closed
+	if acquired {
+		return nil
+	}
+
+	obj := manager.conn.Object(bluezService, c.path)
 	call := obj.CallWithContext(ctx, gattCharInterface+".StopNotify", 0)
 	if call.Err != nil {
 		return fmt.Errorf("failed to stop notifications: %w", call.Err)
 	}
 
-	c.mu.Lock()
-	c.subscribed = false
-	c.mu.Unlock()
-
 	return nil
 }
 
@@ -909,8 +1005,15 @@ func (p *linuxPeripheral) Disable(ctx context.Context) error {
 	return p.adapter.SetPowerState(false)
 }
 
+// AddService adds a service to the GATT application, which is registered at BlueZ by StartAdvertising
 func (p *linuxPeripheral) AddService(uuid UUID, primary bool) (PeripheralService, error) {
-	// This would require registering a GATT service with BlueZ
+	p.mu.RLock()
+	registered := p.registered
+	p.mu.RUnlock()
+	if registered {
+		return nil, fmt.Errorf("services can not be added while the GATT application is registered")
+	}
+
 	service := &linuxPeripheralService{
 		peripheral:      p,
 		uuid:            uuid,
@@ -918,7 +1021,7 @@ func (p *linuxPeripheral) AddService(uuid UUID, primary bool) (PeripheralService
 		characteristics: make(map[dbus.ObjectPath]*linuxPeripheralCharacteristic),
 	}
 
-	servicePath := dbus.ObjectPath(fmt.Sprintf("/org/gobot/service_%s", strings.ReplaceAll(uuid.String(), "-", "_")))
+	servicePath := dbus.ObjectPath(fmt.Sprintf("%s/service_%s", p.appPath(), strings.ReplaceAll(uuid.String(), "-", "_")))
 	service.path = servicePath
 
 	p.mu.Lock()
@@ -951,59 +1054,12 @@ func (p *linuxPeripheral) Services() []PeripheralService {
 	return services
 }
 
-func (p *linuxPeripheral) StartAdvertising(ctx context.Context, params AdvertisingParams, data AdvertisementData) error {
-	obj := p.adapter.manager.conn.Object(bluezService, p.adapter.path)
-
-	// Set discoverable and pairable
-	propsIface, _ := prop.Export(p.adapter.manager.conn, p.adapter.path, prop.Map{})
-	propsIface.Set(adapterInterface, "Discoverable", dbus.MakeVariant(params.Discoverable))
-	propsIface.Set(adapterInterface, "Pairable", dbus.MakeVariant(true))
-
-	if data.LocalName != "" {
-		propsIface.Set(adapterInterface, "Alias", dbus.MakeVariant(data.LocalName))
-	}
-
-	call := obj.CallWithContext(ctx, adapterInterface+".StartDiscovery", 0)
-	if call.Err != nil {
-		return fmt.Errorf("failed to start advertising: %w", call.Err)
-	}
-
-	p.mu.Lock()
-	p.advertising = true
-	p.mu.Unlock()
-
-	return nil
-}
-
-func (p *linuxPeripheral) StopAdvertising(ctx context.Context) error {
-	obj := p.adapter.manager.conn.Object(bluezService, p.adapter.path)
-
-	call := obj.CallWithContext(ctx, adapterInterface+".StopDiscovery", 0)
-	if call.Err != nil {
-		return fmt.Errorf("failed to stop advertising: %w", call.Err)
-	}
-
-	p.mu.Lock()
-	p.advertising = false
-	p.mu.Unlock()
-
-	return nil
-}
-
 func (p *linuxPeripheral) IsAdvertising() bool {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	return p.advertising
 }
 
-func (p *linuxPeripheral) OnConnect(callback func(Device)) {
-	// D-Bus signal monitoring for device connections not yet implemented
-}
-
-func (p *linuxPeripheral) OnDisconnect(callback func(Device)) {
-	// D-Bus signal monitoring for device disconnections not yet implemented
-}
-
 // linuxPeripheralService implementation
 func (s *linuxPeripheralService) UUID() UUID {
 	s.mu.RLock()
@@ -1086,12 +1142,6 @@ func (c *linuxPeripheralCharacteristic) SetValue(data []byte) error {
 	return nil
 }
 
-func (c *linuxPeripheralCharacteristic) NotifySubscribers(data []byte) error {
-	// This would send notifications to subscribed central devices
-	c.SetValue(data)
-	return nil
-}
-
 func (c *linuxPeripheralCharacteristic) OnRead(callback func() []byte) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
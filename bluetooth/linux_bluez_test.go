@@ -0,0 +1,565 @@
+//go:build linux
+
+package bluetooth
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	testAdapterPath = dbus.ObjectPath("/org/bluez/hci0")
+	testDevicePath  = dbus.ObjectPath("/org/bluez/hci0/dev_11_22_33_44_55_66")
+)
+
+// newTestBus starts a private session bus and returns its address, the test is skipped if no D-Bus daemon is
+// available
+func newTestBus(t *testing.T) string {
+	t.Helper()
+
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not available")
+	}
+
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("can not pipe dbus-daemon output: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("dbus-daemon can not be started: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("can not read the address of dbus-daemon: %v", err)
+	}
+
+	return strings.TrimSpace(address)
+}
+
+// newTestBusConn connects to the bus, the connection is closed on cleanup
+func newTestBusConn(t *testing.T, address string) *dbus.Conn {
+	t.Helper()
+
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("can not connect to the test bus: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+// testBlueZ is a stand-in for the BlueZ daemon, which owns the name "org.bluez" at the test bus and exports an
+// object tree of devices, services, characteristics and descriptors with the object manager at "/"
+type testBlueZ struct {
+	t       *testing.T
+	conn    *dbus.Conn
+	mu      sync.Mutex
+	calls   []string
+	fail    map[string]bool // "path.Method" to fail
+	objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	sockets map[string][2]*os.File  // both ends of acquired file descriptors by "path.Method"
+	mtu     uint16                  // returned with acquired file descriptors
+	filter  map[string]dbus.Variant // the last discovery filter
+
+	// the registered GATT application and advertisement of a peripheral
+	appOwner      string
+	application   map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	advertisement map[string]dbus.Variant
+}
+
+func newTestBlueZ(t *testing.T, address string) *testBlueZ {
+	t.Helper()
+
+	b := &testBlueZ{
+		t:       t,
+		conn:    newTestBusConn(t, address),
+		fail:    make(map[string]bool),
+		objects: make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant),
+		sockets: make(map[string][2]*os.File),
+		mtu:     247,
+	}
+	reply, err := b.conn.RequestName(bluezService, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("can not own %s at the test bus: %v", bluezService, err)
+	}
+	if err := b.conn.Export(&testBlueZObjectManager{bluez: b}, bluezRootPath, objectManagerInterface); err != nil {
+		t.Fatalf("can not export the object manager: %v", err)
+	}
+
+	return b
+}
+
+// addDevice exports a connected device object with the methods of org.bluez.Device1
+func (b *testBlueZ) addDevice(path dbus.ObjectPath) {
+	b.t.Helper()
+
+	b.addObject(path, deviceInterface, map[string]dbus.Variant{
+		"Connected":        dbus.MakeVariant(true),
+		"ServicesResolved": dbus.MakeVariant(false),
+	})
+}
+
+// addCharacteristic exports a characteristic object with the methods of org.bluez.GattCharacteristic1
+func (b *testBlueZ) addCharacteristic(path dbus.ObjectPath) {
+	b.t.Helper()
+
+	b.addObject(path, gattCharInterface, map[string]dbus.Variant{})
+}
+
+// addObject adds the object with the properties of the interface to the tree and exports the methods of the
+// interface, if needed by the tests
+func (b *testBlueZ) addObject(path dbus.ObjectPath, iface string, props map[string]dbus.Variant) {
+	b.t.Helper()
+
+	b.mu.Lock()
+	_, known := b.objects[path]
+	if !known {
+		b.objects[path] = make(map[string]map[string]dbus.Variant)
+	}
+	b.objects[path][iface] = props
+	b.mu.Unlock()
+
+	var err error
+	if !known {
+		err = b.conn.Export(&testBlueZProperties{bluez: b, path: path}, path, propertiesInterface)
+	}
+	switch iface {
+	case adapterInterface:
+		err = errors.Join(err, b.conn.Export(&testBlueZAdapter{bluez: b, path: path}, path, iface))
+	case deviceInterface:
+		err = errors.Join(err, b.conn.Export(&testBlueZDevice{bluez: b, path: path}, path, iface))
+	case gattCharInterface:
+		err = errors.Join(err, b.conn.Export(&testBlueZCharacteristic{bluez: b, path: path}, path, iface))
+	}
+	if err != nil {
+		b.t.Fatalf("can not export %s at %s: %v", iface, path, err)
+	}
+}
+
+// addObjectLater adds the object to the tree and emits the InterfacesAdded signal, like BlueZ does for objects
+// added after the services are resolved
+func (b *testBlueZ) addObjectLater(path dbus.ObjectPath, iface string, props map[string]dbus.Variant) {
+	b.t.Helper()
+
+	b.addObject(path, iface, props)
+	err := b.conn.Emit(bluezRootPath, objectManagerInterface+"."+interfacesAddedMember, path,
+		map[string]map[string]dbus.Variant{iface: props})
+	if err != nil {
+		b.t.Fatalf("can not emit added interfaces of %s: %v", path, err)
+	}
+}
+
+// removeObject removes the object from the tree and emits the InterfacesRemoved signal
+func (b *testBlueZ) removeObject(path dbus.ObjectPath) {
+	b.t.Helper()
+
+	b.mu.Lock()
+	var interfaces []string
+	for iface := range b.objects[path] {
+		interfaces = append(interfaces, iface)
+	}
+	delete(b.objects, path)
+	b.mu.Unlock()
+
+	err := b.conn.Emit(bluezRootPath, objectManagerInterface+"."+interfacesRemovedMember, path, interfaces)
+	if err != nil {
+		b.t.Fatalf("can not emit removed interfaces of %s: %v", path, err)
+	}
+}
+
+// setProperty changes the property of the object and emits the PropertiesChanged signal
+func (b *testBlueZ) setProperty(path dbus.ObjectPath, iface, name string, value interface{}) {
+	b.t.Helper()
+
+	b.mu.Lock()
+	if props, ok := b.objects[path][iface]; ok {
+		props[name] = dbus.MakeVariant(value)
+	}
+	b.mu.Unlock()
+
+	b.emitPropertiesChanged(path, iface, map[string]dbus.Variant{name: dbus.MakeVariant(value)})
+}
+
+// notify emits a value change of the characteristic, like BlueZ does for a notification of the remote device
+func (b *testBlueZ) notify(path dbus.ObjectPath, value []byte) {
+	b.t.Helper()
+
+	b.emitPropertiesChanged(path, gattCharInterface, map[string]dbus.Variant{"Value": dbus.MakeVariant(value)})
+}
+
+// disconnectRemote emits the change of the connection state, like BlueZ does when the remote device is gone
+func (b *testBlueZ) disconnectRemote(path dbus.ObjectPath) {
+	b.t.Helper()
+
+	b.emitPropertiesChanged(path, deviceInterface, map[string]dbus.Variant{"Connected": dbus.MakeVariant(false)})
+}
+
+func (b *testBlueZ) emitPropertiesChanged(path dbus.ObjectPath, iface string, changed map[string]dbus.Variant) {
+	b.t.Helper()
+
+	err := b.conn.Emit(path, propertiesInterface+"."+propertiesChangedMember, iface, changed, []string{})
+	if err != nil {
+		b.t.Fatalf("can not emit changed properties of %s: %v", path, err)
+	}
+}
+
+// call records the method call and returns an error, if the call should fail
+func (b *testBlueZ) call(path dbus.ObjectPath, method string) *dbus.Error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	call := string(path) + "." + method
+	b.calls = append(b.calls, call)
+	if b.fail[call] {
+		return dbus.NewError("org.bluez.Error.Failed", []interface{}{method + " failed"})
+	}
+	return nil
+}
+
+func (b *testBlueZ) failCall(path dbus.ObjectPath, method string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.fail[string(path)+"."+method] = true
+}
+
+func (b *testBlueZ) recordedCalls() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]string(nil), b.calls...)
+}
+
+// addAdapter exports an adapter object with the methods of org.bluez.GattManager1 and
+// org.bluez.LEAdvertisingManager1, which read the registered objects of the peripheral
+func (b *testBlueZ) addAdapter(path dbus.ObjectPath) {
+	b.t.Helper()
+
+	b.addObject(path, adapterInterface, map[string]dbus.Variant{"Powered": dbus.MakeVariant(true)})
+	err := errors.Join(
+		b.conn.Export(&testBlueZGattManager{bluez: b, path: path}, path, gattManagerInterface),
+		b.conn.Export(&testBlueZAdvertisingManager{bluez: b, path: path}, path, leAdvertisingManagerInterface),
+	)
+	if err != nil {
+		b.t.Fatalf("can not export adapter %s: %v", path, err)
+	}
+}
+
+// peripheralObject returns the object of the registered GATT application, like BlueZ uses it for requests of
+// remote devices
+func (b *testBlueZ) peripheralObject(path dbus.ObjectPath) dbus.BusObject {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.conn.Object(b.appOwner, path)
+}
+
+type testBlueZGattManager struct {
+	bluez *testBlueZ
+	path  dbus.ObjectPath
+}
+
+func (m *testBlueZGattManager) RegisterApplication(sender dbus.Sender, app dbus.ObjectPath,
+	_ map[string]dbus.Variant,
+) *dbus.Error {
+	if err := m.bluez.call(m.path, "RegisterApplication"); err != nil {
+		return err
+	}
+
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	err := m.bluez.conn.Object(string(sender), app).Call(objectManagerInterface+".GetManagedObjects", 0).
+		Store(&objects)
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	m.bluez.mu.Lock()
+	defer m.bluez.mu.Unlock()
+	m.bluez.appOwner = string(sender)
+	m.bluez.application = objects
+	return nil
+}
+
+func (m *testBlueZGattManager) UnregisterApplication(_ dbus.ObjectPath) *dbus.Error {
+	m.bluez.mu.Lock()
+	m.bluez.application = nil
+	m.bluez.mu.Unlock()
+
+	return m.bluez.call(m.path, "UnregisterApplication")
+}
+
+type testBlueZAdvertisingManager struct {
+	bluez *testBlueZ
+	path  dbus.ObjectPath
+}
+
+func (m *testBlueZAdvertisingManager) RegisterAdvertisement(sender dbus.Sender, adv dbus.ObjectPath,
+	_ map[string]dbus.Variant,
+) *dbus.Error {
+	if err := m.bluez.call(m.path, "RegisterAdvertisement"); err != nil {
+		return err
+	}
+
+	var props map[string]dbus.Variant
+	err := m.bluez.conn.Object(string(sender), adv).Call(propertiesInterface+".GetAll", 0,
+		leAdvertisementInterface).Store(&props)
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	m.bluez.mu.Lock()
+	defer m.bluez.mu.Unlock()
+	m.bluez.advertisement = props
+	return nil
+}
+
+func (m *testBlueZAdvertisingManager) UnregisterAdvertisement(_ dbus.ObjectPath) *dbus.Error {
+	m.bluez.mu.Lock()
+	m.bluez.advertisement = nil
+	m.bluez.mu.Unlock()
+
+	return m.bluez.call(m.path, "UnregisterAdvertisement")
+}
+
+type testBlueZObjectManager struct {
+	bluez *testBlueZ
+}
+
+func (m *testBlueZObjectManager) GetManagedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant,
+	*dbus.Error,
+) {
+	m.bluez.mu.Lock()
+	defer m.bluez.mu.Unlock()
+
+	objects := make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant, len(m.bluez.objects))
+	for path, interfaces := range m.bluez.objects {
+		objects[path] = make(map[string]map[string]dbus.Variant, len(interfaces))
+		for iface, props := range interfaces {
+			objects[path][iface] = make(map[string]dbus.Variant, len(props))
+			for name, value := range props {
+				objects[path][iface][name] = value
+			}
+		}
+	}
+	return objects, nil
+}
+
+type testBlueZProperties struct {
+	bluez *testBlueZ
+	path  dbus.ObjectPath
+}
+
+func (p *testBlueZProperties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	p.bluez.mu.Lock()
+	defer p.bluez.mu.Unlock()
+
+	value, ok := p.bluez.objects[p.path][iface][name]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{name})
+	}
+	return value, nil
+}
+
+type testBlueZAdapter struct {
+	bluez *testBlueZ
+	path  dbus.ObjectPath
+}
+
+func (a *testBlueZAdapter) SetDiscoveryFilter(filter map[string]dbus.Variant) *dbus.Error {
+	if err := a.bluez.call(a.path, "SetDiscoveryFilter"); err != nil {
+		return err
+	}
+
+	a.bluez.mu.Lock()
+	defer a.bluez.mu.Unlock()
+
+	a.bluez.filter = filter
+	return nil
+}
+
+func (a *testBlueZAdapter) StartDiscovery() *dbus.Error {
+	return a.bluez.call(a.path, "StartDiscovery")
+}
+
+func (a *testBlueZAdapter) StopDiscovery() *dbus.Error { return a.bluez.call(a.path, "StopDiscovery") }
+
+// discoveryFilter returns the discovery filter, which was set last
+func (b *testBlueZ) discoveryFilter() map[string]dbus.Variant {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.filter
+}
+
+type testBlueZDevice struct {
+	bluez *testBlueZ
+	path  dbus.ObjectPath
+}
+
+func (d *testBlueZDevice) Connect() *dbus.Error { return d.bluez.call(d.path, "Connect") }
+
+func (d *testBlueZDevice) Disconnect() *dbus.Error { return d.bluez.call(d.path, "Disconnect") }
+
+type testBlueZCharacteristic struct {
+	bluez *testBlueZ
+	path  dbus.ObjectPath
+}
+
+func (c *testBlueZCharacteristic) StartNotify() *dbus.Error {
+	return c.bluez.call(c.path, "StartNotify")
+}
+
+func (c *testBlueZCharacteristic) StopNotify() *dbus.Error { return c.bluez.call(c.path, "StopNotify") }
+
+// ReadValue returns the "Value" property starting at the offset
+func (c *testBlueZCharacteristic) ReadValue(options map[string]dbus.Variant) ([]byte, *dbus.Error) {
+	if err := c.bluez.call(c.path, "ReadValue"); err != nil {
+		return nil, err
+	}
+
+	c.bluez.mu.Lock()
+	defer c.bluez.mu.Unlock()
+
+	value, _ := c.bluez.objects[c.path][gattCharInterface]["Value"].Value().([]byte)
+	offset := offsetOption(options)
+	if offset > len(value) {
+		return nil, errGattInvalidOffset
+	}
+	return value[offset:], nil
+}
+
+// WriteValue stores the value at the offset in the "Value" property
+func (c *testBlueZCharacteristic) WriteValue(value []byte, options map[string]dbus.Variant) *dbus.Error {
+	if err := c.bluez.call(c.path, "WriteValue"); err != nil {
+		return err
+	}
+
+	c.bluez.mu.Lock()
+	defer c.bluez.mu.Unlock()
+
+	current, _ := c.bluez.objects[c.path][gattCharInterface]["Value"].Value().([]byte)
+	offset := offsetOption(options)
+	if offset > len(current) {
+		return errGattInvalidOffset
+	}
+	c.bluez.objects[c.path][gattCharInterface]["Value"] = dbus.MakeVariant(append(current[:offset:offset], value...))
+	return nil
+}
+
+func (c *testBlueZCharacteristic) AcquireWrite(_ map[string]dbus.Variant) (dbus.UnixFD, uint16, *dbus.Error) {
+	return c.bluez.acquire(c.path, "AcquireWrite")
+}
+
+func (c *testBlueZCharacteristic) AcquireNotify(_ map[string]dbus.Variant) (dbus.UnixFD, uint16, *dbus.Error) {
+	return c.bluez.acquire(c.path, "AcquireNotify")
+}
+
+// acquire creates a socket pair like BlueZ, keeps one end and returns the other end with the MTU
+func (b *testBlueZ) acquire(path dbus.ObjectPath, method string) (dbus.UnixFD, uint16, *dbus.Error) {
+	if err := b.call(path, method); err != nil {
+		return 0, 0, err
+	}
+
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return 0, 0, dbus.MakeFailedError(err)
+	}
+	local := os.NewFile(uintptr(fds[0]), method)
+	remote := os.NewFile(uintptr(fds[1]), method)
+	b.t.Cleanup(func() {
+		_ = local.Close()
+		_ = remote.Close()
+	})
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sockets[string(path)+"."+method] = [2]*os.File{local, remote}
+	return dbus.UnixFD(fds[1]), b.mtu, nil
+}
+
+// socket returns the BlueZ side of the file descriptor acquired by the method. The other end is sent by duplication,
+// so it is closed here, which needs to be done after the acquiring call has returned.
+func (b *testBlueZ) socket(path dbus.ObjectPath, method string) *os.File {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sockets, ok := b.sockets[string(path)+"."+method]
+	if !ok {
+		return nil
+	}
+	_ = sockets[1].Close()
+	return sockets[0]
+}
+
+// newTestLinuxAdapter creates a manager connected to the test bus with an adapter
+func newTestLinuxAdapter(t *testing.T, address string) *linuxAdapter {
+	t.Helper()
+
+	m := newLinuxManager(newTestBusConn(t, address))
+	adapter := &linuxAdapter{manager: m, path: testAdapterPath, properties: map[string]dbus.Variant{}}
+	adapter.central = &linuxCentral{adapter: adapter, devices: make(map[dbus.ObjectPath]*linuxDevice)}
+	adapter.peripheral = &linuxPeripheral{
+		adapter:  adapter,
+		services: make(map[dbus.ObjectPath]*linuxPeripheralService),
+	}
+	m.adapters[adapter.path] = adapter
+
+	return adapter
+}
+
+// newTestLinuxDevice creates a manager connected to the test bus with a connected device
+func newTestLinuxDevice(t *testing.T, address string, path dbus.ObjectPath) *linuxDevice {
+	t.Helper()
+
+	adapter := newTestLinuxAdapter(t, address)
+
+	device := &linuxDevice{
+		central:   adapter.central,
+		path:      path,
+		services:  make(map[dbus.ObjectPath]*linuxService),
+		connected: true,
+	}
+	adapter.central.devices[path] = device
+
+	return device
+}
+
+// addTestLinuxCharacteristic adds a service with one characteristic to the device
+func addTestLinuxCharacteristic(d *linuxDevice, path dbus.ObjectPath) *linuxCharacteristic {
+	servicePath := path[:strings.LastIndex(string(path), "/")]
+	service, ok := d.services[servicePath]
+	if !ok {
+		service = &linuxService{
+			device:          d,
+			path:            servicePath,
+			characteristics: make(map[dbus.ObjectPath]*linuxCharacteristic),
+		}
+		d.services[servicePath] = service
+	}
+
+	char := &linuxCharacteristic{
+		service:     service,
+		path:        path,
+		descriptors: make(map[dbus.ObjectPath]*linuxDescriptor),
+	}
+	service.characteristics[path] = char
+
+	return char
+}
@@ -0,0 +1,371 @@
+//go:build linux
+
+package bluetooth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	objectManagerInterface  = "org.freedesktop.DBus.ObjectManager"
+	interfacesAddedMember   = "InterfacesAdded"
+	interfacesRemovedMember = "InterfacesRemoved"
+	bluezRootPath           = dbus.ObjectPath("/")
+)
+
+// linuxDeviceWatch receives the signals of BlueZ about the GATT objects of a device and the resolution of its
+// services, until the device is disconnected
+type linuxDeviceWatch struct {
+	signals chan *dbus.Signal
+	done    chan struct{}
+
+	mu           sync.Mutex
+	resolved     chan struct{}
+	resolvedOnce *sync.Once
+}
+
+// resolution returns the channel, which is closed when BlueZ has resolved the services of the current connection
+func (w *linuxDeviceWatch) resolution() <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.resolved
+}
+
+// setResolved closes the channel of the resolution or, when the services are not resolved anymore, e.g. after a
+// disconnect, replaces a closed one for the next connection
+func (w *linuxDeviceWatch) setResolved(resolved bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if resolved {
+		w.resolvedOnce.Do(func() { close(w.resolved) })
+		return
+	}
+	select {
+	case <-w.resolved:
+		w.resolved = make(chan struct{})
+		w.resolvedOnce = &sync.Once{}
+	default:
+	}
+}
+
+// DiscoverServices connects to the device, if not already done, waits until BlueZ has resolved the services and
+// reads the GATT services, characteristics and descriptors of the device. Only the services with the given UUIDs
+// are added, all services for an empty list. Services, which appear later, are added until the device is
+// disconnected.
+func (d *linuxDevice) DiscoverServices(ctx context.Context, uuids []UUID) error {
+	conn := d.central.adapter.manager.conn
+	obj := conn.Object(bluezService, d.path)
+
+	connect := !d.Connected()
+	if connect {
+		call := obj.CallWithContext(ctx, deviceInterface+".Connect", 0)
+		if call.Err != nil {
+			return fmt.Errorf("failed to connect for service discovery: %w", call.Err)
+		}
+
+		d.mu.Lock()
+		d.connected = true
+		d.mu.Unlock()
+	}
+
+	watch, err := d.startWatch(uuids)
+	if err != nil {
+		return err
+	}
+	if connect {
+		// a resolution of a former connection is not valid anymore
+		watch.setResolved(false)
+	}
+
+	// the watch is started before, so the change of the property can not be missed
+	resolution := watch.resolution()
+	if resolved, err := obj.GetProperty(deviceInterface + ".ServicesResolved"); err != nil ||
+		resolved.Value() != true {
+		select {
+		case <-resolution:
+		case <-ctx.Done():
+			return fmt.Errorf("services not resolved: %w", ctx.Err())
+		}
+	}
+
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	call := conn.Object(bluezService, bluezRootPath).CallWithContext(ctx,
+		objectManagerInterface+".GetManagedObjects", 0)
+	if err := call.Store(&objects); err != nil {
+		return fmt.Errorf("failed to get GATT objects: %w", err)
+	}
+
+	d.addGattObjects(objects)
+
+	return nil
+}
+
+// startWatch starts to receive the signals for the device or updates the UUID filter of a running watch
+func (d *linuxDevice) startWatch(uuids []UUID) (*linuxDeviceWatch, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.filter = slices.Clone(uuids)
+	if d.watch != nil {
+		return d.watch, nil
+	}
+
+	conn := d.central.adapter.manager.conn
+	for _, match := range d.watchMatches() {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			return nil, fmt.Errorf("failed to watch GATT objects of %s: %w", d.path, err)
+		}
+	}
+
+	w := &linuxDeviceWatch{
+		signals:      make(chan *dbus.Signal, 16),
+		done:         make(chan struct{}),
+		resolved:     make(chan struct{}),
+		resolvedOnce: &sync.Once{},
+	}
+	conn.Signal(w.signals)
+	d.watch = w
+
+	go func() {
+		for {
+			select {
+			case <-w.done:
+				return
+			case sig, ok := <-w.signals:
+				if !ok {
+					return
+				}
+				d.handleWatchSignal(w, sig)
+			}
+		}
+	}()
+
+	return w, nil
+}
+
+// stopWatch stops to receive the signals for the device
+func (d *linuxDevice) stopWatch() {
+	d.mu.Lock()
+	w := d.watch
+	d.watch = nil
+	d.mu.Unlock()
+
+	if w == nil {
+		return
+	}
+
+	conn := d.central.adapter.manager.conn
+	conn.RemoveSignal(w.signals)
+	for _, match := range d.watchMatches() {
+		_ = conn.RemoveMatchSignal(match...)
+	}
+	close(w.done)
+}
+
+func (d *linuxDevice) watchMatches() [][]dbus.MatchOption {
+	objectMatch := func(member string) []dbus.MatchOption {
+		return []dbus.MatchOption{
+			dbus.WithMatchSender(bluezService),
+			dbus.WithMatchInterface(objectManagerInterface),
+			dbus.WithMatchMember(member),
+			dbus.WithMatchArgPath(0, string(d.path)+"/"),
+		}
+	}
+
+	return [][]dbus.MatchOption{
+		propertiesChangedMatch(d.path, deviceInterface),
+		objectMatch(interfacesAddedMember),
+		objectMatch(interfacesRemovedMember),
+	}
+}
+
+func (d *linuxDevice) handleWatchSignal(w *linuxDeviceWatch, sig *dbus.Signal) {
+	if sig == nil || len(sig.Body) < 2 {
+		return
+	}
+
+	switch sig.Name {
+	case propertiesInterface + "." + propertiesChangedMember:
+		iface, _ := sig.Body[0].(string)
+		changed, ok := sig.Body[1].(map[string]dbus.Variant)
+		if sig.Path != d.path || iface != deviceInterface || !ok {
+			return
+		}
+		if resolved, ok := changed["ServicesResolved"]; ok {
+			w.setResolved(resolved.Value() == true)
+		}
+		if connected, ok := changed["Connected"]; ok && connected.Value() == false {
+			w.setResolved(false)
+		}
+	case objectManagerInterface + "." + interfacesAddedMember:
+		path, _ := sig.Body[0].(dbus.ObjectPath)
+		interfaces, ok := sig.Body[1].(map[string]map[string]dbus.Variant)
+		if !ok || !strings.HasPrefix(string(path), string(d.path)+"/") {
+			return
+		}
+		d.addGattObjects(map[dbus.ObjectPath]map[string]map[string]dbus.Variant{path: interfaces})
+	case objectManagerInterface + "." + interfacesRemovedMember:
+		path, _ := sig.Body[0].(dbus.ObjectPath)
+		interfaces, ok := sig.Body[1].([]string)
+		if !ok || !strings.HasPrefix(string(path), string(d.path)+"/") {
+			return
+		}
+		d.removeGattObject(path, interfaces)
+	}
+}
+
+// addGattObjects adds the services of the device, which pass the UUID filter, with their characteristics and
+// descriptors. Known objects keep their identity, only the properties are updated.
+func (d *linuxDevice) addGattObjects(objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for path, interfaces := range objects {
+		props, ok := interfaces[gattServiceInterface]
+		if !ok || objectPathProperty(props, "Device") != d.path {
+			continue
+		}
+		u, err := uuidProperty(props)
+		if err != nil || (len(d.filter) > 0 && !slices.Contains(d.filter, u)) {
+			continue
+		}
+		if service, ok := d.services[path]; ok {
+			service.mu.Lock()
+			service.properties = props
+			service.mu.Unlock()
+			continue
+		}
+		d.services[path] = &linuxService{
+			device:          d,
+			path:            path,
+			uuid:            u,
+			properties:      props,
+			characteristics: make(map[dbus.ObjectPath]*linuxCharacteristic),
+		}
+	}
+
+	for path, interfaces := range objects {
+		props, ok := interfaces[gattCharInterface]
+		if !ok {
+			continue
+		}
+		service, ok := d.services[objectPathProperty(props, "Service")]
+		if !ok {
+			continue
+		}
+		u, err := uuidProperty(props)
+		if err != nil {
+			continue
+		}
+		service.mu.Lock()
+		if char, ok := service.characteristics[path]; ok {
+			char.mu.Lock()
+			char.properties = props
+			char.mu.Unlock()
+		} else {
+			service.characteristics[path] = &linuxCharacteristic{
+				service:     service,
+				path:        path,
+				uuid:        u,
+				properties:  props,
+				descriptors: make(map[dbus.ObjectPath]*linuxDescriptor),
+			}
+		}
+		service.mu.Unlock()
+	}
+
+	for path, interfaces := range objects {
+		props, ok := interfaces[gattDescInterface]
+		if !ok {
+			continue
+		}
+		char := d.characteristicLocked(objectPathProperty(props, "Characteristic"))
+		if char == nil {
+			continue
+		}
+		char.mu.Lock()
+		if desc, ok := char.descriptors[path]; ok {
+			desc.mu.Lock()
+			desc.properties = props
+			desc.mu.Unlock()
+		} else {
+			char.descriptors[path] = &linuxDescriptor{characteristic: char, path: path, properties: props}
+		}
+		char.mu.Unlock()
+	}
+}
+
+// removeGattObject removes the service, characteristic or descriptor, which was removed by BlueZ
+func (d *linuxDevice) removeGattObject(path dbus.ObjectPath, interfaces []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, iface := range interfaces {
+		switch iface {
+		case gattServiceInterface:
+			if service, ok := d.services[path]; ok {
+				for charPath := range service.characteristics {
+					d.central.adapter.manager.notifications.remove(charPath)
+				}
+				delete(d.services, path)
+			}
+		case gattCharInterface:
+			for _, service := range d.services {
+				service.mu.Lock()
+				if _, ok := service.characteristics[path]; ok {
+					d.central.adapter.manager.notifications.remove(path)
+					delete(service.characteristics, path)
+				}
+				service.mu.Unlock()
+			}
+		case gattDescInterface:
+			if char := d.characteristicLocked(path[:strings.LastIndex(string(path), "/")]); char != nil {
+				char.mu.Lock()
+				delete(char.descriptors, path)
+				char.mu.Unlock()
+			}
+		}
+	}
+}
+
+// characteristicLocked returns the characteristic with the given path, the device needs to be locked
+func (d *linuxDevice) characteristicLocked(path dbus.ObjectPath) *linuxCharacteristic {
+	for _, service := range d.services {
+		service.mu.RLock()
+		char, ok := service.characteristics[path]
+		service.mu.RUnlock()
+		if ok {
+			return char
+		}
+	}
+	return nil
+}
+
+func objectPathProperty(props map[string]dbus.Variant, name string) dbus.ObjectPath {
+	if v, ok := props[name]; ok {
+		if path, ok := v.Value().(dbus.ObjectPath); ok {
+			return path
+		}
+	}
+	return ""
+}
+
+func uuidProperty(props map[string]dbus.Variant) (UUID, error) {
+	v, ok := props["UUID"]
+	if !ok {
+		return UUID{}, fmt.Errorf("missing UUID")
+	}
+	s, ok := v.Value().(string)
+	if !ok {
+		return UUID{}, fmt.Errorf("invalid UUID %v", v)
+	}
+	return NewUUID(s)
+}
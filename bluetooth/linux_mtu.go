@@ -0,0 +1,244 @@
+//go:build linux
+
+package bluetooth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	defaultATTMTU = 23 // minimum ATT MTU, used until the negotiated MTU is known
+	attHeaderSize = 3  // opcode and handle of a write command or notification
+)
+
+// RequestMTU validates the MTU and refreshes the negotiated MTU from BlueZ. BlueZ exchanges the largest supported
+// MTU by itself on connection, so the MTU can not be requested explicitly over D-Bus. ErrOperationNotSupported is
+// returned, if neither the "MTU" property of a characteristic nor an acquired file descriptor reveals the MTU.
+func (d *linuxDevice) RequestMTU(ctx context.Context, mtu uint16) error {
+	if err := ValidateMTU(mtu); err != nil {
+		return err
+	}
+	if !d.Connected() {
+		return ErrNotConnected
+	}
+
+	conn := d.central.adapter.manager.conn
+	var writable *linuxCharacteristic
+	for _, char := range d.characteristics() {
+		var value dbus.Variant
+		call := conn.Object(bluezService, char.path).CallWithContext(ctx, propertiesInterface+".Get", 0,
+			gattCharInterface, "MTU")
+		if call.Store(&value) == nil {
+			if negotiated, ok := value.Value().(uint16); ok {
+				d.setMTU(negotiated)
+				return nil
+			}
+		}
+		if writable == nil && char.hasProperty("WriteAcquired") {
+			writable = char
+		}
+	}
+
+	// older versions of BlueZ reveal the MTU only together with an acquired file descriptor
+	if writable != nil {
+		if _, err := writable.acquireWrite(ctx); err == nil {
+			return nil
+		}
+	}
+
+	return ErrOperationNotSupported
+}
+
+// GetMTU returns the ATT MTU negotiated by BlueZ, which is known from acquired file descriptors or the "MTU"
+// property of the characteristics, or the default ATT MTU of 23 bytes
+func (d *linuxDevice) GetMTU() uint16 {
+	d.mu.RLock()
+	mtu := d.mtu
+	d.mu.RUnlock()
+
+	if mtu >= defaultATTMTU {
+		return mtu
+	}
+
+	for _, char := range d.characteristics() {
+		char.mu.RLock()
+		v, ok := char.properties["MTU"]
+		char.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		if negotiated, ok := v.Value().(uint16); ok && negotiated > mtu {
+			mtu = negotiated
+		}
+	}
+
+	if mtu < defaultATTMTU {
+		return defaultATTMTU
+	}
+	return mtu
+}
+
+func (d *linuxDevice) setMTU(mtu uint16) {
+	if mtu < defaultATTMTU {
+		return
+	}
+
+	d.mu.Lock()
+	d.mtu = mtu
+	d.mu.Unlock()
+}
+
+// characteristics returns the characteristics of all services of the device
+func (d *linuxDevice) characteristics() []*linuxCharacteristic {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var chars []*linuxCharacteristic
+	for _, service := range d.services {
+		service.mu.RLock()
+		for _, char := range service.characteristics {
+			chars = append(chars, char)
+		}
+		service.mu.RUnlock()
+	}
+	return chars
+}
+
+// releaseAcquired closes the acquired write file descriptors of all characteristics and forgets the MTU, the
+// acquired notifications are closed together with the subscriptions
+func (d *linuxDevice) releaseAcquired() {
+	for _, char := range d.characteristics() {
+		char.releaseWrite()
+	}
+
+	d.mu.Lock()
+	d.mtu = 0
+	d.mu.Unlock()
+}
+
+// ReadWithOffset reads the value of the characteristic starting at the given offset. Like for Read, BlueZ uses
+// "read blob" requests for values longer than the MTU allows in one response.
+func (c *linuxCharacteristic) ReadWithOffset(ctx context.Context, offset uint16) ([]byte, error) {
+	obj := c.service.device.central.adapter.manager.conn.Object(bluezService, c.path)
+
+	var value []byte
+	call := obj.CallWithContext(ctx, gattCharInterface+".ReadValue", 0,
+		map[string]dbus.Variant{"offset": dbus.MakeVariant(offset)})
+	if err := call.Store(&value); err != nil {
+		return nil, fmt.Errorf("failed to read characteristic at offset %d: %w", offset, err)
+	}
+
+	return value, nil
+}
+
+// WriteWithOffset writes the data to the value of the characteristic starting at the given offset. Like for
+// Write, BlueZ uses prepared writes for data longer than the MTU allows in one request.
+func (c *linuxCharacteristic) WriteWithOffset(ctx context.Context, data []byte, offset uint16) error {
+	obj := c.service.device.central.adapter.manager.conn.Object(bluezService, c.path)
+
+	options := map[string]dbus.Variant{
+		"type":   dbus.MakeVariant("request"),
+		"offset": dbus.MakeVariant(offset),
+	}
+
+	call := obj.CallWithContext(ctx, gattCharInterface+".WriteValue", 0, data, options)
+	if call.Err != nil {
+		return fmt.Errorf("failed to write characteristic at offset %d: %w", offset, call.Err)
+	}
+
+	return nil
+}
+
+// acquireWrite returns the file descriptor for writes without response, which is acquired from BlueZ on first
+// usage. ErrOperationNotSupported is returned, if BlueZ does not offer the file descriptor for the characteristic.
+func (c *linuxCharacteristic) acquireWrite(ctx context.Context) (*os.File, error) {
+	c.acquireMu.Lock()
+	defer c.acquireMu.Unlock()
+
+	c.mu.RLock()
+	f := c.writer
+	_, supported := c.properties["WriteAcquired"]
+	c.mu.RUnlock()
+
+	if f != nil {
+		return f, nil
+	}
+	if !supported {
+		return nil, ErrOperationNotSupported
+	}
+
+	f, mtu, err := c.acquire(ctx, "AcquireWrite")
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.writer = f
+	c.mu.Unlock()
+	c.service.device.setMTU(mtu)
+
+	return f, nil
+}
+
+// releaseWrite closes the acquired file descriptor for writes without response, if any
+func (c *linuxCharacteristic) releaseWrite() {
+	c.mu.Lock()
+	f := c.writer
+	c.writer = nil
+	c.mu.Unlock()
+
+	if f != nil {
+		_ = f.Close()
+	}
+}
+
+// acquireNotify returns a file descriptor, which receives the notifications of the characteristic, and the MTU.
+// ErrOperationNotSupported is returned, if BlueZ does not offer the file descriptor for the characteristic.
+func (c *linuxCharacteristic) acquireNotify(ctx context.Context) (*os.File, uint16, error) {
+	if !c.hasProperty("NotifyAcquired") {
+		return nil, 0, ErrOperationNotSupported
+	}
+
+	f, mtu, err := c.acquire(ctx, "AcquireNotify")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.service.device.setMTU(mtu)
+
+	return f, mtu, nil
+}
+
+// acquire calls the given acquire method of BlueZ and wraps the received socket, each read or write transfers one
+// complete value
+func (c *linuxCharacteristic) acquire(ctx context.Context, method string) (*os.File, uint16, error) {
+	obj := c.service.device.central.adapter.manager.conn.Object(bluezService, c.path)
+
+	var fd dbus.UnixFD
+	var mtu uint16
+	call := obj.CallWithContext(ctx, gattCharInterface+"."+method, 0, map[string]dbus.Variant{})
+	if err := call.Store(&fd, &mtu); err != nil {
+		return nil, 0, fmt.Errorf("failed to %s: %w", method, err)
+	}
+
+	// use non-blocking mode, so closing the file interrupts a pending read
+	if err := syscall.SetNonblock(int(fd), true); err != nil {
+		_ = syscall.Close(int(fd))
+		return nil, 0, fmt.Errorf("failed to %s: %w", method, err)
+	}
+
+	return os.NewFile(uintptr(fd), fmt.Sprintf("%s-%s", c.path, method)), mtu, nil
+}
+
+func (c *linuxCharacteristic) hasProperty(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.properties[name]
+	return ok
+}
@@ -0,0 +1,205 @@
+//go:build linux
+
+package bluetooth
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ OffsetCharacteristic = (*linuxCharacteristic)(nil)
+
+func TestLinuxCharacteristicWriteWithoutResponseAcquired(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addDevice(testDevicePath)
+	bluez.addCharacteristic(testCharPath1)
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	char := addTestLinuxCharacteristic(device, testCharPath1)
+	char.properties = map[string]dbus.Variant{"WriteAcquired": dbus.MakeVariant(false)}
+	require.Equal(t, uint16(23), device.GetMTU())
+	// act
+	require.NoError(t, char.WriteWithoutResponse(context.Background(), []byte("first")))
+	require.NoError(t, char.WriteWithoutResponse(context.Background(), []byte("second")))
+	// assert: each write is one packet at the socket
+	socket := bluez.socket(testCharPath1, "AcquireWrite")
+	require.NotNil(t, socket)
+	buf := make([]byte, 64)
+	n, err := socket.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), buf[:n])
+	n, err = socket.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), buf[:n])
+	assert.Equal(t, uint16(247), device.GetMTU())
+	assert.Equal(t, 1, countTestCalls(bluez, string(testCharPath1)+".AcquireWrite"))
+	assert.NotContains(t, bluez.recordedCalls(), string(testCharPath1)+".WriteValue")
+	// act: the acquired file descriptor and the MTU are released on disconnect
+	require.NoError(t, device.Disconnect(context.Background()))
+	// assert
+	assert.Equal(t, uint16(23), device.GetMTU())
+	_, err = socket.Read(buf)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestLinuxCharacteristicWriteWithoutResponseFallback(t *testing.T) {
+	tests := map[string]struct {
+		properties map[string]dbus.Variant
+		failCall   string
+	}{
+		"not_offered": {
+			properties: map[string]dbus.Variant{},
+		},
+		"acquire_failed": {
+			properties: map[string]dbus.Variant{"WriteAcquired": dbus.MakeVariant(true)},
+			failCall:   "AcquireWrite",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			address := newTestBus(t)
+			bluez := newTestBlueZ(t, address)
+			bluez.addCharacteristic(testCharPath1)
+			if tc.failCall != "" {
+				bluez.failCall(testCharPath1, tc.failCall)
+			}
+			device := newTestLinuxDevice(t, address, testDevicePath)
+			char := addTestLinuxCharacteristic(device, testCharPath1)
+			char.properties = tc.properties
+			// act
+			err := char.WriteWithoutResponse(context.Background(), []byte{0x01, 0x02})
+			// assert
+			require.NoError(t, err)
+			assert.Contains(t, bluez.recordedCalls(), string(testCharPath1)+".WriteValue")
+			assert.Equal(t, uint16(23), device.GetMTU())
+		})
+	}
+}
+
+func TestLinuxCharacteristicSubscribeAcquired(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.mtu = 185
+	bluez.addCharacteristic(testCharPath1)
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	char := addTestLinuxCharacteristic(device, testCharPath1)
+	char.properties = map[string]dbus.Variant{"NotifyAcquired": dbus.MakeVariant(false)}
+	values := make(chan []byte, 10)
+	// act
+	require.NoError(t, char.Subscribe(context.Background(), func(v []byte) { values <- v }))
+	socket := bluez.socket(testCharPath1, "AcquireNotify")
+	require.NotNil(t, socket)
+	_, err := socket.Write([]byte{0x01, 0x02})
+	require.NoError(t, err)
+	_, err = socket.Write([]byte{0x03})
+	require.NoError(t, err)
+	// assert
+	assert.Equal(t, []byte{0x01, 0x02}, receiveTestValue(t, values))
+	assert.Equal(t, []byte{0x03}, receiveTestValue(t, values))
+	assert.True(t, testSubscribed(char))
+	assert.Equal(t, uint16(185), device.GetMTU())
+	assert.NotContains(t, bluez.recordedCalls(), string(testCharPath1)+".StartNotify")
+	// act
+	require.NoError(t, char.Unsubscribe(context.Background()))
+	// assert: closing the file descriptor stops the notifications at BlueZ
+	_, err = socket.Read(make([]byte, 8))
+	require.ErrorIs(t, err, io.EOF)
+	assert.False(t, testSubscribed(char))
+	assert.NotContains(t, bluez.recordedCalls(), string(testCharPath1)+".StopNotify")
+}
+
+func TestLinuxCharacteristicOffsets(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addObject(testCharPath1, gattCharInterface, map[string]dbus.Variant{
+		"Value": dbus.MakeVariant([]byte("firmware-v1")),
+	})
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	char := addTestLinuxCharacteristic(device, testCharPath1)
+	// act & assert
+	value, err := char.ReadWithOffset(context.Background(), 9)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), value)
+	require.NoError(t, char.WriteWithOffset(context.Background(), []byte("v2"), 9))
+	value, err = char.Read(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("firmware-v2"), value)
+	_, err = char.ReadWithOffset(context.Background(), 20)
+	require.ErrorContains(t, err, "failed to read characteristic at offset 20")
+	err = char.WriteWithOffset(context.Background(), []byte{0x00}, 20)
+	require.ErrorContains(t, err, "failed to write characteristic at offset 20")
+}
+
+func TestLinuxDeviceRequestMTU(t *testing.T) {
+	tests := map[string]struct {
+		mtu        uint16
+		properties map[string]dbus.Variant
+		wantMTU    uint16
+		wantErr    string
+	}{
+		"mtu_property": {
+			mtu:        517,
+			properties: map[string]dbus.Variant{"MTU": dbus.MakeVariant(uint16(185))},
+			wantMTU:    185,
+		},
+		"acquired_write": {
+			mtu:        247,
+			properties: map[string]dbus.Variant{"WriteAcquired": dbus.MakeVariant(false)},
+			wantMTU:    247,
+		},
+		"error_not_supported": {
+			mtu:        247,
+			properties: map[string]dbus.Variant{},
+			wantMTU:    23,
+			wantErr:    "operation not supported",
+		},
+		"error_invalid_mtu": {
+			mtu:        600,
+			properties: map[string]dbus.Variant{"MTU": dbus.MakeVariant(uint16(185))},
+			wantMTU:    23,
+			wantErr:    "must be between 23 and 517",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			address := newTestBus(t)
+			bluez := newTestBlueZ(t, address)
+			bluez.addObject(testCharPath1, gattCharInterface, tc.properties)
+			device := newTestLinuxDevice(t, address, testDevicePath)
+			char := addTestLinuxCharacteristic(device, testCharPath1)
+			char.properties = map[string]dbus.Variant{}
+			if _, ok := tc.properties["WriteAcquired"]; ok {
+				char.properties["WriteAcquired"] = tc.properties["WriteAcquired"]
+			}
+			// act
+			err := device.RequestMTU(context.Background(), tc.mtu)
+			// assert
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.wantErr)
+			}
+			assert.Equal(t, tc.wantMTU, device.GetMTU())
+		})
+	}
+}
+
+func countTestCalls(bluez *testBlueZ, call string) int {
+	var count int
+	for _, c := range bluez.recordedCalls() {
+		if c == call {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,231 @@
+//go:build linux
+
+package bluetooth
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	propertiesInterface     = "org.freedesktop.DBus.Properties"
+	propertiesChangedMember = "PropertiesChanged"
+)
+
+// linuxNotifications routes the PropertiesChanged signals of BlueZ to the callbacks of subscribed
+// characteristics. A change of "Value" is a notification or indication of the remote device, a change of
+// "Connected" to false at the device ends all subscriptions of the device. Notifications acquired by a file
+// descriptor are read from the file instead.
+type linuxNotifications struct {
+	conn    *dbus.Conn
+	mu      sync.Mutex
+	signals chan *dbus.Signal
+	subs    map[dbus.ObjectPath]*linuxNotifySubscription // by characteristic path
+	devices map[dbus.ObjectPath]int                      // number of subscriptions by device path
+}
+
+type linuxNotifySubscription struct {
+	char     *linuxCharacteristic
+	device   dbus.ObjectPath
+	callback func([]byte)
+	file     *os.File // acquired notifications, nil for signals
+}
+
+func newLinuxNotifications(conn *dbus.Conn) *linuxNotifications {
+	return &linuxNotifications{
+		conn:    conn,
+		subs:    make(map[dbus.ObjectPath]*linuxNotifySubscription),
+		devices: make(map[dbus.ObjectPath]int),
+	}
+}
+
+// add registers the callback for value changes of the characteristic, an existing callback is replaced
+func (n *linuxNotifications) add(char *linuxCharacteristic, device dbus.ObjectPath, callback func([]byte)) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if sub, ok := n.subs[char.path]; ok {
+		sub.callback = callback
+		return nil
+	}
+
+	if n.signals == nil {
+		n.signals = make(chan *dbus.Signal, 16)
+		n.conn.Signal(n.signals)
+		go n.route(n.signals)
+	}
+
+	if err := n.conn.AddMatchSignal(propertiesChangedMatch(char.path, gattCharInterface)...); err != nil {
+		return fmt.Errorf("failed to match value changes of %s: %w", char.path, err)
+	}
+	if n.devices[device] == 0 {
+		if err := n.conn.AddMatchSignal(propertiesChangedMatch(device, deviceInterface)...); err != nil {
+			_ = n.conn.RemoveMatchSignal(propertiesChangedMatch(char.path, gattCharInterface)...)
+			return fmt.Errorf("failed to match connection changes of %s: %w", device, err)
+		}
+	}
+
+	n.subs[char.path] = &linuxNotifySubscription{char: char, device: device, callback: callback}
+	n.devices[device]++
+
+	return nil
+}
+
+// attach routes the notifications received by the acquired file to the callback of the subscription, the file is
+// closed together with the subscription
+func (n *linuxNotifications) attach(path dbus.ObjectPath, f *os.File, mtu uint16) {
+	n.mu.Lock()
+	sub, ok := n.subs[path]
+	if ok {
+		sub.file = f
+	}
+	n.mu.Unlock()
+
+	if !ok {
+		_ = f.Close()
+		return
+	}
+
+	go n.read(path, f, mtu)
+}
+
+// read calls the callback with each notification received by the file, until the file is closed
+func (n *linuxNotifications) read(path dbus.ObjectPath, f *os.File, mtu uint16) {
+	buf := make([]byte, mtu)
+	for {
+		count, err := f.Read(buf)
+		if err != nil {
+			// closed by removing the subscription or by BlueZ, e.g. on disconnect
+			return
+		}
+
+		n.mu.Lock()
+		var callback func([]byte)
+		if sub, ok := n.subs[path]; ok && sub.file == f {
+			callback = sub.callback
+		}
+		n.mu.Unlock()
+		if callback != nil {
+			callback(slices.Clone(buf[:count]))
+		}
+	}
+}
+
+// remove unregisters the callback of the characteristic, true is returned if the notifications were acquired
+func (n *linuxNotifications) remove(path dbus.ObjectPath) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.removeLocked(path)
+}
+
+// removeDevice unregisters the callbacks of all characteristics of the device and marks them as unsubscribed
+func (n *linuxNotifications) removeDevice(device dbus.ObjectPath) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for path, sub := range n.subs {
+		if sub.device != device {
+			continue
+		}
+		n.removeLocked(path)
+		sub.char.mu.Lock()
+		sub.char.subscribed = false
+		sub.char.mu.Unlock()
+	}
+}
+
+func (n *linuxNotifications) removeLocked(path dbus.ObjectPath) bool {
+	sub, ok := n.subs[path]
+	if !ok {
+		return false
+	}
+
+	delete(n.subs, path)
+	_ = n.conn.RemoveMatchSignal(propertiesChangedMatch(path, gattCharInterface)...)
+	if sub.file != nil {
+		_ = sub.file.Close()
+	}
+
+	n.devices[sub.device]--
+	if n.devices[sub.device] <= 0 {
+		delete(n.devices, sub.device)
+		_ = n.conn.RemoveMatchSignal(propertiesChangedMatch(sub.device, deviceInterface)...)
+	}
+
+	return sub.file != nil
+}
+
+// route calls the callbacks one after another in the order of the signals, until the connection is closed
+func (n *linuxNotifications) route(signals chan *dbus.Signal) {
+	for sig := range signals {
+		if sig == nil || sig.Name != propertiesInterface+"."+propertiesChangedMember || len(sig.Body) < 2 {
+			continue
+		}
+		iface, _ := sig.Body[0].(string)
+		changed, ok := sig.Body[1].(map[string]dbus.Variant)
+		if !ok {
+			continue
+		}
+
+		switch iface {
+		case gattCharInterface:
+			value, ok := changed["Value"]
+			if !ok {
+				continue
+			}
+			data, ok := value.Value().([]byte)
+			if !ok {
+				continue
+			}
+			n.mu.Lock()
+			var callback func([]byte)
+			if sub, ok := n.subs[sig.Path]; ok {
+				callback = sub.callback
+			}
+			n.mu.Unlock()
+			if callback != nil {
+				callback(data)
+			}
+		case deviceInterface:
+			if connected, ok := changed["Connected"]; ok {
+				if isConnected, ok := connected.Value().(bool); ok && !isConnected {
+					n.disconnected(sig.Path)
+				}
+			}
+		}
+	}
+}
+
+// disconnected ends the subscriptions of a device, which was disconnected by the remote side or by BlueZ
+func (n *linuxNotifications) disconnected(device dbus.ObjectPath) {
+	var dev *linuxDevice
+	n.mu.Lock()
+	for _, sub := range n.subs {
+		if sub.device == device {
+			dev = sub.char.service.device
+			break
+		}
+	}
+	n.mu.Unlock()
+
+	n.removeDevice(device)
+	if dev != nil {
+		dev.mu.Lock()
+		dev.connected = false
+		dev.mu.Unlock()
+	}
+}
+
+func propertiesChangedMatch(path dbus.ObjectPath, iface string) []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(propertiesInterface),
+		dbus.WithMatchMember(propertiesChangedMember),
+		dbus.WithMatchArg(0, iface),
+	}
+}
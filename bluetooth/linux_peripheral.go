@@ -0,0 +1,595 @@
+//go:build linux
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	gattManagerInterface          = "org.bluez.GattManager1"
+	leAdvertisingManagerInterface = "org.bluez.LEAdvertisingManager1"
+	leAdvertisementInterface      = "org.bluez.LEAdvertisement1"
+	gobotObjectPath               = "/org/gobot"
+)
+
+// D-Bus errors returned to BlueZ by the exported GATT characteristics
+var (
+	errGattFailed        = dbus.NewError("org.bluez.Error.Failed", []interface{}{"operation failed"})
+	errGattNotPermitted  = dbus.NewError("org.bluez.Error.NotPermitted", []interface{}{"operation not permitted"})
+	errGattInvalidOffset = dbus.NewError("org.bluez.Error.InvalidOffset", []interface{}{"invalid offset"})
+)
+
+var characteristicFlags = []struct {
+	property CharacteristicProperty
+	flag     string
+}{
+	{CharacteristicBroadcast, "broadcast"},
+	{CharacteristicRead, "read"},
+	{CharacteristicWriteWithoutResponse, "write-without-response"},
+	{CharacteristicWrite, "write"},
+	{CharacteristicNotify, "notify"},
+	{CharacteristicIndicate, "indicate"},
+	{CharacteristicAuthenticatedSignedWrites, "authenticated-signed-writes"},
+	{CharacteristicExtendedProperties, "extended-properties"},
+}
+
+// linuxGattApplication is exported as object manager at the root of the GATT application
+type linuxGattApplication struct {
+	peripheral *linuxPeripheral
+}
+
+// linuxGattCharacteristicObject is exported as org.bluez.GattCharacteristic1 and calls the handlers of the
+// characteristic on requests of the remote device
+type linuxGattCharacteristicObject struct {
+	char *linuxPeripheralCharacteristic
+}
+
+// linuxAdvertisementObject is exported as org.bluez.LEAdvertisement1
+type linuxAdvertisementObject struct {
+	peripheral *linuxPeripheral
+}
+
+// StartAdvertising registers the GATT application with all added services and the advertisement at BlueZ.
+// Services can not be added until the advertising is stopped.
+func (p *linuxPeripheral) StartAdvertising(ctx context.Context, params AdvertisingParams,
+	data AdvertisementData,
+) error {
+	// BlueZ calls back during the registration, so the peripheral must not be locked while calling
+	p.advMu.Lock()
+	defer p.advMu.Unlock()
+
+	p.mu.RLock()
+	advertising, registered, hasServices := p.advertising, p.registered, len(p.services) > 0
+	p.mu.RUnlock()
+
+	if advertising {
+		return fmt.Errorf("advertising already started")
+	}
+
+	adapterObj := p.adapter.manager.conn.Object(bluezService, p.adapter.path)
+
+	if hasServices && !registered {
+		if err := p.exportApplication(); err != nil {
+			p.unexportApplication()
+			return fmt.Errorf("failed to export GATT application: %w", err)
+		}
+		call := adapterObj.CallWithContext(ctx, gattManagerInterface+".RegisterApplication", 0,
+			dbus.ObjectPath(p.appPath()), map[string]dbus.Variant{})
+		if call.Err != nil {
+			p.unexportApplication()
+			return fmt.Errorf("failed to register GATT application: %w", call.Err)
+		}
+		p.mu.Lock()
+		p.registered = true
+		p.mu.Unlock()
+	}
+
+	if err := p.exportAdvertisement(params, data); err != nil {
+		p.unexportAdvertisement()
+		return fmt.Errorf("failed to export advertisement: %w", err)
+	}
+	call := adapterObj.CallWithContext(ctx, leAdvertisingManagerInterface+".RegisterAdvertisement", 0,
+		p.advertisementPath(), map[string]dbus.Variant{})
+	if call.Err != nil {
+		p.unexportAdvertisement()
+		return fmt.Errorf("failed to start advertising: %w", call.Err)
+	}
+
+	p.mu.Lock()
+	p.advertising = true
+	p.mu.Unlock()
+
+	return nil
+}
+
+// StopAdvertising unregisters the advertisement and the GATT application at BlueZ
+func (p *linuxPeripheral) StopAdvertising(ctx context.Context) error {
+	p.advMu.Lock()
+	defer p.advMu.Unlock()
+
+	p.mu.Lock()
+	advertising, registered := p.advertising, p.registered
+	p.advertising, p.registered = false, false
+	p.mu.Unlock()
+
+	adapterObj := p.adapter.manager.conn.Object(bluezService, p.adapter.path)
+
+	var err error
+	if advertising {
+		call := adapterObj.CallWithContext(ctx, leAdvertisingManagerInterface+".UnregisterAdvertisement", 0,
+			p.advertisementPath())
+		if call.Err != nil {
+			err = fmt.Errorf("failed to stop advertising: %w", call.Err)
+		}
+	}
+	p.unexportAdvertisement()
+
+	if registered {
+		call := adapterObj.CallWithContext(ctx, gattManagerInterface+".UnregisterApplication", 0,
+			dbus.ObjectPath(p.appPath()))
+		if call.Err != nil {
+			err = errors.Join(err, fmt.Errorf("failed to unregister GATT application: %w", call.Err))
+		}
+	}
+	p.unexportApplication()
+
+	return err
+}
+
+// OnConnect sets the callback for connections of remote devices to the adapter
+func (p *linuxPeripheral) OnConnect(callback func(Device)) {
+	p.mu.Lock()
+	p.onConnect = callback
+	p.mu.Unlock()
+
+	p.watchConnections()
+}
+
+// OnDisconnect sets the callback for disconnections of remote devices from the adapter
+func (p *linuxPeripheral) OnDisconnect(callback func(Device)) {
+	p.mu.Lock()
+	p.onDisconnect = callback
+	p.mu.Unlock()
+
+	p.watchConnections()
+}
+
+// appPath returns the root of the GATT application, e.g. "/org/gobot/hci0"
+func (p *linuxPeripheral) appPath() string {
+	return gobotObjectPath + "/" + path.Base(string(p.adapter.path))
+}
+
+func (p *linuxPeripheral) advertisementPath() dbus.ObjectPath {
+	return dbus.ObjectPath(p.appPath() + "/advertisement0")
+}
+
+// exportApplication exports the object manager, the services and the characteristics
+func (p *linuxPeripheral) exportApplication() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	conn := p.adapter.manager.conn
+	if err := conn.Export(&linuxGattApplication{peripheral: p}, dbus.ObjectPath(p.appPath()),
+		objectManagerInterface); err != nil {
+		return err
+	}
+
+	for _, service := range p.services {
+		_, err := prop.Export(conn, service.path, prop.Map{gattServiceInterface: service.dbusProps()})
+		if err != nil {
+			return err
+		}
+		for _, char := range service.peripheralCharacteristics() {
+			props, err := prop.Export(conn, char.path, prop.Map{gattCharInterface: char.dbusProps()})
+			if err != nil {
+				return err
+			}
+			if err := conn.Export(&linuxGattCharacteristicObject{char: char}, char.path,
+				gattCharInterface); err != nil {
+				return err
+			}
+			char.mu.Lock()
+			char.props = props
+			char.mu.Unlock()
+		}
+	}
+
+	return nil
+}
+
+// unexportApplication removes all objects of the GATT application from D-Bus
+func (p *linuxPeripheral) unexportApplication() {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	conn := p.adapter.manager.conn
+	_ = conn.Export(nil, dbus.ObjectPath(p.appPath()), objectManagerInterface)
+
+	for _, service := range p.services {
+		_ = conn.Export(nil, service.path, propertiesInterface)
+		for _, char := range service.peripheralCharacteristics() {
+			_ = conn.Export(nil, char.path, propertiesInterface)
+			_ = conn.Export(nil, char.path, gattCharInterface)
+			char.mu.Lock()
+			char.props = nil
+			char.notifying = false
+			char.mu.Unlock()
+		}
+	}
+}
+
+// exportAdvertisement exports the advertisement with its properties
+func (p *linuxPeripheral) exportAdvertisement(params AdvertisingParams, data AdvertisementData) error {
+	conn := p.adapter.manager.conn
+	advPath := p.advertisementPath()
+
+	_, err := prop.Export(conn, advPath, prop.Map{leAdvertisementInterface: advertisementProps(params, data)})
+	if err != nil {
+		return err
+	}
+
+	return conn.Export(&linuxAdvertisementObject{peripheral: p}, advPath, leAdvertisementInterface)
+}
+
+func (p *linuxPeripheral) unexportAdvertisement() {
+	conn := p.adapter.manager.conn
+	_ = conn.Export(nil, p.advertisementPath(), propertiesInterface)
+	_ = conn.Export(nil, p.advertisementPath(), leAdvertisementInterface)
+}
+
+// watchConnections starts to receive the changes of the connection state of the adapter's devices
+func (p *linuxPeripheral) watchConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.connSignals != nil {
+		return
+	}
+
+	conn := p.adapter.manager.conn
+	err := conn.AddMatchSignal(
+		dbus.WithMatchPathNamespace(p.adapter.path),
+		dbus.WithMatchInterface(propertiesInterface),
+		dbus.WithMatchMember(propertiesChangedMember),
+		dbus.WithMatchArg(0, deviceInterface),
+	)
+	if err != nil {
+		return
+	}
+
+	p.connSignals = make(chan *dbus.Signal, 16)
+	conn.Signal(p.connSignals)
+
+	go func(signals chan *dbus.Signal) {
+		for sig := range signals {
+			p.handleConnectionSignal(sig)
+		}
+	}(p.connSignals)
+}
+
+func (p *linuxPeripheral) handleConnectionSignal(sig *dbus.Signal) {
+	if sig == nil || sig.Name != propertiesInterface+"."+propertiesChangedMember || len(sig.Body) < 2 {
+		return
+	}
+	iface, _ := sig.Body[0].(string)
+	changed, ok := sig.Body[1].(map[string]dbus.Variant)
+	if !ok || iface != deviceInterface {
+		return
+	}
+	connected, ok := changed["Connected"]
+	if !ok {
+		return
+	}
+	isConnected, ok := connected.Value().(bool)
+	if !ok {
+		return
+	}
+
+	device := p.adapter.central.remoteDevice(sig.Path, isConnected)
+	if device == nil {
+		return
+	}
+
+	p.mu.RLock()
+	callback := p.onDisconnect
+	if isConnected {
+		callback = p.onConnect
+	}
+	p.mu.RUnlock()
+
+	if callback != nil {
+		callback(device)
+	}
+}
+
+// remoteDevice returns the device of the adapter with the given path and updates its connection state
+func (c *linuxCentral) remoteDevice(devicePath dbus.ObjectPath, connected bool) *linuxDevice {
+	prefix := string(c.adapter.path) + "/dev_"
+	if !strings.HasPrefix(string(devicePath), prefix) {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	device, ok := c.devices[devicePath]
+	if !ok {
+		address := strings.ReplaceAll(strings.TrimPrefix(string(devicePath), prefix), "_", ":")
+		device = &linuxDevice{
+			central:    c,
+			path:       devicePath,
+			properties: map[string]dbus.Variant{"Address": dbus.MakeVariant(address)},
+			services:   make(map[dbus.ObjectPath]*linuxService),
+		}
+		c.devices[devicePath] = device
+	}
+
+	device.mu.Lock()
+	device.connected = connected
+	device.mu.Unlock()
+
+	return device
+}
+
+// GetManagedObjects returns the services and characteristics of the GATT application
+func (a *linuxGattApplication) GetManagedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant,
+	*dbus.Error,
+) {
+	a.peripheral.mu.RLock()
+	defer a.peripheral.mu.RUnlock()
+
+	objects := make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant)
+	for _, service := range a.peripheral.services {
+		objects[service.path] = map[string]map[string]dbus.Variant{
+			gattServiceInterface: variants(service.dbusProps()),
+		}
+		for _, char := range service.peripheralCharacteristics() {
+			objects[char.path] = map[string]map[string]dbus.Variant{gattCharInterface: variants(char.dbusProps())}
+		}
+	}
+
+	return objects, nil
+}
+
+// ReadValue returns the value of the characteristic, the read handler is called if set
+func (o *linuxGattCharacteristicObject) ReadValue(options map[string]dbus.Variant) ([]byte, *dbus.Error) {
+	o.char.mu.RLock()
+	permitted := o.char.properties&CharacteristicRead != 0
+	onRead := o.char.onRead
+	o.char.mu.RUnlock()
+
+	if !permitted {
+		return nil, errGattNotPermitted
+	}
+
+	value := o.char.Value()
+	if onRead != nil {
+		value = onRead()
+		_ = o.char.SetValue(value)
+	}
+
+	offset := offsetOption(options)
+	if offset > len(value) {
+		return nil, errGattInvalidOffset
+	}
+
+	return value[offset:], nil
+}
+
+// WriteValue stores the written value at the characteristic, the write handler is called before if set and can
+// reject the value by an error
+func (o *linuxGattCharacteristicObject) WriteValue(value []byte, options map[string]dbus.Variant) *dbus.Error {
+	o.char.mu.RLock()
+	permitted := o.char.properties&(CharacteristicWrite|CharacteristicWriteWithoutResponse) != 0
+	onWrite := o.char.onWrite
+	o.char.mu.RUnlock()
+
+	if !permitted {
+		return errGattNotPermitted
+	}
+
+	offset := offsetOption(options)
+	current := o.char.Value()
+	if offset > len(current) {
+		return errGattInvalidOffset
+	}
+	newValue := append(current[:offset], value...)
+
+	if onWrite != nil {
+		if err := onWrite(newValue); err != nil {
+			return dbus.NewError(errGattFailed.Name, []interface{}{err.Error()})
+		}
+	}
+
+	_ = o.char.SetValue(newValue)
+	return nil
+}
+
+// StartNotify is called by BlueZ, when the first remote device subscribes to the characteristic
+func (o *linuxGattCharacteristicObject) StartNotify() *dbus.Error {
+	o.char.mu.Lock()
+	permitted := o.char.properties&(CharacteristicNotify|CharacteristicIndicate) != 0
+	if permitted {
+		o.char.notifying = true
+	}
+	onSubscribe := o.char.onSubscribe
+	o.char.mu.Unlock()
+
+	if !permitted {
+		return errGattNotPermitted
+	}
+	if onSubscribe != nil {
+		onSubscribe()
+	}
+	return nil
+}
+
+// StopNotify is called by BlueZ, when the last remote device unsubscribes from the characteristic
+func (o *linuxGattCharacteristicObject) StopNotify() *dbus.Error {
+	o.char.mu.Lock()
+	o.char.notifying = false
+	onUnsubscribe := o.char.onUnsubscribe
+	o.char.mu.Unlock()
+
+	if onUnsubscribe != nil {
+		onUnsubscribe()
+	}
+	return nil
+}
+
+// Release is called by BlueZ, when the advertisement is removed
+func (a *linuxAdvertisementObject) Release() *dbus.Error {
+	a.peripheral.mu.Lock()
+	a.peripheral.advertising = false
+	a.peripheral.mu.Unlock()
+
+	return nil
+}
+
+// NotifySubscribers stores the value and sends it to the subscribed remote devices
+func (c *linuxPeripheralCharacteristic) NotifySubscribers(data []byte) error {
+	if err := c.SetValue(data); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	props := c.props
+	notifying := c.notifying
+	c.mu.RUnlock()
+
+	if props == nil || !notifying {
+		return nil
+	}
+
+	conn := c.service.peripheral.adapter.manager.conn
+	err := conn.Emit(c.path, propertiesInterface+"."+propertiesChangedMember, gattCharInterface,
+		map[string]dbus.Variant{"Value": dbus.MakeVariant(slices.Clone(data))}, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to notify subscribers: %w", err)
+	}
+
+	return nil
+}
+
+func (s *linuxPeripheralService) peripheralCharacteristics() []*linuxPeripheralCharacteristic {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	chars := make([]*linuxPeripheralCharacteristic, 0, len(s.characteristics))
+	for _, char := range s.characteristics {
+		chars = append(chars, char)
+	}
+	return chars
+}
+
+func (s *linuxPeripheralService) dbusProps() map[string]*prop.Prop {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return map[string]*prop.Prop{
+		"UUID":    {Value: s.uuid.String(), Emit: prop.EmitConst},
+		"Primary": {Value: s.primary, Emit: prop.EmitConst},
+	}
+}
+
+func (c *linuxPeripheralCharacteristic) dbusProps() map[string]*prop.Prop {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var flags []string
+	for _, f := range characteristicFlags {
+		if c.properties&f.property != 0 {
+			flags = append(flags, f.flag)
+		}
+	}
+
+	return map[string]*prop.Prop{
+		"UUID":    {Value: c.uuid.String(), Emit: prop.EmitConst},
+		"Service": {Value: c.service.path, Emit: prop.EmitConst},
+		"Flags":   {Value: flags, Emit: prop.EmitConst},
+	}
+}
+
+// advertisementProps converts the parameters and the data to the properties of org.bluez.LEAdvertisement1
+func advertisementProps(params AdvertisingParams, data AdvertisementData) map[string]*prop.Prop {
+	advType := "broadcast"
+	if params.Connectable {
+		advType = "peripheral"
+	}
+
+	props := map[string]*prop.Prop{
+		"Type":         {Value: advType, Emit: prop.EmitConst},
+		"Discoverable": {Value: params.Discoverable, Emit: prop.EmitConst},
+	}
+
+	if data.LocalName != "" {
+		props["LocalName"] = &prop.Prop{Value: data.LocalName, Emit: prop.EmitConst}
+	}
+	if len(data.ServiceUUIDs) > 0 {
+		uuids := make([]string, 0, len(data.ServiceUUIDs))
+		for _, u := range data.ServiceUUIDs {
+			uuids = append(uuids, u.String())
+		}
+		props["ServiceUUIDs"] = &prop.Prop{Value: uuids, Emit: prop.EmitConst}
+	}
+	if len(data.ServiceData) > 0 {
+		serviceData := make(map[string]dbus.Variant, len(data.ServiceData))
+		for u, d := range data.ServiceData {
+			serviceData[u.String()] = dbus.MakeVariant(slices.Clone(d))
+		}
+		props["ServiceData"] = &prop.Prop{Value: serviceData, Emit: prop.EmitConst}
+	}
+	if len(data.ManufacturerData) > 0 {
+		manufacturerData := make(map[uint16]dbus.Variant, len(data.ManufacturerData))
+		for id, d := range data.ManufacturerData {
+			manufacturerData[id] = dbus.MakeVariant(slices.Clone(d))
+		}
+		props["ManufacturerData"] = &prop.Prop{Value: manufacturerData, Emit: prop.EmitConst}
+	}
+	if data.Appearance != nil {
+		props["Appearance"] = &prop.Prop{Value: *data.Appearance, Emit: prop.EmitConst}
+	}
+	if data.TxPowerLevel != nil {
+		props["Includes"] = &prop.Prop{Value: []string{"tx-power"}, Emit: prop.EmitConst}
+	}
+	if params.TxPower != nil {
+		props["TxPower"] = &prop.Prop{Value: int16(*params.TxPower), Emit: prop.EmitConst}
+	}
+	if params.Timeout > 0 {
+		props["Timeout"] = &prop.Prop{Value: uint16(params.Timeout.Seconds()), Emit: prop.EmitConst}
+	}
+	if params.Interval > 0 {
+		interval := uint32(params.Interval.Milliseconds())
+		props["MinInterval"] = &prop.Prop{Value: interval, Emit: prop.EmitConst}
+		props["MaxInterval"] = &prop.Prop{Value: interval, Emit: prop.EmitConst}
+	}
+
+	return props
+}
+
+func variants(props map[string]*prop.Prop) map[string]dbus.Variant {
+	result := make(map[string]dbus.Variant, len(props))
+	for name, p := range props {
+		result[name] = dbus.MakeVariant(p.Value)
+	}
+	return result
+}
+
+// offsetOption returns the "offset" option of a read or write request of BlueZ
+func offsetOption(options map[string]dbus.Variant) int {
+	if v, ok := options["offset"]; ok {
+		if offset, ok := v.Value().(uint16); ok {
+			return int(offset)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,192 @@
+//go:build linux
+
+package bluetooth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testStatusServiceUUID = "12345678-1234-1234-1234-123456789abc"
+	testStatusCharUUID    = "12345678-1234-1234-1234-123456789abd"
+)
+
+// newTestPeripheral creates a peripheral with a status service and characteristic and starts advertising
+func newTestPeripheral(t *testing.T) (*testBlueZ, *linuxPeripheral, *linuxPeripheralCharacteristic) {
+	t.Helper()
+
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addAdapter(testAdapterPath)
+	p := newTestLinuxAdapter(t, address).peripheral
+	service, err := p.AddService(mustTestUUID(t, testStatusServiceUUID), true)
+	require.NoError(t, err)
+	char, err := service.AddCharacteristic(mustTestUUID(t, testStatusCharUUID),
+		CharacteristicRead|CharacteristicWrite|CharacteristicNotify, []byte("idle"))
+	require.NoError(t, err)
+
+	params := DefaultAdvertisingParams()
+	data := AdvertisementData{
+		LocalName:        "gobot",
+		ServiceUUIDs:     []UUID{mustTestUUID(t, testStatusServiceUUID)},
+		ManufacturerData: map[uint16][]byte{0xffff: {0x01, 0x02}},
+	}
+	require.NoError(t, p.StartAdvertising(context.Background(), params, data))
+
+	return bluez, p, char.(*linuxPeripheralCharacteristic)
+}
+
+func TestLinuxPeripheralAdvertising(t *testing.T) {
+	// arrange & act
+	bluez, p, char := newTestPeripheral(t)
+	// assert
+	assert.True(t, p.IsAdvertising())
+	bluez.mu.Lock()
+	adv := bluez.advertisement
+	app := bluez.application
+	bluez.mu.Unlock()
+	assert.Equal(t, "peripheral", adv["Type"].Value())
+	assert.Equal(t, "gobot", adv["LocalName"].Value())
+	assert.Equal(t, []string{testStatusServiceUUID}, adv["ServiceUUIDs"].Value())
+	assert.Equal(t, map[uint16]dbus.Variant{0xffff: dbus.MakeVariant([]byte{0x01, 0x02})},
+		adv["ManufacturerData"].Value())
+	assert.Equal(t, uint32(100), adv["MinInterval"].Value())
+	require.Len(t, app, 2)
+	require.Contains(t, app, char.path)
+	charProps := app[char.path][gattCharInterface]
+	assert.Equal(t, testStatusCharUUID, charProps["UUID"].Value())
+	assert.Equal(t, char.service.path, charProps["Service"].Value())
+	assert.Equal(t, []string{"read", "write", "notify"}, charProps["Flags"].Value())
+	assert.Equal(t, true, app[char.service.path][gattServiceInterface]["Primary"].Value())
+	_, err := p.AddService(mustTestUUID(t, "180F"), true)
+	require.ErrorContains(t, err, "can not be added")
+	// act
+	require.NoError(t, p.StopAdvertising(context.Background()))
+	// assert
+	assert.False(t, p.IsAdvertising())
+	assert.Subset(t, bluez.recordedCalls(), []string{
+		string(testAdapterPath) + ".UnregisterAdvertisement",
+		string(testAdapterPath) + ".UnregisterApplication",
+	})
+	err = bluez.peripheralObject(char.path).Call(gattCharInterface+".ReadValue", 0, map[string]dbus.Variant{}).Err
+	require.Error(t, err)
+}
+
+func TestLinuxPeripheralAdvertisingFailed(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addAdapter(testAdapterPath)
+	bluez.failCall(testAdapterPath, "RegisterAdvertisement")
+	p := newTestLinuxAdapter(t, address).peripheral
+	// act
+	err := p.StartAdvertising(context.Background(), DefaultAdvertisingParams(), AdvertisementData{})
+	// assert
+	require.ErrorContains(t, err, "failed to start advertising")
+	assert.False(t, p.IsAdvertising())
+	assert.NotContains(t, bluez.recordedCalls(), string(testAdapterPath)+".RegisterApplication")
+}
+
+func TestLinuxPeripheralCharacteristicRequests(t *testing.T) {
+	// arrange
+	bluez, p, char := newTestPeripheral(t)
+	defer func() { _ = p.StopAdvertising(context.Background()) }()
+	obj := bluez.peripheralObject(char.path)
+	written := make(chan []byte, 1)
+	char.OnWrite(func(data []byte) error {
+		if string(data) == "invalid" {
+			return fmt.Errorf("invalid state")
+		}
+		written <- data
+		return nil
+	})
+	// act & assert: read
+	var value []byte
+	require.NoError(t, obj.Call(gattCharInterface+".ReadValue", 0, map[string]dbus.Variant{}).Store(&value))
+	assert.Equal(t, []byte("idle"), value)
+	err := obj.Call(gattCharInterface+".ReadValue", 0,
+		map[string]dbus.Variant{"offset": dbus.MakeVariant(uint16(2))}).Store(&value)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("le"), value)
+	char.OnRead(func() []byte { return []byte("busy") })
+	require.NoError(t, obj.Call(gattCharInterface+".ReadValue", 0, map[string]dbus.Variant{}).Store(&value))
+	assert.Equal(t, []byte("busy"), value)
+	// act & assert: write
+	require.NoError(t, obj.Call(gattCharInterface+".WriteValue", 0, []byte("run"), map[string]dbus.Variant{}).Err)
+	assert.Equal(t, []byte("run"), <-written)
+	assert.Equal(t, []byte("run"), char.Value())
+	err = obj.Call(gattCharInterface+".WriteValue", 0, []byte("invalid"), map[string]dbus.Variant{}).Err
+	require.ErrorContains(t, err, "invalid state")
+	assert.Equal(t, []byte("run"), char.Value())
+}
+
+func TestLinuxPeripheralNotifySubscribers(t *testing.T) {
+	// arrange
+	bluez, p, char := newTestPeripheral(t)
+	defer func() { _ = p.StopAdvertising(context.Background()) }()
+	require.NoError(t, bluez.conn.AddMatchSignal(propertiesChangedMatch(char.path, gattCharInterface)...))
+	signals := make(chan *dbus.Signal, 10)
+	bluez.conn.Signal(signals)
+	var subscribed, unsubscribed int
+	var mtx sync.Mutex
+	char.OnSubscribe(func() { mtx.Lock(); subscribed++; mtx.Unlock() })
+	char.OnUnsubscribe(func() { mtx.Lock(); unsubscribed++; mtx.Unlock() })
+	obj := bluez.peripheralObject(char.path)
+	// act: a value without subscribers is only stored
+	require.NoError(t, char.NotifySubscribers([]byte("first")))
+	require.NoError(t, obj.Call(gattCharInterface+".StartNotify", 0).Err)
+	require.NoError(t, char.NotifySubscribers([]byte("second")))
+	// assert
+	select {
+	case sig := <-signals:
+		require.Len(t, sig.Body, 3)
+		assert.Equal(t, gattCharInterface, sig.Body[0])
+		assert.Equal(t, map[string]dbus.Variant{"Value": dbus.MakeVariant([]byte("second"))}, sig.Body[1])
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "notification not emitted")
+	}
+	require.NoError(t, obj.Call(gattCharInterface+".StopNotify", 0).Err)
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.Equal(t, 1, subscribed)
+	assert.Equal(t, 1, unsubscribed)
+}
+
+func TestLinuxPeripheralConnectionCallbacks(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addDevice(testDevicePath)
+	p := newTestLinuxAdapter(t, address).peripheral
+	connected := make(chan Device, 1)
+	disconnected := make(chan Device, 1)
+	p.OnConnect(func(d Device) { connected <- d })
+	p.OnDisconnect(func(d Device) { disconnected <- d })
+	// act
+	bluez.setProperty(testDevicePath, deviceInterface, "Connected", true)
+	// assert
+	select {
+	case d := <-connected:
+		assert.Equal(t, "11:22:33:44:55:66", d.Address().String())
+		assert.True(t, d.Connected())
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "connect callback not called")
+	}
+	// act
+	bluez.disconnectRemote(testDevicePath)
+	// assert
+	select {
+	case d := <-disconnected:
+		assert.False(t, d.Connected())
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "disconnect callback not called")
+	}
+}
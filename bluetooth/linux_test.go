@@ -0,0 +1,448 @@
+//go:build linux
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCharPath1 = testDevicePath + "/service000c/char000d"
+	testCharPath2 = testDevicePath + "/service000c/char0010"
+)
+
+func testSubscribed(c *linuxCharacteristic) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.subscribed
+}
+
+func receiveTestValue(t *testing.T, values chan []byte) []byte {
+	t.Helper()
+
+	select {
+	case value := <-values:
+		return value
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "notification not received")
+		return nil
+	}
+}
+
+func TestLinuxCharacteristicSubscribe(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addCharacteristic(testCharPath1)
+	bluez.addCharacteristic(testCharPath2)
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	char1 := addTestLinuxCharacteristic(device, testCharPath1)
+	char2 := addTestLinuxCharacteristic(device, testCharPath2)
+	values1 := make(chan []byte, 10)
+	values2 := make(chan []byte, 10)
+	// act
+	require.NoError(t, char1.Subscribe(context.Background(), func(v []byte) { values1 <- v }))
+	require.NoError(t, char2.Subscribe(context.Background(), func(v []byte) { values2 <- v }))
+	bluez.notify(testCharPath1, []byte{0x01})
+	bluez.notify(testCharPath2, []byte{0x02})
+	bluez.notify(testCharPath1, []byte{0x03, 0x04})
+	// assert
+	assert.Equal(t, []byte{0x01}, receiveTestValue(t, values1))
+	assert.Equal(t, []byte{0x03, 0x04}, receiveTestValue(t, values1))
+	assert.Equal(t, []byte{0x02}, receiveTestValue(t, values2))
+	assert.True(t, testSubscribed(char1))
+	assert.Contains(t, bluez.recordedCalls(), string(testCharPath1)+".StartNotify")
+}
+
+func TestLinuxCharacteristicUnsubscribe(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addCharacteristic(testCharPath1)
+	bluez.addCharacteristic(testCharPath2)
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	char1 := addTestLinuxCharacteristic(device, testCharPath1)
+	char2 := addTestLinuxCharacteristic(device, testCharPath2)
+	values1 := make(chan []byte, 10)
+	values2 := make(chan []byte, 10)
+	require.NoError(t, char1.Subscribe(context.Background(), func(v []byte) { values1 <- v }))
+	require.NoError(t, char2.Subscribe(context.Background(), func(v []byte) { values2 <- v }))
+	// act
+	require.NoError(t, char1.Unsubscribe(context.Background()))
+	bluez.notify(testCharPath1, []byte{0x01})
+	bluez.notify(testCharPath2, []byte{0x02})
+	// assert: the signals are delivered in order, so the first value would be received before the second
+	assert.Equal(t, []byte{0x02}, receiveTestValue(t, values2))
+	assert.Empty(t, values1)
+	assert.False(t, testSubscribed(char1))
+	assert.Contains(t, bluez.recordedCalls(), string(testCharPath1)+".StopNotify")
+	assert.NotContains(t, device.central.adapter.manager.notifications.subs, testCharPath1)
+}
+
+func TestLinuxCharacteristicSubscribeFailed(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addCharacteristic(testCharPath1)
+	bluez.failCall(testCharPath1, "StartNotify")
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	char := addTestLinuxCharacteristic(device, testCharPath1)
+	// act
+	err := char.Subscribe(context.Background(), func([]byte) {})
+	// assert
+	require.ErrorContains(t, err, "failed to start notifications")
+	assert.False(t, testSubscribed(char))
+	assert.Empty(t, device.central.adapter.manager.notifications.subs)
+	assert.Empty(t, device.central.adapter.manager.notifications.devices)
+	require.ErrorContains(t, char.Subscribe(context.Background(), nil), "callback is mandatory")
+}
+
+func TestLinuxDeviceDisconnectEndsSubscriptions(t *testing.T) {
+	tests := map[string]struct {
+		disconnect func(*testBlueZ, *linuxDevice) error
+	}{
+		"disconnect": {
+			disconnect: func(_ *testBlueZ, d *linuxDevice) error { return d.Disconnect(context.Background()) },
+		},
+		"disconnect_by_remote": {
+			disconnect: func(b *testBlueZ, d *linuxDevice) error {
+				b.disconnectRemote(d.path)
+				return nil
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			address := newTestBus(t)
+			bluez := newTestBlueZ(t, address)
+			bluez.addDevice(testDevicePath)
+			bluez.addCharacteristic(testCharPath1)
+			device := newTestLinuxDevice(t, address, testDevicePath)
+			char := addTestLinuxCharacteristic(device, testCharPath1)
+			notifications := device.central.adapter.manager.notifications
+			require.NoError(t, char.Subscribe(context.Background(), func([]byte) {}))
+			// act
+			require.NoError(t, tc.disconnect(bluez, device))
+			// assert
+			assert.Eventually(t, func() bool {
+				notifications.mu.Lock()
+				defer notifications.mu.Unlock()
+				return len(notifications.subs) == 0 && len(notifications.devices) == 0
+			}, 2*time.Second, 10*time.Millisecond)
+			assert.Eventually(t, func() bool { return !device.Connected() }, 2*time.Second, 10*time.Millisecond)
+			assert.False(t, testSubscribed(char))
+		})
+	}
+}
+
+const (
+	testBatteryServicePath = testDevicePath + "/service000c"
+	testBatteryLevelPath   = testBatteryServicePath + "/char000d"
+	testInfoServicePath    = testDevicePath + "/service0010"
+	testOtherServicePath   = dbus.ObjectPath("/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF/service000c")
+)
+
+func mustTestUUID(t *testing.T, s string) UUID {
+	t.Helper()
+
+	u, err := NewUUID(s)
+	require.NoError(t, err)
+	return u
+}
+
+// addTestGattTree adds a battery and a device information service to the test device and a service to another
+// device
+func addTestGattTree(b *testBlueZ) {
+	b.addObject(testBatteryServicePath, gattServiceInterface, map[string]dbus.Variant{
+		"UUID":    dbus.MakeVariant("0000180f-0000-1000-8000-00805f9b34fb"),
+		"Device":  dbus.MakeVariant(testDevicePath),
+		"Primary": dbus.MakeVariant(true),
+	})
+	b.addObject(testBatteryLevelPath, gattCharInterface, map[string]dbus.Variant{
+		"UUID":    dbus.MakeVariant("00002a19-0000-1000-8000-00805f9b34fb"),
+		"Service": dbus.MakeVariant(testBatteryServicePath),
+		"Flags":   dbus.MakeVariant([]string{"read", "notify"}),
+	})
+	b.addObject(testBatteryLevelPath+"/desc000f", gattDescInterface, map[string]dbus.Variant{
+		"UUID":           dbus.MakeVariant("00002902-0000-1000-8000-00805f9b34fb"),
+		"Characteristic": dbus.MakeVariant(testBatteryLevelPath),
+	})
+	b.addObject(testInfoServicePath, gattServiceInterface, map[string]dbus.Variant{
+		"UUID":    dbus.MakeVariant("0000180a-0000-1000-8000-00805f9b34fb"),
+		"Device":  dbus.MakeVariant(testDevicePath),
+		"Primary": dbus.MakeVariant(true),
+	})
+	b.addObject(testOtherServicePath, gattServiceInterface, map[string]dbus.Variant{
+		"UUID":   dbus.MakeVariant("0000180d-0000-1000-8000-00805f9b34fb"),
+		"Device": dbus.MakeVariant(dbus.ObjectPath("/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF")),
+	})
+}
+
+func TestLinuxDeviceDiscoverServices(t *testing.T) {
+	tests := map[string]struct {
+		uuids        []string
+		wantServices []string
+	}{
+		"all_services": {
+			wantServices: []string{"180F", "180A"},
+		},
+		"filtered_services": {
+			uuids:        []string{"180F", "1812"},
+			wantServices: []string{"180F"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			address := newTestBus(t)
+			bluez := newTestBlueZ(t, address)
+			bluez.addDevice(testDevicePath)
+			bluez.setProperty(testDevicePath, deviceInterface, "ServicesResolved", true)
+			addTestGattTree(bluez)
+			device := newTestLinuxDevice(t, address, testDevicePath)
+			var uuids []UUID
+			for _, u := range tc.uuids {
+				uuids = append(uuids, mustTestUUID(t, u))
+			}
+			// act
+			err := device.DiscoverServices(context.Background(), uuids)
+			// assert
+			require.NoError(t, err)
+			defer device.stopWatch()
+			var got []UUID
+			for _, service := range device.Services() {
+				got = append(got, service.UUID())
+			}
+			var want []UUID
+			for _, u := range tc.wantServices {
+				want = append(want, mustTestUUID(t, u))
+			}
+			assert.ElementsMatch(t, want, got)
+			service, err := device.GetService(mustTestUUID(t, "180F"))
+			require.NoError(t, err)
+			assert.True(t, service.Primary())
+			char, err := service.GetCharacteristic(mustTestUUID(t, "2A19"))
+			require.NoError(t, err)
+			assert.Equal(t, CharacteristicRead|CharacteristicNotify, char.Properties())
+			require.Len(t, char.Descriptors(), 1)
+			assert.Equal(t, mustTestUUID(t, "2902"), char.Descriptors()[0].UUID())
+			assert.NotContains(t, bluez.recordedCalls(), string(testDevicePath)+".Connect")
+		})
+	}
+}
+
+func TestLinuxDeviceDiscoverServicesWaitsForResolution(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addDevice(testDevicePath)
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	device.connected = false
+	done := make(chan error, 1)
+	// act
+	go func() { done <- device.DiscoverServices(context.Background(), nil) }()
+	// assert
+	require.Eventually(t, func() bool {
+		return slices.Contains(bluez.recordedCalls(), string(testDevicePath)+".Connect")
+	}, 2*time.Second, 10*time.Millisecond)
+	select {
+	case err := <-done:
+		require.Fail(t, "discovery finished before the services are resolved", "error: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	addTestGattTree(bluez)
+	bluez.setProperty(testDevicePath, deviceInterface, "ServicesResolved", true)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "discovery not finished")
+	}
+	assert.Len(t, device.Services(), 2)
+	require.NoError(t, device.Disconnect(context.Background()))
+	assert.Nil(t, device.watch)
+}
+
+func TestLinuxDeviceDiscoverServicesAfterReconnect(t *testing.T) {
+	// arrange: the services were resolved for the former connection
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addDevice(testDevicePath)
+	bluez.setProperty(testDevicePath, deviceInterface, "ServicesResolved", true)
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	require.NoError(t, device.DiscoverServices(context.Background(), nil))
+	defer device.stopWatch()
+	watch := device.watch
+	bluez.setProperty(testDevicePath, deviceInterface, "ServicesResolved", false)
+	bluez.setProperty(testDevicePath, deviceInterface, "Connected", false)
+	require.Eventually(t, func() bool {
+		select {
+		case <-watch.resolution():
+			return false
+		default:
+			return true
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+	device.mu.Lock()
+	device.connected = false
+	device.mu.Unlock()
+	done := make(chan error, 1)
+	// act
+	go func() { done <- device.DiscoverServices(context.Background(), nil) }()
+	// assert
+	select {
+	case err := <-done:
+		require.Fail(t, "discovery finished before the services are resolved again", "error: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	bluez.setProperty(testDevicePath, deviceInterface, "ServicesResolved", true)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "discovery not finished")
+	}
+}
+
+func TestLinuxDeviceDiscoverServicesTimeout(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addDevice(testDevicePath)
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// act
+	err := device.DiscoverServices(ctx, nil)
+	// assert
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, device.Services())
+	device.stopWatch()
+}
+
+func TestLinuxDeviceServicesAddedLater(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addDevice(testDevicePath)
+	bluez.setProperty(testDevicePath, deviceInterface, "ServicesResolved", true)
+	device := newTestLinuxDevice(t, address, testDevicePath)
+	require.NoError(t, device.DiscoverServices(context.Background(), nil))
+	defer device.stopWatch()
+	require.Empty(t, device.Services())
+	battery := mustTestUUID(t, "180F")
+	// act
+	bluez.addObjectLater(testBatteryServicePath, gattServiceInterface, map[string]dbus.Variant{
+		"UUID":   dbus.MakeVariant(battery.String()),
+		"Device": dbus.MakeVariant(testDevicePath),
+	})
+	bluez.addObjectLater(testBatteryLevelPath, gattCharInterface, map[string]dbus.Variant{
+		"UUID":    dbus.MakeVariant("00002a19-0000-1000-8000-00805f9b34fb"),
+		"Service": dbus.MakeVariant(testBatteryServicePath),
+		"Flags":   dbus.MakeVariant([]string{"read"}),
+	})
+	// assert
+	require.Eventually(t, func() bool {
+		service, err := device.GetService(battery)
+		if err != nil {
+			return false
+		}
+		_, err = service.GetCharacteristic(mustTestUUID(t, "2A19"))
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	// act
+	bluez.removeObject(testBatteryServicePath)
+	// assert
+	require.Eventually(t, func() bool {
+		_, err := device.GetService(battery)
+		return errors.Is(err, ErrServiceNotFound)
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestLinuxCentralScanFilter(t *testing.T) {
+	// arrange
+	address := newTestBus(t)
+	bluez := newTestBlueZ(t, address)
+	bluez.addAdapter(testAdapterPath)
+	central := newTestLinuxAdapter(t, address).central
+	params := DefaultScanParams()
+	params.Timeout = 5 * time.Second
+	params.Filter = ScanFilter{NamePrefix: "gobot", MinRSSI: -80, ManufacturerIDs: []uint16{CompanyIDApple}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	found := make(chan Advertisement, 10)
+	scanErr := make(chan error, 1)
+	emitDevice := func(mac, name string, rssi int16, manufacturerData map[uint16]dbus.Variant) {
+		path := testAdapterPath + dbus.ObjectPath("/dev_"+mac)
+		props := map[string]dbus.Variant{
+			"Name":             dbus.MakeVariant(name),
+			"RSSI":             dbus.MakeVariant(rssi),
+			"ManufacturerData": dbus.MakeVariant(manufacturerData),
+		}
+		err := bluez.conn.Emit(bluezRootPath, objectManagerInterface+"."+interfacesAddedMember, path,
+			map[string]map[string]dbus.Variant{deviceInterface: props})
+		require.NoError(t, err)
+	}
+	apple := map[uint16]dbus.Variant{CompanyIDApple: dbus.MakeVariant([]byte{0x01})}
+	// act
+	go func() { scanErr <- central.Scan(ctx, params, func(adv Advertisement) { found <- adv }) }()
+	require.Eventually(t, func() bool {
+		return slices.Contains(bluez.recordedCalls(), string(testAdapterPath)+".StartDiscovery")
+	}, 2*time.Second, 10*time.Millisecond)
+	emitDevice("11_22_33_44_55_01", "gobot-1", -60, apple)
+	emitDevice("11_22_33_44_55_01", "gobot-1", -65, apple) // duplicate
+	emitDevice("11_22_33_44_55_02", "gobot-2", -60, map[uint16]dbus.Variant{0x0059: dbus.MakeVariant([]byte{0x01})})
+	emitDevice("11_22_33_44_55_03", "other", -60, apple)
+	emitDevice("11_22_33_44_55_04", "gobot-4", -50, apple)
+	// assert: the signals are handled in order, so the filtered ones are handled before the last one
+	var names []string
+	for range 2 {
+		select {
+		case adv := <-found:
+			names = append(names, adv.LocalName)
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "advertisement not reported")
+		}
+	}
+	assert.Equal(t, []string{"gobot-1", "gobot-4"}, names)
+	assert.Empty(t, found)
+	filter := bluez.discoveryFilter()
+	assert.Equal(t, "le", filter["Transport"].Value())
+	assert.Equal(t, "gobot", filter["Pattern"].Value())
+	assert.Equal(t, int16(-80), filter["RSSI"].Value())
+	assert.Equal(t, false, filter["DuplicateData"].Value())
+	assert.NotContains(t, filter, "UUIDs")
+	// act
+	cancel()
+	// assert
+	select {
+	case err := <-scanErr:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "scan not stopped")
+	}
+	assert.Empty(t, bluez.discoveryFilter())
+}
+
+func TestLinuxDiscoveryFilter(t *testing.T) {
+	// arrange
+	params := DefaultScanParams()
+	params.DuplicateWindow = time.Second
+	params.Filter.ServiceUUIDs = []UUID{mustParseUUID("180D")}
+	// act
+	filter := discoveryFilter(params)
+	// assert
+	assert.Equal(t, []string{"0000180d-0000-1000-8000-00805f9b34fb"}, filter["UUIDs"].Value())
+	assert.Equal(t, true, filter["DuplicateData"].Value())
+	assert.NotContains(t, filter, "RSSI")
+	assert.NotContains(t, filter, "Pattern")
+}
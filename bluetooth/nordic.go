@@ -348,6 +348,8 @@ func (c *nordicCentral) Disable(ctx context.Context) error {
 	return ErrNotSupported
 }
 
+// Scan applies the filter and the duplicate suppression of the params in software. The advertising data is not
+// parsed yet, so a filter by services, name or manufacturer reports no advertisement, only MinRSSI is useful.
 func (c *nordicCentral) Scan(ctx context.Context, params ScanParams, callback func(Advertisement)) error {
 	c.mu.Lock()
 	if c.scanning {
@@ -355,7 +357,7 @@ func (c *nordicCentral) Scan(ctx context.Context, params ScanParams, callback fu
 		return fmt.Errorf("scan already in progress")
 	}
 	c.scanning = true
-	c.scanCallback = callback
+	c.scanCallback = scanCallback(params, callback)
 	c.mu.Unlock()
 
 	defer func() {
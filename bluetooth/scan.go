@@ -0,0 +1,114 @@
+package bluetooth
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScanFilter restricts the advertisements reported by a scan. All given criteria need to match, an empty filter
+// reports all advertisements. Platforms push the criteria down to the controller, as far as supported.
+type ScanFilter struct {
+	ServiceUUIDs    []UUID   // at least one of the services needs to be advertised
+	MinRSSI         int16    // minimum signal strength, 0 for no threshold
+	NamePrefix      string   // prefix of the local name
+	ManufacturerIDs []uint16 // manufacturer data of at least one of the companies needs to be advertised
+}
+
+// IsEmpty returns true, if the filter does not restrict the advertisements
+func (f ScanFilter) IsEmpty() bool {
+	return len(f.ServiceUUIDs) == 0 && f.MinRSSI == 0 && f.NamePrefix == "" && len(f.ManufacturerIDs) == 0
+}
+
+// Matches returns true, if the advertisement passes all criteria of the filter
+func (f ScanFilter) Matches(adv Advertisement) bool {
+	if len(f.ServiceUUIDs) > 0 && !slices.ContainsFunc(adv.ServiceUUIDs, func(u UUID) bool {
+		return slices.Contains(f.ServiceUUIDs, u)
+	}) {
+		return false
+	}
+
+	if f.MinRSSI != 0 && adv.RSSI < f.MinRSSI {
+		return false
+	}
+
+	if f.NamePrefix != "" && !strings.HasPrefix(adv.LocalName, f.NamePrefix) {
+		return false
+	}
+
+	if len(f.ManufacturerIDs) > 0 && !slices.ContainsFunc(f.ManufacturerIDs, func(id uint16) bool {
+		_, ok := adv.ManufacturerData[id]
+		return ok
+	}) {
+		return false
+	}
+
+	return true
+}
+
+// scanDeduplicator suppresses advertisements, which were already reported for the device with the same content.
+// A changed content is reported immediately, an unchanged content again after the window is elapsed. A window of
+// zero suppresses an unchanged content for the whole scan. The signal strength is not part of the content.
+type scanDeduplicator struct {
+	window time.Duration
+	mu     sync.Mutex
+	seen   map[Address]scanDeduplicatorEntry
+}
+
+type scanDeduplicatorEntry struct {
+	adv      Advertisement
+	reported time.Time
+}
+
+func newScanDeduplicator(window time.Duration) *scanDeduplicator {
+	return &scanDeduplicator{window: window, seen: make(map[Address]scanDeduplicatorEntry)}
+}
+
+// report returns true, if the advertisement received at the given time needs to be reported
+func (d *scanDeduplicator) report(adv Advertisement, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if last, ok := d.seen[adv.Address]; ok && sameAdvertisementContent(last.adv, adv) &&
+		(d.window <= 0 || now.Sub(last.reported) < d.window) {
+		return false
+	}
+
+	d.seen[adv.Address] = scanDeduplicatorEntry{adv: adv, reported: now}
+	return true
+}
+
+// scanCallback wraps the callback of a scan, so only the advertisements passing the filter of the parameters are
+// reported and duplicates are suppressed, if requested by the parameters
+func scanCallback(params ScanParams, callback func(Advertisement)) func(Advertisement) {
+	var dedup *scanDeduplicator
+	if params.FilterDuplicates {
+		dedup = newScanDeduplicator(params.DuplicateWindow)
+	}
+
+	return func(adv Advertisement) {
+		if !params.Filter.Matches(adv) {
+			return
+		}
+		if dedup != nil && !dedup.report(adv, time.Now()) {
+			return
+		}
+		callback(adv)
+	}
+}
+
+func sameAdvertisementContent(a, b Advertisement) bool {
+	if a.LocalName != b.LocalName || a.Connectable != b.Connectable || !slices.Equal(a.ServiceUUIDs, b.ServiceUUIDs) {
+		return false
+	}
+	if (a.TxPowerLevel == nil) != (b.TxPowerLevel == nil) ||
+		(a.TxPowerLevel != nil && *a.TxPowerLevel != *b.TxPowerLevel) {
+		return false
+	}
+
+	return maps.EqualFunc(a.ServiceData, b.ServiceData, bytes.Equal) &&
+		maps.EqualFunc(a.ManufacturerData, b.ManufacturerData, bytes.Equal)
+}
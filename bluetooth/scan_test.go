@@ -0,0 +1,124 @@
+package bluetooth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanFilterMatches(t *testing.T) {
+	heartRate := mustParseUUID("180D")
+	battery := mustParseUUID("180F")
+	adv := Advertisement{
+		RSSI:             -60,
+		LocalName:        "gobot-sensor",
+		ServiceUUIDs:     []UUID{heartRate},
+		ManufacturerData: map[uint16][]byte{CompanyIDApple: {0x01}},
+	}
+	tests := map[string]struct {
+		filter ScanFilter
+		want   bool
+	}{
+		"empty":                  {filter: ScanFilter{}, want: true},
+		"service":                {filter: ScanFilter{ServiceUUIDs: []UUID{battery, heartRate}}, want: true},
+		"service_missing":        {filter: ScanFilter{ServiceUUIDs: []UUID{battery}}, want: false},
+		"rssi":                   {filter: ScanFilter{MinRSSI: -60}, want: true},
+		"rssi_too_weak":          {filter: ScanFilter{MinRSSI: -59}, want: false},
+		"name_prefix":            {filter: ScanFilter{NamePrefix: "gobot-"}, want: true},
+		"name_prefix_mismatch":   {filter: ScanFilter{NamePrefix: "sensor"}, want: false},
+		"manufacturer":           {filter: ScanFilter{ManufacturerIDs: []uint16{0x0059, CompanyIDApple}}, want: true},
+		"manufacturer_missing":   {filter: ScanFilter{ManufacturerIDs: []uint16{0x0059}}, want: false},
+		"all_criteria":           {filter: ScanFilter{[]UUID{heartRate}, -70, "gobot", []uint16{CompanyIDApple}}, want: true},
+		"all_criteria_one_fails": {filter: ScanFilter{[]UUID{heartRate}, -50, "gobot", []uint16{CompanyIDApple}}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.filter.Matches(adv))
+			assert.Equal(t, name == "empty", tc.filter.IsEmpty())
+		})
+	}
+}
+
+func TestScanDeduplicator(t *testing.T) {
+	addr1 := Address{MAC: [6]byte{1}}
+	addr2 := Address{MAC: [6]byte{2}}
+	start := time.Now()
+	tests := map[string]struct {
+		window time.Duration
+		want   []bool
+	}{
+		"without_window": {window: 0, want: []bool{true, false, true, true, false, true}},
+		"with_window":    {window: time.Second, want: []bool{true, false, true, true, true, true}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			d := newScanDeduplicator(tc.window)
+			advs := []struct {
+				adv Advertisement
+				at  time.Duration
+			}{
+				{Advertisement{Address: addr1, RSSI: -50, LocalName: "a"}, 0},
+				{Advertisement{Address: addr1, RSSI: -70, LocalName: "a"}, 500 * time.Millisecond}, // only RSSI changed
+				{Advertisement{Address: addr2, LocalName: "a"}, 600 * time.Millisecond},            // other device
+				{Advertisement{Address: addr1, LocalName: "b"}, 700 * time.Millisecond},            // content changed
+				{Advertisement{Address: addr1, LocalName: "b"}, 1800 * time.Millisecond},           // window elapsed
+				{Advertisement{
+					Address:          addr1,
+					LocalName:        "b",
+					ManufacturerData: map[uint16][]byte{CompanyIDApple: {0x01}},
+				}, 1900 * time.Millisecond},
+			}
+			// act
+			var got []bool
+			for _, a := range advs {
+				got = append(got, d.report(a.adv, start.Add(a.at)))
+			}
+			// assert
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestScanCallback(t *testing.T) {
+	// arrange
+	params := DefaultScanParams()
+	params.Filter = ScanFilter{NamePrefix: "gobot"}
+	var reported []string
+	callback := scanCallback(params, func(adv Advertisement) { reported = append(reported, adv.LocalName) })
+	// act
+	callback(Advertisement{Address: Address{MAC: [6]byte{1}}, LocalName: "gobot-1"})
+	callback(Advertisement{Address: Address{MAC: [6]byte{1}}, LocalName: "gobot-1"})
+	callback(Advertisement{Address: Address{MAC: [6]byte{2}}, LocalName: "other"})
+	callback(Advertisement{Address: Address{MAC: [6]byte{3}}, LocalName: "gobot-3"})
+	// assert
+	assert.Equal(t, []string{"gobot-1", "gobot-3"}, reported)
+}
+
+func TestScanCallbackWithDuplicates(t *testing.T) {
+	// arrange
+	params := DefaultScanParams()
+	params.FilterDuplicates = false
+	var count int
+	callback := scanCallback(params, func(Advertisement) { count++ })
+	// act
+	callback(Advertisement{LocalName: "gobot"})
+	callback(Advertisement{LocalName: "gobot"})
+	// assert
+	assert.Equal(t, 2, count)
+}
+
+func TestValidateScanParamsFilter(t *testing.T) {
+	params := ScanParams{Timeout: 10 * time.Second, Interval: 30 * time.Millisecond, Window: 20 * time.Millisecond}
+	params.DuplicateWindow = -time.Second
+	require.ErrorContains(t, params.Validate(), "duplicate window")
+
+	params.DuplicateWindow = time.Second
+	params.Filter.MinRSSI = 30
+	require.ErrorContains(t, params.Validate(), "filter min RSSI")
+
+	params.Filter.MinRSSI = -80
+	require.NoError(t, params.Validate())
+}
@@ -36,6 +36,8 @@ const (
 	MaxScanInterval  = 40959 * time.Microsecond
 	MinScanWindow    = 2500 * time.Microsecond
 	MaxScanWindow    = 40959 * time.Microsecond
+	MinScanRSSI      = -127
+	MaxScanRSSI      = 20
 
 	// Advertising parameter limits
 	MinAdvertisingInterval = 20 * time.Millisecond
@@ -219,6 +221,15 @@ func ValidateScanParams(params ScanParams) error {
 			"scan window cannot be greater than scan interval")
 	}
 
+	if params.DuplicateWindow < 0 {
+		return NewValidationError("duplicate window", params.DuplicateWindow, "cannot be negative")
+	}
+
+	if params.Filter.MinRSSI < MinScanRSSI || params.Filter.MinRSSI > MaxScanRSSI {
+		return NewValidationError("filter min RSSI", params.Filter.MinRSSI,
+			fmt.Sprintf("must be between %d and %d", MinScanRSSI, MaxScanRSSI))
+	}
+
 	return nil
 }
 
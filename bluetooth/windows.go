@@ -236,7 +236,7 @@ func (c *windowsCentral) Scan(ctx context.Context, params ScanParams, callback f
 		return fmt.Errorf("scan already in progress")
 	}
 	c.scanning = true
-	c.scanCallback = callback
+	c.scanCallback = scanCallback(params, callback)
 	c.mu.Unlock()
 
 	defer func() {
@@ -23,11 +23,14 @@ type nameOption string
 // Driver implements the interface gobot.Driver.
 type Driver struct {
 	gobot.Commander
-	connection interface{}
-	driverCfg  *configuration
-	afterStart func() error
-	beforeHalt func() error
-	mutex      *sync.Mutex
+	connection     interface{}
+	driverCfg      *configuration
+	afterStart     func() error
+	beforeHalt     func() error
+	afterReconnect func() error
+	started        bool
+	registered     bool
+	mutex          *sync.Mutex
 }
 
 // NewDriver creates a new basic BLE gobot driver.
@@ -86,14 +89,33 @@ func (d *Driver) Connection() gobot.Connection {
 	return nil
 }
 
+// SetAfterReconnect sets the function, which is called after the adaptor has re-established a lost connection
+// and restored the notification subscriptions, e.g. to re-run the parts of the initialization sequence, which
+// configure the device. The function is not called for a halted driver. Adaptors, which do not implement
+// gobot.BLEReconnectNotifier, never call it.
+func (d *Driver) SetAfterReconnect(afterReconnect func() error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.afterReconnect = afterReconnect
+}
+
 // Start initializes the driver.
 func (d *Driver) Start() error {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	// currently there is nothing to do here for the driver
+	if n, ok := d.connection.(gobot.BLEReconnectNotifier); ok && !d.registered {
+		n.OnReconnect(d.reconnected)
+		d.registered = true
+	}
+
+	if err := d.afterStart(); err != nil {
+		return err
+	}
 
-	return d.afterStart()
+	d.started = true
+	return nil
 }
 
 // Halt halts the driver.
@@ -101,7 +123,7 @@ func (d *Driver) Halt() error {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	// currently there is nothing to do after halt for the driver
+	d.started = false
 
 	return d.beforeHalt()
 }
@@ -116,6 +138,18 @@ func (d *Driver) Adaptor() gobot.BLEConnector {
 	return nil
 }
 
+// reconnected is called by the adaptor after the connection was re-established
+func (d *Driver) reconnected() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.started || d.afterReconnect == nil {
+		return nil
+	}
+
+	return d.afterReconnect()
+}
+
 func (d *Driver) Mutex() *sync.Mutex {
 	return d.mutex
 }
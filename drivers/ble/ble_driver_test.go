@@ -84,6 +84,31 @@ func TestHalt(t *testing.T) {
 	require.EqualError(t, d.Halt(), "before halt error")
 }
 
+func TestAfterReconnect(t *testing.T) {
+	// arrange
+	a := testutil.NewBleTestAdaptor()
+	d := NewDriver(a, "BLE_BASIC", nil, nil)
+	var calls int
+	d.SetAfterReconnect(func() error {
+		calls++
+		return nil
+	})
+	// act, assert: not started
+	require.NoError(t, a.SimulateReconnect())
+	assert.Equal(t, 0, calls)
+	// act, assert: started twice registers only once
+	require.NoError(t, d.Start())
+	require.NoError(t, d.Start())
+	require.NoError(t, a.SimulateReconnect())
+	assert.Equal(t, 1, calls)
+	// act, assert: error is passed to the adaptor
+	d.SetAfterReconnect(func() error { return fmt.Errorf("after reconnect error") })
+	require.EqualError(t, a.SimulateReconnect(), "after reconnect error")
+	// act, assert: halted
+	require.NoError(t, d.Halt())
+	require.NoError(t, a.SimulateReconnect())
+}
+
 func TestAdaptor(t *testing.T) {
 	wrongConnectorType := struct {
 		a uint32
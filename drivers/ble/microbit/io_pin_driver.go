@@ -39,6 +39,7 @@ func NewIOPinDriver(a gobot.BLEConnector, opts ...ble.OptionApplier) *IOPinDrive
 	}
 
 	d.Driver = ble.NewDriver(a, "Microbit IO Pins", d.initialize, nil, opts...)
+	d.SetAfterReconnect(d.initialize)
 
 	return d
 }
@@ -94,6 +94,7 @@ func NewMinidroneDriver(a gobot.BLEConnector, opts ...ble.OptionApplier) *Minidr
 		Eventer: gobot.NewEventer(),
 	}
 	d.Driver = ble.NewDriver(a, "Minidrone", d.initialize, d.shutdown, opts...)
+	d.SetAfterReconnect(d.reinitialize)
 
 	d.AddEvent(BatteryEvent)
 	d.AddEvent(FlightStatusEvent)
@@ -371,6 +372,18 @@ func (d *MinidroneDriver) initialize() error {
 	return d.FlatTrim()
 }
 
+// reinitialize requests all states and calibrates the Minidrone again after the connection was re-established,
+// the subscriptions are restored by the adaptor and the Pcmd communication is still running
+func (d *MinidroneDriver) reinitialize() error {
+	d.Adaptor().WithoutResponses(true)
+
+	if err := d.GenerateAllStates(); err != nil {
+		return err
+	}
+
+	return d.FlatTrim()
+}
+
 // shutdown stops minidrone driver (void)
 func (d *MinidroneDriver) shutdown() error {
 	err := d.Land()
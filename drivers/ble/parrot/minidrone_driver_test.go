@@ -45,6 +45,27 @@ func TestMinidroneHalt(t *testing.T) {
 	require.NoError(t, d.Halt())
 }
 
+func TestMinidroneReconnect(t *testing.T) {
+	// arrange
+	a := testutil.NewBleTestAdaptor()
+	d := NewMinidroneDriver(a)
+	require.NoError(t, d.Start())
+	var written [][]byte
+	a.SetWriteCharacteristicTestFunc(func(cUUID string, data []byte) error {
+		if cUUID == commandChara { // ignore the running Pcmd communication
+			written = append(written, data)
+		}
+		return nil
+	})
+	// act
+	err := a.SimulateReconnect()
+	// assert
+	require.NoError(t, err)
+	require.Len(t, written, 2)
+	assert.Equal(t, byte(0x04), written[0][0])                // all states
+	assert.Equal(t, []byte{0x00, 0x00, 0x00}, written[1][3:]) // flat trim
+}
+
 func TestMinidroneTakeoff(t *testing.T) {
 	d := initTestMinidroneDriver()
 	require.NoError(t, d.TakeOff())
@@ -6,6 +6,11 @@ import (
 	"gobot.io/x/gobot/v2"
 )
 
+const (
+	defaultMTU    = 23 // minimum ATT MTU of Bluetooth LE, used if the adaptor does not know the negotiated MTU
+	attHeaderSize = 3  // opcode and handle of a write
+)
+
 // SerialPortDriver is a implementation of serial over Bluetooth LE
 // Inspired by https://github.com/monteslu/ble-serial by @monteslu
 type SerialPortDriver struct {
@@ -66,11 +71,21 @@ func (p *SerialPortDriver) Read(b []byte) (int, error) {
 	return n, nil
 }
 
-// Write writes to the BLE serial port connection
+// Write writes to the BLE serial port connection. The data is split into chunks, which fit into one write with the
+// MTU negotiated by the adaptor. On error, the count of the completely written chunks is returned.
 func (p *SerialPortDriver) Write(b []byte) (int, error) {
-	err := p.Adaptor().WriteCharacteristic(p.tid, b)
-	n := len(b)
-	return n, err
+	size := p.chunkSize()
+
+	var n int
+	for n < len(b) {
+		end := min(n+size, len(b))
+		if err := p.Adaptor().WriteCharacteristic(p.tid, b[n:end]); err != nil {
+			return n, err
+		}
+		n = end
+	}
+
+	return n, nil
 }
 
 // Close closes the BLE serial port connection
@@ -82,3 +97,13 @@ func (p *SerialPortDriver) Close() error {
 func (p *SerialPortDriver) Address() string {
 	return p.Adaptor().Address()
 }
+
+// chunkSize returns the payload size of one write
+func (p *SerialPortDriver) chunkSize() int {
+	mtu := defaultMTU
+	if provider, ok := p.Adaptor().(gobot.BLEMTUProvider); ok && provider.MTU() > defaultMTU {
+		mtu = provider.MTU()
+	}
+
+	return mtu - attHeaderSize
+}
@@ -127,36 +127,62 @@ func TestSerialPortRead(t *testing.T) {
 
 func TestSerialPortWrite(t *testing.T) {
 	const transmitCharacteristicUUID = "456"
+	data := make([]byte, 50)
+	for i := range data {
+		data[i] = byte(i)
+	}
 	tests := map[string]struct {
-		writeData []byte
-		simError  bool
-		wantCount int
-		wantData  []byte
-		wantErr   string
+		writeData  []byte
+		mtu        int
+		simErrorAt int
+		wantCount  int
+		wantChunks [][]byte
+		wantErr    string
 	}{
 		"write_ok": {
-			writeData: []byte{1, 2, 3},
-			wantCount: 3,
-			wantData:  []byte{1, 2, 3},
+			writeData:  []byte{1, 2, 3},
+			wantCount:  3,
+			wantChunks: [][]byte{{1, 2, 3}},
+		},
+		"write_chunks_default_mtu": {
+			writeData:  data,
+			wantCount:  50,
+			wantChunks: [][]byte{data[:20], data[20:40], data[40:]},
+		},
+		"write_chunks_negotiated_mtu": {
+			writeData:  data,
+			mtu:        30,
+			wantCount:  50,
+			wantChunks: [][]byte{data[:27], data[27:]},
 		},
 		"error_write": {
-			writeData: []byte{1, 2, 3},
-			simError:  true,
-			wantCount: 3,
-			wantData:  []byte{1, 2, 3},
-			wantErr:   "write error",
+			writeData:  []byte{1, 2, 3},
+			simErrorAt: 1,
+			wantCount:  0,
+			wantChunks: [][]byte{{1, 2, 3}},
+			wantErr:    "write error",
+		},
+		"error_write_second_chunk": {
+			writeData:  data,
+			simErrorAt: 2,
+			wantCount:  20,
+			wantChunks: [][]byte{data[:20], data[20:40]},
+			wantErr:    "write error",
 		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			// arrange
 			a := testutil.NewBleTestAdaptor()
+			if tc.mtu > 0 {
+				a.SetMTU(tc.mtu)
+			}
 			var gotUUID string
-			var gotData []byte
+			var gotChunks [][]byte
 			a.SetWriteCharacteristicTestFunc(func(cUUID string, data []byte) error {
 				gotUUID = cUUID
-				gotData = append(gotData, data...)
-				if tc.simError {
+				gotChunks = append(gotChunks, data)
+				if len(gotChunks) == tc.simErrorAt {
 					return fmt.Errorf("write error")
 				}
 				return nil
@@ -173,7 +199,7 @@ func TestSerialPortWrite(t *testing.T) {
 			}
 			assert.Equal(t, tc.wantCount, gotCount)
 			assert.Equal(t, transmitCharacteristicUUID, gotUUID)
-			assert.Equal(t, tc.wantData, gotData)
+			assert.Equal(t, tc.wantChunks, gotChunks)
 		})
 	}
 }
@@ -88,6 +88,7 @@ func newOllieBaseDriver(
 		packetChannel:          make(chan *packet, 1024),
 	}
 	d.Driver = ble.NewDriver(a, name, d.initialize, d.shutdown, opts...)
+	d.SetAfterReconnect(d.reinitialize)
 
 	d.AddEvent(spherocommon.ErrorEvent)
 	d.AddEvent(spherocommon.CollisionEvent)
@@ -206,13 +207,7 @@ func (d *OllieDriver) SetDataStreamingConfig(dsc spherocommon.DataStreamingConfi
 
 // initialize tells driver to get ready to do work
 func (d *OllieDriver) initialize() error {
-	if err := d.antiDOSOff(); err != nil {
-		return err
-	}
-	if err := d.SetTXPower(7); err != nil {
-		return err
-	}
-	if err := d.Wake(); err != nil {
+	if err := d.wakeUp(); err != nil {
 		return err
 	}
 
@@ -237,6 +232,30 @@ func (d *OllieDriver) initialize() error {
 	return nil
 }
 
+// reinitialize configures Ollie again after the connection was re-established, the subscription to the
+// response notifications is restored by the adaptor
+func (d *OllieDriver) reinitialize() error {
+	if err := d.wakeUp(); err != nil {
+		return err
+	}
+
+	d.ConfigureCollisionDetection(d.defaultCollisionConfig)
+	d.enableStopOnDisconnect()
+
+	return nil
+}
+
+// wakeUp turns off Anti-DOS, sets the transmit level and wakes Ollie up
+func (d *OllieDriver) wakeUp() error {
+	if err := d.antiDOSOff(); err != nil {
+		return err
+	}
+	if err := d.SetTXPower(7); err != nil {
+		return err
+	}
+	return d.Wake()
+}
+
 // antiDOSOff turns off Anti-DOS code so we can control Ollie
 func (d *OllieDriver) antiDOSOff() error {
 	str := "011i3"
@@ -7,13 +7,18 @@ import (
 	"gobot.io/x/gobot/v2"
 )
 
-var _ gobot.BLEConnector = (*bleTestClientAdaptor)(nil)
+var (
+	_ gobot.BLEConnector         = (*bleTestClientAdaptor)(nil)
+	_ gobot.BLEMTUProvider       = (*bleTestClientAdaptor)(nil)
+	_ gobot.BLEReconnectNotifier = (*bleTestClientAdaptor)(nil)
+)
 
 type bleTestClientAdaptor struct {
 	name             string
 	address          string
 	mtx              sync.Mutex
 	withoutResponses bool
+	mtu              int
 
 	simulateConnectErr      bool
 	simulateSubscribeErr    bool
@@ -22,11 +27,13 @@ type bleTestClientAdaptor struct {
 	writeCharacteristicFunc func(string, []byte) error
 	subscribeFunc           func([]byte)
 	subscribeCharaUUID      string
+	reconnectHandlers       []func() error
 }
 
 func NewBleTestAdaptor() *bleTestClientAdaptor {
 	return &bleTestClientAdaptor{
 		address: "01:02:03:0A:0B:0C",
+		mtu:     23,
 		readCharacteristicFunc: func(cUUID string) ([]byte, error) {
 			return []byte(cUUID), nil
 		},
@@ -52,6 +59,12 @@ func (t *bleTestClientAdaptor) SetWriteCharacteristicTestFunc(f func(cUUID strin
 	t.writeCharacteristicFunc = f
 }
 
+func (t *bleTestClientAdaptor) SetMTU(mtu int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.mtu = mtu
+}
+
 func (t *bleTestClientAdaptor) SetSimulateConnectError(val bool) {
 	t.simulateConnectErr = val
 }
@@ -70,6 +83,25 @@ func (t *bleTestClientAdaptor) SendTestDataToSubscriber(data []byte) {
 	t.subscribeFunc(data)
 }
 
+// SimulateReconnect calls all handlers registered by OnReconnect, like after a re-established connection
+func (t *bleTestClientAdaptor) SimulateReconnect() error {
+	t.mtx.Lock()
+	handlers := t.reconnectHandlers
+	t.mtx.Unlock()
+	for _, handler := range handlers {
+		if err := handler(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *bleTestClientAdaptor) OnReconnect(handler func() error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.reconnectHandlers = append(t.reconnectHandlers, handler)
+}
+
 func (t *bleTestClientAdaptor) Connect() error {
 	if t.simulateConnectErr {
 		return fmt.Errorf("connect error")
@@ -92,6 +124,12 @@ func (t *bleTestClientAdaptor) SetName(n string)          { t.name = n }
 func (t *bleTestClientAdaptor) Address() string           { return t.address }
 func (t *bleTestClientAdaptor) WithoutResponses(use bool) { t.withoutResponses = use }
 
+func (t *bleTestClientAdaptor) MTU() int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.mtu
+}
+
 func (t *bleTestClientAdaptor) ReadCharacteristic(cUUID string) ([]byte, error) {
 	t.mtx.Lock()
 	defer t.mtx.Unlock()
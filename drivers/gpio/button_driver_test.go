@@ -234,3 +234,30 @@ func TestButtonActive(t *testing.T) {
 		})
 	}
 }
+
+func TestButtonTypedSubscription(t *testing.T) {
+	// arrange
+	d, a := initTestButtonDriverWithStubbedAdaptor()
+	var mtx sync.Mutex
+	val := 0
+	a.digitalReadFunc = func(string) (int, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return val, nil
+	}
+	require.NoError(t, d.Start())
+	defer func() { _ = d.Halt() }()
+	sub := gobot.SubscribeTyped[int](d, ButtonPush, nil)
+	defer sub.Unsubscribe()
+	// act
+	mtx.Lock()
+	val = 1
+	mtx.Unlock()
+	// assert
+	select {
+	case v := <-sub.C():
+		assert.Equal(t, 1, v)
+	case <-time.After(buttonTestDelay * time.Millisecond):
+		assert.Fail(t, "Button Event \"Push\" was not published")
+	}
+}
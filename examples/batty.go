@@ -17,7 +17,7 @@ import (
 func main() {
 	gbot := gobot.NewManager()
 
-	api.NewAPI(gbot).Start()
+	gbot.AddService(api.NewAPI(gbot))
 
 	gbot.AddCommand("echo", func(params map[string]interface{}) interface{} {
 		return params["a"]
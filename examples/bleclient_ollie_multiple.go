@@ -49,7 +49,7 @@ func NewSwarmBot(port string) *gobot.Robot {
 
 func main() {
 	manager := gobot.NewManager()
-	api.NewAPI(manager).Start()
+	manager.AddService(api.NewAPI(manager))
 
 	for _, port := range os.Args[1:] {
 		bot := NewSwarmBot(port)
@@ -15,7 +15,7 @@ import (
 
 func main() {
 	manager := gobot.NewManager()
-	api.NewAPI(manager).Start()
+	manager.AddService(api.NewAPI(manager))
 
 	digisparkAdaptor := digispark.NewAdaptor()
 	led := gpio.NewLedDriver(digisparkAdaptor, "0")
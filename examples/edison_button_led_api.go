@@ -17,7 +17,7 @@ import (
 
 func main() {
 	manager := gobot.NewManager()
-	api.NewAPI(manager).Start()
+	manager.AddService(api.NewAPI(manager))
 
 	e := edison.NewAdaptor()
 
@@ -19,7 +19,7 @@ import (
 func main() {
 	manager := gobot.NewManager()
 	a := api.NewAPI(manager)
-	a.Start()
+	manager.AddService(a)
 
 	firmataAdaptor := firmata.NewAdaptor("/dev/ttyACM0")
 	led := gpio.NewLedDriver(firmataAdaptor, "13")
@@ -23,7 +23,7 @@ func main() {
 		fmt.Fprintf(w, "Hello, %q \n", html.EscapeString(r.URL.Path))
 	})
 	a.Debug()
-	a.Start()
+	manager.AddService(a)
 
 	manager.AddCommand("custom_gobot_command",
 		func(params map[string]interface{}) interface{} {
@@ -25,7 +25,7 @@ func main() {
 	a.AddHandler(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "Hello, %q \n", html.EscapeString(r.URL.Path))
 	})
-	a.Start()
+	manager.AddService(a)
 
 	manager.AddCommand("custom_gobot_command",
 		func(params map[string]interface{}) interface{} {
@@ -7,6 +7,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -33,7 +34,9 @@ func main() {
 	})
 
 	// starts the API without the default C3PIO API and web interface.
-	a.StartWithoutDefaults()
+	if err := a.StartWithoutDefaults(context.Background()); err != nil {
+		panic(err)
+	}
 
 	manager.AddRobot(gobot.NewRobot("hello"))
 
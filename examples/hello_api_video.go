@@ -7,6 +7,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -92,7 +93,9 @@ func main() {
 	// starts the API without the default C3PIO API and web interface.
 	// However, the C3PIO API was added manually using a.AddC3PIORoutes() which
 	// means the REST API will be available, but not the web interface.
-	a.StartWithoutDefaults()
+	if err := a.StartWithoutDefaults(context.Background()); err != nil {
+		panic(err)
+	}
 
 	hello := manager.AddRobot(gobot.NewRobot("hello"))
 
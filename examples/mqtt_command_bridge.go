@@ -0,0 +1,43 @@
+//go:build example
+// +build example
+
+//
+// Do not build by default.
+
+package main
+
+import (
+	"fmt"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/platforms/mqtt"
+)
+
+// Call the command e.g. with mosquitto clients:
+//
+//	mosquitto_sub -h test.mosquitto.org -t 'gobot/reply/#' &
+//	mosquitto_pub -h test.mosquitto.org -t gobot/robots/bridgeBot/commands/hello -m '{"name":"world"}'
+func main() {
+	manager := gobot.NewManager()
+	mqttAdaptor := mqtt.NewAdaptor("tcp://test.mosquitto.org:1883", "bridge")
+	bridge := mqtt.NewCommandBridge(mqttAdaptor, manager, "")
+
+	work := func() {
+		if err := bridge.Start(); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	robot := gobot.NewRobot("bridgeBot",
+		[]gobot.Connection{mqttAdaptor},
+		work,
+	)
+	robot.AddCommand("hello", func(params map[string]interface{}) interface{} {
+		return fmt.Sprintf("hello %v", params["name"])
+	})
+	manager.AddRobot(robot)
+
+	if err := manager.Start(); err != nil {
+		panic(err)
+	}
+}
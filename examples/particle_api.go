@@ -26,7 +26,7 @@ import (
 
 func main() {
 	manager := gobot.NewManager()
-	api.NewAPI(manager).Start()
+	manager.AddService(api.NewAPI(manager))
 
 	core := particle.NewAdaptor(os.Args[1], os.Args[2])
 	led := gpio.NewLedDriver(core, "D7")
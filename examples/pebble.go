@@ -18,7 +18,7 @@ func main() {
 	manager := gobot.NewManager()
 	api := api.NewAPI(manager)
 	api.Port = "8080"
-	api.Start()
+	manager.AddService(api)
 
 	pebbleAdaptor := pebble.NewAdaptor()
 	pebbleDriver := pebble.NewDriver(pebbleAdaptor)
@@ -18,7 +18,7 @@ func main() {
 	manager := gobot.NewManager()
 	a := api.NewAPI(manager)
 	a.Port = "8080"
-	a.Start()
+	manager.AddService(a)
 
 	pebbleAdaptor := pebble.NewAdaptor()
 	pebbleDriver := pebble.NewDriver(pebbleAdaptor)
@@ -15,7 +15,7 @@ import (
 
 func main() {
 	manager := gobot.NewManager()
-	api.NewAPI(manager).Start()
+	manager.AddService(api.NewAPI(manager))
 
 	spheros := map[string]string{
 		"Sphero-BPO": "/dev/rfcomm0",
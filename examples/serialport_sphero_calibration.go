@@ -17,7 +17,7 @@ import (
 func main() {
 	manager := gobot.NewManager()
 	a := api.NewAPI(manager)
-	a.Start()
+	manager.AddService(a)
 
 	ballConn := serialport.NewAdaptor("/dev/rfcomm0")
 	ball := sphero.NewSpheroDriver(ballConn)
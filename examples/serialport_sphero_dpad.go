@@ -18,7 +18,7 @@ import (
 func main() {
 	manager := gobot.NewManager()
 	a := api.NewAPI(manager)
-	a.Start()
+	manager.AddService(a)
 
 	conn := serialport.NewAdaptor("/dev/rfcomm0")
 	ball := sphero.NewSpheroDriver(conn)
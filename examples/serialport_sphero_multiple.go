@@ -52,7 +52,7 @@ func NewSwarmBot(port string) *gobot.Robot {
 
 func main() {
 	manager := gobot.NewManager()
-	api.NewAPI(manager).Start()
+	manager.AddService(api.NewAPI(manager))
 
 	spheros := []string{
 		"/dev/rfcomm0",
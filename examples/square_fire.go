@@ -19,7 +19,7 @@ import (
 func main() {
 	manager := gobot.NewManager()
 	a := api.NewAPI(manager)
-	a.Start()
+	manager.AddService(a)
 
 	board := edison.NewAdaptor()
 	red := gpio.NewLedDriver(board, "3")
@@ -15,7 +15,12 @@ type Robot = core.Robot
 type Robots = core.Robots
 type Event = core.Event
 type Commander = core.Commander
+type SchemaCommander = core.SchemaCommander
+type SchemaCommanderAccessor = core.SchemaCommanderAccessor
 type Eventer = core.Eventer
+type EventerStats = core.EventerStats
+type SubscriptionEventer = core.SubscriptionEventer
+type SubscriptionEventerAccessor = core.SubscriptionEventerAccessor
 type Pinner = core.Pinner
 
 // Connection and device types
@@ -27,13 +32,84 @@ type Adaptor = adaptor.Adaptor
 
 // Manager
 type Manager = robot.Manager
+type Service = robot.Service
 
 // Core functions
 var NewRobot = core.NewRobot
 var NewManager = robot.NewManager
 var NewEvent = core.NewEvent
 var NewEventer = core.NewEventer
+var SubscriptionEventerOf = core.SubscriptionEventerOf
 var NewCommander = core.NewCommander
+var SchemaCommanderOf = core.SchemaCommanderOf
+
+// Event subscription options
+type SubscriptionOption = core.SubscriptionOption
+type OverflowPolicy = core.OverflowPolicy
+const OverflowDropNewest = core.OverflowDropNewest
+const OverflowDropOldest = core.OverflowDropOldest
+const OverflowBlock = core.OverflowBlock
+var WithSubscriptionBuffer = core.WithSubscriptionBuffer
+var WithOverflowPolicy = core.WithOverflowPolicy
+var WithBlockTimeout = core.WithBlockTimeout
+var WithDropWarning = core.WithDropWarning
+
+// Typed commands
+type CommandSchema = core.CommandSchema
+type CommandParam = core.CommandParam
+type ParamType = core.ParamType
+const ParamAny = core.ParamAny
+const ParamString = core.ParamString
+const ParamNumber = core.ParamNumber
+const ParamInteger = core.ParamInteger
+const ParamBoolean = core.ParamBoolean
+const ParamDuration = core.ParamDuration
+const ParamObject = core.ParamObject
+const ParamArray = core.ParamArray
+var ErrInvalidCommandParams = core.ErrInvalidCommandParams
+
+// Robot lifecycle states
+type RobotState = core.RobotState
+type StateTransition = core.StateTransition
+type StateHook = core.StateHook
+const StateInitializing = core.StateInitializing
+const StateConnecting = core.StateConnecting
+const StateStartingDevices = core.StateStartingDevices
+const StateRunning = core.StateRunning
+const StateDegraded = core.StateDegraded
+const StateStopping = core.StateStopping
+const StateStopped = core.StateStopped
+const StateFailed = core.StateFailed
+
+// Scheduled work
+type RobotWork = core.RobotWork
+type WorkSchedule = core.WorkSchedule
+type ScheduleOption = core.ScheduleOption
+type MissedRunPolicy = core.MissedRunPolicy
+const MissedRunSkip = core.MissedRunSkip
+const MissedRunCatchUpOnce = core.MissedRunCatchUpOnce
+var ParseCron = core.ParseCron
+var OnceAt = core.OnceAt
+var WithWorkName = core.WithWorkName
+var WithJitter = core.WithJitter
+var WithMissedRunPolicy = core.WithMissedRunPolicy
+
+// Typed event subscriptions
+type TypedSubscription[T any] = core.TypedSubscription[T]
+
+// SubscribeTyped subscribes to the named event with data of type T, see core.SubscribeTyped
+func SubscribeTyped[T any](e Eventer, name string, filter func(T) bool,
+	opts ...SubscriptionOption,
+) *TypedSubscription[T] {
+	return core.SubscribeTyped(e, name, filter, opts...)
+}
+
+// OnTyped calls the handler for the named event with data of type T, see core.OnTyped
+func OnTyped[T any](e Eventer, name string, filter func(T) bool, f func(T),
+	opts ...SubscriptionOption,
+) *TypedSubscription[T] {
+	return core.OnTyped(e, name, filter, f, opts...)
+}
 
 // Robot options
 var WithName = core.WithName
@@ -41,14 +117,23 @@ var WithWork = core.WithWork
 var WithAutoRun = core.WithAutoRun
 var WithConnections = core.WithConnections
 var WithDevices = core.WithDevices
+var WithSupervision = core.WithSupervision
+var WithRestartInterval = core.WithRestartInterval
+var WithDependencies = core.WithDependencies
+var WithStartTimeout = core.WithStartTimeout
+var WithHaltTimeout = core.WithHaltTimeout
+var WithWorkTimeout = core.WithWorkTimeout
+var WithWorkStateFile = core.WithWorkStateFile
 
 // JSON types
 type JSONRobot = core.JSONRobot
 type JSONConnection = adaptor.JSONConnection
 type JSONDevice = device.JSONDevice
+type JSONRobotWork = core.JSONRobotWork
 var NewJSONRobot = core.NewJSONRobot
 var NewJSONConnection = adaptor.NewJSONConnection
 var NewJSONDevice = device.NewJSONDevice
+var NewJSONRobotWork = core.NewJSONRobotWork
 
 // Utility functions
 var Rand = gobotutils.Rand
@@ -70,6 +155,8 @@ type I2cOperations = adaptor.I2cOperations
 type SpiOperations = adaptor.SpiOperations
 type OneWireOperations = adaptor.OneWireOperations
 type BLEConnector = adaptor.BLEConnector
+type BLEMTUProvider = adaptor.BLEMTUProvider
+type BLEReconnectNotifier = adaptor.BLEReconnectNotifier
 type Porter = adaptor.Porter
 
 // Digital pin interfaces
@@ -28,6 +28,13 @@ type Config struct {
 	APIHost    string
 	EnableCORS bool
 	EnableAuth bool
+	// APISocket is the path of a unix socket, which is used instead of host and port
+	APISocket string
+	// APICertFile and APIKeyFile switch on TLS for the API
+	APICertFile string
+	APIKeyFile  string
+	// APIClientCAFile switches on mutual TLS, clients need a certificate signed by one of the CAs of the file
+	APIClientCAFile string
 
 	// Performance settings
 	MaxConcurrentDevices int
@@ -52,10 +59,15 @@ func Default() *Config {
 
 		// API defaults
 		APIPort:    getEnvInt("GOBOT_API_PORT", 3000),
-		APIHost:    getEnvString("GOBOT_API_HOST", "0.0.0.0"),
+		APIHost:    getEnvString("GOBOT_API_HOST", ""), // all interfaces for IPv4 and IPv6
 		EnableCORS: getEnvBool("GOBOT_API_CORS", true),
 		EnableAuth: getEnvBool("GOBOT_API_AUTH", false),
 
+		APISocket:       getEnvString("GOBOT_API_SOCKET", ""),
+		APICertFile:     getEnvString("GOBOT_API_CERT", ""),
+		APIKeyFile:      getEnvString("GOBOT_API_KEY", ""),
+		APIClientCAFile: getEnvString("GOBOT_API_CLIENT_CA", ""),
+
 		// Performance defaults
 		MaxConcurrentDevices: getEnvInt("GOBOT_MAX_DEVICES", 100),
 		MemoryLimit:         getEnvInt64("GOBOT_MEMORY_LIMIT", 512*1024*1024), // 512MB
@@ -82,6 +94,12 @@ func (c *Config) Validate() error {
 	if c.APIPort <= 0 || c.APIPort > 65535 {
 		return &ConfigError{Field: "APIPort", Reason: "must be between 1 and 65535"}
 	}
+	if (c.APICertFile == "") != (c.APIKeyFile == "") {
+		return &ConfigError{Field: "APICertFile", Reason: "must be given together with APIKeyFile"}
+	}
+	if c.APIClientCAFile != "" && c.APICertFile == "" {
+		return &ConfigError{Field: "APIClientCAFile", Reason: "requires APICertFile and APIKeyFile"}
+	}
 	if c.MaxConcurrentDevices <= 0 {
 		return &ConfigError{Field: "MaxConcurrentDevices", Reason: "must be positive"}
 	}
@@ -70,4 +70,30 @@ func TestEnvironmentVariables(t *testing.T) {
 	if !config.EnableDebug {
 		t.Error("Expected debug enabled from env variable")
 	}
-}
\ No newline at end of file
+}
+
+func TestValidateAPITLS(t *testing.T) {
+	t.Parallel()
+	config := Default()
+	config.APICertFile = "server.crt"
+	config.APIKeyFile = "server.key"
+	config.APIClientCAFile = "ca.crt"
+
+	// Certificate, key and client CA are valid together
+	if err := config.Validate(); err != nil {
+		t.Errorf("Valid TLS config failed validation: %v", err)
+	}
+
+	// Certificate without key
+	config.APIKeyFile = ""
+	if err := config.Validate(); err == nil {
+		t.Error("Expected validation error for certificate without key")
+	}
+
+	// Client CA without certificate
+	config = Default()
+	config.APIClientCAFile = "ca.crt"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected validation error for client CA without certificate")
+	}
+}
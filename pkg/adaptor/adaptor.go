@@ -275,6 +275,18 @@ type BLEConnector interface {
 	WithoutResponses(use bool)
 }
 
+// BLEMTUProvider is the interface of a BLE ClientAdaptor, which knows the ATT MTU negotiated with the device
+type BLEMTUProvider interface {
+	// MTU returns the negotiated ATT MTU, the payload of one write or notification is 3 bytes smaller
+	MTU() int
+}
+
+// BLEReconnectNotifier is the interface of a BLE ClientAdaptor, which re-establishes a lost connection by itself
+type BLEReconnectNotifier interface {
+	// OnReconnect registers a handler, which is called after the connection was re-established
+	OnReconnect(handler func() error)
+}
+
 // Porter is the interface that describes an adaptor's port
 type Porter interface {
 	Port() string
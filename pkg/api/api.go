@@ -1,14 +1,22 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync"
 	"time"
 
 	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/internal/config"
+	"gobot.io/x/gobot/v2/pkg/metrics"
 )
 
 // API represents an API server
@@ -20,37 +28,60 @@ type API struct {
 	Cert     string
 	Key      string
 	handlers []func(http.ResponseWriter, *http.Request)
-	start    func(*API)
+	start    func(*API, context.Context) error
+
+	// WebSocketPingInterval is the interval of the liveness pings of the WebSocket endpoint, a client needs to
+	// send any message within two intervals, default is 30 s
+	WebSocketPingInterval time.Duration
+	// WebSocketOrigins are the origins of browser pages, which may open the WebSocket endpoint in addition to the
+	// pages served by the API itself, with the wildcards of AllowRequestsFrom, e.g. "https://*.example.com"
+	WebSocketOrigins []string
+
+	// ClientCA is the file with the CA certificates for the verification of client certificates. If set, each
+	// client needs a valid certificate (mutual TLS), this requires Cert and Key. Without Auth, the client is
+	// identified by its certificate for the Policy, with the common name as subject and the organizational units
+	// as roles.
+	ClientCA string
+	// Socket is the path of a unix socket, the API listens on instead of Host and Port
+	Socket string
+
+	// Auth authenticates each request, unauthenticated requests are rejected with 401, e.g. by NewTokenAuth
+	Auth Authenticator
+	// Policy authorizes the routes by the roles of the authenticated caller, denied requests are rejected with
+	// 403. Without policy, each caller is allowed all actions.
+	Policy *Policy
+	// AuditLog records each command invocation with the caller and the params, e.g. by NewAuditLog
+	AuditLog AuditLogger
+	// Metrics is the registry of the metrics of the robots, events, work and commands, which is served by the
+	// metrics route together with metrics.DefaultRegistry. NewAPI creates a registry for each API.
+	Metrics *metrics.Registry
+
+	mtx          sync.Mutex
+	metricsMutex sync.Mutex
+	server       *http.Server
+	listener     net.Listener
+	served       chan struct{}
+	routesAdded  bool
+}
+
+// NewAPI returns a new api instance, which is configured by the GOBOT_API_* environment variables, see
+// internal/config
+func NewAPI(m *gobot.Manager) *API {
+	return newAPI(m, config.Default())
 }
 
-// NewAPI returns a new api instance
-func NewAPI(m *gobot.Manager) *API {
+func newAPI(m *gobot.Manager, cfg *config.Config) *API {
 	return &API{
-		manager: m,
-		router:  http.NewServeMux(),
-		Port:    "3000",
-		start: func(a *API) {
-			log.Println("Initializing API on " + a.Host + ":" + a.Port + "...")
-			http.Handle("/", a)
-			server := &http.Server{
-				Addr:              a.Host + ":" + a.Port,
-				ReadHeaderTimeout: 30 * time.Second,
-			}
-
-			go func() {
-				if a.Cert != "" && a.Key != "" {
-					if err := server.ListenAndServeTLS(a.Cert, a.Key); err != nil {
-						log.Printf("Server error: %v", err)
-					}
-				} else {
-					log.Println("WARNING: API using insecure connection. " +
-						"We recommend using an SSL certificate with Gobot.")
-					if err := server.ListenAndServe(); err != nil {
-						log.Printf("Server error: %v", err)
-					}
-				}
-			}()
-		},
+		manager:  m,
+		router:   http.NewServeMux(),
+		Host:     cfg.APIHost,
+		Port:     strconv.Itoa(cfg.APIPort),
+		Cert:     cfg.APICertFile,
+		Key:      cfg.APIKeyFile,
+		ClientCA: cfg.APIClientCAFile,
+		Socket:   cfg.APISocket,
+		Metrics:  metrics.NewRegistry(),
+		start:    (*API).serve,
 	}
 }
 
@@ -67,6 +98,17 @@ func (a *API) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+
+	if a.Auth != nil {
+		identity, err := a.Auth.Authenticate(req)
+		if err != nil {
+			res.Header().Set("WWW-Authenticate", `Bearer realm="gobot"`)
+			a.writeJSONStatus(map[string]interface{}{"error": err.Error()}, http.StatusUnauthorized, res)
+			return
+		}
+		req = req.WithContext(contextWithIdentity(req.Context(), identity))
+	}
+
 	a.router.ServeHTTP(res, req)
 }
 
@@ -105,17 +147,20 @@ func (a *API) AddHandler(f func(http.ResponseWriter, *http.Request)) {
 	a.handlers = append(a.handlers, f)
 }
 
-// Start initializes the api by setting up C3PIO API routes and basic web interface.
-func (a *API) Start() {
+// Start initializes the api by setting up C3PIO API routes and basic web interface and starts the server, see
+// StartWithoutDefaults. The API can be added as service to the manager instead, so it is started and shut down
+// together with the robots.
+func (a *API) Start(ctx context.Context) error {
 	a.AddWebRoutes()
 
-	a.start(a)
+	return a.start(a, ctx)
 }
 
-// StartWithoutDefaults initializes the api without setting up the default routes.
-// Good for custom web interfaces.
-func (a *API) StartWithoutDefaults() {
-	a.start(a)
+// StartWithoutDefaults initializes the api without setting up the default routes and starts the server.
+// Good for custom web interfaces. The server runs until the context is done or Shutdown is called. Errors of
+// the listener and of the TLS setup are returned.
+func (a *API) StartWithoutDefaults(ctx context.Context) error {
+	return a.start(a, ctx)
 }
 
 // AddC3PIORoutes adds all of the standard C3PIO routes to the API.
@@ -126,28 +171,42 @@ func (a *API) AddC3PIORoutes() {
 	robotDeviceCommandRoute := "/api/robots/{robot}/devices/{device}/commands/{command}"
 	robotCommandRoute := "/api/robots/{robot}/commands/{command}"
 
-	a.Get("/api/commands", a.mcpCommands)
-	a.Get(mcpCommandRoute, a.executeMcpCommand)
-	a.Post(mcpCommandRoute, a.executeMcpCommand)
-	a.Get("/api/robots", a.robots)
-	a.Get("/api/robots/{robot}", a.robot)
-	a.Get("/api/robots/{robot}/commands", a.robotCommands)
-	a.Get(robotCommandRoute, a.executeRobotCommand)
-	a.Post(robotCommandRoute, a.executeRobotCommand)
-	a.Get("/api/robots/{robot}/devices", a.robotDevices)
-	a.Get("/api/robots/{robot}/devices/{device}", a.robotDevice)
-	a.Get("/api/robots/{robot}/devices/{device}/events/{event}", a.robotDeviceEvent)
-	a.Get("/api/robots/{robot}/devices/{device}/commands", a.robotDeviceCommands)
-	a.Get(robotDeviceCommandRoute, a.executeRobotDeviceCommand)
-	a.Post(robotDeviceCommandRoute, a.executeRobotDeviceCommand)
-	a.Get("/api/robots/{robot}/connections", a.robotConnections)
-	a.Get("/api/robots/{robot}/connections/{connection}", a.robotConnection)
-	a.Get("/api/", a.mcp)
+	a.Get("/api/commands", a.authorized(ActionRead, a.mcpCommands))
+	a.Get(mcpCommandRoute, a.authorized(ActionCommand, a.executeMcpCommand))
+	a.Post(mcpCommandRoute, a.authorized(ActionCommand, a.executeMcpCommand))
+	a.Get("/api/robots", a.authorized(ActionRead, a.robots))
+	a.Get("/api/robots/{robot}", a.authorized(ActionRead, a.robot))
+	a.Get("/api/robots/{robot}/commands", a.authorized(ActionRead, a.robotCommands))
+	a.Get(robotCommandRoute, a.authorized(ActionCommand, a.executeRobotCommand))
+	a.Post(robotCommandRoute, a.authorized(ActionCommand, a.executeRobotCommand))
+	a.Get("/api/robots/{robot}/devices", a.authorized(ActionRead, a.robotDevices))
+	a.Get("/api/robots/{robot}/devices/{device}", a.authorized(ActionRead, a.robotDevice))
+	a.Get("/api/robots/{robot}/devices/{device}/events/{event}", a.authorized(ActionEvents, a.robotDeviceEvent))
+	a.Get("/api/robots/{robot}/devices/{device}/commands", a.authorized(ActionRead, a.robotDeviceCommands))
+	a.Get(robotDeviceCommandRoute, a.authorized(ActionCommand, a.executeRobotDeviceCommand))
+	a.Post(robotDeviceCommandRoute, a.authorized(ActionCommand, a.executeRobotDeviceCommand))
+	a.Get("/api/robots/{robot}/connections", a.authorized(ActionRead, a.robotConnections))
+	a.Get("/api/robots/{robot}/connections/{connection}", a.authorized(ActionRead, a.robotConnection))
+	a.Get("/api/robots/{robot}/work", a.authorized(ActionRead, a.robotWork))
+	a.Get("/api/robots/{robot}/work/{id}", a.authorized(ActionRead, a.robotWorkItem))
+	a.Delete("/api/robots/{robot}/work/{id}", a.authorized(ActionCancelWork, a.cancelRobotWork))
+	a.Get("/api/ws", a.webSocket)
+	a.Get("/api/openapi.json", a.authorized(ActionRead, a.openAPI))
+	a.Get("/metrics", a.authorized(ActionRead, a.prometheusMetrics))
+	a.Get("/api/", a.authorized(ActionRead, a.mcp))
 }
 
 // AddWebRoutes adds basic web routes for API documentation and status.
-// This provides a simple web interface for API discovery.
+// This provides a simple web interface for API discovery. The routes are added only once.
 func (a *API) AddWebRoutes() {
+	a.mtx.Lock()
+	added := a.routesAdded
+	a.routesAdded = true
+	a.mtx.Unlock()
+	if added {
+		return
+	}
+
 	a.AddC3PIORoutes()
 
 	a.Get("/", func(res http.ResponseWriter, req *http.Request) {
@@ -170,6 +229,8 @@ func (a *API) AddWebRoutes() {
             <li><a href="/api/">/api/</a> - API Root</li>
             <li><a href="/api/robots">/api/robots</a> - List all robots</li>
             <li><a href="/api/commands">/api/commands</a> - List all commands</li>
+            <li><a href="/api/openapi.json">/api/openapi.json</a> - OpenAPI document of all commands</li>
+            <li><a href="/metrics">/metrics</a> - Prometheus metrics of robots, commands, events and bus I/O</li>
         </ul>
         <p>For a modern web interface, we recommend using external tools like:</p>
         <ul>
@@ -189,7 +250,15 @@ func (a *API) AddWebRoutes() {
 // mcp returns MCP route handler.
 // Writes JSON with gobot representation
 func (a *API) mcp(res http.ResponseWriter, req *http.Request) {
-	a.writeJSON(map[string]interface{}{"MCP": gobot.NewJSONManager(a.manager)}, res)
+	jsonManager := gobot.NewJSONManager(a.manager)
+	jsonRobots := []*gobot.JSONRobot{}
+	for _, robot := range jsonManager.Robots {
+		if a.allowed(req, ActionRead, robot.Name, "", "") {
+			jsonRobots = append(jsonRobots, robot)
+		}
+	}
+	jsonManager.Robots = jsonRobots
+	a.writeJSON(map[string]interface{}{"MCP": jsonManager}, res)
 }
 
 // mcpCommands returns commands route handler.
@@ -203,7 +272,9 @@ func (a *API) mcpCommands(res http.ResponseWriter, req *http.Request) {
 func (a *API) robots(res http.ResponseWriter, req *http.Request) {
 	jsonRobots := []*gobot.JSONRobot{}
 	a.manager.Robots().Each(func(r *gobot.Robot) {
-		jsonRobots = append(jsonRobots, gobot.NewJSONRobot(r))
+		if a.allowed(req, ActionRead, r.Name, "", "") {
+			jsonRobots = append(jsonRobots, gobot.NewJSONRobot(r))
+		}
 	})
 	a.writeJSON(map[string]interface{}{"robots": jsonRobots}, res)
 }
@@ -366,30 +437,66 @@ func (a *API) executeRobotCommand(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
-// executeCommand writes JSON response with `f` returned value.
+// executeCommand writes JSON response with `f` returned value, or its error with a non-2xx status.
 func (a *API) executeCommand(f func(map[string]interface{}) interface{},
 	res http.ResponseWriter,
 	req *http.Request,
 ) {
-	body := make(map[string]interface{})
-	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
-		log.Printf("Error: %v", err)
+	if f == nil {
+		a.writeJSON(map[string]interface{}{"error": "Unknown Command"}, res)
+		return
 	}
 
-	if f != nil {
-		a.writeJSON(map[string]interface{}{"result": f(body)}, res)
-	} else {
-		a.writeJSON(map[string]interface{}{"error": "Unknown Command"}, res)
+	params, err := io.ReadAll(req.Body)
+	if err != nil {
+		a.writeJSONStatus(map[string]interface{}{"error": fmt.Sprintf("invalid params: %v", err)},
+			http.StatusBadRequest, res)
+		return
+	}
+
+	start := time.Now()
+	result, err := callCommand(f, params)
+	a.metrics().observeCommand(req.PathValue("robot"), req.PathValue("device"), req.PathValue("command"), start,
+		err != nil)
+
+	entry := AuditEntry{
+		Robot:   req.PathValue("robot"),
+		Device:  req.PathValue("device"),
+		Command: req.PathValue("command"),
+		Allowed: true,
+	}
+	if len(params) > 0 {
+		_ = json.Unmarshal(params, &entry.Params)
 	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	a.audit(req, "http", entry)
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errInvalidParams) || errors.Is(err, gobot.ErrInvalidCommandParams) {
+			status = http.StatusBadRequest
+		}
+		a.writeJSONStatus(map[string]interface{}{"error": err.Error()}, status, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"result": result}, res)
 }
 
 // writeJSON writes `j` as JSON in response
 func (a *API) writeJSON(j interface{}, res http.ResponseWriter) {
+	a.writeJSONStatus(j, http.StatusOK, res)
+}
+
+// writeJSONStatus writes `j` as JSON in response with the given HTTP status
+func (a *API) writeJSONStatus(j interface{}, status int, res http.ResponseWriter) {
 	data, err := json.Marshal(j)
 	if err != nil {
 		log.Printf("Error: %v", err)
 	}
 	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(status)
 	if _, err := res.Write(data); err != nil {
 		log.Printf("Error: %v", err)
 	}
@@ -4,6 +4,7 @@ package api
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"gobot.io/x/gobot/v2"
 )
@@ -21,8 +23,8 @@ func initTestAPI() *API {
 	log.SetOutput(NullReadWriteCloser{})
 	g := gobot.NewManager()
 	a := NewAPI(g)
-	a.start = func(m *API) {}
-	a.Start()
+	a.start = func(*API, context.Context) error { return nil }
+	_ = a.Start(context.Background())
 	a.Debug()
 
 	g.AddRobot(newTestRobot("Robot1"))
@@ -40,10 +42,10 @@ func TestStartWithoutDefaults(t *testing.T) {
 	log.SetOutput(NullReadWriteCloser{})
 	g := gobot.NewManager()
 	a := NewAPI(g)
-	a.start = func(m *API) {}
+	a.start = func(*API, context.Context) error { return nil }
 
 	a.Get("/", func(res http.ResponseWriter, req *http.Request) {})
-	a.StartWithoutDefaults()
+	require.NoError(t, a.StartWithoutDefaults(context.Background()))
 
 	request, _ := http.NewRequest("GET", "/", nil)
 	response := httptest.NewRecorder()
@@ -241,6 +243,48 @@ func TestExecuteRobotCommand(t *testing.T) {
 	assert.Equal(t, "No Robot found with the name UnknownRobot1", body.(map[string]interface{})["error"])
 }
 
+func TestExecuteRobotCommandError(t *testing.T) {
+	tests := map[string]struct {
+		command    func(map[string]interface{}) interface{}
+		body       string
+		wantStatus int
+		wantErr    string
+	}{
+		"error": {
+			command:    func(map[string]interface{}) interface{} { return fmt.Errorf("beep failed") },
+			wantStatus: http.StatusInternalServerError,
+			wantErr:    "beep failed",
+		},
+		"panic": {
+			command:    func(params map[string]interface{}) interface{} { return params["message"].(string) },
+			wantStatus: http.StatusInternalServerError,
+			wantErr:    "command failed: interface conversion: interface {} is nil, not string",
+		},
+		"invalid_json": {
+			command:    func(map[string]interface{}) interface{} { return nil },
+			body:       `{"message":`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "invalid params: unexpected end of JSON input",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			a := initTestAPI()
+			a.manager.Robot("Robot1").AddCommand("Beep", tc.command)
+			request, _ := http.NewRequest("POST", "/api/robots/Robot1/commands/Beep", bytes.NewBufferString(tc.body))
+			response := httptest.NewRecorder()
+			// act
+			a.ServeHTTP(response, request)
+			// assert
+			assert.Equal(t, tc.wantStatus, response.Code)
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+			assert.Equal(t, map[string]interface{}{"error": tc.wantErr}, body)
+		})
+	}
+}
+
 func TestRobotDevice(t *testing.T) {
 	a := initTestAPI()
 
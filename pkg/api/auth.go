@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMissingToken is returned by TokenAuth, if the request contains no bearer token
+	ErrMissingToken = fmt.Errorf("missing bearer token")
+	// ErrInvalidToken is returned by TokenAuth for a malformed token or a token with an invalid signature
+	ErrInvalidToken = fmt.Errorf("invalid token")
+	// ErrTokenExpired is returned by TokenAuth for an expired token
+	ErrTokenExpired = fmt.Errorf("token expired")
+)
+
+// tokenHeader is the only accepted JOSE header, so tokens with "alg": "none" or other algorithms are rejected
+const tokenHeader = `{"alg":"HS256","typ":"JWT"}`
+
+// Identity is the authenticated caller of the API
+type Identity struct {
+	Subject string   `json:"sub"`
+	Roles   []string `json:"roles,omitempty"`
+}
+
+// Authenticator returns the identity of the caller of a request, see API.Auth
+type Authenticator interface {
+	Authenticate(req *http.Request) (*Identity, error)
+}
+
+type identityKey struct{}
+
+// IdentityFromContext returns the identity of the authenticated caller of the request, e.g. for custom handlers
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
+
+func contextWithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// tokenClaims are the claims of a token, times are seconds since epoch
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Roles     []string `json:"roles,omitempty"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+}
+
+// TokenAuth authenticates requests by HMAC-SHA256 signed JSON web tokens (HS256). The token is taken from the
+// header "Authorization: Bearer <token>" or, for WebSocket clients of browsers, from the query parameter
+// "access_token".
+type TokenAuth struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewTokenAuth returns a token authenticator with the shared secret, which signs and verifies the tokens
+func NewTokenAuth(secret []byte) *TokenAuth {
+	return &TokenAuth{secret: secret, now: time.Now}
+}
+
+// Issue returns a signed token for the identity, which expires after the given duration. A duration of zero
+// issues a token without expiry.
+func (t *TokenAuth) Issue(identity Identity, ttl time.Duration) (string, error) {
+	now := t.now()
+	claims := tokenClaims{Subject: identity.Subject, Roles: identity.Roles, IssuedAt: now.Unix()}
+	if ttl > 0 {
+		claims.ExpiresAt = now.Add(ttl).Unix()
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(tokenHeader)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(t.sign(unsigned)), nil
+}
+
+// Verify checks the signature and the expiry of the token and returns its identity
+func (t *TokenAuth) Verify(token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil || h.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm", ErrInvalidToken)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, t.sign(parts[0]+"."+parts[1])) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if claims.ExpiresAt != 0 && !t.now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrTokenExpired
+	}
+
+	return &Identity{Subject: claims.Subject, Roles: claims.Roles}, nil
+}
+
+// Authenticate verifies the bearer token of the request
+func (t *TokenAuth) Authenticate(req *http.Request) (*Identity, error) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = req.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	return t.Verify(strings.TrimSpace(token))
+}
+
+func (t *TokenAuth) sign(unsigned string) []byte {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
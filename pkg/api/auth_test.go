@@ -0,0 +1,82 @@
+//nolint:usestdlibvars,noctx // ok here
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenAuthIssueVerify(t *testing.T) {
+	// arrange
+	auth := NewTokenAuth([]byte("secret"))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+	token, err := auth.Issue(Identity{Subject: "alice", Roles: []string{"operator"}}, time.Hour)
+	require.NoError(t, err)
+	// act
+	identity, err := auth.Verify(token)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "alice", Roles: []string{"operator"}}, identity)
+
+	now = now.Add(time.Hour)
+	_, err = auth.Verify(token)
+	require.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestTokenAuthInvalidTokens(t *testing.T) {
+	auth := NewTokenAuth([]byte("secret"))
+	token, err := auth.Issue(Identity{Subject: "alice", Roles: []string{"operator"}}, 0)
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+	otherToken, err := NewTokenAuth([]byte("other")).Issue(Identity{Subject: "alice"}, 0)
+	require.NoError(t, err)
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	forgedClaims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","roles":["supervisor"]}`))
+
+	tests := map[string]struct {
+		token   string
+		wantErr string
+	}{
+		"malformed":        {token: "abc", wantErr: "invalid token: malformed"},
+		"other_secret":     {token: otherToken, wantErr: "invalid token: bad signature"},
+		"forged_claims":    {token: parts[0] + "." + forgedClaims + "." + parts[2], wantErr: "invalid token: bad signature"},
+		"algorithm_none":   {token: noneHeader + "." + parts[1] + ".", wantErr: "invalid token: unsupported algorithm"},
+		"invalid_encoding": {token: parts[0] + "." + parts[1] + ".!", wantErr: "invalid token: bad signature"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// act
+			_, err := auth.Verify(tc.token)
+			// assert
+			require.ErrorIs(t, err, ErrInvalidToken)
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestTokenAuthAuthenticate(t *testing.T) {
+	// arrange
+	auth := NewTokenAuth([]byte("secret"))
+	token, err := auth.Issue(Identity{Subject: "alice"}, time.Minute)
+	require.NoError(t, err)
+	header, _ := http.NewRequest("GET", "/api/", nil)
+	header.Header.Set("Authorization", "Bearer "+token)
+	query, _ := http.NewRequest("GET", "/api/ws?access_token="+token, nil)
+	missing, _ := http.NewRequest("GET", "/api/", nil)
+	// act & assert
+	identity, err := auth.Authenticate(header)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", identity.Subject)
+	identity, err = auth.Authenticate(query)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", identity.Subject)
+	_, err = auth.Authenticate(missing)
+	require.ErrorIs(t, err, ErrMissingToken)
+}
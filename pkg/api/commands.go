@@ -0,0 +1,265 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/metrics"
+)
+
+// ExecuteCommand executes the command addressed by the path segments and returns the JSON response. The paths
+// mirror the command routes of AddC3PIORoutes without the leading "api", e.g.:
+//
+//	commands
+//	commands/{command}
+//	robots/{robot}/commands
+//	robots/{robot}/commands/{command}
+//	robots/{robot}/devices/{device}/commands
+//	robots/{robot}/devices/{device}/commands/{command}
+//
+// The params are a JSON object, which is passed to the command. The response is {"result": ...} for an
+// executed command, {"commands": [...]} for a command list and {"error": "..."} for a failure. This allows to
+// serve the same commands over transports other than HTTP, e.g. MQTT or NATS. The invocations are not counted in
+// the metrics, see CommandExecutor.
+func ExecuteCommand(manager *gobot.Manager, path []string, params []byte) []byte {
+	response, err := json.Marshal(executeCommandPath(manager, path, params, nil))
+	if err != nil {
+		response, _ = json.Marshal(map[string]interface{}{"error": err.Error()})
+	}
+	return response
+}
+
+// commandTarget is the command or command list, which is addressed by the path segments of ExecuteCommand. The
+// robot and device are empty for the commands of the manager and of a robot.
+type commandTarget struct {
+	robot   string
+	device  string
+	command string
+	scope   int
+	list    bool
+}
+
+const (
+	managerScope = iota
+	robotScope
+	deviceScope
+)
+
+// parseCommandPath returns the target of the path segments, false for an unknown path
+func parseCommandPath(path []string) (commandTarget, bool) {
+	var target commandTarget
+	var rest []string
+	switch {
+	case len(path) >= 1 && path[0] == "commands":
+		rest = path[1:]
+	case len(path) >= 3 && path[0] == "robots" && path[2] == "commands":
+		target.robot, target.scope, rest = path[1], robotScope, path[3:]
+	case len(path) >= 5 && path[0] == "robots" && path[2] == "devices" && path[4] == "commands":
+		target.robot, target.device, target.scope, rest = path[1], path[3], deviceScope, path[5:]
+	default:
+		return target, false
+	}
+
+	switch len(rest) {
+	case 0:
+		target.list = true
+	case 1:
+		target.command = rest[0]
+	default:
+		return target, false
+	}
+	return target, true
+}
+
+func executeCommandPath(manager *gobot.Manager, path []string, params []byte, m *apiMetrics) map[string]interface{} {
+	target, ok := parseCommandPath(path)
+	if !ok {
+		return map[string]interface{}{"error": "Unknown Command"}
+	}
+
+	var commander gobot.Commander = manager
+	if target.scope != managerScope {
+		robot := manager.Robot(target.robot)
+		if robot == nil {
+			return map[string]interface{}{"error": "No Robot found with the name " + target.robot}
+		}
+		commander = robot
+		if target.scope == deviceScope {
+			device := robot.Device(target.device)
+			if device == nil {
+				return map[string]interface{}{"error": "No Device found with the name " + target.device}
+			}
+			deviceCommander, ok := device.(gobot.Commander)
+			if !ok {
+				return map[string]interface{}{"commands": []string{}}
+			}
+			commander = deviceCommander
+		}
+	}
+
+	if target.list {
+		commands := []string{}
+		for command := range commander.Commands() {
+			commands = append(commands, command)
+		}
+		return map[string]interface{}{"commands": commands}
+	}
+
+	f := commander.Command(target.command)
+	if f == nil {
+		return map[string]interface{}{"error": "Unknown Command"}
+	}
+	start := time.Now()
+	response := runCommand(f, params)
+	_, failed := response["error"]
+	m.observeCommand(target.robot, target.device, target.command, start, failed)
+	return response
+}
+
+// TokenVerifier returns the identity of a bearer token, e.g. TokenAuth
+type TokenVerifier interface {
+	Verify(token string) (*Identity, error)
+}
+
+// CommandExecutor executes the commands of ExecuteCommand for a transport other than HTTP, e.g. the command
+// bridges of MQTT and NATS, with the authentication, the policy and the audit log of the API. Each request
+// carries the bearer token of its caller, which is verified by Auth. Without Auth the callers are not
+// authenticated, so a policy denies all commands then.
+type CommandExecutor struct {
+	Manager *gobot.Manager
+	// Transport is recorded in the audit log, e.g. "mqtt"
+	Transport string
+	// Auth verifies the token of each request, requests without valid token are rejected, e.g. by NewTokenAuth
+	Auth TokenVerifier
+	// Policy grants the commands to the roles of the caller, all commands are allowed without policy
+	Policy *Policy
+	// AuditLog records each command invocation of an authenticated caller, also a denied one
+	AuditLog AuditLogger
+	// Metrics counts the command invocations, e.g. the registry of the API
+	Metrics *metrics.Registry
+}
+
+// Execute authenticates the caller by the token and executes the command addressed by the path segments like
+// ExecuteCommand. It returns the JSON response, which is {"error": "..."} for a missing or invalid token and
+// {"error": "Forbidden"} for a command or command list not granted by the policy. An optional "Bearer " prefix
+// of the token is ignored.
+func (e *CommandExecutor) Execute(token string, path []string, params []byte) []byte {
+	response, err := json.Marshal(e.authenticated(token, path, params))
+	if err != nil {
+		response, _ = json.Marshal(map[string]interface{}{"error": err.Error()})
+	}
+	return response
+}
+
+func (e *CommandExecutor) authenticated(token string, path []string, params []byte) map[string]interface{} {
+	if e.Auth == nil {
+		return e.execute(nil, path, params)
+	}
+
+	token = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(token), "Bearer "))
+	if token == "" {
+		return map[string]interface{}{"error": ErrMissingToken.Error()}
+	}
+	identity, err := e.Auth.Verify(token)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return e.execute(identity, path, params)
+}
+
+func (e *CommandExecutor) execute(identity *Identity, path []string, params []byte) map[string]interface{} {
+	target, ok := parseCommandPath(path)
+	if !ok {
+		return map[string]interface{}{"error": "Unknown Command"}
+	}
+
+	if target.list {
+		if !e.allowed(identity, ActionRead, target) {
+			return map[string]interface{}{"error": "Forbidden"}
+		}
+		return executeCommandPath(e.Manager, path, params, nil)
+	}
+
+	entry := AuditEntry{Robot: target.robot, Device: target.device, Command: target.command}
+	if !e.allowed(identity, ActionCommand, target) {
+		entry.Error = "Forbidden"
+		e.audit(identity, entry)
+		return map[string]interface{}{"error": "Forbidden"}
+	}
+
+	response := executeCommandPath(e.Manager, path, params, newAPIMetrics(e.Metrics))
+	entry.Allowed = true
+	if len(params) > 0 {
+		_ = json.Unmarshal(params, &entry.Params)
+	}
+	if err, ok := response["error"].(string); ok {
+		entry.Error = err
+	}
+	e.audit(identity, entry)
+	return response
+}
+
+// allowed returns whether the caller is granted the action on the target, the command list of the manager
+// needs the permission for any robot like its route of the API
+func (e *CommandExecutor) allowed(identity *Identity, action Action, target commandTarget) bool {
+	if e.Policy == nil {
+		return true
+	}
+	if e.Policy.Allowed(identity, action, target.robot, target.device, target.command) {
+		return true
+	}
+	return action == ActionRead && target.scope == managerScope && e.Policy.allowedAny(identity, action)
+}
+
+// audit completes the entry by the caller and records it
+func (e *CommandExecutor) audit(identity *Identity, entry AuditEntry) {
+	if e.AuditLog == nil {
+		return
+	}
+
+	entry.Time = time.Now()
+	entry.Transport = e.Transport
+	if identity != nil {
+		entry.Subject = identity.Subject
+		entry.Roles = identity.Roles
+	}
+	e.AuditLog.Audit(entry)
+}
+
+// errInvalidParams is returned by callCommand, if the params are no JSON object
+var errInvalidParams = fmt.Errorf("invalid params")
+
+// runCommand calls the command with the decoded params and returns its result or error as response
+func runCommand(f func(map[string]interface{}) interface{}, params []byte) map[string]interface{} {
+	result, err := callCommand(f, params)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"result": result}
+}
+
+// callCommand calls the command with the decoded params. A panic of the command, e.g. caused by missing params,
+// is returned as error, because a remote caller must not be able to crash the robot.
+func callCommand(f func(map[string]interface{}) interface{}, params []byte) (result interface{}, err error) {
+	body := make(map[string]interface{})
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &body); err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidParams, err)
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("command failed: %v", r)
+		}
+	}()
+
+	result = f(body)
+	if err, ok := result.(error); ok {
+		return nil, err
+	}
+	return result, nil
+}
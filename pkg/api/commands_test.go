@@ -0,0 +1,173 @@
+//nolint:forcetypeassert // ok here
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+)
+
+func TestExecuteCommand(t *testing.T) {
+	g := gobot.NewManager()
+	g.AddRobot(newTestRobot("Robot1"))
+	g.AddCommand("TestFunction", func(params map[string]interface{}) interface{} {
+		return "hey " + params["message"].(string)
+	})
+	g.AddCommand("FailingFunction", func(params map[string]interface{}) interface{} {
+		return errors.New("motor stalled")
+	})
+
+	tests := map[string]struct {
+		path   string
+		params string
+		want   map[string]interface{}
+	}{
+		"manager_command": {
+			path:   "commands/TestFunction",
+			params: `{"message":"Beep Boop"}`,
+			want:   map[string]interface{}{"result": "hey Beep Boop"},
+		},
+		"manager_commands": {
+			path: "commands",
+			want: map[string]interface{}{"commands": []interface{}{"FailingFunction", "TestFunction"}},
+		},
+		"robot_command": {
+			path:   "robots/Robot1/commands/robotTestFunction",
+			params: `{"message":"Beep Boop","robot":"Robot1"}`,
+			want:   map[string]interface{}{"result": "hey Robot1, Beep Boop"},
+		},
+		"robot_commands": {
+			path: "robots/Robot1/commands",
+			want: map[string]interface{}{"commands": []interface{}{"robotTestFunction"}},
+		},
+		"device_command": {
+			path:   "robots/Robot1/devices/Device1/commands/TestDriverCommand",
+			params: `{"name":"human"}`,
+			want:   map[string]interface{}{"result": "hello human"},
+		},
+		"device_commands": {
+			path: "robots/Robot1/devices/Device1/commands",
+			want: map[string]interface{}{"commands": []interface{}{"DriverCommand", "TestDriverCommand"}},
+		},
+		"error_returned": {
+			path: "commands/FailingFunction",
+			want: map[string]interface{}{"error": "motor stalled"},
+		},
+		"error_panic": {
+			path: "commands/TestFunction",
+			want: map[string]interface{}{"error": "command failed: interface conversion: " +
+				"interface {} is nil, not string"},
+		},
+		"error_invalid_params": {
+			path:   "commands/TestFunction",
+			params: `{"message":`,
+			want:   map[string]interface{}{"error": "invalid params: unexpected end of JSON input"},
+		},
+		"error_unknown_command": {
+			path: "robots/Robot1/commands/unknown",
+			want: map[string]interface{}{"error": "Unknown Command"},
+		},
+		"error_unknown_robot": {
+			path: "robots/UnknownRobot1/commands/robotTestFunction",
+			want: map[string]interface{}{"error": "No Robot found with the name UnknownRobot1"},
+		},
+		"error_unknown_device": {
+			path: "robots/Robot1/devices/UnknownDevice1/commands/TestDriverCommand",
+			want: map[string]interface{}{"error": "No Device found with the name UnknownDevice1"},
+		},
+		"error_unknown_path": {
+			path: "robots/Robot1",
+			want: map[string]interface{}{"error": "Unknown Command"},
+		},
+		"error_too_long": {
+			path: "commands/TestFunction/more",
+			want: map[string]interface{}{"error": "Unknown Command"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// act
+			response := ExecuteCommand(g, strings.Split(tc.path, "/"), []byte(tc.params))
+			// assert
+			var got map[string]interface{}
+			require.NoError(t, json.Unmarshal(response, &got))
+			if commands, ok := got["commands"].([]interface{}); ok {
+				assert.ElementsMatch(t, tc.want["commands"], commands)
+				return
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCommandExecutor(t *testing.T) {
+	// arrange
+	g := gobot.NewManager()
+	g.AddRobot(newTestRobot("Robot1"))
+	auth := NewTokenAuth([]byte("secret"))
+	auditLog := &bytes.Buffer{}
+	e := &CommandExecutor{
+		Manager:   g,
+		Transport: "mqtt",
+		Auth:      auth,
+		Policy: NewPolicy(
+			Rule{Role: "supervisor", Action: ActionRead, Robot: "Robot1"},
+			Rule{Role: "supervisor", Action: ActionCommand, Robot: "Robot1", Name: "robotTestFunction"},
+		),
+		AuditLog: NewAuditLog(auditLog),
+	}
+	token, err := auth.Issue(Identity{Subject: "alice", Roles: []string{"supervisor"}}, 0)
+	require.NoError(t, err)
+	// act
+	allowed := e.Execute("Bearer "+token, strings.Split("robots/Robot1/commands/robotTestFunction", "/"),
+		[]byte(`{"message":"Beep Boop","robot":"Robot1"}`))
+	denied := e.Execute(token, strings.Split("robots/Robot1/devices/Device1/commands/TestDriverCommand", "/"),
+		[]byte(`{"name":"human"}`))
+	list := e.Execute(token, strings.Split("commands", "/"), nil)
+	deniedList := e.Execute(token, strings.Split("robots/Robot2/commands", "/"), nil)
+	missing := e.Execute("", strings.Split("robots/Robot1/commands/robotTestFunction", "/"), nil)
+	invalid := e.Execute(token+"x", strings.Split("robots/Robot1/commands/robotTestFunction", "/"), nil)
+	// assert
+	assert.JSONEq(t, `{"result":"hey Robot1, Beep Boop"}`, string(allowed))
+	assert.JSONEq(t, `{"error":"Forbidden"}`, string(denied))
+	assert.JSONEq(t, `{"commands":[]}`, string(list))
+	assert.JSONEq(t, `{"error":"Forbidden"}`, string(deniedList))
+	assert.JSONEq(t, `{"error":"missing bearer token"}`, string(missing))
+	assert.Contains(t, string(invalid), "invalid token")
+
+	lines := strings.Split(strings.TrimSpace(auditLog.String()), "\n")
+	require.Len(t, lines, 2)
+	var allowedEntry, deniedEntry AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &allowedEntry))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &deniedEntry))
+	assert.Equal(t, "alice", allowedEntry.Subject)
+	assert.Equal(t, []string{"supervisor"}, allowedEntry.Roles)
+	assert.Equal(t, "mqtt", allowedEntry.Transport)
+	assert.Equal(t, "robotTestFunction", allowedEntry.Command)
+	assert.Equal(t, map[string]interface{}{"message": "Beep Boop", "robot": "Robot1"}, allowedEntry.Params)
+	assert.True(t, allowedEntry.Allowed)
+	assert.Equal(t, "alice", deniedEntry.Subject)
+	assert.Equal(t, "Device1", deniedEntry.Device)
+	assert.Equal(t, "TestDriverCommand", deniedEntry.Command)
+	assert.Nil(t, deniedEntry.Params)
+	assert.False(t, deniedEntry.Allowed)
+	assert.Equal(t, "Forbidden", deniedEntry.Error)
+}
+
+func TestCommandExecutorWithoutAuth(t *testing.T) {
+	g := gobot.NewManager()
+	g.AddRobot(newTestRobot("Robot1"))
+	e := &CommandExecutor{Manager: g, Transport: "nats"}
+
+	response := e.Execute("", strings.Split("robots/Robot1/devices/Device1/commands/TestDriverCommand", "/"),
+		[]byte(`{"name":"human"}`))
+
+	assert.JSONEq(t, `{"result":"hello human"}`, string(response))
+}
@@ -36,6 +36,16 @@ func (t *testDriver) SetName(n string)             { t.name = n }
 func (t *testDriver) Pin() string                  { return t.pin }
 func (t *testDriver) Connection() gobot.Connection { return t.connection }
 
+func (t *testDriver) SchemaCommander() gobot.SchemaCommander {
+	sc, _ := t.Commander.(gobot.SchemaCommander)
+	return sc
+}
+
+func (t *testDriver) SubscriptionEventer() gobot.SubscriptionEventer {
+	se, _ := t.Eventer.(gobot.SubscriptionEventer)
+	return se
+}
+
 func newTestDriver(adaptor *testAdaptor, name string, pin string) *testDriver {
 	t := &testDriver{
 		name:       name,
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/metrics"
+)
+
+// apiMetrics are the metrics of the robots, events, work and commands of an API. The bus I/O metrics are counted
+// by the system package in metrics.DefaultRegistry, all are served by the metrics route.
+type apiMetrics struct {
+	robotState         *metrics.GaugeVec
+	eventsPublished    *metrics.CounterVec
+	eventsDropped      *metrics.CounterVec
+	workRuns           *metrics.CounterVec
+	commandInvocations *metrics.CounterVec
+	commandErrors      *metrics.CounterVec
+	commandDuration    *metrics.HistogramVec
+}
+
+// newAPIMetrics returns the metrics in the registry, nil without registry
+func newAPIMetrics(registry *metrics.Registry) *apiMetrics {
+	if registry == nil {
+		return nil
+	}
+
+	return &apiMetrics{
+		robotState: registry.Gauge("gobot_robot_state",
+			"State of the robot, the gauge of the current state is 1.", "robot", "state"),
+		eventsPublished: registry.Counter("gobot_events_published_total",
+			"Number of events published by a robot or device.", "robot", "device"),
+		eventsDropped: registry.Counter("gobot_events_dropped_total",
+			"Number of events dropped by a robot or device, on publish or for a slow subscriber.", "robot", "device"),
+		workRuns: registry.Counter("gobot_work_runs_total",
+			"Number of runs of the registered work of a robot.", "robot", "work", "kind"),
+		commandInvocations: registry.Counter("gobot_commands_total",
+			"Number of command invocations.", "robot", "device", "command"),
+		commandErrors: registry.Counter("gobot_command_errors_total",
+			"Number of command invocations, which returned an error.", "robot", "device", "command"),
+		commandDuration: registry.Histogram("gobot_command_duration_seconds",
+			"Duration of command invocations.", nil, "robot", "device", "command"),
+	}
+}
+
+// metrics returns the metrics in the registry of the API
+func (a *API) metrics() *apiMetrics {
+	return newAPIMetrics(a.Metrics)
+}
+
+var robotStates = []gobot.RobotState{
+	gobot.StateInitializing,
+	gobot.StateConnecting,
+	gobot.StateStartingDevices,
+	gobot.StateRunning,
+	gobot.StateDegraded,
+	gobot.StateStopping,
+	gobot.StateStopped,
+	gobot.StateFailed,
+}
+
+// prometheusMetrics returns the route handler of the metrics in the Prometheus text format, or in the OpenMetrics
+// text format, if accepted by the client
+func (a *API) prometheusMetrics(res http.ResponseWriter, req *http.Request) {
+	openMetrics := strings.Contains(req.Header.Get("Accept"), "application/openmetrics-text")
+	contentType := metrics.ContentType
+	if openMetrics {
+		contentType = metrics.OpenMetricsContentType
+	}
+
+	// the collection and the writing of concurrent scrapes are serialized
+	a.metricsMutex.Lock()
+	defer a.metricsMutex.Unlock()
+
+	registries := []*metrics.Registry{metrics.DefaultRegistry}
+	if m := a.metrics(); m != nil {
+		m.collect(a.manager)
+		registries = append(registries, a.Metrics)
+	}
+	res.Header().Set("Content-Type", contentType)
+	if err := metrics.Write(res, openMetrics, registries...); err != nil {
+		log.Printf("Error: %v", err)
+	}
+}
+
+// collect reads the state, the event counters and the work of all robots of the manager, so removed robots,
+// devices and work disappear from the metrics
+func (m *apiMetrics) collect(manager *gobot.Manager) {
+	m.robotState.Reset()
+	m.eventsPublished.Reset()
+	m.eventsDropped.Reset()
+	m.workRuns.Reset()
+
+	manager.Robots().Each(func(robot *gobot.Robot) {
+		current := robot.State()
+		for _, state := range robotStates {
+			value := 0.0
+			if state == current {
+				value = 1
+			}
+			m.robotState.With(robot.Name, string(state)).Set(value)
+		}
+
+		m.collectEventer(robot.Name, "", robot.Eventer)
+		robot.Devices().Each(func(device gobot.Device) {
+			if eventer, ok := device.(gobot.Eventer); ok {
+				m.collectEventer(robot.Name, device.Name(), eventer)
+			}
+		})
+
+		for _, rw := range robot.WorkRegistry().List() {
+			name := rw.Name()
+			if name == "" {
+				name = rw.ID().String()
+			}
+			m.workRuns.With(robot.Name, name, rw.Kind()).Set(float64(rw.TickCount()))
+		}
+	})
+}
+
+func (m *apiMetrics) collectEventer(robot, device string, eventer gobot.Eventer) {
+	se, ok := gobot.SubscriptionEventerOf(eventer)
+	if !ok {
+		return
+	}
+
+	stats := se.Stats()
+	m.eventsPublished.With(robot, device).Set(float64(stats.Published))
+	m.eventsDropped.With(robot, device).Set(float64(stats.Dropped))
+}
+
+// observeCommand counts the invocation of a command, which was started at the given time. The device of a robot
+// command and the robot and device of a manager command are empty. Without metrics nothing is counted.
+func (m *apiMetrics) observeCommand(robot, device, command string, start time.Time, failed bool) {
+	if m == nil {
+		return
+	}
+
+	m.commandInvocations.With(robot, device, command).Inc()
+	m.commandDuration.With(robot, device, command).Observe(time.Since(start).Seconds())
+
+	errs := m.commandErrors.With(robot, device, command)
+	if failed {
+		errs.Inc()
+	}
+}
@@ -0,0 +1,122 @@
+//nolint:usestdlibvars // ok here
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	// arrange
+	a := initTestAPI()
+	robot := a.manager.Robot("Robot1")
+	robot.AddCommand("MetricsCommand", func(params map[string]interface{}) interface{} {
+		if params["fail"] == true {
+			return errors.New("failed")
+		}
+		return "ok"
+	})
+	device := robot.Device("Device1").(*testDriver)
+	device.Publish("TestEvent", 1)
+	eventer, ok := gobot.SubscriptionEventerOf(device)
+	require.True(t, ok)
+	require.Eventually(t, func() bool { return eventer.Stats().Published == 1 }, time.Second, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	work := robot.Every(ctx, time.Millisecond, func() {})
+	require.Eventually(t, func() bool { return work.TickCount() > 0 }, time.Second, time.Millisecond)
+	cancel()
+
+	for _, body := range []string{`{}`, `{"fail": true}`} {
+		request, _ := http.NewRequest("POST", "/api/robots/Robot1/commands/MetricsCommand", bytes.NewBufferString(body))
+		a.ServeHTTP(httptest.NewRecorder(), request)
+	}
+	executor := &CommandExecutor{Manager: a.manager, Metrics: a.Metrics}
+	executor.Execute("", []string{"robots", "Robot1", "commands", "MetricsCommand"}, []byte(`{"fail": true}`))
+	// act
+	request, _ := http.NewRequest("GET", "/metrics", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	// assert
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, metrics.ContentType, response.Header().Get("Content-Type"))
+	body := response.Body.String()
+	assert.Contains(t, body, "# TYPE gobot_robot_state gauge\n")
+	assert.Contains(t, body, `gobot_robot_state{robot="Robot1",state="stopped"} 1`)
+	assert.Contains(t, body, `gobot_robot_state{robot="Robot1",state="running"} 0`)
+	assert.Contains(t, body, `gobot_events_published_total{robot="Robot1",device="Device1"} 1`)
+	assert.Contains(t, body, `gobot_events_dropped_total{robot="Robot1",device="Device1"} 0`)
+	assert.Contains(t, body, `gobot_work_runs_total{robot="Robot1",work="`+work.ID().String()+`",kind="every"}`)
+	assert.Contains(t, body, `gobot_commands_total{robot="Robot1",device="",command="MetricsCommand"} 3`)
+	assert.Contains(t, body, `gobot_command_errors_total{robot="Robot1",device="",command="MetricsCommand"} 2`)
+	assert.Contains(t, body, `gobot_command_duration_seconds_count{robot="Robot1",device="",command="MetricsCommand"} 3`)
+}
+
+func TestMetricsPerAPI(t *testing.T) {
+	// arrange
+	a1 := initTestAPI()
+	m2 := gobot.NewManager()
+	m2.AddRobot(gobot.NewRobot("Other"))
+	a2 := NewAPI(m2)
+	a2.AddC3PIORoutes()
+	scrape := func(a *API) string {
+		request, _ := http.NewRequest("GET", "/metrics", nil)
+		response := httptest.NewRecorder()
+		a.ServeHTTP(response, request)
+		return response.Body.String()
+	}
+	// act
+	request, _ := http.NewRequest("POST", "/api/commands/TestFunction", bytes.NewBufferString(`{"message":"hi"}`))
+	a1.ServeHTTP(httptest.NewRecorder(), request)
+	body1 := scrape(a1)
+	body2 := scrape(a2)
+	// assert
+	assert.Contains(t, body1, `gobot_robot_state{robot="Robot1",state="stopped"} 1`)
+	assert.Contains(t, body1, `gobot_commands_total{robot="",device="",command="TestFunction"} 1`)
+	assert.NotContains(t, body1, `robot="Other"`)
+	assert.Contains(t, body2, `gobot_robot_state{robot="Other",state="stopped"} 1`)
+	assert.NotContains(t, body2, `robot="Robot1"`)
+	assert.NotContains(t, body2, "gobot_commands_total")
+}
+
+func TestMetricsOpenMetrics(t *testing.T) {
+	// arrange
+	a := initTestAPI()
+	request, _ := http.NewRequest("GET", "/metrics", nil)
+	request.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	response := httptest.NewRecorder()
+	// act
+	a.ServeHTTP(response, request)
+	// assert
+	assert.Equal(t, metrics.OpenMetricsContentType, response.Header().Get("Content-Type"))
+	assert.Contains(t, response.Body.String(), "# TYPE gobot_events_published counter\n")
+	assert.Regexp(t, "# EOF\n$", response.Body.String())
+}
+
+func TestMetricsForbidden(t *testing.T) {
+	// arrange
+	a := initTestAPI()
+	auth := NewTokenAuth([]byte("secret"))
+	a.Auth = auth
+	a.Policy = NewPolicy(Rule{Role: "operator", Action: ActionCommand})
+	token, err := auth.Issue(Identity{Subject: "monitor", Roles: []string{"operator"}}, 0)
+	require.NoError(t, err)
+	request, _ := http.NewRequest("GET", "/metrics", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	response := httptest.NewRecorder()
+	// act
+	a.ServeHTTP(response, request)
+	// assert
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
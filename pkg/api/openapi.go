@@ -0,0 +1,168 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+
+	"gobot.io/x/gobot/v2"
+)
+
+// openAPIVersion is the version of the OpenAPI specification of the generated document
+const openAPIVersion = "3.0.3"
+
+// openAPI returns the route handler of the OpenAPI document of all commands
+func (a *API) openAPI(res http.ResponseWriter, req *http.Request) {
+	a.writeJSON(OpenAPIDocument(a.manager), res)
+}
+
+// OpenAPIDocument returns an OpenAPI 3 document, which describes the command routes of the manager, of each
+// robot and of each device. The params and results of typed commands are described by their schema, see
+// SchemaCommander.AddTypedCommand. Untyped commands accept any JSON object.
+func OpenAPIDocument(manager *gobot.Manager) map[string]interface{} {
+	paths := make(map[string]interface{})
+	addCommands := func(prefix, operationPrefix, tag string, commander gobot.Commander) {
+		schemas, withSchemas := gobot.SchemaCommanderOf(commander)
+		for name := range commander.Commands() {
+			var schema gobot.CommandSchema
+			var typed bool
+			if withSchemas {
+				schema, typed = schemas.CommandSchema(name)
+			}
+			paths[prefix+"/commands/"+url.PathEscape(name)] = map[string]interface{}{
+				"post": openAPIOperation(operationPrefix+name, tag, name, schema, typed),
+			}
+		}
+	}
+
+	addCommands("/api", "", "manager", manager)
+	manager.Robots().Each(func(robot *gobot.Robot) {
+		robotPath := "/api/robots/" + url.PathEscape(robot.Name)
+		addCommands(robotPath, robot.Name+".", robot.Name, robot)
+		robot.Devices().Each(func(device gobot.Device) {
+			if commander, ok := device.(gobot.Commander); ok {
+				addCommands(robotPath+"/devices/"+url.PathEscape(device.Name()),
+					robot.Name+"."+device.Name()+".", robot.Name, commander)
+			}
+		})
+	})
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "Gobot API",
+			"version": "2",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"error": map[string]interface{}{"type": "string"}},
+					"required":   []string{"error"},
+				},
+			},
+		},
+	}
+}
+
+// openAPIOperation returns the operation of a command
+func openAPIOperation(id, tag, name string, schema gobot.CommandSchema, typed bool) map[string]interface{} {
+	params := map[string]interface{}{"type": "object"}
+	result := map[string]interface{}{}
+	responses := map[string]interface{}{}
+	if typed {
+		properties := make(map[string]interface{}, len(schema.Params))
+		required := []string{}
+		for _, p := range schema.Params {
+			properties[p.Name] = openAPIParamSchema(p)
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+		params["properties"] = properties
+		params["additionalProperties"] = false
+		if len(required) > 0 {
+			params["required"] = required
+		}
+
+		result = openAPITypeSchema(schema.Result, true)
+		if schema.ResultDescription != "" {
+			result["description"] = schema.ResultDescription
+		}
+
+		responses["400"] = openAPIResponse("Invalid params", map[string]interface{}{
+			"$ref": "#/components/schemas/Error",
+		})
+	}
+	responses["200"] = openAPIResponse("Result of the command or error", map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"result": result,
+			"error":  map[string]interface{}{"type": "string"},
+		},
+	})
+
+	summary := schema.Description
+	if summary == "" {
+		summary = name
+	}
+
+	return map[string]interface{}{
+		"operationId": id,
+		"summary":     summary,
+		"tags":        []string{tag},
+		"requestBody": map[string]interface{}{
+			"required": false,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": params}},
+		},
+		"responses": responses,
+	}
+}
+
+func openAPIResponse(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+	}
+}
+
+// openAPIParamSchema returns the JSON schema of a param including its restrictions
+func openAPIParamSchema(p gobot.CommandParam) map[string]interface{} {
+	schema := openAPITypeSchema(p.Type, false)
+	if p.Description != "" {
+		schema["description"] = p.Description
+	}
+	if p.Default != nil {
+		schema["default"] = p.Default
+	}
+	if len(p.Enum) > 0 {
+		schema["enum"] = p.Enum
+	}
+	if p.Minimum != nil {
+		schema["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		schema["maximum"] = *p.Maximum
+	}
+
+	return schema
+}
+
+// openAPITypeSchema returns the JSON schema of a type, a duration param is a string or a number of
+// milliseconds, a duration result is encoded by Go as integer nanoseconds
+func openAPITypeSchema(t gobot.ParamType, result bool) map[string]interface{} {
+	switch t {
+	case gobot.ParamAny:
+		return map[string]interface{}{}
+	case gobot.ParamDuration:
+		if result {
+			return map[string]interface{}{"type": "integer", "format": "nanoseconds"}
+		}
+		return map[string]interface{}{"oneOf": []interface{}{
+			map[string]interface{}{"type": "string", "example": "500ms"},
+			map[string]interface{}{"type": "number", "format": "milliseconds"},
+		}}
+	default:
+		return map[string]interface{}{"type": string(t)}
+	}
+}
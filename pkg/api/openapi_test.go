@@ -0,0 +1,149 @@
+//nolint:forcetypeassert,usestdlibvars // ok here
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+)
+
+func initTestTypedCommandAPI() *API {
+	a := initTestAPI()
+	commander, _ := gobot.SchemaCommanderOf(a.manager.Robot("Robot1").Device("Device1").(*testDriver))
+	commander.AddTypedCommand("Blink", gobot.CommandSchema{
+		Description: "Blinks the LED",
+		Params: []gobot.CommandParam{
+			{Name: "times", Type: gobot.ParamInteger, Required: true},
+			{Name: "interval", Type: gobot.ParamDuration, Default: 100 * time.Millisecond},
+		},
+		Result: gobot.ParamDuration,
+	}, func(params map[string]interface{}) interface{} {
+		return time.Duration(params["times"].(int)) * params["interval"].(time.Duration)
+	})
+
+	return a
+}
+
+func TestTypedCommand(t *testing.T) {
+	tests := map[string]struct {
+		body       string
+		wantStatus int
+		want       map[string]interface{}
+	}{
+		"valid": {
+			body:       `{"times": 3, "interval": "1s"}`,
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"result": float64(3 * time.Second)},
+		},
+		"default": {
+			body:       `{"times": 2}`,
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"result": float64(200 * time.Millisecond)},
+		},
+		"missing_param": {
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]interface{}{"error": "invalid command params: missing param 'times'"},
+		},
+		"wrong_type": {
+			body:       `{"times": "3"}`,
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]interface{}{"error": "invalid command params: param 'times' must be an integer"},
+		},
+		"invalid_json": {
+			body:       `{"times":`,
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]interface{}{"error": "invalid params: unexpected end of JSON input"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			a := initTestTypedCommandAPI()
+			request, _ := http.NewRequest("POST", "/api/robots/Robot1/devices/Device1/commands/Blink",
+				bytes.NewBufferString(tc.body))
+			response := httptest.NewRecorder()
+			// act
+			a.ServeHTTP(response, request)
+			// assert
+			assert.Equal(t, tc.wantStatus, response.Code)
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+			assert.Equal(t, tc.want, body)
+		})
+	}
+}
+
+func TestTypedCommandExecuteCommand(t *testing.T) {
+	// arrange
+	a := initTestTypedCommandAPI()
+	path := []string{"robots", "Robot1", "devices", "Device1", "commands", "Blink"}
+	// act & assert
+	assert.JSONEq(t, `{"result": 1000000000}`, string(ExecuteCommand(a.manager, path, []byte(`{"times": 10}`))))
+	assert.JSONEq(t, `{"error": "invalid command params: unknown param 'count'"}`,
+		string(ExecuteCommand(a.manager, path, []byte(`{"times": 10, "count": 1}`))))
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	// arrange
+	a := initTestTypedCommandAPI()
+	// act
+	body := serveTestJSON(a, "GET", "/api/openapi.json")
+	// assert
+	assert.Equal(t, "3.0.3", body["openapi"])
+	paths := body["paths"].(map[string]interface{})
+	assert.Contains(t, paths, "/api/commands/TestFunction")
+	assert.Contains(t, paths, "/api/robots/Robot1/commands/robotTestFunction")
+	assert.Contains(t, paths, "/api/robots/Robot3/devices/Device2/commands/TestDriverCommand")
+
+	untyped := paths["/api/robots/Robot1/devices/Device1/commands/TestDriverCommand"].(map[string]interface{})
+	assert.JSONEq(t, `{"application/json": {"schema": {"type": "object"}}}`, marshalTestJSON(t,
+		untyped["post"].(map[string]interface{})["requestBody"].(map[string]interface{})["content"]))
+
+	typed := paths["/api/robots/Robot1/devices/Device1/commands/Blink"].(map[string]interface{})["post"]
+	assert.JSONEq(t, `{
+		"operationId": "Robot1.Device1.Blink",
+		"summary": "Blinks the LED",
+		"tags": ["Robot1"],
+		"requestBody": {"required": false, "content": {"application/json": {"schema": {
+			"type": "object",
+			"additionalProperties": false,
+			"required": ["times"],
+			"properties": {
+				"times": {"type": "integer"},
+				"interval": {"default": 100000000, "oneOf": [
+					{"type": "string", "example": "500ms"},
+					{"type": "number", "format": "milliseconds"}
+				]}
+			}
+		}}}},
+		"responses": {
+			"200": {"description": "Result of the command or error", "content": {"application/json": {"schema": {
+				"type": "object",
+				"properties": {
+					"result": {"type": "integer", "format": "nanoseconds"},
+					"error": {"type": "string"}
+				}
+			}}}},
+			"400": {"description": "Invalid params", "content": {"application/json": {"schema": {
+				"$ref": "#/components/schemas/Error"
+			}}}}
+		}
+	}`, marshalTestJSON(t, typed))
+}
+
+func marshalTestJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Action is the kind of access to a robot or device, which is granted by a Rule
+type Action string
+
+const (
+	// ActionRead reads the state of the manager, robots, devices, connections and work
+	ActionRead Action = "read"
+	// ActionCommand executes a command
+	ActionCommand Action = "command"
+	// ActionEvents receives events, by the event stream route and by WebSocket subscriptions
+	ActionEvents Action = "events"
+	// ActionCancelWork cancels a work of a robot
+	ActionCancelWork Action = "cancel-work"
+)
+
+// Rule grants the action on the matching robots, devices and commands or events to the role. The patterns are
+// matched by path.Match, an empty pattern matches all. The device of a robot itself and of the manager is empty,
+// the robot of the manager is empty, so only rules with an empty robot pattern match the manager commands.
+//
+// Example, operators read all telemetry, supervisors can land and move the drones:
+//
+//	api.NewPolicy(
+//		api.Rule{Role: "operator", Action: api.ActionRead},
+//		api.Rule{Role: "operator", Action: api.ActionEvents},
+//		api.Rule{Role: "supervisor", Action: api.ActionCommand, Robot: "drone*", Name: "Land"},
+//		api.Rule{Role: "supervisor", Action: api.ActionCommand, Robot: "drone*", Name: "Move"},
+//	)
+type Rule struct {
+	Role   string
+	Action Action
+	Robot  string
+	Device string
+	// Name is the pattern of the command or event
+	Name string
+}
+
+// Policy maps the roles of the callers to the granted actions, see API.Policy
+type Policy struct {
+	rules []Rule
+}
+
+// NewPolicy returns a policy, which grants the actions of the rules and denies all others
+func NewPolicy(rules ...Rule) *Policy {
+	return &Policy{rules: rules}
+}
+
+// Allowed returns whether one of the roles of the identity is granted the action, a missing identity is denied
+func (p *Policy) Allowed(identity *Identity, action Action, robot, device, name string) bool {
+	return p.match(identity, action, func(r Rule) bool {
+		return matchPattern(r.Robot, robot) && matchPattern(r.Device, device) && matchPattern(r.Name, name)
+	})
+}
+
+// allowedAny returns whether one of the roles of the identity is granted the action for any resource
+func (p *Policy) allowedAny(identity *Identity, action Action) bool {
+	return p.match(identity, action, func(Rule) bool { return true })
+}
+
+func (p *Policy) match(identity *Identity, action Action, matches func(Rule) bool) bool {
+	if identity == nil {
+		return false
+	}
+
+	for _, r := range p.rules {
+		if r.Action != action || !matches(r) {
+			continue
+		}
+		for _, role := range identity.Roles {
+			if matchPattern(r.Role, role) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// allowed returns whether the caller of the request is granted the action, all actions are allowed without
+// policy
+func (a *API) allowed(req *http.Request, action Action, robot, device, name string) bool {
+	if a.Policy == nil {
+		return true
+	}
+
+	return a.Policy.Allowed(callerIdentity(req), action, robot, device, name)
+}
+
+// allowedRoute returns whether the caller is granted the action of a route, reading without robot needs the
+// permission for any robot
+func (a *API) allowedRoute(req *http.Request, action Action, robot, device, name string) bool {
+	if a.allowed(req, action, robot, device, name) {
+		return true
+	}
+	if robot != "" || action != ActionRead {
+		return false
+	}
+
+	return a.Policy.allowedAny(callerIdentity(req), action)
+}
+
+// callerIdentity returns the identity of the caller, authenticated by API.Auth or by the verified client
+// certificate of mutual TLS, with the common name as subject and the organizational units as roles
+func callerIdentity(req *http.Request) *Identity {
+	if identity, ok := IdentityFromContext(req.Context()); ok {
+		return identity
+	}
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	subject := req.TLS.VerifiedChains[0][0].Subject
+	return &Identity{Subject: subject.CommonName, Roles: subject.OrganizationalUnit}
+}
+
+// authorized wraps a route handler, which is only called if the caller is granted the action on the robot,
+// device, command or event of the route. Routes without robot need the action for any robot, the handler
+// filters the robots.
+func (a *API) authorized(action Action, f http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		robot, device := req.PathValue("robot"), req.PathValue("device")
+		name := req.PathValue("command") + req.PathValue("event")
+
+		if a.allowedRoute(req, action, robot, device, name) {
+			f(res, req)
+			return
+		}
+
+		if action == ActionCommand {
+			a.audit(req, "http", AuditEntry{Robot: robot, Device: device, Command: name, Error: "Forbidden"})
+		}
+		a.writeJSONStatus(map[string]interface{}{"error": "Forbidden"}, http.StatusForbidden, res)
+	}
+}
+
+// AuditEntry is the record of a command invocation, a denied invocation is not allowed and has no params
+type AuditEntry struct {
+	Time       time.Time              `json:"time"`
+	Subject    string                 `json:"subject,omitempty"`
+	Roles      []string               `json:"roles,omitempty"`
+	RemoteAddr string                 `json:"remote_addr,omitempty"`
+	Transport  string                 `json:"transport"`
+	Robot      string                 `json:"robot,omitempty"`
+	Device     string                 `json:"device,omitempty"`
+	Command    string                 `json:"command"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Allowed    bool                   `json:"allowed"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// AuditLogger records the command invocations, see API.AuditLog
+type AuditLogger interface {
+	Audit(entry AuditEntry)
+}
+
+// auditLog writes the entries as JSON lines
+type auditLog struct {
+	w     io.Writer
+	mutex sync.Mutex
+}
+
+// NewAuditLog returns an audit logger, which writes each entry as a single line of JSON
+func NewAuditLog(w io.Writer) AuditLogger {
+	return &auditLog{w: w}
+}
+
+func (l *auditLog) Audit(entry AuditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Error: can not write audit entry: %v", err)
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, err := l.w.Write(append(data, '\n')); err != nil {
+		log.Printf("Error: can not write audit entry: %v", err)
+	}
+}
+
+// audit completes the entry by the caller of the request and records it
+func (a *API) audit(req *http.Request, transport string, entry AuditEntry) {
+	if a.AuditLog == nil {
+		return
+	}
+
+	entry.Time = time.Now()
+	entry.Transport = transport
+	entry.RemoteAddr = req.RemoteAddr
+	if identity := callerIdentity(req); identity != nil {
+		entry.Subject = identity.Subject
+		entry.Roles = identity.Roles
+	}
+
+	a.AuditLog.Audit(entry)
+}
@@ -0,0 +1,239 @@
+//nolint:forcetypeassert,usestdlibvars,noctx // ok here
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func initTestPolicyAPI(t *testing.T) (*API, *bytes.Buffer, func(roles ...string) string) {
+	t.Helper()
+
+	a := initTestAPI()
+	auth := NewTokenAuth([]byte("secret"))
+	auditLog := &bytes.Buffer{}
+	a.Auth = auth
+	a.AuditLog = NewAuditLog(auditLog)
+	a.Policy = NewPolicy(
+		Rule{Role: "operator", Action: ActionRead},
+		Rule{Role: "operator", Action: ActionEvents},
+		Rule{Role: "supervisor", Action: ActionCommand, Robot: "Robot1", Name: "robotTestFunction"},
+		Rule{Role: "supervisor", Action: ActionCommand, Robot: "Robot*", Device: "Device1", Name: "TestDriver*"},
+		Rule{Role: "robot2", Action: ActionRead, Robot: "Robot2"},
+	)
+
+	token := func(roles ...string) string {
+		token, err := auth.Issue(Identity{Subject: "alice", Roles: roles}, 0)
+		require.NoError(t, err)
+		return token
+	}
+
+	return a, auditLog, token
+}
+
+func serveTestRequest(a *API, method, path, token, body string) *httptest.ResponseRecorder {
+	request, _ := http.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	return response
+}
+
+func TestPolicyRoutes(t *testing.T) {
+	a, _, token := initTestPolicyAPI(t)
+	tests := map[string]struct {
+		method     string
+		path       string
+		roles      []string
+		wantStatus int
+	}{
+		"no_token": {
+			method: "GET", path: "/api/robots", wantStatus: http.StatusUnauthorized,
+		},
+		"read": {
+			method: "GET", path: "/api/robots/Robot1/devices", roles: []string{"operator"}, wantStatus: http.StatusOK,
+		},
+		"read_without_role": {
+			method: "GET", path: "/api/robots/Robot1", roles: []string{"guest"}, wantStatus: http.StatusForbidden,
+		},
+		"read_other_robot": {
+			method: "GET", path: "/api/robots/Robot1", roles: []string{"robot2"}, wantStatus: http.StatusForbidden,
+		},
+		"command_as_operator": {
+			method: "POST", path: "/api/robots/Robot1/commands/robotTestFunction", roles: []string{"operator"},
+			wantStatus: http.StatusForbidden,
+		},
+		"command_as_supervisor": {
+			method: "POST", path: "/api/robots/Robot1/commands/robotTestFunction", roles: []string{"supervisor"},
+			wantStatus: http.StatusOK,
+		},
+		"device_command_as_supervisor": {
+			method: "POST", path: "/api/robots/Robot2/devices/Device1/commands/TestDriverCommand",
+			roles: []string{"supervisor"}, wantStatus: http.StatusOK,
+		},
+		"other_device_command_as_supervisor": {
+			method: "POST", path: "/api/robots/Robot2/devices/Device2/commands/TestDriverCommand",
+			roles: []string{"supervisor"}, wantStatus: http.StatusForbidden,
+		},
+		"manager_command_as_supervisor": {
+			method: "POST", path: "/api/commands/TestFunction", roles: []string{"supervisor"},
+			wantStatus: http.StatusForbidden,
+		},
+		"cancel_work_as_supervisor": {
+			method: "DELETE", path: "/api/robots/Robot1/work/1", roles: []string{"supervisor"},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tok := ""
+			if tc.roles != nil {
+				tok = token(tc.roles...)
+			}
+			// act
+			response := serveTestRequest(a, tc.method, tc.path, tok, `{"message":"Beep Boop","name":"human","robot":"Robot1"}`)
+			// assert
+			assert.Equal(t, tc.wantStatus, response.Code)
+		})
+	}
+}
+
+func TestPolicyRobotsFiltered(t *testing.T) {
+	// arrange
+	a, _, token := initTestPolicyAPI(t)
+	// act
+	response := serveTestRequest(a, "GET", "/api/robots", token("robot2"), "")
+	// assert
+	require.Equal(t, http.StatusOK, response.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+	robots := body["robots"].([]interface{})
+	require.Len(t, robots, 1)
+	assert.Equal(t, "Robot2", robots[0].(map[string]interface{})["name"])
+}
+
+func TestPolicyAuditLog(t *testing.T) {
+	// arrange
+	a, auditLog, token := initTestPolicyAPI(t)
+	// act
+	serveTestRequest(a, "POST", "/api/robots/Robot1/commands/robotTestFunction", token("supervisor"),
+		`{"message":"Beep Boop","robot":"Robot1"}`)
+	serveTestRequest(a, "POST", "/api/robots/Robot1/commands/robotTestFunction", token("operator"),
+		`{"message":"Beep Boop","robot":"Robot1"}`)
+	// assert
+	lines := strings.Split(strings.TrimSpace(auditLog.String()), "\n")
+	require.Len(t, lines, 2)
+	var allowed, denied AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &allowed))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &denied))
+	assert.Equal(t, "alice", allowed.Subject)
+	assert.Equal(t, []string{"supervisor"}, allowed.Roles)
+	assert.Equal(t, "http", allowed.Transport)
+	assert.Equal(t, "Robot1", allowed.Robot)
+	assert.Equal(t, "robotTestFunction", allowed.Command)
+	assert.Equal(t, map[string]interface{}{"message": "Beep Boop", "robot": "Robot1"}, allowed.Params)
+	assert.True(t, allowed.Allowed)
+	assert.False(t, allowed.Time.IsZero())
+	assert.Equal(t, []string{"operator"}, denied.Roles)
+	assert.False(t, denied.Allowed)
+	assert.Equal(t, "Forbidden", denied.Error)
+}
+
+func TestPolicyClientCertificate(t *testing.T) {
+	tests := map[string]struct {
+		state      *tls.ConnectionState
+		wantStatus int
+	}{
+		"operator": {
+			state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+				Subject: pkix.Name{CommonName: "bob", OrganizationalUnit: []string{"operator"}},
+			}}}},
+			wantStatus: http.StatusOK,
+		},
+		"other_unit": {
+			state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+				Subject: pkix.Name{CommonName: "bob", OrganizationalUnit: []string{"guest"}},
+			}}}},
+			wantStatus: http.StatusForbidden,
+		},
+		"unverified": {
+			state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{
+				Subject: pkix.Name{CommonName: "bob", OrganizationalUnit: []string{"operator"}},
+			}}},
+			wantStatus: http.StatusForbidden,
+		},
+		"no_tls": {wantStatus: http.StatusForbidden},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			a, _, _ := initTestPolicyAPI(t)
+			a.Auth = nil
+			request, _ := http.NewRequest("GET", "/api/robots/Robot1", nil)
+			request.TLS = tc.state
+			response := httptest.NewRecorder()
+			// act
+			a.ServeHTTP(response, request)
+			// assert
+			assert.Equal(t, tc.wantStatus, response.Code)
+		})
+	}
+}
+
+func TestPolicyClientCertificateAuditLog(t *testing.T) {
+	// arrange
+	a, auditLog, _ := initTestPolicyAPI(t)
+	a.Auth = nil
+	request, _ := http.NewRequest("POST", "/api/robots/Robot1/commands/robotTestFunction",
+		strings.NewReader(`{"message":"Beep Boop","robot":"Robot1"}`))
+	request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+		Subject: pkix.Name{CommonName: "bob", OrganizationalUnit: []string{"supervisor"}},
+	}}}}
+	response := httptest.NewRecorder()
+	// act
+	a.ServeHTTP(response, request)
+	// assert
+	assert.Equal(t, http.StatusOK, response.Code)
+	var entry AuditEntry
+	require.NoError(t, json.Unmarshal(auditLog.Bytes(), &entry))
+	assert.Equal(t, "bob", entry.Subject)
+	assert.Equal(t, []string{"supervisor"}, entry.Roles)
+	assert.True(t, entry.Allowed)
+}
+
+func TestPolicyWebSocket(t *testing.T) {
+	// arrange
+	a, auditLog, token := initTestPolicyAPI(t)
+	server := httptest.NewServer(a)
+	t.Cleanup(server.Close)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws?access_token="
+	_, err := websocket.Dial(url, "", server.URL)
+	require.Error(t, err)
+	ws, err := websocket.Dial(url+token("operator"), "", server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ws.Close() })
+	// act
+	require.NoError(t, websocket.JSON.Send(ws, WebSocketMessage{
+		ID: "1", Type: WebSocketCommand, Robot: "Robot1", Command: "robotTestFunction",
+		Params: json.RawMessage(`{"message":"Beep Boop","robot":"Robot1"}`),
+	}))
+	// assert
+	assert.Equal(t, "Forbidden", receiveWebSocket(t, ws, WebSocketError).Error)
+	var entry AuditEntry
+	require.NoError(t, json.Unmarshal(auditLog.Bytes(), &entry))
+	assert.Equal(t, "websocket", entry.Transport)
+	assert.False(t, entry.Allowed)
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+var (
+	// ErrAPIStarted is returned by Start, if the server of the API is already running
+	ErrAPIStarted = fmt.Errorf("API server already started")
+	// ErrAPIClientCA is returned by Start, if the file of the client CA contains no certificate
+	ErrAPIClientCA = fmt.Errorf("no valid certificate in client CA file")
+	// ErrAPISocket is returned by Start, if the path of the unix socket exists, but is no socket
+	ErrAPISocket = fmt.Errorf("API socket path is no socket")
+)
+
+const apiReadHeaderTimeout = 30 * time.Second
+
+// Shutdown stops the server of the API gracefully. The server does not accept new connections and waits for
+// the active requests until the context is done. Open WebSocket connections are closed.
+func (a *API) Shutdown(ctx context.Context) error {
+	a.mtx.Lock()
+	server := a.server
+	served := a.served
+	a.server = nil
+	a.listener = nil
+	a.mtx.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	err := server.Shutdown(ctx)
+	select {
+	case <-served:
+	case <-ctx.Done():
+	}
+
+	return err
+}
+
+// Addr returns the address the server of the API listens on, nil if the server is not running. This is useful
+// with port "0", which selects a free port.
+func (a *API) Addr() net.Addr {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if a.listener == nil {
+		return nil
+	}
+
+	return a.listener.Addr()
+}
+
+// serve opens the listener and serves the API with its own server until the context is done or Shutdown is
+// called. Only the serving itself runs in the background, so errors of the listener are returned.
+func (a *API) serve(ctx context.Context) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if a.server != nil {
+		return ErrAPIStarted
+	}
+
+	tlsConfig, err := a.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	listener, err := a.listen()
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	} else {
+		log.Println("WARNING: API using insecure connection. " +
+			"We recommend using an SSL certificate with Gobot.")
+	}
+
+	log.Println("Initializing API on " + listener.Addr().String() + "...")
+
+	// the requests are not canceled by the context of the start, but by Shutdown
+	baseCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	server := &http.Server{
+		Handler:           a,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: apiReadHeaderTimeout,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+	server.RegisterOnShutdown(cancel)
+
+	served := make(chan struct{})
+	a.server = server
+	a.listener = listener
+	a.served = served
+
+	go func() {
+		defer close(served)
+		defer cancel()
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Server error: %v", err)
+		}
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := a.Shutdown(context.Background()); err != nil {
+				log.Printf("Server shutdown error: %v", err)
+			}
+		case <-served:
+		}
+	}()
+
+	return nil
+}
+
+// listen opens the unix socket, if given, otherwise the TCP port
+func (a *API) listen() (net.Listener, error) {
+	if a.Socket != "" {
+		// a stale socket of a previous run would block the listener, other files are never removed
+		info, err := os.Lstat(a.Socket)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, err
+		case info.Mode().Type() != fs.ModeSocket:
+			return nil, fmt.Errorf("%w: %s", ErrAPISocket, a.Socket)
+		default:
+			if err := os.Remove(a.Socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+
+		return net.Listen("unix", a.Socket)
+	}
+
+	return net.Listen("tcp", net.JoinHostPort(a.Host, a.Port))
+}
+
+// tlsConfig returns the TLS configuration for the certificate and key, nil without certificate. With a client
+// CA, each client needs a certificate signed by this CA.
+func (a *API) tlsConfig() (*tls.Config, error) {
+	if a.Cert == "" && a.Key == "" {
+		if a.ClientCA != "" {
+			return nil, fmt.Errorf("client CA %s requires a certificate and key", a.ClientCA)
+		}
+		return nil, nil //nolint:nilnil // no TLS is valid
+	}
+
+	cert, err := tls.LoadX509KeyPair(a.Cert, a.Key)
+	if err != nil {
+		return nil, fmt.Errorf("loading the API certificate failed: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if a.ClientCA != "" {
+		pem, err := os.ReadFile(a.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("loading the API client CA failed: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w %s", ErrAPIClientCA, a.ClientCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
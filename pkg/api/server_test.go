@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/internal/config"
+)
+
+// testCertificates are the files of a CA and a server certificate and the certificate of a client signed by the CA
+type testCertificates struct {
+	caFile, certFile, keyFile string
+	client                    tls.Certificate
+	pool                      *x509.CertPool
+}
+
+func initTestCertificates(t *testing.T) testCertificates {
+	t.Helper()
+
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			DNSNames:     []string{"localhost"},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	certs := testCertificates{
+		caFile:   filepath.Join(dir, "ca.pem"),
+		certFile: filepath.Join(dir, "cert.pem"),
+		keyFile:  filepath.Join(dir, "key.pem"),
+		pool:     x509.NewCertPool(),
+	}
+	certs.pool.AddCert(caCert)
+	require.NoError(t, os.WriteFile(certs.caFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0o600))
+	serverCert, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	require.NoError(t, os.WriteFile(certs.certFile, serverCert, 0o600))
+	require.NoError(t, os.WriteFile(certs.keyFile, serverKey, 0o600))
+	clientCert, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+	certs.client, err = tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+
+	return certs
+}
+
+func initTestServerAPI(t *testing.T) *API {
+	t.Helper()
+
+	log.SetOutput(NullReadWriteCloser{})
+	a := newAPI(gobot.NewManager(), &config.Config{APIHost: "127.0.0.1"})
+	a.Port = "0"
+	t.Cleanup(func() { _ = a.Shutdown(context.Background()) })
+
+	return a
+}
+
+func TestServerStartShutdown(t *testing.T) {
+	// arrange
+	a := initTestServerAPI(t)
+	require.Nil(t, a.Addr())
+	// act
+	require.NoError(t, a.Start(context.Background()))
+	// assert
+	require.NotNil(t, a.Addr())
+	resp, err := http.Get("http://" + a.Addr().String() + "/api/robots")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.JSONEq(t, `{"robots":[]}`, string(body))
+	require.ErrorIs(t, a.Start(context.Background()), ErrAPIStarted)
+
+	addr := a.Addr().String()
+	require.NoError(t, a.Shutdown(context.Background()))
+	assert.Nil(t, a.Addr())
+	_, err = net.Dial("tcp", addr)
+	require.Error(t, err)
+	require.NoError(t, a.Shutdown(context.Background()))
+}
+
+func TestServerContextDone(t *testing.T) {
+	// arrange
+	a := initTestServerAPI(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, a.Start(ctx))
+	// act
+	cancel()
+	// assert
+	assert.Eventually(t, func() bool { return a.Addr() == nil }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestServerUnixSocket(t *testing.T) {
+	// arrange
+	a := initTestServerAPI(t)
+	a.Socket = filepath.Join(t.TempDir(), "api.sock")
+	// stale socket of a previous run
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: a.Socket, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", a.Socket)
+		},
+	}}
+	// act
+	require.NoError(t, a.Start(context.Background()))
+	// assert
+	resp, err := client.Get("http://gobot/api/robots")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServerUnixSocketNoSocket(t *testing.T) {
+	// arrange
+	a := initTestServerAPI(t)
+	a.Socket = filepath.Join(t.TempDir(), "api.sock")
+	require.NoError(t, os.WriteFile(a.Socket, []byte("data"), 0o600))
+	// act
+	err := a.Start(context.Background())
+	// assert
+	require.ErrorIs(t, err, ErrAPISocket)
+	content, err := os.ReadFile(a.Socket)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(content))
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	// arrange
+	certs := initTestCertificates(t)
+	a := initTestServerAPI(t)
+	a.Cert = certs.certFile
+	a.Key = certs.keyFile
+	a.ClientCA = certs.caFile
+	require.NoError(t, a.Start(context.Background()))
+	url := "https://" + a.Addr().String() + "/api/robots"
+	clientFor := func(certificates ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      certs.pool,
+			Certificates: certificates,
+			MinVersion:   tls.VersionTLS12,
+		}}}
+	}
+	// act & assert
+	resp, err := clientFor(certs.client).Get(url)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = clientFor().Get(url)
+	require.Error(t, err)
+}
+
+func TestServerStartErrors(t *testing.T) {
+	certs := initTestCertificates(t)
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = busy.Close() })
+	_, busyPort, err := net.SplitHostPort(busy.Addr().String())
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		setup   func(a *API)
+		wantErr string
+	}{
+		"port_in_use": {
+			setup:   func(a *API) { a.Port = busyPort },
+			wantErr: "address already in use",
+		},
+		"missing_key": {
+			setup:   func(a *API) { a.Cert = certs.certFile; a.Key = filepath.Join(t.TempDir(), "missing.pem") },
+			wantErr: "loading the API certificate failed",
+		},
+		"client_ca_without_certificate": {
+			setup:   func(a *API) { a.ClientCA = certs.caFile },
+			wantErr: "requires a certificate and key",
+		},
+		"invalid_client_ca": {
+			setup:   func(a *API) { a.Cert = certs.certFile; a.Key = certs.keyFile; a.ClientCA = certs.keyFile },
+			wantErr: ErrAPIClientCA.Error(),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			a := initTestServerAPI(t)
+			tc.setup(a)
+			// act
+			err := a.Start(context.Background())
+			// assert
+			require.ErrorContains(t, err, tc.wantErr)
+			assert.Nil(t, a.Addr())
+		})
+	}
+}
+
+func TestServerShutdownClosesWebSockets(t *testing.T) {
+	// arrange
+	a := initTestServerAPI(t)
+	require.NoError(t, a.Start(context.Background()))
+	ws, err := websocket.Dial("ws://"+a.Addr().String()+"/api/ws", "", "http://"+a.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ws.Close() })
+	// act
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, a.Shutdown(ctx))
+	// assert
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var msg WebSocketMessage
+	require.ErrorIs(t, websocket.JSON.Receive(ws, &msg), io.EOF)
+}
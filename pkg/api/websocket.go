@@ -0,0 +1,448 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"gobot.io/x/gobot/v2"
+)
+
+// Types of the messages of the WebSocket protocol
+const (
+	// WebSocketSubscribe is sent by the client to subscribe to the events matching the robot, device and event
+	// patterns, the server answers with WebSocketSubscribed including the ID of the subscription
+	WebSocketSubscribe = "subscribe"
+	// WebSocketSubscribed is the answer of the server to WebSocketSubscribe
+	WebSocketSubscribed = "subscribed"
+	// WebSocketUnsubscribe is sent by the client to remove the subscription with the given ID, the server answers
+	// with WebSocketUnsubscribed
+	WebSocketUnsubscribe = "unsubscribe"
+	// WebSocketUnsubscribed is the answer of the server to WebSocketUnsubscribe
+	WebSocketUnsubscribed = "unsubscribed"
+	// WebSocketCommand is sent by the client to execute a command of the manager, a robot or a device, the server
+	// answers with WebSocketResult or WebSocketError
+	WebSocketCommand = "command"
+	// WebSocketResult is the answer of the server to a successful WebSocketCommand
+	WebSocketResult = "result"
+	// WebSocketEvent is sent by the server for each event matching a subscription
+	WebSocketEvent = "event"
+	// WebSocketError is the answer of the server to a failed request
+	WebSocketError = "error"
+	// WebSocketPing can be sent by both sides, it is answered by WebSocketPong
+	WebSocketPing = "ping"
+	// WebSocketPong is the answer to WebSocketPing
+	WebSocketPong = "pong"
+)
+
+const (
+	defaultWebSocketPingInterval = 30 * time.Second
+	webSocketWriteTimeout        = 10 * time.Second
+	webSocketEventBuffer         = 100
+	webSocketMaxPayload          = 1 << 20
+)
+
+// ErrWebSocketOrigin is returned by the handshake of the WebSocket endpoint for a page of a foreign origin
+var ErrWebSocketOrigin = fmt.Errorf("origin not allowed")
+
+// WebSocketMessage is a message of the WebSocket protocol in both directions. The ID of a request of the client
+// is returned in the answer of the server, so the client can correlate them.
+//
+// Examples:
+//
+//	{"id": "1", "type": "subscribe", "robot": "bot", "device": "sensor*", "event": "*"}
+//	{"id": "1", "type": "subscribed", "subscription": "1"}
+//	{"type": "event", "subscription": "1", "robot": "bot", "device": "sensor1", "event": "data", "data": 42}
+//	{"id": "2", "type": "command", "robot": "bot", "device": "led", "command": "On", "params": {}}
+//	{"id": "2", "type": "result", "result": null}
+//
+// The patterns of a subscription are matched by path.Match, an empty pattern matches all. Events of a robot itself
+// have an empty device. A command without robot is a command of the manager, a command without device is a command
+// of the robot. A command without name returns the list of the available commands.
+type WebSocketMessage struct {
+	ID           string          `json:"id,omitempty"`
+	Type         string          `json:"type"`
+	Subscription string          `json:"subscription,omitempty"`
+	Robot        string          `json:"robot,omitempty"`
+	Device       string          `json:"device,omitempty"`
+	Event        string          `json:"event,omitempty"`
+	Command      string          `json:"command,omitempty"`
+	Params       json.RawMessage `json:"params,omitempty"`
+	Result       interface{}     `json:"result,omitempty"`
+	Data         json.RawMessage `json:"data,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// webSocketSubscription are the patterns of a subscription
+type webSocketSubscription struct {
+	id                   string
+	robot, device, event string
+}
+
+// webSocketSession is the state of a single WebSocket connection
+type webSocketSession struct {
+	api     *API
+	ws      *websocket.Conn
+	req     *http.Request
+	ctx     context.Context //nolint:containedctx // done by intention
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	writeMu sync.Mutex
+	mtx     sync.Mutex
+	lastID  int
+	subs    []webSocketSubscription
+	sources map[string]webSocketSource
+}
+
+// webSocketSource is the subscription of the session to the events of a robot or device
+type webSocketSource struct {
+	robot   string
+	device  string
+	eventer gobot.Eventer
+	events  chan *gobot.Event
+}
+
+// webSocket returns the route handler of the WebSocket endpoint, which multiplexes commands and events of all
+// robots on a single connection, see WebSocketMessage
+func (a *API) webSocket(res http.ResponseWriter, req *http.Request) {
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			var err error
+			config.Origin, err = websocket.Origin(config, req)
+			if err != nil {
+				return err
+			}
+			return a.checkWebSocketOrigin(req, config.Origin)
+		},
+		Handler: a.serveWebSocket,
+	}
+	server.ServeHTTP(res, req)
+}
+
+// checkWebSocketOrigin rejects the handshake of a browser page from a foreign site (cross-site WebSocket hijacking),
+// the browser sends the cookies and the client certificate of the user also for such a page. The origin is
+// optional for non-browser clients, their access is controlled by the handlers of the API.
+func (a *API) checkWebSocketOrigin(req *http.Request, origin *url.URL) error {
+	if origin == nil || strings.EqualFold(origin.Host, req.Host) {
+		return nil
+	}
+
+	c := &CORS{AllowOrigins: a.WebSocketOrigins}
+	c.generatePatterns()
+	if c.isOriginAllowed(req.Header.Get("Origin")) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrWebSocketOrigin, origin)
+}
+
+func (a *API) serveWebSocket(ws *websocket.Conn) {
+	ws.MaxPayloadBytes = webSocketMaxPayload
+	// the context of the request is canceled on the shutdown of the server, which does not close hijacked
+	// connections by itself
+	ctx, cancel := context.WithCancel(ws.Request().Context())
+	s := &webSocketSession{
+		api:     a,
+		ws:      ws,
+		req:     ws.Request(),
+		ctx:     ctx,
+		cancel:  cancel,
+		sources: make(map[string]webSocketSource),
+	}
+
+	pingInterval := a.WebSocketPingInterval
+	if pingInterval <= 0 {
+		pingInterval = defaultWebSocketPingInterval
+	}
+
+	s.wg.Add(2)
+	go s.ping(pingInterval)
+	go func() {
+		defer s.wg.Done()
+		<-ctx.Done()
+		_ = ws.Close()
+	}()
+
+	s.read(2 * pingInterval)
+	s.close()
+}
+
+// read handles the messages of the client until the connection fails or the client is not alive anymore
+func (s *webSocketSession) read(timeout time.Duration) {
+	for {
+		if err := s.ws.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return
+		}
+
+		var msg WebSocketMessage
+		if err := websocket.JSON.Receive(s.ws, &msg); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				s.send(WebSocketMessage{Type: WebSocketError, Error: fmt.Sprintf("invalid message: %v", err)})
+				continue
+			}
+			return
+		}
+
+		switch msg.Type {
+		case WebSocketSubscribe:
+			s.subscribe(msg)
+		case WebSocketUnsubscribe:
+			s.unsubscribe(msg)
+		case WebSocketCommand:
+			// commands run concurrently, the answers are correlated by the ID
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.command(msg)
+			}()
+		case WebSocketPing:
+			s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketPong})
+		case WebSocketPong:
+			// the read deadline is already extended
+		default:
+			s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketError, Error: "Unknown message type " + msg.Type})
+		}
+	}
+}
+
+// close stops the forwarding of events and removes the subscriptions of the device events
+func (s *webSocketSession) close() {
+	// the connection is closed by the cancellation
+	s.cancel()
+	s.wg.Wait()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, source := range s.sources {
+		source.eventer.Unsubscribe(source.events)
+	}
+}
+
+// ping checks the liveness of the client, which needs to answer within the read timeout
+func (s *webSocketSession) ping(interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.send(WebSocketMessage{Type: WebSocketPing})
+		}
+	}
+}
+
+func (s *webSocketSession) send(msg WebSocketMessage) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.ws.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout)); err != nil {
+		return
+	}
+	if err := websocket.JSON.Send(s.ws, msg); err != nil && s.ctx.Err() == nil {
+		log.Printf("Error: can not send WebSocket message '%s': %v", msg.Type, err)
+	}
+}
+
+func (s *webSocketSession) subscribe(msg WebSocketMessage) {
+	sub := webSocketSubscription{robot: msg.Robot, device: msg.Device, event: msg.Event}
+	for _, pattern := range []string{sub.robot, sub.device, sub.event} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketError, Error: "invalid pattern " + pattern})
+			return
+		}
+	}
+
+	s.mtx.Lock()
+	s.lastID++
+	sub.id = strconv.Itoa(s.lastID)
+	s.subs = append(s.subs, sub)
+	s.mtx.Unlock()
+
+	s.api.manager.Robots().Each(func(robot *gobot.Robot) {
+		if !matchPattern(sub.robot, robot.Name) {
+			return
+		}
+		if matchPattern(sub.device, "") {
+			s.attach(robot.Name, "", robot)
+		}
+		robot.Devices().Each(func(device gobot.Device) {
+			if eventer, ok := device.(gobot.Eventer); ok && matchPattern(sub.device, device.Name()) {
+				s.attach(robot.Name, device.Name(), eventer)
+			}
+		})
+	})
+
+	s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketSubscribed, Subscription: sub.id})
+}
+
+func (s *webSocketSession) unsubscribe(msg WebSocketMessage) {
+	s.mtx.Lock()
+	found := false
+	for i, sub := range s.subs {
+		if sub.id == msg.Subscription {
+			s.subs = append(s.subs[:i], s.subs[i+1:]...)
+			found = true
+			break
+		}
+	}
+	s.mtx.Unlock()
+
+	if !found {
+		s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketError, Error: "Unknown subscription " + msg.Subscription})
+		return
+	}
+	s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketUnsubscribed, Subscription: msg.Subscription})
+}
+
+// attach subscribes once to the events of the robot or device and forwards them to the matching subscriptions
+func (s *webSocketSession) attach(robot, device string, eventer gobot.Eventer) {
+	key := robot + "\x00" + device
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.sources[key]; ok || s.ctx.Err() != nil {
+		return
+	}
+
+	source := webSocketSource{
+		robot:   robot,
+		device:  device,
+		eventer: eventer,
+	}
+	if se, ok := gobot.SubscriptionEventerOf(eventer); ok {
+		source.events = se.SubscribeWithOptions(gobot.WithSubscriptionBuffer(webSocketEventBuffer))
+	} else {
+		source.events = eventer.Subscribe()
+	}
+	s.sources[key] = source
+
+	s.wg.Add(1)
+	go s.forward(source)
+}
+
+// forward sends the events of the source for all matching subscriptions until the connection is closed
+func (s *webSocketSession) forward(source webSocketSource) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case evt := <-source.events:
+			if !s.api.allowed(s.req, ActionEvents, source.robot, source.device, evt.Name) {
+				continue
+			}
+
+			var ids []string
+			s.mtx.Lock()
+			for _, sub := range s.subs {
+				if matchPattern(sub.robot, source.robot) && matchPattern(sub.device, source.device) &&
+					matchPattern(sub.event, evt.Name) {
+					ids = append(ids, sub.id)
+				}
+			}
+			s.mtx.Unlock()
+			if len(ids) == 0 {
+				continue
+			}
+
+			data, err := json.Marshal(evt.Data)
+			if err != nil {
+				data, _ = json.Marshal(fmt.Sprintf("%v", evt.Data))
+			}
+			for _, id := range ids {
+				s.send(WebSocketMessage{
+					Type:         WebSocketEvent,
+					Subscription: id,
+					Robot:        source.robot,
+					Device:       source.device,
+					Event:        evt.Name,
+					Data:         data,
+				})
+			}
+		}
+	}
+}
+
+// command executes the command by the command paths of ExecuteCommand, if the caller is allowed to
+func (s *webSocketSession) command(msg WebSocketMessage) {
+	if msg.Command == "" && !s.api.allowedRoute(s.req, ActionRead, msg.Robot, msg.Device, "") {
+		s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketError, Error: "Forbidden"})
+		return
+	}
+	if msg.Command != "" && !s.api.allowed(s.req, ActionCommand, msg.Robot, msg.Device, msg.Command) {
+		s.api.audit(s.req, "websocket", AuditEntry{
+			Robot: msg.Robot, Device: msg.Device, Command: msg.Command, Error: "Forbidden",
+		})
+		s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketError, Error: "Forbidden"})
+		return
+	}
+
+	cmdPath := []string{"commands"}
+	switch {
+	case msg.Robot == "":
+	case msg.Device == "":
+		cmdPath = []string{"robots", msg.Robot, "commands"}
+	default:
+		cmdPath = []string{"robots", msg.Robot, "devices", msg.Device, "commands"}
+	}
+	if msg.Command != "" {
+		cmdPath = append(cmdPath, msg.Command)
+	}
+
+	response := executeCommandPath(s.api.manager, cmdPath, msg.Params, s.api.metrics())
+	if msg.Command != "" {
+		s.audit(msg, response)
+	}
+	if errMsg, ok := response["error"]; ok {
+		s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketError, Error: fmt.Sprintf("%v", errMsg)})
+		return
+	}
+
+	result, ok := response["result"]
+	if !ok {
+		result = response["commands"]
+	}
+	s.send(WebSocketMessage{ID: msg.ID, Type: WebSocketResult, Result: result})
+}
+
+// audit records the invocation of the command with the decoded params
+func (s *webSocketSession) audit(msg WebSocketMessage, response map[string]interface{}) {
+	entry := AuditEntry{Robot: msg.Robot, Device: msg.Device, Command: msg.Command, Allowed: true}
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &entry.Params); err != nil {
+			entry.Params = map[string]interface{}{"raw": string(msg.Params)}
+		}
+	}
+	if errMsg, ok := response["error"]; ok {
+		entry.Error = fmt.Sprintf("%v", errMsg)
+	}
+
+	s.api.audit(s.req, "websocket", entry)
+}
+
+// matchPattern reports whether the name matches the pattern, an empty pattern matches all names
+func matchPattern(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
@@ -0,0 +1,192 @@
+//nolint:forcetypeassert // ok here
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func initTestWebSocket(t *testing.T, a *API) *websocket.Conn {
+	t.Helper()
+
+	server := httptest.NewServer(a)
+	t.Cleanup(server.Close)
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws", "", server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ws.Close() })
+
+	return ws
+}
+
+// receiveWebSocket returns the next message of the given type, other messages like pings are skipped
+func receiveWebSocket(t *testing.T, ws *websocket.Conn, msgType string) WebSocketMessage {
+	t.Helper()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(2*time.Second)))
+	for {
+		var msg WebSocketMessage
+		require.NoError(t, websocket.JSON.Receive(ws, &msg))
+		if msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+func TestWebSocketEvents(t *testing.T) {
+	// arrange
+	a := initTestAPI()
+	ws := initTestWebSocket(t, a)
+	device := a.manager.Robot("Robot1").Device("Device1").(*testDriver)
+	// act & assert
+	require.NoError(t, websocket.JSON.Send(ws, WebSocketMessage{
+		ID: "1", Type: WebSocketSubscribe, Robot: "Robot1", Device: "Device*", Event: "TestEvent",
+	}))
+	subscribed := receiveWebSocket(t, ws, WebSocketSubscribed)
+	assert.Equal(t, "1", subscribed.ID)
+	assert.Equal(t, "1", subscribed.Subscription)
+
+	a.manager.Robot("Robot2").Device("Device1").(*testDriver).Publish("TestEvent", "not subscribed")
+	device.Publish("OtherEvent", "not subscribed")
+	device.Publish("TestEvent", map[string]interface{}{"value": 42})
+	evt := receiveWebSocket(t, ws, WebSocketEvent)
+	assert.Equal(t, WebSocketMessage{
+		Type:         WebSocketEvent,
+		Subscription: "1",
+		Robot:        "Robot1",
+		Device:       "Device1",
+		Event:        "TestEvent",
+		Data:         json.RawMessage(`{"value":42}`),
+	}, evt)
+
+	require.NoError(t, websocket.JSON.Send(ws, WebSocketMessage{ID: "2", Type: WebSocketUnsubscribe, Subscription: "1"}))
+	assert.Equal(t, "2", receiveWebSocket(t, ws, WebSocketUnsubscribed).ID)
+	device.Publish("TestEvent", "unsubscribed")
+	require.NoError(t, websocket.JSON.Send(ws, WebSocketMessage{ID: "3", Type: WebSocketPing}))
+	var msg WebSocketMessage
+	require.NoError(t, websocket.JSON.Receive(ws, &msg))
+	assert.Equal(t, WebSocketMessage{ID: "3", Type: WebSocketPong}, msg)
+
+	require.NoError(t, websocket.JSON.Send(ws, WebSocketMessage{ID: "4", Type: WebSocketUnsubscribe, Subscription: "1"}))
+	assert.Equal(t, "Unknown subscription 1", receiveWebSocket(t, ws, WebSocketError).Error)
+}
+
+func TestWebSocketCommands(t *testing.T) {
+	tests := map[string]struct {
+		request WebSocketMessage
+		want    WebSocketMessage
+	}{
+		"manager": {
+			request: WebSocketMessage{Command: "TestFunction", Params: json.RawMessage(`{"message":"Beep Boop"}`)},
+			want:    WebSocketMessage{Type: WebSocketResult, Result: "hey Beep Boop"},
+		},
+		"robot": {
+			request: WebSocketMessage{
+				Robot: "Robot1", Command: "robotTestFunction",
+				Params: json.RawMessage(`{"message":"Beep Boop","robot":"Robot1"}`),
+			},
+			want: WebSocketMessage{Type: WebSocketResult, Result: "hey Robot1, Beep Boop"},
+		},
+		"device": {
+			request: WebSocketMessage{
+				Robot: "Robot1", Device: "Device1", Command: "TestDriverCommand",
+				Params: json.RawMessage(`{"name":"human"}`),
+			},
+			want: WebSocketMessage{Type: WebSocketResult, Result: "hello human"},
+		},
+		"command_list": {
+			request: WebSocketMessage{Robot: "Robot1"},
+			want:    WebSocketMessage{Type: WebSocketResult, Result: []interface{}{"robotTestFunction"}},
+		},
+		"unknown_command": {
+			request: WebSocketMessage{Robot: "Robot1", Command: "UnknownCommand"},
+			want:    WebSocketMessage{Type: WebSocketError, Error: "Unknown Command"},
+		},
+		"unknown_robot": {
+			request: WebSocketMessage{Robot: "UnknownRobot1", Command: "robotTestFunction"},
+			want:    WebSocketMessage{Type: WebSocketError, Error: "No Robot found with the name UnknownRobot1"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			ws := initTestWebSocket(t, initTestAPI())
+			tc.request.ID = name
+			tc.request.Type = WebSocketCommand
+			tc.want.ID = name
+			// act
+			require.NoError(t, websocket.JSON.Send(ws, tc.request))
+			// assert
+			var msg WebSocketMessage
+			require.NoError(t, websocket.JSON.Receive(ws, &msg))
+			assert.Equal(t, tc.want, msg)
+		})
+	}
+}
+
+func TestWebSocketInvalidMessages(t *testing.T) {
+	// arrange
+	ws := initTestWebSocket(t, initTestAPI())
+	// act & assert
+	require.NoError(t, websocket.Message.Send(ws, "no json"))
+	assert.Contains(t, receiveWebSocket(t, ws, WebSocketError).Error, "invalid message")
+
+	require.NoError(t, websocket.JSON.Send(ws, WebSocketMessage{ID: "1", Type: "unknown"}))
+	assert.Equal(t, "Unknown message type unknown", receiveWebSocket(t, ws, WebSocketError).Error)
+
+	require.NoError(t, websocket.JSON.Send(ws, WebSocketMessage{ID: "2", Type: WebSocketSubscribe, Device: "["}))
+	assert.Equal(t, "invalid pattern [", receiveWebSocket(t, ws, WebSocketError).Error)
+}
+
+func TestWebSocketLiveness(t *testing.T) {
+	// arrange
+	a := initTestAPI()
+	a.WebSocketPingInterval = 20 * time.Millisecond
+	ws := initTestWebSocket(t, a)
+	// act & assert: the server pings and closes the connection of the silent client
+	receiveWebSocket(t, ws, WebSocketPing)
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var err error
+	for err == nil {
+		var msg WebSocketMessage
+		err = websocket.JSON.Receive(ws, &msg)
+	}
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	a := initTestAPI()
+	a.WebSocketOrigins = []string{"https://*.example.com"}
+	server := httptest.NewServer(a)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws"
+
+	tests := map[string]struct {
+		origin  string
+		wantErr bool
+	}{
+		"same_origin":    {origin: server.URL},
+		"allowed_origin": {origin: "https://app.example.com"},
+		"foreign_origin": {origin: "https://evil.test", wantErr: true},
+		"foreign_suffix": {origin: "https://example.com.evil.test", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ws, err := websocket.Dial(url, "", tc.origin)
+			if tc.wantErr {
+				require.ErrorContains(t, err, "bad status")
+				return
+			}
+			require.NoError(t, err)
+			_ = ws.Close()
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"gobot.io/x/gobot/v2"
+)
+
+// robotWork returns the work route handler.
+// Writes JSON with the representation of all registered work of the robot
+func (a *API) robotWork(res http.ResponseWriter, req *http.Request) {
+	robot := a.manager.Robot(req.PathValue("robot"))
+	if robot == nil {
+		a.writeJSON(map[string]interface{}{"error": "No Robot found with the name " + req.PathValue("robot")}, res)
+		return
+	}
+
+	jsonWork := []*gobot.JSONRobotWork{}
+	for _, rw := range robot.WorkRegistry().List() {
+		jsonWork = append(jsonWork, gobot.NewJSONRobotWork(rw))
+	}
+	a.writeJSON(map[string]interface{}{"work": jsonWork}, res)
+}
+
+// robotWorkItem returns the work item route handler.
+// Writes JSON with the representation of the requested work
+func (a *API) robotWorkItem(res http.ResponseWriter, req *http.Request) {
+	if rw, errMsg := a.robotWorkFor(req); rw == nil {
+		a.writeJSON(map[string]interface{}{"error": errMsg}, res)
+	} else {
+		a.writeJSON(map[string]interface{}{"work": gobot.NewJSONRobotWork(rw)}, res)
+	}
+}
+
+// cancelRobotWork returns the route handler to cancel a work.
+// Writes JSON with the representation of the cancelled work
+func (a *API) cancelRobotWork(res http.ResponseWriter, req *http.Request) {
+	if rw, errMsg := a.robotWorkFor(req); rw == nil {
+		a.writeJSON(map[string]interface{}{"error": errMsg}, res)
+	} else {
+		rw.CallCancelFunc()
+		a.writeJSON(map[string]interface{}{"work": gobot.NewJSONRobotWork(rw)}, res)
+	}
+}
+
+func (a *API) robotWorkFor(req *http.Request) (*gobot.RobotWork, string) {
+	robot := a.manager.Robot(req.PathValue("robot"))
+	if robot == nil {
+		return nil, "No Robot found with the name " + req.PathValue("robot")
+	}
+
+	id, err := uuid.Parse(req.PathValue("id"))
+	if err != nil {
+		return nil, "Invalid work ID " + req.PathValue("id")
+	}
+
+	if rw := robot.WorkRegistry().Get(id); rw != nil {
+		return rw, ""
+	}
+
+	return nil, "No Work found with the ID " + req.PathValue("id")
+}
@@ -0,0 +1,74 @@
+//nolint:forcetypeassert,usestdlibvars,bodyclose,noctx // ok here
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+)
+
+func serveTestJSON(a *API, method string, path string) map[string]interface{} {
+	request, _ := http.NewRequest(method, path, nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(response.Body).Decode(&body)
+	return body
+}
+
+func TestRobotWork(t *testing.T) {
+	a := initTestAPI()
+	robot := a.manager.Robot("Robot1")
+	rw, err := robot.Cron(context.Background(), "0 6 * * *", func() {}, gobot.WithWorkName("water"))
+	require.NoError(t, err)
+	defer rw.CallCancelFunc()
+
+	// list
+	body := serveTestJSON(a, "GET", "/api/robots/Robot1/work")
+	work := body["work"].([]interface{})
+	require.Len(t, work, 1)
+	assert.Equal(t, rw.ID().String(), work[0].(map[string]interface{})["id"])
+	assert.Equal(t, "water", work[0].(map[string]interface{})["name"])
+	assert.Equal(t, "0 6 * * *", work[0].(map[string]interface{})["schedule"])
+
+	// single work
+	body = serveTestJSON(a, "GET", "/api/robots/Robot1/work/"+rw.ID().String())
+	assert.Equal(t, "schedule", body["work"].(map[string]interface{})["kind"])
+
+	// no work
+	body = serveTestJSON(a, "GET", "/api/robots/Robot2/work")
+	assert.Empty(t, body["work"])
+
+	// unknown robot
+	body = serveTestJSON(a, "GET", "/api/robots/UnknownRobot1/work")
+	assert.Equal(t, "No Robot found with the name UnknownRobot1", body["error"])
+}
+
+func TestCancelRobotWork(t *testing.T) {
+	a := initTestAPI()
+	robot := a.manager.Robot("Robot1")
+	rw := robot.Every(context.Background(), time.Hour, func() {})
+
+	// cancel
+	body := serveTestJSON(a, "DELETE", "/api/robots/Robot1/work/"+rw.ID().String())
+	assert.Equal(t, rw.ID().String(), body["work"].(map[string]interface{})["id"])
+	robot.WorkEveryWaitGroup.Wait()
+	assert.Empty(t, robot.WorkRegistry().List())
+
+	// unknown work
+	body = serveTestJSON(a, "DELETE", "/api/robots/Robot1/work/"+rw.ID().String())
+	assert.Equal(t, "No Work found with the ID "+rw.ID().String(), body["error"])
+
+	// invalid ID
+	body = serveTestJSON(a, "DELETE", "/api/robots/Robot1/work/42")
+	assert.Equal(t, "Invalid work ID 42", body["error"])
+}
@@ -0,0 +1,348 @@
+// Package bridge provides the transport independent part of bridging the events of the devices to a message
+// transport, e.g. MQTT or NATS, see the event bridges of those platforms.
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gobot.io/x/gobot/v2"
+)
+
+// Placeholders of the event naming template, which are replaced by the names of the event source
+const (
+	EventRobotPlaceholder  = "{robot}"
+	EventDevicePlaceholder = "{device}"
+	EventNamePlaceholder   = "{event}"
+)
+
+const eventInjectLevel = "inject"
+
+// EventEncoding defines how the data of an event is encoded for the transport
+type EventEncoding int
+
+const (
+	// EventEncodingJSON encodes the event data as JSON, this is the default
+	EventEncodingJSON EventEncoding = iota
+	// EventEncodingRaw passes []byte unchanged and uses the string representation for other data
+	EventEncodingRaw
+)
+
+// ErrUnknownEventSource is returned, when an injected event does not address a bridged device event
+var ErrUnknownEventSource = fmt.Errorf("unknown event source")
+
+// EventBridgeOption is a configuration option for an EventBridge
+type EventBridgeOption func(*EventBridge)
+
+// EventSelection selects the events to bridge, an empty name matches all
+type EventSelection struct {
+	Robot  string
+	Device string
+	Event  string
+}
+
+// EventBridge republishes the events of devices to a transport, e.g. MQTT or NATS, and injects events received
+// from the transport into the devices. The name (topic or subject) of an event is created from a template like
+// "gobot/robots/{robot}/devices/{device}/events/{event}". For injection, the template is extended by a last level
+// "inject", and the placeholders need to occupy whole levels.
+type EventBridge struct {
+	manager    *gobot.Manager
+	publish    func(name string, payload []byte) error
+	template   string
+	separator  string
+	encoding   EventEncoding
+	rateLimit  time.Duration
+	injection  bool
+	selections []EventSelection
+	mtx        sync.Mutex
+	sources    []eventSource
+	lastSent   map[string]time.Time
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+type eventSource struct {
+	robot   string
+	device  string
+	eventer gobot.Eventer
+	events  chan *gobot.Event
+}
+
+// WithEventNaming sets the template for the event names and the separator of its levels
+func WithEventNaming(template, separator string) EventBridgeOption {
+	return func(b *EventBridge) {
+		b.template = template
+		b.separator = separator
+	}
+}
+
+// WithEventSelection adds a selection of events to bridge, an empty name matches all. Without any selection,
+// all events of all devices are bridged.
+func WithEventSelection(robot, device, event string) EventBridgeOption {
+	return func(b *EventBridge) {
+		b.selections = append(b.selections, EventSelection{Robot: robot, Device: device, Event: event})
+	}
+}
+
+// WithEventEncoding sets the encoding of the event data
+func WithEventEncoding(encoding EventEncoding) EventBridgeOption {
+	return func(b *EventBridge) {
+		b.encoding = encoding
+	}
+}
+
+// WithEventRateLimit sets the minimum interval between two published events of the same device event. Events
+// within the interval are dropped.
+func WithEventRateLimit(interval time.Duration) EventBridgeOption {
+	return func(b *EventBridge) {
+		b.rateLimit = interval
+	}
+}
+
+// WithEventInjection enables the reverse direction, so events received from the transport are published by
+// the selected devices
+func WithEventInjection() EventBridgeOption {
+	return func(b *EventBridge) {
+		b.injection = true
+	}
+}
+
+// NewEventBridge creates a bridge for the device events of the manager's robots. The publish function sends
+// the encoded event to the transport.
+func NewEventBridge(manager *gobot.Manager, publish func(name string, payload []byte) error,
+	opts ...EventBridgeOption,
+) *EventBridge {
+	b := &EventBridge{
+		manager:   manager,
+		publish:   publish,
+		template:  "gobot/robots/{robot}/devices/{device}/events/{event}",
+		separator: "/",
+		lastSent:  make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// InjectionEnabled returns whether events from the transport are injected into the devices
+func (b *EventBridge) InjectionEnabled() bool { return b.injection }
+
+// InjectionFilter returns the subscription filter for injected events, the placeholders are replaced by the
+// single level wildcard of the transport
+func (b *EventBridge) InjectionFilter(wildcard string) string {
+	r := strings.NewReplacer(EventRobotPlaceholder, wildcard, EventDevicePlaceholder, wildcard,
+		EventNamePlaceholder, wildcard)
+	return r.Replace(b.template) + b.separator + eventInjectLevel
+}
+
+// Start subscribes to the events of all selected devices
+func (b *EventBridge) Start() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.cancel != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	b.manager.Robots().Each(func(robot *gobot.Robot) {
+		robot.Devices().Each(func(device gobot.Device) {
+			eventer, ok := device.(gobot.Eventer)
+			if !ok || !b.selected(robot.Name, device.Name(), "") {
+				return
+			}
+			source := eventSource{
+				robot:   robot.Name,
+				device:  device.Name(),
+				eventer: eventer,
+				events:  eventer.Subscribe(),
+			}
+			b.sources = append(b.sources, source)
+			b.wg.Add(1)
+			go b.forward(ctx, source)
+		})
+	})
+
+	return nil
+}
+
+// Halt removes the subscriptions of all device events
+func (b *EventBridge) Halt() error {
+	b.mtx.Lock()
+	if b.cancel == nil {
+		b.mtx.Unlock()
+		return nil
+	}
+	b.cancel()
+	b.cancel = nil
+	sources := b.sources
+	b.sources = nil
+	b.mtx.Unlock()
+
+	b.wg.Wait()
+	for _, source := range sources {
+		source.eventer.Unsubscribe(source.events)
+	}
+	return nil
+}
+
+// Inject publishes the event addressed by the name at the device. The name needs to match the injection
+// filter and the event needs to be a selected event, which is known by the device.
+func (b *EventBridge) Inject(name string, payload []byte) error {
+	if !b.injection {
+		return fmt.Errorf("%w: injection is disabled", ErrUnknownEventSource)
+	}
+
+	robot, device, event, ok := b.parseInjectName(name)
+	if !ok || event == "" {
+		return fmt.Errorf("%w: '%s' does not match '%s'", ErrUnknownEventSource, name, b.InjectionFilter("*"))
+	}
+
+	data, err := b.decode(payload)
+	if err != nil {
+		return err
+	}
+
+	b.mtx.Lock()
+	sources := b.sources
+	b.mtx.Unlock()
+
+	injected := false
+	for _, source := range sources {
+		if (robot != "" && source.robot != robot) || (device != "" && source.device != device) ||
+			!b.selected(source.robot, source.device, event) || source.eventer.Event(event) == "" {
+			continue
+		}
+		source.eventer.Publish(event, data)
+		injected = true
+	}
+	if !injected {
+		return fmt.Errorf("%w: '%s'", ErrUnknownEventSource, name)
+	}
+	return nil
+}
+
+// forward publishes the selected events of the source until the context is canceled
+func (b *EventBridge) forward(ctx context.Context, source eventSource) {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt := <-source.events:
+			if !b.selected(source.robot, source.device, evt.Name) || !b.allowed(source, evt.Name) {
+				continue
+			}
+			payload, err := b.encode(evt.Data)
+			if err != nil {
+				log.Printf("Error: can not encode event '%s' of '%s': %v", evt.Name, source.device, err)
+				continue
+			}
+			if err := b.publish(b.eventName(source.robot, source.device, evt.Name), payload); err != nil {
+				log.Printf("Error: can not publish event '%s' of '%s': %v", evt.Name, source.device, err)
+			}
+		}
+	}
+}
+
+// selected reports whether the event matches any selection, an empty event matches all events
+func (b *EventBridge) selected(robot, device, event string) bool {
+	if len(b.selections) == 0 {
+		return true
+	}
+	for _, s := range b.selections {
+		if (s.Robot == "" || s.Robot == robot) && (s.Device == "" || s.Device == device) &&
+			(event == "" || s.Event == "" || s.Event == event) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed applies the rate limit for the event of the source
+func (b *EventBridge) allowed(source eventSource, event string) bool {
+	if b.rateLimit <= 0 {
+		return true
+	}
+
+	key := source.robot + "\x00" + source.device + "\x00" + event
+	now := time.Now()
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if last, ok := b.lastSent[key]; ok && now.Sub(last) < b.rateLimit {
+		return false
+	}
+	b.lastSent[key] = now
+	return true
+}
+
+func (b *EventBridge) eventName(robot, device, event string) string {
+	r := strings.NewReplacer(EventRobotPlaceholder, robot, EventDevicePlaceholder, device,
+		EventNamePlaceholder, event)
+	return r.Replace(b.template)
+}
+
+// parseInjectName extracts the names of the placeholders, a missing placeholder results in an empty name
+func (b *EventBridge) parseInjectName(name string) (robot, device, event string, ok bool) {
+	levels := strings.Split(name, b.separator)
+	templateLevels := strings.Split(b.template+b.separator+eventInjectLevel, b.separator)
+	if len(levels) != len(templateLevels) {
+		return "", "", "", false
+	}
+
+	for i, level := range templateLevels {
+		switch level {
+		case EventRobotPlaceholder:
+			robot = levels[i]
+		case EventDevicePlaceholder:
+			device = levels[i]
+		case EventNamePlaceholder:
+			event = levels[i]
+		default:
+			if level != levels[i] {
+				return "", "", "", false
+			}
+		}
+	}
+	return robot, device, event, true
+}
+
+func (b *EventBridge) encode(data interface{}) ([]byte, error) {
+	if b.encoding == EventEncodingRaw {
+		switch d := data.(type) {
+		case []byte:
+			return d, nil
+		case string:
+			return []byte(d), nil
+		case nil:
+			return []byte{}, nil
+		default:
+			return []byte(fmt.Sprintf("%v", d)), nil
+		}
+	}
+	return json.Marshal(data)
+}
+
+func (b *EventBridge) decode(payload []byte) (interface{}, error) {
+	if b.encoding == EventEncodingRaw {
+		return payload, nil
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("invalid event data: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,155 @@
+package bridge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+)
+
+type testPublished struct {
+	name    string
+	payload string
+}
+
+func newTestEventBridge(opts ...EventBridgeOption) (*EventBridge, *gobot.Robot, chan testPublished) {
+	g := gobot.NewManager()
+	r := g.AddRobot(newTestRobot("Robot1"))
+	published := make(chan testPublished, 10)
+	b := NewEventBridge(g, func(name string, payload []byte) error {
+		published <- testPublished{name: name, payload: string(payload)}
+		return nil
+	}, opts...)
+	return b, r, published
+}
+
+func waitForPublished(t *testing.T, published chan testPublished) testPublished {
+	t.Helper()
+	select {
+	case p := <-published:
+		return p
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not published")
+		return testPublished{}
+	}
+}
+
+func assertNotPublished(t *testing.T, published chan testPublished) {
+	t.Helper()
+	select {
+	case p := <-published:
+		assert.Fail(t, "unexpected event", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventBridgeForward(t *testing.T) {
+	// arrange
+	b, r, published := newTestEventBridge()
+	require.NoError(t, b.Start())
+	defer func() { _ = b.Halt() }()
+	device := r.Device("Device1").(gobot.Eventer) //nolint:forcetypeassert // ok here
+	// act
+	device.Publish("TestEvent", map[string]interface{}{"value": 42})
+	// assert
+	p := waitForPublished(t, published)
+	assert.Equal(t, "gobot/robots/Robot1/devices/Device1/events/TestEvent", p.name)
+	assert.JSONEq(t, `{"value":42}`, p.payload)
+}
+
+func TestEventBridgeSelectionAndNaming(t *testing.T) {
+	// arrange
+	b, r, published := newTestEventBridge(
+		WithEventNaming("factory.{robot}.{device}.{event}", "."),
+		WithEventSelection("Robot1", "Device2", ""),
+		WithEventEncoding(EventEncodingRaw),
+	)
+	require.NoError(t, b.Start())
+	defer func() { _ = b.Halt() }()
+	// act
+	r.Device("Device1").(gobot.Eventer).Publish("TestEvent", "ignored") //nolint:forcetypeassert // ok here
+	r.Device("Device2").(gobot.Eventer).Publish("TestEvent", 1.5)       //nolint:forcetypeassert // ok here
+	// assert
+	p := waitForPublished(t, published)
+	assert.Equal(t, testPublished{name: "factory.Robot1.Device2.TestEvent", payload: "1.5"}, p)
+	assertNotPublished(t, published)
+}
+
+func TestEventBridgeRateLimit(t *testing.T) {
+	// arrange
+	b, r, published := newTestEventBridge(WithEventRateLimit(time.Hour))
+	require.NoError(t, b.Start())
+	defer func() { _ = b.Halt() }()
+	device := r.Device("Device1").(gobot.Eventer) //nolint:forcetypeassert // ok here
+	// act
+	device.Publish("TestEvent", 1)
+	device.Publish("TestEvent", 2)
+	// assert
+	assert.Equal(t, "1", waitForPublished(t, published).payload)
+	assertNotPublished(t, published)
+}
+
+func TestEventBridgeHalt(t *testing.T) {
+	b, r, published := newTestEventBridge()
+	require.NoError(t, b.Start())
+
+	require.NoError(t, b.Halt())
+	r.Device("Device1").(gobot.Eventer).Publish("TestEvent", 1) //nolint:forcetypeassert // ok here
+
+	assertNotPublished(t, published)
+	require.NoError(t, b.Halt())
+}
+
+func TestEventBridgeInject(t *testing.T) {
+	// arrange
+	b, r, published := newTestEventBridge(WithEventInjection(), WithEventSelection("", "Device1", ""))
+	assert.True(t, b.InjectionEnabled())
+	assert.Equal(t, "gobot/robots/+/devices/+/events/+/inject", b.InjectionFilter("+"))
+	require.NoError(t, b.Start())
+	defer func() { _ = b.Halt() }()
+	received := make(chan interface{}, 1)
+	device := r.Device("Device1").(gobot.Eventer) //nolint:forcetypeassert // ok here
+	require.NoError(t, device.On("TestEvent", func(data interface{}) { received <- data }))
+	// act
+	err := b.Inject("gobot/robots/Robot1/devices/Device1/events/TestEvent/inject", []byte(`{"pressed":true}`))
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"pressed": true}, <-received)
+	// the injected event is forwarded like any other event
+	assert.Equal(t, "gobot/robots/Robot1/devices/Device1/events/TestEvent", waitForPublished(t, published).name)
+}
+
+func TestEventBridgeInjectErrors(t *testing.T) {
+	b, _, _ := newTestEventBridge()
+	err := b.Inject("gobot/robots/Robot1/devices/Device1/events/TestEvent/inject", nil)
+	require.ErrorIs(t, err, ErrUnknownEventSource)
+	require.ErrorContains(t, err, "injection is disabled")
+
+	b, _, _ = newTestEventBridge(WithEventInjection(), WithEventSelection("", "Device1", ""))
+	require.NoError(t, b.Start())
+	defer func() { _ = b.Halt() }()
+
+	tests := map[string]struct {
+		name    string
+		payload string
+		wantErr string
+	}{
+		"wrong_level":   {name: "gobot/robots/Robot1/devices/Device1/events/TestEvent", wantErr: "does not match"},
+		"unknown_event": {name: "gobot/robots/Robot1/devices/Device1/events/Unknown/inject", wantErr: "unknown event"},
+		"not_selected":  {name: "gobot/robots/Robot1/devices/Device2/events/TestEvent/inject", wantErr: "unknown"},
+		"unknown_robot": {name: "gobot/robots/Robot2/devices/Device1/events/TestEvent/inject", wantErr: "unknown"},
+		"invalid_json": {
+			name:    "gobot/robots/Robot1/devices/Device1/events/TestEvent/inject",
+			payload: "{",
+			wantErr: "invalid event data",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.ErrorContains(t, b.Inject(tc.name, []byte(tc.payload)), tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package bridge
+
+import (
+	"gobot.io/x/gobot/v2"
+)
+
+type testDriver struct {
+	name       string
+	connection gobot.Connection
+	gobot.Eventer
+}
+
+func (t *testDriver) Start() error                 { return nil }
+func (t *testDriver) Halt() error                  { return nil }
+func (t *testDriver) Name() string                 { return t.name }
+func (t *testDriver) SetName(n string)             { t.name = n }
+func (t *testDriver) Connection() gobot.Connection { return t.connection }
+
+func newTestDriver(adaptor *testAdaptor, name string) *testDriver {
+	t := &testDriver{
+		name:       name,
+		connection: adaptor,
+		Eventer:    gobot.NewEventer(),
+	}
+
+	t.AddEvent("TestEvent")
+
+	return t
+}
+
+type testAdaptor struct {
+	name string
+}
+
+func (t *testAdaptor) Finalize() error  { return nil }
+func (t *testAdaptor) Connect() error   { return nil }
+func (t *testAdaptor) Name() string     { return t.name }
+func (t *testAdaptor) SetName(n string) { t.name = n }
+
+func newTestRobot(name string) *gobot.Robot {
+	adaptor := &testAdaptor{name: "Connection1"}
+	return gobot.NewRobot(name,
+		[]gobot.Connection{adaptor},
+		[]gobot.Device{newTestDriver(adaptor, "Device1"), newTestDriver(adaptor, "Device2")},
+	)
+}
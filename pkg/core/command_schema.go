@@ -0,0 +1,199 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ErrInvalidCommandParams is returned by a typed command, if the params do not match its schema
+var ErrInvalidCommandParams = fmt.Errorf("invalid command params")
+
+// ParamType is the type of a param or the result of a typed command
+type ParamType string
+
+// Types of the params and results of typed commands, the values are converted by CommandSchema.Validate
+const (
+	// ParamAny accepts any value unchanged
+	ParamAny ParamType = ""
+	// ParamString is a string
+	ParamString ParamType = "string"
+	// ParamNumber is converted to float64
+	ParamNumber ParamType = "number"
+	// ParamInteger is converted to int, a number with fraction is invalid
+	ParamInteger ParamType = "integer"
+	// ParamBoolean is a bool
+	ParamBoolean ParamType = "boolean"
+	// ParamDuration is a duration string like "500ms" or a number of milliseconds, which is converted to
+	// time.Duration
+	ParamDuration ParamType = "duration"
+	// ParamObject is a map[string]interface{}
+	ParamObject ParamType = "object"
+	// ParamArray is a []interface{}
+	ParamArray ParamType = "array"
+)
+
+// CommandParam describes a single param of a typed command
+type CommandParam struct {
+	Name        string
+	Type        ParamType
+	Description string
+	Required    bool
+	// Default is used for a missing optional param, nil means the param is omitted
+	Default interface{}
+	// Enum restricts a string param to the given values
+	Enum []string
+	// Minimum and Maximum restrict a number or integer param
+	Minimum *float64
+	Maximum *float64
+}
+
+// CommandSchema describes the params and the result of a typed command, see SchemaCommander.AddTypedCommand
+type CommandSchema struct {
+	Description       string
+	Params            []CommandParam
+	Result            ParamType
+	ResultDescription string
+}
+
+// Validate checks the params against the schema and returns the params converted to the Go types of the
+// ParamType values. Unknown params are rejected, missing optional params get their default value. The error
+// wraps ErrInvalidCommandParams.
+func (s CommandSchema) Validate(params map[string]interface{}) (map[string]interface{}, error) {
+	valid := make(map[string]interface{}, len(s.Params))
+	known := make(map[string]bool, len(s.Params))
+	for _, p := range s.Params {
+		known[p.Name] = true
+
+		value, ok := params[p.Name]
+		if !ok || value == nil {
+			if p.Required {
+				return nil, fmt.Errorf("%w: missing param '%s'", ErrInvalidCommandParams, p.Name)
+			}
+			if p.Default != nil {
+				valid[p.Name] = p.Default
+			}
+			continue
+		}
+
+		converted, err := p.convert(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: param '%s' %w", ErrInvalidCommandParams, p.Name, err)
+		}
+		valid[p.Name] = converted
+	}
+
+	var unknown []string
+	for name := range params {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: unknown param '%s'", ErrInvalidCommandParams, unknown[0])
+	}
+
+	return valid, nil
+}
+
+// convert returns the value as Go type of the param type and checks the restrictions
+func (p CommandParam) convert(value interface{}) (interface{}, error) {
+	switch p.Type {
+	case ParamAny:
+		return value, nil
+	case ParamString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a string")
+		}
+		if len(p.Enum) > 0 && !slices.Contains(p.Enum, s) {
+			return nil, fmt.Errorf("must be one of %v", p.Enum)
+		}
+		return s, nil
+	case ParamNumber:
+		f, ok := toFloat(value)
+		if !ok {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return f, p.checkRange(f)
+	case ParamInteger:
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return int(f), p.checkRange(f)
+	case ParamBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return b, nil
+	case ParamDuration:
+		switch v := value.(type) {
+		case time.Duration:
+			return v, nil
+		case string:
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("must be a duration like '500ms'")
+			}
+			return d, nil
+		}
+		if ms, ok := toFloat(value); ok {
+			return time.Duration(ms * float64(time.Millisecond)), nil
+		}
+		return nil, fmt.Errorf("must be a duration like '500ms' or a number of milliseconds")
+	case ParamObject:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("must be an object")
+		}
+		return m, nil
+	case ParamArray:
+		a, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("must be an array")
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("has the unknown type '%s'", p.Type)
+	}
+}
+
+func (p CommandParam) checkRange(f float64) error {
+	if p.Minimum != nil && f < *p.Minimum {
+		return fmt.Errorf("must be at least %v", *p.Minimum)
+	}
+	if p.Maximum != nil && f > *p.Maximum {
+		return fmt.Errorf("must be at most %v", *p.Maximum)
+	}
+
+	return nil
+}
+
+// toFloat converts the numeric types of JSON and Go callers
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
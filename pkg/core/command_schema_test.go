@@ -0,0 +1,93 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandSchemaValidate(t *testing.T) {
+	minimum := 0.0
+	maximum := 100.0
+	schema := CommandSchema{
+		Params: []CommandParam{
+			{Name: "name", Type: ParamString, Required: true},
+			{Name: "mode", Type: ParamString, Enum: []string{"fast", "slow"}, Default: "slow"},
+			{Name: "speed", Type: ParamInteger, Minimum: &minimum, Maximum: &maximum},
+			{Name: "level", Type: ParamNumber},
+			{Name: "on", Type: ParamBoolean},
+			{Name: "duration", Type: ParamDuration},
+			{Name: "options", Type: ParamObject},
+			{Name: "values", Type: ParamArray},
+			{Name: "any", Type: ParamAny},
+		},
+	}
+	tests := map[string]struct {
+		params  map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		"converted_json_values": {
+			params: map[string]interface{}{
+				"name": "bot", "speed": 42.0, "level": 1.5, "on": true, "duration": "1.5s",
+				"options": map[string]interface{}{"a": 1.0}, "values": []interface{}{1.0}, "any": "x",
+			},
+			want: map[string]interface{}{
+				"name": "bot", "mode": "slow", "speed": 42, "level": 1.5, "on": true, "duration": 1500 * time.Millisecond,
+				"options": map[string]interface{}{"a": 1.0}, "values": []interface{}{1.0}, "any": "x",
+			},
+		},
+		"go_values": {
+			params: map[string]interface{}{"name": "bot", "level": 2, "duration": 20 * time.Millisecond},
+			want:   map[string]interface{}{"name": "bot", "mode": "slow", "level": 2.0, "duration": 20 * time.Millisecond},
+		},
+		"duration_in_milliseconds": {
+			params: map[string]interface{}{"name": "bot", "duration": 250.0},
+			want:   map[string]interface{}{"name": "bot", "mode": "slow", "duration": 250 * time.Millisecond},
+		},
+		"missing_required": {
+			params:  map[string]interface{}{},
+			wantErr: "invalid command params: missing param 'name'",
+		},
+		"wrong_type": {
+			params:  map[string]interface{}{"name": 1.0},
+			wantErr: "invalid command params: param 'name' must be a string",
+		},
+		"not_in_enum": {
+			params:  map[string]interface{}{"name": "bot", "mode": "medium"},
+			wantErr: "invalid command params: param 'mode' must be one of [fast slow]",
+		},
+		"fraction_for_integer": {
+			params:  map[string]interface{}{"name": "bot", "speed": 1.5},
+			wantErr: "invalid command params: param 'speed' must be an integer",
+		},
+		"above_maximum": {
+			params:  map[string]interface{}{"name": "bot", "speed": 101.0},
+			wantErr: "invalid command params: param 'speed' must be at most 100",
+		},
+		"invalid_duration": {
+			params:  map[string]interface{}{"name": "bot", "duration": "soon"},
+			wantErr: "invalid command params: param 'duration' must be a duration like '500ms'",
+		},
+		"unknown_param": {
+			params:  map[string]interface{}{"name": "bot", "robot": "Robot1"},
+			wantErr: "invalid command params: unknown param 'robot'",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// act
+			got, err := schema.Validate(tc.params)
+			// assert
+			if tc.wantErr != "" {
+				require.ErrorIs(t, err, ErrInvalidCommandParams)
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
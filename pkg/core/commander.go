@@ -2,6 +2,7 @@ package core
 
 type commander struct {
 	commands map[string]func(map[string]interface{}) interface{}
+	schemas  map[string]CommandSchema
 }
 
 // Commander is the interface which describes the behaviour for a Driver or Adaptor
@@ -15,10 +16,42 @@ type Commander interface {
 	AddCommand(name string, command func(map[string]interface{}) interface{})
 }
 
-// NewCommander returns a new Commander.
-func NewCommander() Commander {
+// SchemaCommander is a Commander with typed commands, like the commander of NewCommander. Use SchemaCommanderOf to
+// get it from a Commander.
+type SchemaCommander interface {
+	Commander
+	// AddTypedCommand adds a command given a name and the schema of its params and result. The params are
+	// validated and converted before the command is called.
+	AddTypedCommand(name string, schema CommandSchema, command func(map[string]interface{}) interface{})
+	// CommandSchema returns the schema of a typed command. Returns false for an untyped or unknown command.
+	CommandSchema(name string) (schema CommandSchema, ok bool)
+}
+
+// SchemaCommanderAccessor is implemented by a robot, manager or device, which embeds a SchemaCommander by the
+// Commander interface, to give access to it.
+type SchemaCommanderAccessor interface {
+	// SchemaCommander returns the embedded commander, nil if it is no SchemaCommander
+	SchemaCommander() SchemaCommander
+}
+
+// SchemaCommanderOf returns the commander as SchemaCommander, if implemented. For a robot, manager or device,
+// which implements SchemaCommanderAccessor, the commander of the accessor is returned.
+func SchemaCommanderOf(c Commander) (SchemaCommander, bool) {
+	if sc, ok := c.(SchemaCommander); ok {
+		return sc, true
+	}
+	if a, ok := c.(SchemaCommanderAccessor); ok {
+		sc := a.SchemaCommander()
+		return sc, sc != nil
+	}
+	return nil, false
+}
+
+// NewCommander returns a new Commander, which implements also SchemaCommander.
+func NewCommander() SchemaCommander {
 	return &commander{
 		commands: make(map[string]func(map[string]interface{}) interface{}),
+		schemas:  make(map[string]CommandSchema),
 	}
 }
 
@@ -35,4 +68,26 @@ func (c *commander) Commands() map[string]func(map[string]interface{}) interface
 // AddCommand adds a new command, when passed a command name and the command interface.
 func (c *commander) AddCommand(name string, command func(map[string]interface{}) interface{}) {
 	c.commands[name] = command
+	delete(c.schemas, name)
+}
+
+// AddTypedCommand adds a new command with schema. The added command returns an error wrapping
+// ErrInvalidCommandParams, if the params do not match the schema.
+func (c *commander) AddTypedCommand(name string, schema CommandSchema,
+	command func(map[string]interface{}) interface{},
+) {
+	c.commands[name] = func(params map[string]interface{}) interface{} {
+		valid, err := schema.Validate(params)
+		if err != nil {
+			return err
+		}
+		return command(valid)
+	}
+	c.schemas[name] = schema
+}
+
+// CommandSchema returns the schema of a typed command
+func (c *commander) CommandSchema(name string) (CommandSchema, bool) {
+	schema, ok := c.schemas[name]
+	return schema, ok
 }
@@ -2,8 +2,10 @@ package core
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCommander(t *testing.T) {
@@ -18,3 +20,70 @@ func TestCommander(t *testing.T) {
 	assert.NotNil(t, c.Command("test"))
 	assert.Nil(t, c.Command("booyeah"))
 }
+
+func TestCommanderTypedCommand(t *testing.T) {
+	// arrange
+	c := NewCommander()
+	schema := CommandSchema{
+		Description: "waits",
+		Params:      []CommandParam{{Name: "duration", Type: ParamDuration, Required: true}},
+	}
+	c.AddTypedCommand("wait", schema, func(params map[string]any) any {
+		return params["duration"]
+	})
+	c.AddCommand("untyped", func(map[string]any) any { return nil })
+	// act & assert
+	got, ok := c.CommandSchema("wait")
+	assert.True(t, ok)
+	assert.Equal(t, schema, got)
+	_, ok = c.CommandSchema("untyped")
+	assert.False(t, ok)
+
+	assert.Equal(t, 2*time.Second, c.Command("wait")(map[string]any{"duration": "2s"}))
+	err, ok := c.Command("wait")(map[string]any{}).(error)
+	require.True(t, ok)
+	require.ErrorIs(t, err, ErrInvalidCommandParams)
+
+	c.AddCommand("wait", func(map[string]any) any { return nil })
+	_, ok = c.CommandSchema("wait")
+	assert.False(t, ok)
+}
+
+type testCommanderDevice struct {
+	Commander
+}
+
+func (d *testCommanderDevice) SchemaCommander() SchemaCommander {
+	sc, _ := d.Commander.(SchemaCommander)
+	return sc
+}
+
+type testPlainCommander struct {
+	Commander
+}
+
+func TestSchemaCommanderOf(t *testing.T) {
+	c := NewCommander()
+
+	tests := map[string]struct {
+		commander Commander
+		wantOk    bool
+	}{
+		"commander":        {commander: c, wantOk: true},
+		"accessor":         {commander: &testCommanderDevice{Commander: c}, wantOk: true},
+		"embedded":         {commander: testPlainCommander{Commander: c}},
+		"robot":            {commander: &Robot{Commander: c}, wantOk: true},
+		"accessor_nil":     {commander: &testCommanderDevice{}},
+		"nil":              {},
+		"accessor_foreign": {commander: &testCommanderDevice{Commander: testPlainCommander{}}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sc, ok := SchemaCommanderOf(tc.commander)
+			assert.Equal(t, tc.wantOk, ok)
+			if tc.wantOk {
+				assert.Equal(t, c, sc)
+			}
+		})
+	}
+}
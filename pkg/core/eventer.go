@@ -3,11 +3,55 @@ package core
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type eventChannel chan *Event
 
+// OverflowPolicy defines what happens with a new event, when the channel of a subscriber is full
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest drops the new event, this is the default
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest removes the oldest event from the channel to make room for the new event. Without a
+	// buffer, the new event is dropped like with OverflowDropNewest.
+	OverflowDropOldest
+	// OverflowBlock waits for room in the channel up to the block timeout and drops the new event afterwards.
+	// Note that the delivery to all other subscribers waits meanwhile.
+	OverflowBlock
+)
+
+const defaultBlockTimeout = 100 * time.Millisecond
+
+// SubscriptionOption is a configuration option for a subscription of an Eventer
+type SubscriptionOption func(*subscription)
+
+// subscription contains the channel of a subscriber and its overflow handling
+type subscription struct {
+	out          eventChannel
+	bufferSize   int
+	policy       OverflowPolicy
+	blockTimeout time.Duration
+	onDrop       func(dropped uint64)
+	dropped      atomic.Uint64
+	dropping     bool
+	// name of the delivered events, all events are delivered for an empty name
+	name string
+
+	// totalDropped counts the drops of all subscriptions of the eventer
+	totalDropped *atomic.Uint64
+}
+
+// EventerStats are the counters of an Eventer since its creation
+type EventerStats struct {
+	// Published is the number of events, which were accepted by Publish
+	Published uint64
+	// Dropped is the number of events, which were dropped by Publish or for any subscriber
+	Dropped uint64
+}
+
 type eventer struct {
 	// map of valid Event names
 	eventnames map[string]string
@@ -16,7 +60,7 @@ type eventer struct {
 	in eventChannel
 
 	// map of out channels used by subscribers
-	outs map[eventChannel]eventChannel
+	outs map[eventChannel]*subscription
 
 	// mutex to protect the eventChannel map
 	eventsMutex sync.Mutex
@@ -27,6 +71,9 @@ type eventer struct {
 
 	// done channel to signal shutdown completion
 	done chan struct{}
+
+	published atomic.Uint64
+	dropped   atomic.Uint64
 }
 
 const eventChanBufferSize = 10
@@ -66,13 +113,48 @@ type Eventer interface {
 	Shutdown(ctx context.Context) error
 }
 
-// NewEventer returns a new Eventer.
-func NewEventer() Eventer {
+// SubscriptionEventer is an Eventer with configurable subscriptions and counters of the events, like the eventer
+// of NewEventer. Use SubscriptionEventerOf to get it from an Eventer.
+type SubscriptionEventer interface {
+	Eventer
+
+	// SubscribeWithOptions subscribes to events with the given buffer size and overflow handling
+	SubscribeWithOptions(opts ...SubscriptionOption) (events eventChannel)
+
+	// Dropped returns the number of events, which were dropped for the subscribed event channel
+	Dropped(events eventChannel) uint64
+
+	// Stats returns the number of published and dropped events
+	Stats() EventerStats
+}
+
+// SubscriptionEventerAccessor is implemented by a robot, manager or device, which embeds a SubscriptionEventer by
+// the Eventer interface, to give access to it.
+type SubscriptionEventerAccessor interface {
+	// SubscriptionEventer returns the embedded eventer, nil if it is no SubscriptionEventer
+	SubscriptionEventer() SubscriptionEventer
+}
+
+// SubscriptionEventerOf returns the eventer as SubscriptionEventer, if implemented. For a robot, manager or device,
+// which implements SubscriptionEventerAccessor, the eventer of the accessor is returned.
+func SubscriptionEventerOf(e Eventer) (SubscriptionEventer, bool) {
+	if se, ok := e.(SubscriptionEventer); ok {
+		return se, true
+	}
+	if a, ok := e.(SubscriptionEventerAccessor); ok {
+		se := a.SubscriptionEventer()
+		return se, se != nil
+	}
+	return nil, false
+}
+
+// NewEventer returns a new Eventer, which implements also SubscriptionEventer.
+func NewEventer() SubscriptionEventer {
 	ctx, cancel := context.WithCancel(context.Background())
 	evtr := &eventer{
 		eventnames: make(map[string]string),
 		in:         make(eventChannel, eventChanBufferSize),
-		outs:       make(map[eventChannel]eventChannel),
+		outs:       make(map[eventChannel]*subscription),
 		ctx:        ctx,
 		cancel:     cancel,
 		done:       make(chan struct{}),
@@ -85,14 +167,13 @@ func NewEventer() Eventer {
 			select {
 			case evt := <-evtr.in:
 				evtr.eventsMutex.Lock()
-				for _, out := range evtr.outs {
-					select {
-					case out <- evt:
-					case <-evtr.ctx.Done():
+				for _, sub := range evtr.outs {
+					if sub.name != "" && sub.name != evt.Name {
+						continue
+					}
+					if !sub.deliver(evtr.ctx, evt) {
 						evtr.eventsMutex.Unlock()
 						return
-					default:
-						// Drop event if channel is full to prevent blocking
 					}
 				}
 				evtr.eventsMutex.Unlock()
@@ -131,20 +212,40 @@ func (e *eventer) Publish(name string, data interface{}) {
 	evt := NewEvent(name, data)
 	select {
 	case e.in <- evt:
+		e.published.Add(1)
 	case <-e.ctx.Done():
 		// Eventer is shutting down, drop the event
+		e.dropped.Add(1)
 	case <-time.After(100 * time.Millisecond):
 		// Drop event if channel is full to prevent blocking
+		e.dropped.Add(1)
 	}
 }
 
-// Subscribe to any events from this eventer
+// Subscribe to any events from this eventer. The channel buffers 10 events, further events are dropped until
+// the subscriber has received some.
 func (e *eventer) Subscribe() eventChannel {
+	return e.SubscribeWithOptions()
+}
+
+// SubscribeWithOptions subscribes to any events from this eventer with the given buffer size and overflow
+// handling, e.g. for subscribers which must not lose events of a fast stream.
+func (e *eventer) SubscribeWithOptions(opts ...SubscriptionOption) eventChannel {
+	sub := &subscription{
+		bufferSize:   eventChanBufferSize,
+		policy:       OverflowDropNewest,
+		blockTimeout: defaultBlockTimeout,
+		totalDropped: &e.dropped,
+	}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	sub.out = make(eventChannel, sub.bufferSize)
+
 	e.eventsMutex.Lock()
 	defer e.eventsMutex.Unlock()
-	out := make(eventChannel, eventChanBufferSize)
-	e.outs[out] = out
-	return out
+	e.outs[sub.out] = sub
+	return sub.out
 }
 
 // Unsubscribe from the event channel
@@ -154,6 +255,23 @@ func (e *eventer) Unsubscribe(events eventChannel) {
 	delete(e.outs, events)
 }
 
+// Dropped returns the number of events, which were dropped for the subscribed event channel. The counter of an
+// unsubscribed channel is not available anymore.
+func (e *eventer) Dropped(events eventChannel) uint64 {
+	e.eventsMutex.Lock()
+	sub, ok := e.outs[events]
+	e.eventsMutex.Unlock()
+	if !ok {
+		return 0
+	}
+	return sub.dropped.Load()
+}
+
+// Stats returns the number of published and dropped events, the drops of all subscribers are included
+func (e *eventer) Stats() EventerStats {
+	return EventerStats{Published: e.published.Load(), Dropped: e.dropped.Load()}
+}
+
 // On executes the event handler f when e is Published to.
 func (e *eventer) On(n string, f func(s interface{})) error {
 	out := e.Subscribe()
@@ -208,3 +326,97 @@ func (e *eventer) Shutdown(ctx context.Context) error {
 		return ctx.Err()
 	}
 }
+
+// WithSubscriptionBuffer sets the number of events, which are buffered for the subscriber (default 10)
+func WithSubscriptionBuffer(size int) SubscriptionOption {
+	return func(s *subscription) {
+		s.bufferSize = max(size, 0)
+	}
+}
+
+// WithOverflowPolicy sets the handling of new events, when the buffer of the subscriber is full
+func WithOverflowPolicy(policy OverflowPolicy) SubscriptionOption {
+	return func(s *subscription) {
+		s.policy = policy
+	}
+}
+
+// WithBlockTimeout sets the maximum time to wait for room in the buffer with OverflowBlock (default 100ms)
+func WithBlockTimeout(timeout time.Duration) SubscriptionOption {
+	return func(s *subscription) {
+		s.blockTimeout = timeout
+	}
+}
+
+// WithDropWarning sets a hook, which is called when the subscription starts to drop events, with the total
+// number of dropped events. The hook is called again only after an event was delivered in between.
+func WithDropWarning(onDrop func(dropped uint64)) SubscriptionOption {
+	return func(s *subscription) {
+		s.onDrop = onDrop
+	}
+}
+
+// withEventName restricts the subscription to the events with the given name, so only these are buffered and
+// counted as dropped, see SubscribeTyped
+func withEventName(name string) SubscriptionOption {
+	return func(s *subscription) {
+		s.name = name
+	}
+}
+
+// deliver puts the event into the channel according to the overflow policy and returns false, if the context
+// was canceled meanwhile
+func (s *subscription) deliver(ctx context.Context, evt *Event) bool {
+	select {
+	case s.out <- evt:
+		s.dropping = false
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case OverflowDropOldest:
+		// The publisher is the only sender, so the new event fits after the oldest was removed, unless the
+		// subscriber took it meanwhile. Without a buffer there is no oldest event, the new event is dropped then.
+		if cap(s.out) > 0 {
+			select {
+			case <-s.out:
+				s.drop()
+			default:
+				// the subscriber has received an event in between
+				s.dropping = false
+			}
+			select {
+			case s.out <- evt:
+				return true
+			default:
+			}
+		}
+	case OverflowBlock:
+		timer := time.NewTimer(s.blockTimeout)
+		defer timer.Stop()
+		select {
+		case s.out <- evt:
+			s.dropping = false
+			return true
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+		}
+	}
+
+	s.drop()
+	return true
+}
+
+// drop counts a dropped event and calls the warning hook, when drops start
+func (s *subscription) drop() {
+	dropped := s.dropped.Add(1)
+	if s.totalDropped != nil {
+		s.totalDropped.Add(1)
+	}
+	if !s.dropping && s.onDrop != nil {
+		go s.onDrop(dropped)
+	}
+	s.dropping = true
+}
@@ -76,3 +76,184 @@ func TestEventerOnce(t *testing.T) {
 	case <-time.After(10 * time.Millisecond):
 	}
 }
+
+func TestEventerSubscribeDropNewest(t *testing.T) {
+	// arrange
+	e := NewEventer()
+	warnings := make(chan uint64, 10)
+	events := e.SubscribeWithOptions(WithSubscriptionBuffer(2), WithDropWarning(func(dropped uint64) {
+		warnings <- dropped
+	}))
+	// act
+	for i := 0; i < 5; i++ {
+		e.Publish("test", i)
+	}
+	// assert
+	require.Eventually(t, func() bool { return e.Dropped(events) == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, (<-events).Data)
+	assert.Equal(t, 1, (<-events).Data)
+	assert.Equal(t, uint64(1), <-warnings)
+	select {
+	case dropped := <-warnings:
+		require.Fail(t, "unexpected warning", dropped)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// drops start again after a successful delivery
+	e.Publish("test", 5)
+	e.Publish("test", 6)
+	e.Publish("test", 7)
+	assert.Equal(t, uint64(4), <-warnings)
+	assert.Equal(t, uint64(4), e.Dropped(events))
+}
+
+func TestEventerSubscribeDropOldest(t *testing.T) {
+	// arrange
+	e := NewEventer()
+	events := e.SubscribeWithOptions(WithSubscriptionBuffer(2), WithOverflowPolicy(OverflowDropOldest))
+	// act
+	for i := 0; i < 5; i++ {
+		e.Publish("test", i)
+	}
+	// assert
+	require.Eventually(t, func() bool { return e.Dropped(events) == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, 3, (<-events).Data)
+	assert.Equal(t, 4, (<-events).Data)
+}
+
+func TestEventerSubscribeDropOldestWarning(t *testing.T) {
+	// arrange
+	e := NewEventer()
+	warnings := make(chan uint64, 10)
+	events := e.SubscribeWithOptions(WithSubscriptionBuffer(1), WithOverflowPolicy(OverflowDropOldest),
+		WithDropWarning(func(dropped uint64) { warnings <- dropped }))
+	// act
+	e.Publish("test", 0)
+	e.Publish("test", 1)
+	e.Publish("test", 2)
+	// assert
+	require.Eventually(t, func() bool { return e.Dropped(events) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(1), <-warnings)
+	assert.Equal(t, 2, (<-events).Data)
+	select {
+	case dropped := <-warnings:
+		require.Fail(t, "unexpected warning", dropped)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// drops start again after the subscriber has received an event
+	e.Publish("test", 3)
+	e.Publish("test", 4)
+	assert.Equal(t, uint64(3), <-warnings)
+	assert.Equal(t, 4, (<-events).Data)
+}
+
+func TestEventerSubscribeDropOldestUnbuffered(t *testing.T) {
+	// arrange
+	e := NewEventer()
+	events := e.SubscribeWithOptions(WithSubscriptionBuffer(0), WithOverflowPolicy(OverflowDropOldest))
+	other := e.Subscribe()
+	// act
+	e.Publish("test", 1)
+	// assert
+	require.Eventually(t, func() bool { return e.Dropped(events) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, (<-other).Data)
+}
+
+func TestEventerSubscribeBlock(t *testing.T) {
+	// arrange
+	e := NewEventer()
+	events := e.SubscribeWithOptions(WithSubscriptionBuffer(1), WithOverflowPolicy(OverflowBlock),
+		WithBlockTimeout(time.Second))
+	received := make(chan interface{}, 10)
+	go func() {
+		for evt := range events {
+			time.Sleep(5 * time.Millisecond)
+			received <- evt.Data
+		}
+	}()
+	// act
+	for i := 0; i < 5; i++ {
+		e.Publish("test", i)
+	}
+	// assert
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, i, <-received)
+	}
+	assert.Equal(t, uint64(0), e.Dropped(events))
+}
+
+func TestEventerSubscribeBlockTimeout(t *testing.T) {
+	e := NewEventer()
+	events := e.SubscribeWithOptions(WithSubscriptionBuffer(1), WithOverflowPolicy(OverflowBlock),
+		WithBlockTimeout(time.Millisecond))
+
+	e.Publish("test", 1)
+	e.Publish("test", 2)
+
+	require.Eventually(t, func() bool { return e.Dropped(events) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, (<-events).Data)
+}
+
+func TestEventerDroppedUnsubscribed(t *testing.T) {
+	e := NewEventer()
+	events := e.Subscribe()
+	e.Unsubscribe(events)
+
+	assert.Equal(t, uint64(0), e.Dropped(events))
+}
+
+func TestEventerStats(t *testing.T) {
+	// arrange
+	e := NewEventer()
+	events := e.SubscribeWithOptions(WithSubscriptionBuffer(2))
+	e.Subscribe()
+	// act
+	for i := 0; i < 5; i++ {
+		e.Publish("test", i)
+	}
+	// assert
+	require.Eventually(t, func() bool { return e.Stats().Dropped == 3 }, time.Second, time.Millisecond)
+	e.Unsubscribe(events)
+	assert.Equal(t, EventerStats{Published: 5, Dropped: 3}, e.Stats())
+}
+
+type testEventerDevice struct {
+	Eventer
+}
+
+func (d testEventerDevice) SubscriptionEventer() SubscriptionEventer {
+	se, _ := d.Eventer.(SubscriptionEventer)
+	return se
+}
+
+type testPlainEventer struct {
+	Eventer
+}
+
+func TestSubscriptionEventerOf(t *testing.T) {
+	e := NewEventer()
+
+	tests := map[string]struct {
+		eventer Eventer
+		wantOk  bool
+	}{
+		"eventer":          {eventer: e, wantOk: true},
+		"accessor":         {eventer: &testEventerDevice{Eventer: e}, wantOk: true},
+		"accessor_value":   {eventer: testEventerDevice{Eventer: e}, wantOk: true},
+		"accessor_nil":     {eventer: &testEventerDevice{}},
+		"embedded":         {eventer: testPlainEventer{Eventer: e}},
+		"other_eventer":    {eventer: testPlainEventer{}},
+		"nil":              {},
+		"accessor_foreign": {eventer: &testEventerDevice{Eventer: testPlainEventer{}}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			se, ok := SubscriptionEventerOf(tc.eventer)
+			assert.Equal(t, tc.wantOk, ok)
+			if tc.wantOk {
+				assert.Equal(t, e, se)
+			}
+		})
+	}
+}
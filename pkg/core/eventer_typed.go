@@ -0,0 +1,117 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// TypedSubscription is the handle of a subscription to one named event with data of type T, e.g. the int value
+// of a button push or the float64 value of an analog sensor. Events of other names, with data of another type
+// or rejected by the filter are skipped.
+type TypedSubscription[T any] struct {
+	eventer    Eventer
+	counter    SubscriptionEventer
+	events     eventChannel
+	out        chan T
+	done       chan struct{}
+	stopped    chan struct{}
+	once       sync.Once
+	mismatched atomic.Uint64
+}
+
+// SubscribeTyped subscribes to the event with the given name, whose data is of type T. The optional filter
+// selects the events to deliver. The options apply to the underlying subscription, which receives only the events
+// with the given name, so a slow receiver of the typed channel leads to the configured overflow handling. For an
+// eventer, which is no SubscriptionEventer, the options are ignored and all events are received.
+func SubscribeTyped[T any](e Eventer, name string, filter func(T) bool,
+	opts ...SubscriptionOption,
+) *TypedSubscription[T] {
+	s := &TypedSubscription[T]{
+		eventer: e,
+		out:     make(chan T),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if se, ok := SubscriptionEventerOf(e); ok {
+		s.counter = se
+		s.events = se.SubscribeWithOptions(append([]SubscriptionOption{withEventName(name)}, opts...)...)
+	} else {
+		s.events = e.Subscribe()
+	}
+
+	go func() {
+		defer close(s.stopped)
+		defer close(s.out)
+		for {
+			select {
+			case evt := <-s.events:
+				if evt.Name != name {
+					continue
+				}
+				data, ok := evt.Data.(T)
+				if !ok {
+					s.mismatched.Add(1)
+					continue
+				}
+				if filter != nil && !filter(data) {
+					continue
+				}
+				select {
+				case s.out <- data:
+				case <-s.done:
+					return
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// OnTyped calls the handler for each event with the given name, whose data is of type T and which passes the
+// optional filter. The handler is called in a separate go routine, one event after another, until the returned
+// subscription is unsubscribed.
+func OnTyped[T any](e Eventer, name string, filter func(T) bool, f func(T),
+	opts ...SubscriptionOption,
+) *TypedSubscription[T] {
+	s := SubscribeTyped(e, name, filter, opts...)
+	go func() {
+		for data := range s.out {
+			f(data)
+		}
+	}()
+
+	return s
+}
+
+// C returns the channel of the event data, which is closed on unsubscribe
+func (s *TypedSubscription[T]) C() <-chan T {
+	return s.out
+}
+
+// Unsubscribe removes the subscription from the eventer and closes the channel of the event data. It is safe to
+// call it more than once.
+func (s *TypedSubscription[T]) Unsubscribe() {
+	s.once.Do(func() {
+		s.eventer.Unsubscribe(s.events)
+		close(s.done)
+		<-s.stopped
+	})
+}
+
+// Dropped returns the number of events with the subscribed name, which were dropped by the underlying
+// subscription, always 0 for an eventer, which is no SubscriptionEventer
+func (s *TypedSubscription[T]) Dropped() uint64 {
+	if s.counter == nil {
+		return 0
+	}
+	return s.counter.Dropped(s.events)
+}
+
+// Mismatched returns the number of events with the subscribed name, which were skipped because the data is not
+// of type T
+func (s *TypedSubscription[T]) Mismatched() uint64 {
+	return s.mismatched.Load()
+}
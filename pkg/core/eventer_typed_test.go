@@ -0,0 +1,102 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeTyped(t *testing.T) {
+	// arrange
+	e := NewEventer()
+	sub := SubscribeTyped(e, "value", func(v float64) bool { return v > 0 })
+	defer sub.Unsubscribe()
+	// act
+	e.Publish("value", 1.5)
+	e.Publish("other", 2.5)
+	e.Publish("value", "wrong type")
+	e.Publish("value", -1.0)
+	e.Publish("value", 3.5)
+	// assert
+	assert.InDelta(t, 1.5, <-sub.C(), 0.0)
+	assert.InDelta(t, 3.5, <-sub.C(), 0.0)
+	assert.Equal(t, uint64(1), sub.Mismatched())
+	assert.Equal(t, uint64(0), sub.Dropped())
+}
+
+func TestSubscribeTypedDroppedOnlyOfName(t *testing.T) {
+	// arrange
+	e := NewEventer()
+	probe := e.Subscribe()
+	defer e.Unsubscribe(probe)
+	entered := make(chan struct{}, 3)
+	release := make(chan struct{})
+	sub := SubscribeTyped(e, "value", func(int) bool {
+		entered <- struct{}{}
+		<-release
+		return false
+	}, WithSubscriptionBuffer(1))
+	e.Publish("value", 1)
+	<-entered
+	// act
+	e.Publish("other", 2)
+	e.Publish("other", 3)
+	e.Publish("value", 4)
+	e.Publish("value", 5)
+	e.Publish("done", nil)
+	for evt := range probe {
+		if evt.Name == "done" {
+			break
+		}
+	}
+	// assert
+	assert.Equal(t, uint64(1), sub.Dropped())
+	close(release)
+	sub.Unsubscribe()
+}
+
+func TestSubscribeTypedInterface(t *testing.T) {
+	e := NewEventer()
+	sub := SubscribeTyped[error](e, "error", nil)
+	defer sub.Unsubscribe()
+
+	e.Publish("error", errors.New("read failed"))
+
+	require.EqualError(t, <-sub.C(), "read failed")
+}
+
+func TestSubscribeTypedUnsubscribe(t *testing.T) {
+	e := NewEventer()
+	sub := SubscribeTyped[int](e, "push", nil)
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	e.Publish("push", 1)
+
+	_, ok := <-sub.C()
+	assert.False(t, ok)
+}
+
+func TestOnTyped(t *testing.T) {
+	// arrange
+	e := NewEventer()
+	received := make(chan int, 10)
+	sub := OnTyped(e, "push", func(v int) bool { return v == 1 }, func(v int) { received <- v })
+	// act
+	e.Publish("push", 1)
+	e.Publish("push", 0)
+	e.Publish("push", 1)
+	// assert
+	assert.Equal(t, 1, <-received)
+	assert.Equal(t, 1, <-received)
+	sub.Unsubscribe()
+	e.Publish("push", 1)
+	select {
+	case v := <-received:
+		require.Fail(t, "handler called after unsubscribe", v)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -128,6 +128,7 @@ type JSONRobot struct {
 	Commands    []string          `json:"commands"`
 	Connections []*JSONConnection `json:"connections"`
 	Devices     []*JSONDevice     `json:"devices"`
+	State       RobotState        `json:"state"`
 }
 
 // NewJSONRobot returns a JSONRobot given a Robot.
@@ -137,6 +138,7 @@ func NewJSONRobot(robot *Robot) *JSONRobot {
 		Commands:    []string{},
 		Connections: []*JSONConnection{},
 		Devices:     []*JSONDevice{},
+		State:       robot.State(),
 	}
 
 	for command := range robot.Commands() {
@@ -166,11 +168,34 @@ type Robot struct {
 	workRegistry       *RobotWorkRegistry
 	WorkEveryWaitGroup *sync.WaitGroup
 	WorkAfterWaitGroup *sync.WaitGroup
+	// WorkScheduleWaitGroup is used for the scheduled work, see Schedule
+	WorkScheduleWaitGroup *sync.WaitGroup
+	workState             *workStateFile
 	Commander
 	Eventer
 	// Context for graceful shutdown
 	ctx    context.Context
 	cancel context.CancelFunc
+	// supervision of the connections
+	supervisionInterval  time.Duration
+	minRestartInterval   time.Duration
+	maxRestartInterval   time.Duration
+	cancelSupervision    context.CancelFunc // protected by stateMutex
+	supervisionWaitGroup sync.WaitGroup
+	// startup and shutdown of the connections and devices
+	dependencies  map[string][]string
+	startTimeouts map[string]time.Duration
+	haltTimeouts  map[string]time.Duration
+	workTimeout   time.Duration
+	startReport   atomic.Pointer[LifecycleReport]
+	stopReport    atomic.Pointer[LifecycleReport]
+	// lifecycle state and hooks
+	state                RobotState
+	beforeStateHooks     map[RobotState][]StateHook
+	afterStateHooks      map[RobotState][]StateHook
+	unhealthyConnections map[Connection]struct{}
+	stateMutex           sync.RWMutex
+	transitionMutex      sync.Mutex
 }
 
 // Robots is a collection of Robot
@@ -250,14 +275,25 @@ func NewRobot(v ...any) *Robot {
 		trap: func(c chan os.Signal) {
 			signal.Notify(c, os.Interrupt)
 		},
-		AutoRun:   true,
-		Work:      nil,
-		Eventer:   NewEventer(),
-		Commander: NewCommander(),
-		ctx:       ctx,
-		cancel:    cancel,
+		AutoRun:            true,
+		Work:               nil,
+		Eventer:            NewEventer(),
+		Commander:          NewCommander(),
+		ctx:                ctx,
+		cancel:             cancel,
+		minRestartInterval: defaultMinRestartInterval,
+		maxRestartInterval: defaultMaxRestartInterval,
+		workTimeout:        defaultWorkTimeout,
+		state:              StateStopped,
 	}
 
+	r.AddEvent(ConnectionUnhealthyEvent)
+	r.AddEvent(ConnectionReconnectingEvent)
+	r.AddEvent(ConnectionRecoveredEvent)
+	r.AddEvent(DeviceHaltedEvent)
+	r.AddEvent(DeviceRestartedEvent)
+	r.AddEvent(StateChangedEvent)
+
 	for i := range v {
 		switch val := v[i].(type) {
 		case RobotOption:
@@ -287,6 +323,7 @@ func NewRobot(v ...any) *Robot {
 	}
 	r.WorkAfterWaitGroup = &sync.WaitGroup{}
 	r.WorkEveryWaitGroup = &sync.WaitGroup{}
+	r.WorkScheduleWaitGroup = &sync.WaitGroup{}
 
 	r.running.Store(false)
 	log.Println("Robot", r.Name, "initialized.")
@@ -294,9 +331,21 @@ func NewRobot(v ...any) *Robot {
 	return r
 }
 
-// Start a Robot's Connections, Devices, and work. We stop initialization of
-// connections and devices on first error.
+// Start a Robot's Connections, Devices, and work. The connections and the devices they depend on are started
+// first, afterwards the remaining devices. Independent connections and devices are started in parallel, each one
+// after its dependencies, but the devices of the same connection are started one after another in the order of
+// the robot. A connection or device is not started, if one of its dependencies failed. The work is not
+// started, if any item failed, see StartReport() for the details. The robot enters the failed state in this case.
+// A robot, which is not stopped or failed, is not started again, ErrRobotStarted is returned instead.
 func (r *Robot) Start(args ...any) error {
+	changed, err := r.transition(StateInitializing, nil, []RobotState{StateStopped, StateFailed})
+	if err != nil {
+		return r.fail(err)
+	}
+	if !changed {
+		return fmt.Errorf("%w: robot %s is %s", ErrRobotStarted, r.Name, r.State())
+	}
+
 	if len(args) > 0 && args[0] != nil {
 		var ok bool
 		if r.AutoRun, ok = args[0].(bool); !ok {
@@ -305,14 +354,33 @@ func (r *Robot) Start(args ...any) error {
 		}
 	}
 	log.Println("Starting Robot", r.Name, "...")
-	if err := r.Connections().Start(); err != nil {
+
+	items, err := r.lifecycleItems()
+	if err != nil {
 		log.Println(err)
-		return err
+		return r.fail(err)
 	}
 
-	if err := r.Devices().Start(); err != nil {
+	run := r.newLifecycleRun(items, "start")
+	r.startReport.Store(run.report)
+	connecting, remaining := connectionPhase(items)
+	if err := r.setState(StateConnecting, nil); err != nil {
+		return r.fail(err)
+	}
+	r.startItems(run, connecting)
+
+	if err := r.setState(StateStartingDevices, nil); err != nil {
+		return r.fail(err)
+	}
+	r.startItems(run, remaining)
+
+	if err := run.report.Err(); err != nil {
 		log.Println(err)
-		return err
+		return r.fail(err)
+	}
+
+	if err := r.setState(StateRunning, nil); err != nil {
+		return r.fail(err)
 	}
 
 	if r.Work == nil {
@@ -332,6 +400,7 @@ func (r *Robot) Start(args ...any) error {
 	}()
 
 	r.running.Store(true)
+	r.startSupervision()
 
 	if !r.AutoRun {
 		return nil
@@ -351,39 +420,55 @@ func (r *Robot) Start(args ...any) error {
 	}
 }
 
-// Stop stops a Robot's connections and devices. We try to stop all items and
-// collect all errors.
+// Stop stops a Robot's connections and devices in reverse order of the dependencies, the devices of the same
+// connection are halted one after another in the order of the robot. We try to stop all items
+// and collect all errors, see StopReport() for the details. The robot is in the stopping state meanwhile and in
+// the stopped state afterwards.
 func (r *Robot) Stop() error {
 	var err error
 	log.Println("Stopping Robot", r.Name, "...")
+	if e := r.setState(StateStopping, nil); e != nil {
+		err = AppendError(err, e)
+	}
 	
 	// Cancel context to signal shutdown
 	r.cancel()
+	r.stopSupervision()
 	
-	// Shutdown eventer gracefully
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer shutdownCancel()
-	if e := r.Eventer.Shutdown(shutdownCtx); e != nil {
-		err = AppendError(err, e)
-	}
-	
-	if e := r.Devices().Halt(); e != nil {
-		err = AppendError(err, e)
-	}
-	if e := r.Connections().Finalize(); e != nil {
-		err = AppendError(err, e)
+	if items, e := r.lifecycleItems(); e == nil {
+		report := r.stopItems(items)
+		r.stopReport.Store(report)
+		err = AppendError(err, report.Err())
+	} else {
+		// the dependencies are broken, so at least stop in the order of the robot
+		if e := r.Devices().Halt(); e != nil {
+			err = AppendError(err, e)
+		}
+		if e := r.Connections().Finalize(); e != nil {
+			err = AppendError(err, e)
+		}
 	}
 
 	// Wait for work to complete with timeout
 	select {
 	case <-r.done:
 		// Work completed normally
-	case <-time.After(5 * time.Second):
+	case <-time.After(r.workTimeout):
 		// Work didn't complete in time, continue with shutdown
 		log.Println("Warning: Robot work didn't complete within timeout")
 	}
 	
 	r.running.Store(false)
+	if e := r.setState(StateStopped, nil); e != nil {
+		err = AppendError(err, e)
+	}
+
+	// Shutdown eventer gracefully, after the last state change is published
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if e := r.Eventer.Shutdown(shutdownCtx); e != nil {
+		err = AppendError(err, e)
+	}
 	return err
 }
 
@@ -447,3 +532,15 @@ func (r *Robot) Connection(name string) Connection {
 func (r *Robot) SetTrap(trap func(chan os.Signal)) {
 	r.trap = trap
 }
+
+// SchemaCommander returns the commander of the robot, if it is a SchemaCommander, see SchemaCommanderOf.
+func (r *Robot) SchemaCommander() SchemaCommander {
+	sc, _ := r.Commander.(SchemaCommander)
+	return sc
+}
+
+// SubscriptionEventer returns the eventer of the robot, if it is a SubscriptionEventer, see SubscriptionEventerOf.
+func (r *Robot) SubscriptionEventer() SubscriptionEventer {
+	se, _ := r.Eventer.(SubscriptionEventer)
+	return se
+}
@@ -0,0 +1,221 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCron is returned by ParseCron for a malformed expression
+var ErrInvalidCron = fmt.Errorf("invalid cron expression")
+
+// cronSearchLimit limits the search for the next run time of expressions which never match, e.g. "0 0 30 2 *"
+const cronSearchLimit = 5 * 365 * 24 * time.Hour
+
+// WorkSchedule computes the wall-clock times of scheduled work, see Robot.Schedule
+type WorkSchedule interface {
+	// Next returns the first run time after the given time, the zero time if there is no further run
+	Next(after time.Time) time.Time
+	// String returns the description of the schedule, which is used to detect a changed schedule of persisted work
+	String() string
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names of the values starting at min
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// cronSchedule is a parsed cron expression, each field is a bit set of the matching values
+type cronSchedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+}
+
+// ParseCron parses a standard cron expression with the five fields minute, hour, day of month, month and day of
+// week. A field supports "*", values, ranges "a-b", lists "a,b" and steps "*/n" or "a-b/n". Months and days of
+// week can be given by their english three letter names, Sunday is 0 or 7. The descriptors "@yearly",
+// "@annually", "@monthly", "@weekly", "@daily", "@midnight" and "@hourly" are supported as well. The times are
+// evaluated in the location of the time given to Next, which is the local time for scheduled work.
+//
+// Examples:
+//
+//	"0 6 * * *"     every day at 06:00
+//	"30 7 * * mon"  every Monday at 07:30
+//	"*/15 * * * *"  every quarter of an hour
+func ParseCron(expr string) (WorkSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if descriptor, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("%w '%s': %d fields expected, got %d", ErrInvalidCron, expr, len(cronFields),
+			len(fields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		var err error
+		if bits[i], err = cronFields[i].parse(field); err != nil {
+			return nil, fmt.Errorf("%w '%s': %w", ErrInvalidCron, expr, err)
+		}
+	}
+
+	// Sunday is 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &cronSchedule{
+		expr:          expr,
+		minute:        bits[0],
+		hour:          bits[1],
+		dom:           bits[2],
+		month:         bits[3],
+		dow:           bits[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}, nil
+}
+
+// Next returns the first matching minute after the given time
+func (c *cronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, loc).
+		Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(time.Hour)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c *cronSchedule) String() string {
+	return c.expr
+}
+
+// dayMatches returns whether the day matches, if both day fields are restricted, any of them needs to match
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+
+	return domMatch && dowMatch
+}
+
+// parse returns the bit set of the values of the field
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step '%s' of %s", stepPart, f.name)
+			}
+		}
+
+		first, last := f.min, f.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if first, err = f.value(from); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if last, err = f.value(to); err != nil {
+					return 0, err
+				}
+			case !hasStep:
+				last = first
+			}
+			if first > last {
+				return 0, fmt.Errorf("invalid range '%s' of %s", rangePart, f.name)
+			}
+		}
+
+		for v := first; v <= last; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value '%s' of %s, allowed %d-%d", s, f.name, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// onceSchedule is a single run at a wall-clock time
+type onceSchedule struct {
+	at time.Time
+}
+
+// OnceAt returns a schedule with a single run at the given wall-clock time
+func OnceAt(at time.Time) WorkSchedule {
+	return &onceSchedule{at: at}
+}
+
+// Next returns the time of the run, if it is after the given time
+func (o *onceSchedule) Next(after time.Time) time.Time {
+	if after.Before(o.at) {
+		return o.at
+	}
+
+	return time.Time{}
+}
+
+func (o *onceSchedule) String() string {
+	return "at " + o.at.Format(time.RFC3339)
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	// 2024-01-15 is a Monday
+	from := time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC)
+	tests := map[string]struct {
+		expr string
+		want []time.Time
+	}{
+		"every_minute": {
+			expr: "* * * * *",
+			want: []time.Time{
+				time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC),
+				time.Date(2024, 1, 15, 10, 32, 0, 0, time.UTC),
+			},
+		},
+		"daily": {
+			expr: "0 6 * * *",
+			want: []time.Time{
+				time.Date(2024, 1, 16, 6, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 17, 6, 0, 0, 0, time.UTC),
+			},
+		},
+		"weekly_by_name": {
+			expr: "30 7 * * MON",
+			want: []time.Time{
+				time.Date(2024, 1, 22, 7, 30, 0, 0, time.UTC),
+				time.Date(2024, 1, 29, 7, 30, 0, 0, time.UTC),
+			},
+		},
+		"steps_and_lists": {
+			expr: "*/20 9-11,14 * * *",
+			want: []time.Time{
+				time.Date(2024, 1, 15, 10, 40, 0, 0, time.UTC),
+				time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 15, 11, 20, 0, 0, time.UTC),
+				time.Date(2024, 1, 15, 11, 40, 0, 0, time.UTC),
+				time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC),
+			},
+		},
+		"day_of_month_or_week": {
+			expr: "0 0 1 * 7",
+			want: []time.Time{
+				time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 28, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"leap_day": {
+			expr: "0 12 29 feb *",
+			want: []time.Time{
+				time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		"descriptor": {
+			expr: "@monthly",
+			want: []time.Time{
+				time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"never": {
+			expr: "0 0 30 2 *",
+			want: []time.Time{{}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// act
+			schedule, err := ParseCron(tc.expr)
+			// assert
+			require.NoError(t, err)
+			assert.Equal(t, tc.expr, schedule.String())
+			next := from
+			for _, want := range tc.want {
+				next = schedule.Next(next)
+				assert.Equal(t, want, next)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	tests := map[string]string{
+		"0 6 * *":     "invalid cron expression '0 6 * *': 5 fields expected, got 4",
+		"60 * * * *":  "invalid cron expression '60 * * * *': invalid value '60' of minute, allowed 0-59",
+		"0 5-3 * * *": "invalid cron expression '0 5-3 * * *': invalid range '5-3' of hour",
+		"*/0 * * * *": "invalid cron expression '*/0 * * * *': invalid step '0' of minute",
+		"0 0 * foo *": "invalid cron expression '0 0 * foo *': invalid value 'foo' of month, allowed 1-12",
+	}
+	for expr, want := range tests {
+		t.Run(expr, func(t *testing.T) {
+			// act
+			_, err := ParseCron(expr)
+			// assert
+			require.ErrorIs(t, err, ErrInvalidCron)
+			require.EqualError(t, err, want)
+		})
+	}
+}
+
+func TestOnceAt(t *testing.T) {
+	// arrange
+	at := time.Date(2024, 1, 15, 6, 0, 0, 0, time.UTC)
+	// act
+	schedule := OnceAt(at)
+	// assert
+	assert.Equal(t, at, schedule.Next(at.Add(-time.Hour)))
+	assert.True(t, schedule.Next(at).IsZero())
+	assert.Equal(t, "at 2024-01-15T06:00:00Z", schedule.String())
+}
@@ -0,0 +1,563 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ConnectionKind is the kind of a LifecycleResult of a connection
+	ConnectionKind = "connection"
+	// DeviceKind is the kind of a LifecycleResult of a device
+	DeviceKind = "device"
+)
+
+const defaultWorkTimeout = 5 * time.Second
+
+var (
+	// ErrUnknownDependency is returned by Robot.Start, if a declared dependency refers to an unknown name
+	ErrUnknownDependency = fmt.Errorf("unknown dependency")
+	// ErrAmbiguousDependency is returned by Robot.Start, if a name of a declared dependency is used more than once
+	ErrAmbiguousDependency = fmt.Errorf("ambiguous dependency")
+	// ErrDependencyCycle is returned by Robot.Start, if the declared dependencies contain a cycle
+	ErrDependencyCycle = fmt.Errorf("dependency cycle")
+	// ErrDependencyFailed is the error of an item, which was not started, because a dependency failed
+	ErrDependencyFailed = fmt.Errorf("dependency failed")
+	// ErrLifecycleTimeout is the error of an item, which was not started or halted within its timeout
+	ErrLifecycleTimeout = fmt.Errorf("timeout elapsed")
+)
+
+// ContextStarter is implemented by devices, which can abort the start when the context is done
+type ContextStarter interface {
+	StartContext(ctx context.Context) error
+}
+
+// ContextHalter is implemented by devices, which can abort the halt when the context is done
+type ContextHalter interface {
+	HaltContext(ctx context.Context) error
+}
+
+// ContextConnector is implemented by connections, which can abort the connect when the context is done
+type ContextConnector interface {
+	ConnectContext(ctx context.Context) error
+}
+
+// ContextFinalizer is implemented by connections, which can abort the finalize when the context is done
+type ContextFinalizer interface {
+	FinalizeContext(ctx context.Context) error
+}
+
+// LifecycleResult is the outcome of starting or halting a single connection or device
+type LifecycleResult struct {
+	Name     string
+	Kind     string // ConnectionKind or DeviceKind
+	Duration time.Duration
+	Skipped  bool // not started, because a dependency failed
+	Err      error
+}
+
+// LifecycleReport is the aggregated outcome of starting or stopping all connections and devices of a robot, the
+// results are in the order of the connections and devices of the robot
+type LifecycleReport struct {
+	Robot     string
+	Operation string // "start" or "stop"
+	Results   []LifecycleResult
+}
+
+// Failed returns the results with an error, including the skipped items
+func (lr *LifecycleReport) Failed() []LifecycleResult {
+	var failed []LifecycleResult
+	for _, res := range lr.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+
+	return failed
+}
+
+// Err returns a LifecycleError, if any item failed, otherwise nil
+func (lr *LifecycleReport) Err() error {
+	if len(lr.Failed()) == 0 {
+		return nil
+	}
+
+	return &LifecycleError{Report: lr}
+}
+
+// LifecycleError is returned by Robot.Start and Robot.Stop, if any connection or device failed. The errors of the
+// items can be examined by errors.Is and errors.As.
+type LifecycleError struct {
+	Report *LifecycleReport
+}
+
+// Error lists all failed items
+func (e *LifecycleError) Error() string {
+	failed := e.Report.Failed()
+	msgs := make([]string, 0, len(failed))
+	for _, res := range failed {
+		msgs = append(msgs, fmt.Sprintf("%s %s: %v", res.Kind, res.Name, res.Err))
+	}
+
+	return fmt.Sprintf("%s of robot %s failed for %d of %d items: %s", e.Report.Operation, e.Report.Robot,
+		len(failed), len(e.Report.Results), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of all failed items
+func (e *LifecycleError) Unwrap() []error {
+	var errs []error
+	for _, res := range e.Report.Failed() {
+		errs = append(errs, res.Err)
+	}
+
+	return errs
+}
+
+// WithDependencies declares, that the connection or device with the given name is started after and halted before
+// the connections and devices with the given names. A device depends on its connection without declaration. The
+// names need to be unique among the connections and devices of the robot.
+func WithDependencies(name string, dependsOn ...string) RobotOption {
+	return func(r *Robot) {
+		if r.dependencies == nil {
+			r.dependencies = make(map[string][]string)
+		}
+		r.dependencies[name] = append(r.dependencies[name], dependsOn...)
+	}
+}
+
+// WithStartTimeout sets the maximum time to connect a connection or to start a device. Without names, the timeout
+// is used for all items without an own timeout. A timeout of zero means no limit, which is the default.
+func WithStartTimeout(timeout time.Duration, names ...string) RobotOption {
+	return func(r *Robot) {
+		r.startTimeouts = withTimeout(r.startTimeouts, timeout, names)
+	}
+}
+
+// WithHaltTimeout sets the maximum time to halt a device or to finalize a connection. Without names, the timeout is
+// used for all items without an own timeout. A timeout of zero means no limit, which is the default.
+func WithHaltTimeout(timeout time.Duration, names ...string) RobotOption {
+	return func(r *Robot) {
+		r.haltTimeouts = withTimeout(r.haltTimeouts, timeout, names)
+	}
+}
+
+// WithWorkTimeout substitutes the default time of 5 s, which Robot.Stop waits for the work to complete
+func WithWorkTimeout(timeout time.Duration) RobotOption {
+	return func(r *Robot) {
+		r.workTimeout = timeout
+	}
+}
+
+// StartReport returns the report of the last start of the connections and devices, nil if not started yet
+func (r *Robot) StartReport() *LifecycleReport {
+	return r.startReport.Load()
+}
+
+// StopReport returns the report of the last stop of the connections and devices, nil if not stopped yet
+func (r *Robot) StopReport() *LifecycleReport {
+	return r.stopReport.Load()
+}
+
+// lifecycleItem is a node of the dependency graph of the connections and devices
+type lifecycleItem struct {
+	index      int
+	name       string
+	kind       string
+	connection Connection
+	device     Device
+	deps       []*lifecycleItem
+	dependents []*lifecycleItem
+}
+
+// lifecycleItems builds the dependency graph of all connections and devices in the order of the robot
+func (r *Robot) lifecycleItems() ([]*lifecycleItem, error) {
+	var items []*lifecycleItem
+	byName := make(map[string][]*lifecycleItem)
+	connItems := make(map[Connection]*lifecycleItem)
+
+	for _, c := range *r.Connections() {
+		it := &lifecycleItem{index: len(items), name: c.Name(), kind: ConnectionKind, connection: c}
+		items = append(items, it)
+		byName[it.name] = append(byName[it.name], it)
+		connItems[c] = it
+	}
+
+	for _, d := range *r.Devices() {
+		it := &lifecycleItem{index: len(items), name: d.Name(), kind: DeviceKind, device: d}
+		items = append(items, it)
+		byName[it.name] = append(byName[it.name], it)
+		if c := d.Connection(); c != nil {
+			if connItem, ok := connItems[c]; ok {
+				it.addDependency(connItem)
+			}
+		}
+	}
+
+	lookup := func(name string) (*lifecycleItem, error) {
+		found := byName[name]
+		switch len(found) {
+		case 0:
+			return nil, fmt.Errorf("%w: '%s'", ErrUnknownDependency, name)
+		case 1:
+			return found[0], nil
+		default:
+			return nil, fmt.Errorf("%w: '%s' is used by %d items", ErrAmbiguousDependency, name, len(found))
+		}
+	}
+
+	for name, dependsOn := range r.dependencies {
+		it, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, depName := range dependsOn {
+			dep, err := lookup(depName)
+			if err != nil {
+				return nil, err
+			}
+			it.addDependency(dep)
+		}
+	}
+
+	if err := checkLifecycleCycles(items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (it *lifecycleItem) addDependency(dep *lifecycleItem) {
+	for _, d := range it.deps {
+		if d == dep {
+			return
+		}
+	}
+	it.deps = append(it.deps, dep)
+	dep.dependents = append(dep.dependents, it)
+}
+
+// checkLifecycleCycles returns an error, if not all items can be ordered by their dependencies (Kahn's algorithm)
+func checkLifecycleCycles(items []*lifecycleItem) error {
+	pending := make([]int, len(items))
+	var ready []*lifecycleItem
+	for _, it := range items {
+		pending[it.index] = len(it.deps)
+		if pending[it.index] == 0 {
+			ready = append(ready, it)
+		}
+	}
+
+	ordered := 0
+	for len(ready) > 0 {
+		it := ready[0]
+		ready = ready[1:]
+		ordered++
+		for _, dependent := range it.dependents {
+			pending[dependent.index]--
+			if pending[dependent.index] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if ordered == len(items) {
+		return nil
+	}
+
+	var names []string
+	for _, it := range items {
+		if pending[it.index] > 0 {
+			names = append(names, it.name)
+		}
+	}
+	return fmt.Errorf("%w, can't order %s", ErrDependencyCycle, strings.Join(names, ", "))
+}
+
+// lifecycleRun is a start or a stop of the items, which can be run in several phases
+type lifecycleRun struct {
+	report *LifecycleReport
+	done   []chan struct{}
+}
+
+func (r *Robot) newLifecycleRun(items []*lifecycleItem, operation string) *lifecycleRun {
+	run := &lifecycleRun{
+		report: &LifecycleReport{Robot: r.Name, Operation: operation, Results: make([]LifecycleResult, len(items))},
+		done:   make([]chan struct{}, len(items)),
+	}
+	for _, it := range items {
+		run.report.Results[it.index] = LifecycleResult{Name: it.name, Kind: it.kind}
+		run.done[it.index] = make(chan struct{})
+	}
+
+	return run
+}
+
+// newPartialRun creates a run for a part of all items, the other items are marked as done without error, so the
+// items of the part do not wait for them
+func (r *Robot) newPartialRun(all, part []*lifecycleItem, operation string) *lifecycleRun {
+	run := r.newLifecycleRun(all, operation)
+	inPart := make([]bool, len(all))
+	for _, it := range part {
+		inPart[it.index] = true
+	}
+	for _, it := range all {
+		if !inPart[it.index] {
+			close(run.done[it.index])
+		}
+	}
+
+	return run
+}
+
+// dependentItems returns the item and all items, which depend directly or indirectly on it, in the order of the
+// robot
+func dependentItems(all []*lifecycleItem, item *lifecycleItem) []*lifecycleItem {
+	affected := make([]bool, len(all))
+	var mark func(it *lifecycleItem)
+	mark = func(it *lifecycleItem) {
+		if affected[it.index] {
+			return
+		}
+		affected[it.index] = true
+		for _, dependent := range it.dependents {
+			mark(dependent)
+		}
+	}
+	mark(item)
+
+	var items []*lifecycleItem
+	for _, it := range all {
+		if affected[it.index] {
+			items = append(items, it)
+		}
+	}
+
+	return items
+}
+
+// connectionPhase splits the items into the connections including the devices they depend on and the remaining
+// devices, both in the order of the robot
+func connectionPhase(items []*lifecycleItem) ([]*lifecycleItem, []*lifecycleItem) {
+	inPhase := make([]bool, len(items))
+	var mark func(it *lifecycleItem)
+	mark = func(it *lifecycleItem) {
+		if inPhase[it.index] {
+			return
+		}
+		inPhase[it.index] = true
+		for _, dep := range it.deps {
+			mark(dep)
+		}
+	}
+	for _, it := range items {
+		if it.kind == ConnectionKind {
+			mark(it)
+		}
+	}
+
+	var connecting, remaining []*lifecycleItem
+	for _, it := range items {
+		if inPhase[it.index] {
+			connecting = append(connecting, it)
+		} else {
+			remaining = append(remaining, it)
+		}
+	}
+
+	return connecting, remaining
+}
+
+// startItems starts the items in parallel, each item after all of its dependencies are started successfully. All
+// dependencies of the items need to be part of the given items or of a previous phase of the run.
+func (r *Robot) startItems(run *lifecycleRun, items []*lifecycleItem) {
+	run.runItems(items, func(it *lifecycleItem) []*lifecycleItem { return it.deps }, true,
+		func(it *lifecycleItem) error {
+			log.Printf("Starting %s %s...", it.kind, it.name)
+			return runWithTimeout(r.itemTimeout(r.startTimeouts, it.name), it.start)
+		})
+}
+
+// stopItems halts all items in parallel, each item after all of its dependents are halted
+func (r *Robot) stopItems(items []*lifecycleItem) *LifecycleReport {
+	run := r.newLifecycleRun(items, "stop")
+	r.haltItems(run, items)
+
+	return run.report
+}
+
+// haltItems halts the items in parallel, each item after all of its dependents are halted. All dependents of the
+// items need to be part of the given items or be marked as done in the run.
+func (r *Robot) haltItems(run *lifecycleRun, items []*lifecycleItem) {
+	run.runItems(items, func(it *lifecycleItem) []*lifecycleItem { return it.dependents }, false,
+		func(it *lifecycleItem) error {
+			return runWithTimeout(r.itemTimeout(r.haltTimeouts, it.name), it.halt)
+		})
+}
+
+// runItems runs the function for each item, after it has run for all predecessors of the item. The devices of the
+// same connection run one after another, see sameConnectionPredecessors.
+func (lr *lifecycleRun) runItems(
+	items []*lifecycleItem, predecessors func(*lifecycleItem) []*lifecycleItem, skipOnFailure bool,
+	run func(*lifecycleItem) error,
+) {
+	report := lr.report
+	previous := sameConnectionPredecessors(items, predecessors)
+
+	var wg sync.WaitGroup
+	for _, it := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(lr.done[it.index])
+
+			res := report.Results[it.index]
+			for _, pre := range predecessors(it) {
+				<-lr.done[pre.index]
+				if skipOnFailure && res.Err == nil && report.Results[pre.index].Err != nil {
+					res.Skipped = true
+					res.Err = fmt.Errorf("%w: %s %s", ErrDependencyFailed, pre.kind, pre.name)
+				}
+			}
+			if pre, ok := previous[it]; ok {
+				<-lr.done[pre.index]
+			}
+
+			if !res.Skipped {
+				start := time.Now()
+				res.Err = run(it)
+				res.Duration = time.Since(start)
+			}
+			report.Results[it.index] = res
+		}()
+	}
+	wg.Wait()
+}
+
+// sameConnectionPredecessors returns for each device the device of the same connection, which needs to run
+// before it. The devices of a connection may share a bus, so they are run one after another in the order of the
+// robot, as far as the predecessors allow. A failure of the previous device does not skip the device.
+func sameConnectionPredecessors(
+	items []*lifecycleItem, predecessors func(*lifecycleItem) []*lifecycleItem,
+) map[*lifecycleItem]*lifecycleItem {
+	inItems := make(map[*lifecycleItem]bool, len(items))
+	for _, it := range items {
+		inItems[it] = true
+	}
+
+	// order the items by their predecessors, the first ready item in the order of the robot comes next
+	ordered := make(map[*lifecycleItem]bool, len(items))
+	previous := make(map[*lifecycleItem]*lifecycleItem)
+	last := make(map[Connection]*lifecycleItem)
+	for len(ordered) < len(items) {
+		var next *lifecycleItem
+		for _, it := range items {
+			if !ordered[it] && predecessorsOrdered(it, predecessors, inItems, ordered) {
+				next = it
+				break
+			}
+		}
+		if next == nil {
+			// a cycle, which is rejected by lifecycleItems
+			break
+		}
+		ordered[next] = true
+
+		if next.device == nil || next.device.Connection() == nil {
+			continue
+		}
+		c := next.device.Connection()
+		if pre, ok := last[c]; ok {
+			previous[next] = pre
+		}
+		last[c] = next
+	}
+
+	return previous
+}
+
+func predecessorsOrdered(
+	it *lifecycleItem, predecessors func(*lifecycleItem) []*lifecycleItem, inItems, ordered map[*lifecycleItem]bool,
+) bool {
+	for _, pre := range predecessors(it) {
+		if inItems[pre] && !ordered[pre] {
+			return false
+		}
+	}
+	return true
+}
+
+func (it *lifecycleItem) start(ctx context.Context) error {
+	if it.device != nil {
+		if s, ok := it.device.(ContextStarter); ok {
+			return s.StartContext(ctx)
+		}
+		return it.device.Start()
+	}
+
+	if c, ok := it.connection.(ContextConnector); ok {
+		return c.ConnectContext(ctx)
+	}
+	return it.connection.Connect()
+}
+
+func (it *lifecycleItem) halt(ctx context.Context) error {
+	if it.device != nil {
+		if h, ok := it.device.(ContextHalter); ok {
+			return h.HaltContext(ctx)
+		}
+		return it.device.Halt()
+	}
+
+	if f, ok := it.connection.(ContextFinalizer); ok {
+		return f.FinalizeContext(ctx)
+	}
+	return it.connection.Finalize()
+}
+
+func (r *Robot) itemTimeout(timeouts map[string]time.Duration, name string) time.Duration {
+	if timeout, ok := timeouts[name]; ok {
+		return timeout
+	}
+
+	return timeouts[""]
+}
+
+// runWithTimeout calls the function and returns an error, when the timeout elapses before the function returns.
+// In this case the function keeps running in the background, but the context given to it is done.
+func runWithTimeout(timeout time.Duration, f func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return f(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- f(ctx) }()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%w (%s)", ErrLifecycleTimeout, timeout)
+	}
+}
+
+func withTimeout(timeouts map[string]time.Duration, timeout time.Duration, names []string) map[string]time.Duration {
+	if timeouts == nil {
+		timeouts = make(map[string]time.Duration)
+	}
+
+	if len(names) == 0 {
+		timeouts[""] = timeout
+	}
+	for _, name := range names {
+		timeouts[name] = timeout
+	}
+
+	return timeouts
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ ContextStarter = (*testStartupDriver)(nil)
+	_ ContextHalter  = (*testStartupDriver)(nil)
+)
+
+type testStartupAdaptor struct {
+	name    string
+	log     *testSupervisionLog
+	connect func() error
+}
+
+func (t *testStartupAdaptor) Name() string     { return t.name }
+func (t *testStartupAdaptor) SetName(n string) { t.name = n }
+
+func (t *testStartupAdaptor) Connect() error {
+	t.log.add("connect " + t.name)
+	if t.connect != nil {
+		return t.connect()
+	}
+	return nil
+}
+
+func (t *testStartupAdaptor) Finalize() error {
+	t.log.add("finalize " + t.name)
+	return nil
+}
+
+type testStartupDriver struct {
+	name       string
+	connection Connection
+	log        *testSupervisionLog
+	start      func(ctx context.Context) error
+}
+
+func (t *testStartupDriver) Name() string           { return t.name }
+func (t *testStartupDriver) SetName(n string)       { t.name = n }
+func (t *testStartupDriver) Connection() Connection { return t.connection }
+func (t *testStartupDriver) Start() error           { return t.StartContext(context.Background()) }
+func (t *testStartupDriver) Halt() error            { return t.HaltContext(context.Background()) }
+
+func (t *testStartupDriver) StartContext(ctx context.Context) error {
+	t.log.add("start " + t.name)
+	if t.start != nil {
+		return t.start(ctx)
+	}
+	return nil
+}
+
+func (t *testStartupDriver) HaltContext(context.Context) error {
+	t.log.add("halt " + t.name)
+	return nil
+}
+
+func indexOf(calls []string, call string) int {
+	for i, c := range calls {
+		if c == call {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRobotStartStopDependencyOrder(t *testing.T) {
+	// arrange
+	log := &testSupervisionLog{}
+	a1 := &testStartupAdaptor{name: "bus1", log: log}
+	a2 := &testStartupAdaptor{name: "bus2", log: log}
+	r := NewRobot(
+		WithAutoRun(false),
+		WithWorkTimeout(time.Millisecond),
+		WithConnections(a1, a2),
+		WithDevices(
+			&testStartupDriver{name: "imu", connection: a1, log: log},
+			&testStartupDriver{name: "motor", connection: a2, log: log},
+		),
+		WithDependencies("motor", "imu"),
+		WithDependencies("bus2", "bus1"),
+	)
+	// act
+	require.NoError(t, r.Start())
+	require.NoError(t, r.Stop())
+	// assert
+	calls := log.get()
+	require.Len(t, calls, 8)
+	assert.Less(t, indexOf(calls, "connect bus1"), indexOf(calls, "connect bus2"))
+	assert.Less(t, indexOf(calls, "connect bus1"), indexOf(calls, "start imu"))
+	assert.Less(t, indexOf(calls, "connect bus2"), indexOf(calls, "start motor"))
+	assert.Less(t, indexOf(calls, "start imu"), indexOf(calls, "start motor"))
+	assert.Less(t, indexOf(calls, "halt motor"), indexOf(calls, "halt imu"))
+	assert.Less(t, indexOf(calls, "halt imu"), indexOf(calls, "finalize bus1"))
+	assert.Less(t, indexOf(calls, "halt motor"), indexOf(calls, "finalize bus2"))
+	assert.Less(t, indexOf(calls, "finalize bus2"), indexOf(calls, "finalize bus1"))
+	require.NotNil(t, r.StartReport())
+	assert.Equal(t, "start", r.StartReport().Operation)
+	assert.Equal(t, []string{"bus1", "bus2", "imu", "motor"}, []string{
+		r.StartReport().Results[0].Name, r.StartReport().Results[1].Name,
+		r.StartReport().Results[2].Name, r.StartReport().Results[3].Name,
+	})
+	assert.Equal(t, ConnectionKind, r.StartReport().Results[0].Kind)
+	assert.Equal(t, DeviceKind, r.StartReport().Results[3].Kind)
+	require.NoError(t, r.StopReport().Err())
+}
+
+func TestRobotStartParallel(t *testing.T) {
+	// arrange: both connections need to be connecting at the same time
+	log := &testSupervisionLog{}
+	entered := make(chan struct{}, 2)
+	connect := func() error {
+		entered <- struct{}{}
+		for len(entered) < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	}
+	r := NewRobot(
+		WithAutoRun(false),
+		WithWorkTimeout(time.Millisecond),
+		WithStartTimeout(time.Second),
+		WithConnections(
+			&testStartupAdaptor{name: "bus1", log: log, connect: connect},
+			&testStartupAdaptor{name: "bus2", log: log, connect: connect},
+		),
+	)
+	// act & assert
+	require.NoError(t, r.Start())
+	require.NoError(t, r.Stop())
+}
+
+func TestRobotStartStopDevicesOfConnectionInOrder(t *testing.T) {
+	// arrange: the devices of bus1 must not be started or halted at the same time, sensor depends on led
+	log := &testSupervisionLog{}
+	bus1 := &testStartupAdaptor{name: "bus1", log: log}
+	bus2 := &testStartupAdaptor{name: "bus2", log: log}
+	var active, overlaps atomic.Int32
+	start := func(context.Context) error {
+		if active.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(5 * time.Millisecond)
+		active.Add(-1)
+		return nil
+	}
+	r := NewRobot(
+		WithAutoRun(false),
+		WithWorkTimeout(time.Millisecond),
+		WithConnections(bus1, bus2),
+		WithDevices(
+			&testStartupDriver{name: "sensor", connection: bus1, log: log, start: start},
+			&testStartupDriver{name: "motor", connection: bus1, log: log, start: start},
+			&testStartupDriver{name: "led", connection: bus1, log: log, start: start},
+			&testStartupDriver{name: "display", connection: bus2, log: log},
+		),
+		WithDependencies("sensor", "led"),
+	)
+	// act
+	require.NoError(t, r.Start())
+	require.NoError(t, r.Stop())
+	// assert
+	assert.Equal(t, int32(0), overlaps.Load())
+	calls := log.get()
+	assert.Less(t, indexOf(calls, "start motor"), indexOf(calls, "start led"))
+	assert.Less(t, indexOf(calls, "start led"), indexOf(calls, "start sensor"))
+	assert.Less(t, indexOf(calls, "halt sensor"), indexOf(calls, "halt motor"))
+	assert.Less(t, indexOf(calls, "halt motor"), indexOf(calls, "halt led"))
+}
+
+func TestRobotStartTimeout(t *testing.T) {
+	// arrange
+	log := &testSupervisionLog{}
+	a := &testStartupAdaptor{name: "bus", log: log}
+	var deadline atomic.Bool
+	slow := &testStartupDriver{name: "slow", connection: a, log: log, start: func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		deadline.Store(ok)
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	r := NewRobot(
+		WithName("timeouts"),
+		WithAutoRun(false),
+		WithWorkTimeout(time.Millisecond),
+		WithStartTimeout(time.Second),
+		WithStartTimeout(10*time.Millisecond, "slow"),
+		WithConnections(a),
+		WithDevices(slow, &testStartupDriver{name: "dependent", connection: a, log: log}),
+		WithDependencies("dependent", "slow"),
+	)
+	// act
+	err := r.Start()
+	// assert
+	require.ErrorIs(t, err, ErrLifecycleTimeout)
+	require.ErrorIs(t, err, ErrDependencyFailed)
+	require.EqualError(t, err, "start of robot timeouts failed for 2 of 3 items: "+
+		"device slow: timeout elapsed (10ms); device dependent: dependency failed: device slow")
+	assert.True(t, deadline.Load())
+	failed := r.StartReport().Failed()
+	require.Len(t, failed, 2)
+	assert.False(t, failed[0].Skipped)
+	assert.True(t, failed[1].Skipped)
+	assert.NotContains(t, log.get(), "start dependent")
+	assert.False(t, r.Running())
+	require.NoError(t, r.Stop())
+}
+
+func TestRobotStartDependencyErrors(t *testing.T) {
+	tests := map[string]struct {
+		deps    [][]string
+		wantErr error
+		wantMsg string
+	}{
+		"unknown": {
+			deps:    [][]string{{"dev1", "unknown"}},
+			wantErr: ErrUnknownDependency,
+			wantMsg: "unknown dependency: 'unknown'",
+		},
+		"ambiguous": {
+			deps:    [][]string{{"dev1", "twin"}},
+			wantErr: ErrAmbiguousDependency,
+			wantMsg: "ambiguous dependency: 'twin' is used by 2 items",
+		},
+		"cycle": {
+			deps:    [][]string{{"dev1", "dev2"}, {"dev2", "dev1"}},
+			wantErr: ErrDependencyCycle,
+			wantMsg: "dependency cycle, can't order dev1, dev2",
+		},
+		"cycle_over_connection": {
+			deps:    [][]string{{"bus", "dev1"}},
+			wantErr: ErrDependencyCycle,
+			wantMsg: "dependency cycle, can't order bus, dev1, dev2",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			log := &testSupervisionLog{}
+			a := &testStartupAdaptor{name: "bus", log: log}
+			opts := []any{
+				WithAutoRun(false),
+				WithConnections(a),
+				WithDevices(
+					&testStartupDriver{name: "dev1", connection: a, log: log},
+					&testStartupDriver{name: "dev2", connection: a, log: log},
+					&testStartupDriver{name: "twin", log: log},
+					&testStartupDriver{name: "twin", log: log},
+				),
+			}
+			for _, dep := range tc.deps {
+				opts = append(opts, WithDependencies(dep[0], dep[1:]...))
+			}
+			r := NewRobot(opts...)
+			// act
+			err := r.Start()
+			// assert
+			require.ErrorIs(t, err, tc.wantErr)
+			require.EqualError(t, err, tc.wantMsg)
+			assert.Empty(t, log.get())
+		})
+	}
+}
+
+func TestLifecycleReport(t *testing.T) {
+	// arrange
+	errFailed := fmt.Errorf("failed")
+	report := &LifecycleReport{Robot: "bot", Operation: "stop", Results: []LifecycleResult{
+		{Name: "bus", Kind: ConnectionKind},
+		{Name: "dev", Kind: DeviceKind, Err: errFailed},
+	}}
+	// act
+	err := report.Err()
+	// assert
+	require.ErrorIs(t, err, errFailed)
+	require.EqualError(t, err, "stop of robot bot failed for 1 of 2 items: device dev: failed")
+	require.NoError(t, (&LifecycleReport{}).Err())
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// RobotState is the state of the lifecycle of a Robot
+type RobotState string
+
+const (
+	// StateInitializing is entered by Robot.Start, while the dependencies of the connections and devices are checked
+	StateInitializing RobotState = "initializing"
+	// StateConnecting is entered by Robot.Start, while the connections and the devices they depend on are started
+	StateConnecting RobotState = "connecting"
+	// StateStartingDevices is entered by Robot.Start, while the remaining devices are started
+	StateStartingDevices RobotState = "starting-devices"
+	// StateRunning is entered, when all connections and devices are started and the work is started
+	StateRunning RobotState = "running"
+	// StateDegraded is entered by a supervised Robot, while at least one connection is unhealthy
+	StateDegraded RobotState = "degraded"
+	// StateStopping is entered by Robot.Stop, while the connections and devices are stopped
+	StateStopping RobotState = "stopping"
+	// StateStopped is the state of a new Robot and is entered, when Robot.Stop is finished
+	StateStopped RobotState = "stopped"
+	// StateFailed is entered, when Robot.Start fails
+	StateFailed RobotState = "failed"
+)
+
+// ErrRobotStarted is returned by Robot.Start, if the robot is already started, starting or stopping
+var ErrRobotStarted = fmt.Errorf("robot already started")
+
+// StateChangedEvent is published by a Robot on each change of its state, the event data is a StateTransition
+const StateChangedEvent = "state-changed"
+
+// StateTransition is the data of the StateChangedEvent and the argument of the state hooks
+type StateTransition struct {
+	Robot string
+	From  RobotState
+	To    RobotState
+	Err   error // only for the transition to StateFailed
+}
+
+// StateHook is called before or after a Robot enters a state
+type StateHook func(t StateTransition) error
+
+// State returns the current state of the Robot
+func (r *Robot) State() RobotState {
+	r.stateMutex.RLock()
+	defer r.stateMutex.RUnlock()
+
+	return r.state
+}
+
+// BeforeState registers a hook, which is called before the Robot enters the given state. An error of a hook
+// prevents the Robot from entering one of the states of Robot.Start, so the start fails. For all other states the
+// error is logged, and additionally returned by Robot.Stop for the stopping and stopped states.
+func (r *Robot) BeforeState(state RobotState, hook StateHook) {
+	r.stateMutex.Lock()
+	defer r.stateMutex.Unlock()
+
+	if r.beforeStateHooks == nil {
+		r.beforeStateHooks = make(map[RobotState][]StateHook)
+	}
+	r.beforeStateHooks[state] = append(r.beforeStateHooks[state], hook)
+}
+
+// AfterState registers a hook, which is called after the Robot has entered the given state. Errors of the hook are
+// logged only.
+func (r *Robot) AfterState(state RobotState, hook StateHook) {
+	r.stateMutex.Lock()
+	defer r.stateMutex.Unlock()
+
+	if r.afterStateHooks == nil {
+		r.afterStateHooks = make(map[RobotState][]StateHook)
+	}
+	r.afterStateHooks[state] = append(r.afterStateHooks[state], hook)
+}
+
+// setState changes the state of the robot and publishes the StateChangedEvent, see transition
+func (r *Robot) setState(to RobotState, cause error) error {
+	_, err := r.transition(to, cause, nil)
+	return err
+}
+
+// transition changes the state of the robot, if the current state is one of the given states or no states are
+// given, and differs from the new state. The before hooks of the new state are called before and the after hooks
+// after the change. If a before hook fails, the states of Robot.Start are not entered. Returns whether the state
+// was changed and the errors of the before hooks.
+func (r *Robot) transition(to RobotState, cause error, from []RobotState) (bool, error) {
+	r.transitionMutex.Lock()
+	defer r.transitionMutex.Unlock()
+
+	r.stateMutex.RLock()
+	t := StateTransition{Robot: r.Name, From: r.state, To: to, Err: cause}
+	before := slices.Clone(r.beforeStateHooks[to])
+	after := slices.Clone(r.afterStateHooks[to])
+	r.stateMutex.RUnlock()
+
+	if t.From == to || (len(from) > 0 && !slices.Contains(from, t.From)) {
+		return false, nil
+	}
+
+	var err error
+	for _, hook := range before {
+		if herr := hook(t); herr != nil {
+			err = errors.Join(err, herr)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("before hook of state '%s' of robot %s failed: %w", to, r.Name, err)
+		log.Println(err)
+		if to.vetoable() {
+			return false, err
+		}
+	}
+
+	r.stateMutex.Lock()
+	r.state = to
+	r.stateMutex.Unlock()
+
+	log.Printf("Robot %s changed state from %s to %s", r.Name, t.From, to)
+	r.Publish(StateChangedEvent, t)
+
+	for _, hook := range after {
+		if herr := hook(t); herr != nil {
+			log.Printf("After hook of state '%s' of robot %s failed: %v", to, r.Name, herr)
+		}
+	}
+
+	return true, err
+}
+
+// fail enters the failed state because of the given error and returns the error
+func (r *Robot) fail(err error) error {
+	_ = r.setState(StateFailed, err)
+	return err
+}
+
+// connectionUnhealthy enters the degraded state, when the first connection becomes unhealthy while the robot is
+// running
+func (r *Robot) connectionUnhealthy(c Connection) {
+	r.stateMutex.Lock()
+	r.unhealthyConnections[c] = struct{}{}
+	r.stateMutex.Unlock()
+
+	_, _ = r.transition(StateDegraded, nil, []RobotState{StateRunning})
+}
+
+// connectionRecovered enters the running state again, when the last unhealthy connection is recovered
+func (r *Robot) connectionRecovered(c Connection) {
+	r.stateMutex.Lock()
+	delete(r.unhealthyConnections, c)
+	healthy := len(r.unhealthyConnections) == 0
+	r.stateMutex.Unlock()
+
+	if healthy {
+		_, _ = r.transition(StateRunning, nil, []RobotState{StateDegraded})
+	}
+}
+
+// vetoable returns whether a failing before hook prevents entering the state
+func (s RobotState) vetoable() bool {
+	switch s {
+	case StateInitializing, StateConnecting, StateStartingDevices, StateRunning:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordStates registers an after hook for all states, which records the transitions
+func recordStates(r *Robot) func() []string {
+	var mutex sync.Mutex
+	var states []string
+	for _, state := range []RobotState{
+		StateInitializing, StateConnecting, StateStartingDevices, StateRunning, StateDegraded,
+		StateStopping, StateStopped, StateFailed,
+	} {
+		r.AfterState(state, func(t StateTransition) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			states = append(states, fmt.Sprintf("%s->%s", t.From, t.To))
+			return nil
+		})
+	}
+
+	return func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), states...)
+	}
+}
+
+func TestRobotStateStartStop(t *testing.T) {
+	// arrange
+	log := &testSupervisionLog{}
+	a := &testStartupAdaptor{name: "bus", log: log}
+	r := NewRobot(
+		WithAutoRun(false),
+		WithWorkTimeout(time.Millisecond),
+		WithConnections(a),
+		WithDevices(&testStartupDriver{name: "dev", connection: a, log: log}),
+	)
+	states := recordStates(r)
+	var devicesStarted []string
+	r.BeforeState(StateStartingDevices, func(StateTransition) error {
+		devicesStarted = log.get()
+		return nil
+	})
+	assert.Equal(t, StateStopped, r.State())
+	// act & assert
+	require.NoError(t, r.Start())
+	assert.Equal(t, StateRunning, r.State())
+	assert.Equal(t, StateRunning, NewJSONRobot(r).State)
+	assert.Equal(t, []string{"connect bus"}, devicesStarted)
+	require.NoError(t, r.Stop())
+	assert.Equal(t, StateStopped, r.State())
+	assert.Equal(t, []string{
+		"stopped->initializing", "initializing->connecting", "connecting->starting-devices",
+		"starting-devices->running", "running->stopping", "stopping->stopped",
+	}, states())
+}
+
+func TestRobotStateDoubleStart(t *testing.T) {
+	// arrange
+	log := &testSupervisionLog{}
+	a := &testStartupAdaptor{name: "bus", log: log}
+	r := NewRobot(WithAutoRun(false), WithWorkTimeout(time.Millisecond), WithConnections(a))
+	states := recordStates(r)
+	require.NoError(t, r.Start())
+	// act
+	err := r.Start()
+	// assert
+	require.ErrorIs(t, err, ErrRobotStarted)
+	assert.Equal(t, StateRunning, r.State())
+	assert.Equal(t, []string{"connect bus"}, log.get())
+	require.NoError(t, r.Stop())
+	assert.Equal(t, []string{
+		"stopped->initializing", "initializing->connecting", "connecting->starting-devices",
+		"starting-devices->running", "running->stopping", "stopping->stopped",
+	}, states())
+}
+
+func TestRobotStateDoubleStop(t *testing.T) {
+	// arrange: the device stops the robot again, while it is stopping
+	log := &testSupervisionLog{}
+	a := &testStartupAdaptor{name: "bus", log: log}
+	d := &testStopDriver{testStartupDriver: testStartupDriver{name: "dev", connection: a, log: log}}
+	r := NewRobot(
+		WithAutoRun(false),
+		WithWorkTimeout(time.Millisecond),
+		WithConnections(a),
+		WithDevices(d),
+	)
+	d.robot = r
+	states := recordStates(r)
+	require.NoError(t, r.Start())
+	// act
+	require.NoError(t, r.Stop())
+	// assert
+	assert.True(t, d.stopped.Load())
+	assert.Equal(t, StateStopped, r.State())
+	assert.Equal(t, []string{
+		"stopped->initializing", "initializing->connecting", "connecting->starting-devices",
+		"starting-devices->running", "running->stopping", "stopping->stopped",
+	}, states())
+}
+
+// testStopDriver stops its robot on the first halt
+type testStopDriver struct {
+	testStartupDriver
+	robot   *Robot
+	stopped atomic.Bool
+}
+
+func (d *testStopDriver) HaltContext(ctx context.Context) error {
+	if d.stopped.CompareAndSwap(false, true) {
+		if err := d.robot.Stop(); err != nil {
+			return err
+		}
+	}
+	return d.testStartupDriver.HaltContext(ctx)
+}
+
+func (d *testStopDriver) Halt() error { return d.HaltContext(context.Background()) }
+
+func TestRobotStateEvent(t *testing.T) {
+	// arrange
+	r := NewRobot(WithName("evented"), WithAutoRun(false), WithWorkTimeout(time.Millisecond))
+	events := r.Subscribe()
+	// act
+	require.NoError(t, r.Start())
+	defer func() { _ = r.Stop() }()
+	// assert
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case evt := <-events:
+			if evt.Name != StateChangedEvent {
+				continue
+			}
+			if transition := evt.Data.(StateTransition); transition.To == StateRunning {
+				assert.Equal(t, StateTransition{Robot: "evented", From: StateStartingDevices, To: StateRunning},
+					transition)
+				return
+			}
+		case <-timeout:
+			t.Fatal("running state not published")
+		}
+	}
+}
+
+func TestRobotStateBeforeHookFails(t *testing.T) {
+	// arrange
+	log := &testSupervisionLog{}
+	a := &testStartupAdaptor{name: "bus", log: log}
+	r := NewRobot(
+		WithName("vetoed"),
+		WithAutoRun(false),
+		WithWorkTimeout(time.Millisecond),
+		WithConnections(a),
+		WithDevices(&testStartupDriver{name: "dev", connection: a, log: log}),
+	)
+	var failed StateTransition
+	r.AfterState(StateFailed, func(t StateTransition) error {
+		failed = t
+		return nil
+	})
+	r.BeforeState(StateStartingDevices, func(StateTransition) error { return fmt.Errorf("not ready") })
+	// act
+	err := r.Start()
+	// assert
+	require.EqualError(t, err, "before hook of state 'starting-devices' of robot vetoed failed: not ready")
+	assert.Equal(t, StateFailed, r.State())
+	assert.Equal(t, StateConnecting, failed.From)
+	require.ErrorIs(t, failed.Err, err)
+	assert.Equal(t, []string{"connect bus"}, log.get())
+	assert.False(t, r.Running())
+	require.NoError(t, r.Stop())
+	assert.Equal(t, StateStopped, r.State())
+}
+
+func TestRobotStateStartFails(t *testing.T) {
+	// arrange
+	log := &testSupervisionLog{}
+	r := NewRobot(
+		WithAutoRun(false),
+		WithWorkTimeout(time.Millisecond),
+		WithConnections(&testStartupAdaptor{name: "bus", log: log, connect: func() error {
+			return fmt.Errorf("no bus")
+		}}),
+	)
+	states := recordStates(r)
+	// act
+	err := r.Start()
+	// assert
+	require.Error(t, err)
+	assert.Equal(t, StateFailed, r.State())
+	assert.Equal(t, []string{
+		"stopped->initializing", "initializing->connecting", "connecting->starting-devices",
+		"starting-devices->failed",
+	}, states())
+}
+
+func TestRobotStateStopHookFails(t *testing.T) {
+	// arrange
+	r := NewRobot(WithAutoRun(false), WithWorkTimeout(time.Millisecond))
+	r.BeforeState(StateStopping, func(StateTransition) error { return fmt.Errorf("hook error") })
+	r.AfterState(StateStopped, func(StateTransition) error { return fmt.Errorf("ignored error") })
+	require.NoError(t, r.Start())
+	// act
+	err := r.Stop()
+	// assert
+	require.ErrorContains(t, err, "hook error")
+	require.NotContains(t, err.Error(), "ignored error")
+	assert.Equal(t, StateStopped, r.State())
+}
+
+func TestRobotStateDegraded(t *testing.T) {
+	// arrange
+	r, a1, _ := initTestSupervisedRobot()
+	states := recordStates(r)
+	require.NoError(t, r.Start())
+	events := r.Subscribe()
+	// act
+	a1.unhealthy.Store(true)
+	// assert
+	waitForRobotEvent(t, events, ConnectionRecoveredEvent)
+	require.NoError(t, r.Stop())
+	assert.Equal(t, []string{
+		"stopped->initializing", "initializing->connecting", "connecting->starting-devices",
+		"starting-devices->running", "running->degraded", "degraded->running",
+		"running->stopping", "stopping->stopped",
+	}, states())
+}
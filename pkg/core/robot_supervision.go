@@ -0,0 +1,246 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"gobot.io/x/gobot/v2/internal/interfaces"
+)
+
+const (
+	// ConnectionUnhealthyEvent is published by a supervised Robot, when the health check of a connection fails,
+	// the event data is a SupervisionEvent with the error
+	ConnectionUnhealthyEvent = "connection-unhealthy"
+
+	// ConnectionReconnectingEvent is published by a supervised Robot before each attempt to reconnect an unhealthy
+	// connection, the event data is a SupervisionEvent with the attempt number
+	ConnectionReconnectingEvent = "connection-reconnecting"
+
+	// ConnectionRecoveredEvent is published by a supervised Robot, when an unhealthy connection and its devices
+	// are started again
+	ConnectionRecoveredEvent = "connection-recovered"
+
+	// DeviceHaltedEvent is published by a supervised Robot for each device, which is halted because of its
+	// unhealthy connection
+	DeviceHaltedEvent = "device-halted"
+
+	// DeviceRestartedEvent is published by a supervised Robot for each device, which is started again after its
+	// connection was reconnected
+	DeviceRestartedEvent = "device-restarted"
+)
+
+const (
+	defaultMinRestartInterval = 1 * time.Second
+	defaultMaxRestartInterval = 1 * time.Minute
+)
+
+// ErrConnectionUnhealthy is the error of the SupervisionEvent, if the health check fails without an error
+var ErrConnectionUnhealthy = fmt.Errorf("connection is unhealthy")
+
+// SupervisionEvent is the data of all events published by the supervision of a Robot
+type SupervisionEvent struct {
+	Connection string
+	Device     string // only for the device events
+	Attempt    int    // only for the reconnecting event
+	Err        error  // only for the unhealthy event and the halted event of a failing device
+}
+
+// WithSupervision switches on the supervision of all connections, which implement the Healthcheck interface. The
+// health is checked in the given interval. An unhealthy connection is finalized and reconnected with backoff, the
+// devices of the connection and all devices depending on them are halted before and started again afterwards, like
+// on start and stop with respect to WithDependencies, WithStartTimeout and WithHaltTimeout. The robot is in the
+// degraded state, while any connection is unhealthy.
+func WithSupervision(interval time.Duration) RobotOption {
+	return func(r *Robot) {
+		r.supervisionInterval = interval
+	}
+}
+
+// WithRestartInterval substitutes the default backoff of the supervision. The first reconnect attempt is made after
+// the minimum interval of 1 s, which is doubled for each further attempt up to the maximum interval of 1 min.
+func WithRestartInterval(minInterval, maxInterval time.Duration) RobotOption {
+	return func(r *Robot) {
+		r.minRestartInterval = minInterval
+		r.maxRestartInterval = maxInterval
+	}
+}
+
+// startSupervision starts a supervisor for each connection with health check, if the supervision is switched on
+func (r *Robot) startSupervision() {
+	if r.supervisionInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// the supervisors are registered under the lock, so a concurrent stopSupervision waits for all of them
+	r.stateMutex.Lock()
+	defer r.stateMutex.Unlock()
+	r.cancelSupervision = cancel
+	r.unhealthyConnections = make(map[Connection]struct{})
+
+	for _, c := range *r.Connections() {
+		hc, ok := c.(interfaces.Healthcheck)
+		if !ok {
+			continue
+		}
+
+		r.supervisionWaitGroup.Add(1)
+		go func() {
+			defer r.supervisionWaitGroup.Done()
+			r.superviseConnection(ctx, c, hc)
+		}()
+	}
+}
+
+// stopSupervision stops all supervisors and waits until a running restart is finished
+func (r *Robot) stopSupervision() {
+	r.stateMutex.Lock()
+	cancel := r.cancelSupervision
+	r.cancelSupervision = nil
+	r.stateMutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	r.supervisionWaitGroup.Wait()
+}
+
+// superviseConnection checks the health of the connection until the context is cancelled
+func (r *Robot) superviseConnection(ctx context.Context, c Connection, hc interfaces.Healthcheck) {
+	ticker := time.NewTicker(r.supervisionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		healthy, err := hc.Health()
+		if (healthy && err == nil) || ctx.Err() != nil {
+			continue
+		}
+		if err == nil {
+			err = ErrConnectionUnhealthy
+		}
+
+		log.Printf("Connection %s is unhealthy: %v", c.Name(), err)
+		r.Publish(ConnectionUnhealthyEvent, SupervisionEvent{Connection: c.Name(), Err: err})
+		r.connectionUnhealthy(c)
+
+		all, affected, err := r.connectionItems(c)
+		if err != nil {
+			log.Printf("Restart of connection %s not possible: %v", c.Name(), err)
+			return
+		}
+		r.haltConnection(c, all, affected)
+
+		if !r.restartConnection(ctx, c, all, affected) {
+			return
+		}
+
+		log.Printf("Connection %s recovered", c.Name())
+		r.connectionRecovered(c)
+		r.Publish(ConnectionRecoveredEvent, SupervisionEvent{Connection: c.Name()})
+	}
+}
+
+// restartConnection reconnects and starts the devices with an exponential backoff until it succeeds or the
+// context is cancelled
+func (r *Robot) restartConnection(ctx context.Context, c Connection, all, affected []*lifecycleItem) bool {
+	interval := r.minRestartInterval
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(interval):
+		}
+
+		r.Publish(ConnectionReconnectingEvent, SupervisionEvent{Connection: c.Name(), Attempt: attempt})
+
+		err := r.startConnection(c, all, affected)
+		if err == nil {
+			return true
+		}
+
+		log.Printf("Restart of connection %s failed (attempt %d): %v", c.Name(), attempt, err)
+		interval = min(2*interval, r.maxRestartInterval)
+	}
+}
+
+// connectionItems returns all items of the robot and the items affected by a restart of the connection, which are
+// the connection and all devices, which depend directly or indirectly on it
+func (r *Robot) connectionItems(c Connection) ([]*lifecycleItem, []*lifecycleItem, error) {
+	all, err := r.lifecycleItems()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, it := range all {
+		if it.connection == c {
+			return all, dependentItems(all, it), nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("connection %s is not part of the robot", c.Name())
+}
+
+// haltConnection halts the affected devices and finalizes the connection like Robot.Stop, with respect to the
+// dependencies and the halt timeouts
+func (r *Robot) haltConnection(c Connection, all, affected []*lifecycleItem) {
+	run := r.newPartialRun(all, affected, "stop")
+	r.haltItems(run, affected)
+
+	for _, it := range slices.Backward(affected) {
+		res := run.report.Results[it.index]
+		if res.Err != nil {
+			log.Printf("Halt of %s %s failed: %v", it.kind, it.name, res.Err)
+		}
+		if it.device != nil {
+			r.Publish(DeviceHaltedEvent, SupervisionEvent{Connection: c.Name(), Device: it.name, Err: res.Err})
+		}
+	}
+}
+
+// startConnection connects the connection and starts the affected devices like Robot.Start, with respect to the
+// dependencies and the start timeouts. On error the already started items are halted again.
+func (r *Robot) startConnection(c Connection, all, affected []*lifecycleItem) error {
+	run := r.newPartialRun(all, affected, "start")
+	r.startItems(run, affected)
+
+	var started []*lifecycleItem
+	report := &LifecycleReport{Robot: r.Name, Operation: run.report.Operation}
+	for _, it := range affected {
+		res := run.report.Results[it.index]
+		report.Results = append(report.Results, res)
+		if res.Err == nil {
+			started = append(started, it)
+		}
+	}
+
+	if err := report.Err(); err != nil {
+		haltRun := r.newPartialRun(all, started, "stop")
+		r.haltItems(haltRun, started)
+		for _, it := range started {
+			if herr := haltRun.report.Results[it.index].Err; herr != nil {
+				log.Printf("Halt of %s %s failed: %v", it.kind, it.name, herr)
+			}
+		}
+		return err
+	}
+
+	for _, it := range affected {
+		if it.device != nil {
+			r.Publish(DeviceRestartedEvent, SupervisionEvent{Connection: c.Name(), Device: it.name})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2/internal/interfaces"
+)
+
+var _ interfaces.Healthcheck = (*testHealthAdaptor)(nil)
+
+// testSupervisionLog records the calls of the supervised connections and devices in order
+type testSupervisionLog struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (l *testSupervisionLog) add(call string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *testSupervisionLog) get() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string(nil), l.calls...)
+}
+
+type testHealthAdaptor struct {
+	name           string
+	log            *testSupervisionLog
+	unhealthy      atomic.Bool
+	connectErrors  atomic.Int32 // number of failing connects
+	simulateHealth error
+}
+
+func (t *testHealthAdaptor) Name() string     { return t.name }
+func (t *testHealthAdaptor) SetName(n string) { t.name = n }
+func (t *testHealthAdaptor) Ready() bool      { return !t.unhealthy.Load() }
+
+func (t *testHealthAdaptor) Health() (bool, error) {
+	if t.unhealthy.Load() {
+		return false, t.simulateHealth
+	}
+	return true, nil
+}
+
+func (t *testHealthAdaptor) Connect() error {
+	t.log.add("connect " + t.name)
+	if t.connectErrors.Add(-1) >= 0 {
+		return fmt.Errorf("connect error")
+	}
+	t.unhealthy.Store(false)
+	return nil
+}
+
+func (t *testHealthAdaptor) Finalize() error {
+	t.log.add("finalize " + t.name)
+	return nil
+}
+
+type testSupervisedDriver struct {
+	name       string
+	connection Connection
+	log        *testSupervisionLog
+}
+
+func (t *testSupervisedDriver) Name() string           { return t.name }
+func (t *testSupervisedDriver) SetName(n string)       { t.name = n }
+func (t *testSupervisedDriver) Connection() Connection { return t.connection }
+
+func (t *testSupervisedDriver) Start() error {
+	t.log.add("start " + t.name)
+	return nil
+}
+
+func (t *testSupervisedDriver) Halt() error {
+	t.log.add("halt " + t.name)
+	return nil
+}
+
+// testHangingDriver blocks in start until the context of the start is done
+type testHangingDriver struct {
+	testSupervisedDriver
+	hang atomic.Bool
+}
+
+func (t *testHangingDriver) StartContext(ctx context.Context) error {
+	if t.hang.Load() {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return t.Start()
+}
+
+func initTestSupervisedRobot() (*Robot, *testHealthAdaptor, *testSupervisionLog) {
+	log := &testSupervisionLog{}
+	a1 := &testHealthAdaptor{name: "conn1", log: log, simulateHealth: fmt.Errorf("link down")}
+	a2 := &testHealthAdaptor{name: "conn2", log: log}
+	r := NewRobot(
+		WithName("supervised"),
+		WithAutoRun(false),
+		WithSupervision(time.Millisecond),
+		WithRestartInterval(time.Millisecond, 2*time.Millisecond),
+		WithConnections(a1, a2),
+		WithDevices(
+			&testSupervisedDriver{name: "dev1", connection: a1, log: log},
+			&testSupervisedDriver{name: "dev2", connection: a2, log: log},
+			&testSupervisedDriver{name: "dev3", connection: a1, log: log},
+		),
+	)
+	return r, a1, log
+}
+
+func waitForRobotEvent(t *testing.T, events chan *Event, name string) SupervisionEvent {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case evt := <-events:
+			if evt.Name == name {
+				return evt.Data.(SupervisionEvent)
+			}
+		case <-timeout:
+			t.Fatalf("event '%s' not published", name)
+			return SupervisionEvent{}
+		}
+	}
+}
+
+func TestRobotSupervisionRestartsUnhealthyConnection(t *testing.T) {
+	// arrange
+	r, a1, log := initTestSupervisedRobot()
+	events := r.Subscribe()
+	require.NoError(t, r.Start())
+	defer func() { _ = r.Stop() }()
+	a1.connectErrors.Store(1) // the first reconnect attempt fails
+	// act
+	a1.unhealthy.Store(true)
+	// assert
+	evt := waitForRobotEvent(t, events, ConnectionUnhealthyEvent)
+	assert.Equal(t, "conn1", evt.Connection)
+	require.EqualError(t, evt.Err, "link down")
+	assert.Equal(t, "dev3", waitForRobotEvent(t, events, DeviceHaltedEvent).Device)
+	assert.Equal(t, "dev1", waitForRobotEvent(t, events, DeviceHaltedEvent).Device)
+	assert.Equal(t, 1, waitForRobotEvent(t, events, ConnectionReconnectingEvent).Attempt)
+	assert.Equal(t, 2, waitForRobotEvent(t, events, ConnectionReconnectingEvent).Attempt)
+	assert.Equal(t, "dev1", waitForRobotEvent(t, events, DeviceRestartedEvent).Device)
+	assert.Equal(t, "dev3", waitForRobotEvent(t, events, DeviceRestartedEvent).Device)
+	assert.Equal(t, "conn1", waitForRobotEvent(t, events, ConnectionRecoveredEvent).Connection)
+	calls := log.get()
+	require.Len(t, calls, 12)
+	// the independent connections and devices are started in parallel
+	assert.ElementsMatch(t, []string{
+		"connect conn1", "connect conn2", "start dev1", "start dev2", "start dev3",
+	}, calls[:5])
+	// the devices of the connection are halted and started in parallel
+	assert.ElementsMatch(t, []string{"halt dev3", "halt dev1"}, calls[5:7])
+	assert.Equal(t, []string{"finalize conn1", "connect conn1", "connect conn1"}, calls[7:10])
+	assert.ElementsMatch(t, []string{"start dev1", "start dev3"}, calls[10:])
+}
+
+func TestRobotSupervisionRestartsDependentDevices(t *testing.T) {
+	// arrange: dev2 of the healthy connection depends on dev1 of the unhealthy connection
+	r, a1, log := initTestSupervisedRobot()
+	WithDependencies("dev2", "dev1")(r)
+	events := r.Subscribe()
+	require.NoError(t, r.Start())
+	defer func() { _ = r.Stop() }()
+	// act
+	a1.unhealthy.Store(true)
+	// assert
+	waitForRobotEvent(t, events, ConnectionRecoveredEvent)
+	calls := log.get()
+	require.Len(t, calls, 13)
+	assert.ElementsMatch(t, []string{"halt dev1", "halt dev2", "halt dev3"}, calls[5:8])
+	assert.Less(t, slices.Index(calls, "halt dev2"), slices.Index(calls, "halt dev1"))
+	assert.Equal(t, []string{"finalize conn1", "connect conn1"}, calls[8:10])
+	assert.ElementsMatch(t, []string{"start dev1", "start dev2", "start dev3"}, calls[10:])
+	assert.Less(t, slices.Index(calls[10:], "start dev1"), slices.Index(calls[10:], "start dev2"))
+}
+
+func TestRobotSupervisionStartTimeout(t *testing.T) {
+	// arrange: the device hangs in start after the first start
+	log := &testSupervisionLog{}
+	a1 := &testHealthAdaptor{name: "conn1", log: log}
+	hanging := &testHangingDriver{testSupervisedDriver: testSupervisedDriver{name: "dev1", connection: a1, log: log}}
+	r := NewRobot(
+		WithName("supervised"),
+		WithAutoRun(false),
+		WithSupervision(time.Millisecond),
+		WithRestartInterval(time.Millisecond, 2*time.Millisecond),
+		WithStartTimeout(10*time.Millisecond, "dev1"),
+		WithConnections(a1),
+		WithDevices(hanging),
+	)
+	events := r.Subscribe()
+	require.NoError(t, r.Start())
+	defer func() { _ = r.Stop() }()
+	hanging.hang.Store(true)
+	// act
+	a1.unhealthy.Store(true)
+	// assert: the restart is attempted again, the connection is finalized after the failed start
+	assert.Equal(t, 1, waitForRobotEvent(t, events, ConnectionReconnectingEvent).Attempt)
+	assert.Equal(t, 2, waitForRobotEvent(t, events, ConnectionReconnectingEvent).Attempt)
+	assert.Contains(t, log.get()[4:], "finalize conn1")
+	hanging.hang.Store(false)
+	waitForRobotEvent(t, events, ConnectionRecoveredEvent)
+}
+
+func TestRobotSupervisionUnhealthyWithoutError(t *testing.T) {
+	// arrange
+	r, a1, _ := initTestSupervisedRobot()
+	a1.simulateHealth = nil
+	events := r.Subscribe()
+	require.NoError(t, r.Start())
+	defer func() { _ = r.Stop() }()
+	// act
+	a1.unhealthy.Store(true)
+	// assert
+	evt := waitForRobotEvent(t, events, ConnectionUnhealthyEvent)
+	require.ErrorIs(t, evt.Err, ErrConnectionUnhealthy)
+	waitForRobotEvent(t, events, ConnectionRecoveredEvent)
+}
+
+func TestRobotSupervisionStop(t *testing.T) {
+	// arrange
+	r, a1, log := initTestSupervisedRobot()
+	events := r.Subscribe()
+	require.NoError(t, r.Start())
+	a1.connectErrors.Store(1000) // reconnect fails until stopped
+	a1.unhealthy.Store(true)
+	waitForRobotEvent(t, events, ConnectionReconnectingEvent)
+	// act
+	require.NoError(t, r.Stop())
+	// assert
+	calls := len(log.get())
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, log.get(), calls)
+}
+
+func TestRobotSupervisionStopDuringStart(t *testing.T) {
+	// arrange
+	r, _, _ := initTestSupervisedRobot()
+	started := make(chan struct{})
+	// act: run with -race to detect unprotected access
+	go func() {
+		defer close(started)
+		r.startSupervision()
+	}()
+	r.stopSupervision()
+	<-started
+	r.stopSupervision()
+	// assert
+	r.stateMutex.RLock()
+	defer r.stateMutex.RUnlock()
+	assert.Nil(t, r.cancelSupervision)
+}
+
+func TestRobotWithoutSupervision(t *testing.T) {
+	// arrange
+	r, a1, log := initTestSupervisedRobot()
+	r.supervisionInterval = 0
+	require.NoError(t, r.Start())
+	// act
+	a1.unhealthy.Store(true)
+	time.Sleep(10 * time.Millisecond)
+	// assert
+	assert.Len(t, log.get(), 5)
+	require.NoError(t, r.Stop())
+}
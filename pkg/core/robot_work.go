@@ -3,6 +3,10 @@ package core
 import (
 	"context"
 	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,10 +21,54 @@ type RobotWorkRegistry struct {
 }
 
 const (
-	EveryWorkKind = "every"
-	AfterWorkKind = "after"
+	EveryWorkKind    = "every"
+	AfterWorkKind    = "after"
+	ScheduleWorkKind = "schedule"
 )
 
+// missedRunTolerance is the delay, after which a run of scheduled work is considered as missed, e.g. because the
+// robot was not running or the system was suspended
+const missedRunTolerance = time.Minute
+
+// MissedRunPolicy defines the handling of runs of scheduled work, which were missed
+type MissedRunPolicy int
+
+const (
+	// MissedRunSkip drops all missed runs, the work continues with the next scheduled time, this is the default
+	MissedRunSkip MissedRunPolicy = iota
+	// MissedRunCatchUpOnce runs the work once immediately for all missed runs, afterwards it continues with the
+	// next scheduled time
+	MissedRunCatchUpOnce
+)
+
+// ScheduleOption is a configuration option for scheduled work, see Robot.Schedule
+type ScheduleOption func(*RobotWork)
+
+// WithWorkName names the scheduled work. The ID of named work is derived from the robot name and the work name, so
+// it is stable across restarts. The state of named work is persisted, if the robot has a work state file. A named
+// work replaces a running work of the same name.
+func WithWorkName(name string) ScheduleOption {
+	return func(rw *RobotWork) {
+		rw.name = name
+	}
+}
+
+// WithJitter delays each run of the scheduled work by a random duration up to the given maximum, e.g. to spread
+// the load of many robots with the same schedule
+func WithJitter(maxJitter time.Duration) ScheduleOption {
+	return func(rw *RobotWork) {
+		rw.jitter = maxJitter
+	}
+}
+
+// WithMissedRunPolicy substitutes the default MissedRunSkip for the runs, which are missed while the robot was not
+// running or the system was suspended
+func WithMissedRunPolicy(policy MissedRunPolicy) ScheduleOption {
+	return func(rw *RobotWork) {
+		rw.missedRunPolicy = policy
+	}
+}
+
 // RobotWork and the RobotWork registry represent units of executing computation
 // managed at the Robot level. Unlike the utility functions gobot.After and gobot.Every,
 // RobotWork units require a context.Context, and can be cancelled externally by calling code.
@@ -52,6 +100,14 @@ type RobotWork struct {
 	function   func()
 	ticker     *time.Ticker
 	duration   time.Duration
+	// scheduled work only
+	name            string
+	schedule        WorkSchedule
+	jitter          time.Duration
+	missedRunPolicy MissedRunPolicy
+	lastRun         time.Time
+	nextRun         time.Time
+	mutex           sync.RWMutex
 }
 
 // ID returns the UUID of the RobotWork
@@ -79,9 +135,43 @@ func (rw *RobotWork) Ticker() *time.Ticker {
 
 // TickCount returns the number of times the function successfully ran
 func (rw *RobotWork) TickCount() int {
+	rw.mutex.RLock()
+	defer rw.mutex.RUnlock()
+
 	return rw.tickCount
 }
 
+// Kind returns the kind of the work, e.g. EveryWorkKind
+func (rw *RobotWork) Kind() string {
+	return rw.kind
+}
+
+// Name returns the name of scheduled work, see WithWorkName
+func (rw *RobotWork) Name() string {
+	return rw.name
+}
+
+// Schedule returns the schedule of scheduled work, nil for other kinds
+func (rw *RobotWork) Schedule() WorkSchedule {
+	return rw.schedule
+}
+
+// LastRun returns the time of the last run, the zero time if not run yet
+func (rw *RobotWork) LastRun() time.Time {
+	rw.mutex.RLock()
+	defer rw.mutex.RUnlock()
+
+	return rw.lastRun
+}
+
+// NextRun returns the planned time of the next run of scheduled work, the zero time if there is no further run
+func (rw *RobotWork) NextRun() time.Time {
+	rw.mutex.RLock()
+	defer rw.mutex.RUnlock()
+
+	return rw.nextRun
+}
+
 // Duration returns the timeout until an After fires or the period of an Every
 func (rw *RobotWork) Duration() time.Duration {
 	return rw.duration
@@ -96,6 +186,42 @@ TickCount: %d
 	return fmt.Sprintf(format, rw.id, rw.kind, rw.tickCount)
 }
 
+// JSONRobotWork is a JSON representation of a RobotWork
+type JSONRobotWork struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	Kind      string     `json:"kind"`
+	TickCount int        `json:"tick_count"`
+	Duration  string     `json:"duration,omitempty"`
+	Schedule  string     `json:"schedule,omitempty"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	NextRun   *time.Time `json:"next_run,omitempty"`
+}
+
+// NewJSONRobotWork returns a JSONRobotWork given a RobotWork
+func NewJSONRobotWork(rw *RobotWork) *JSONRobotWork {
+	jsonWork := &JSONRobotWork{
+		ID:        rw.ID().String(),
+		Name:      rw.Name(),
+		Kind:      rw.Kind(),
+		TickCount: rw.TickCount(),
+	}
+	if rw.duration > 0 {
+		jsonWork.Duration = rw.duration.String()
+	}
+	if rw.schedule != nil {
+		jsonWork.Schedule = rw.schedule.String()
+	}
+	if lastRun := rw.LastRun(); !lastRun.IsZero() {
+		jsonWork.LastRun = &lastRun
+	}
+	if nextRun := rw.NextRun(); !nextRun.IsZero() {
+		jsonWork.NextRun = &nextRun
+	}
+
+	return jsonWork
+}
+
 // WorkRegistry returns the Robot's WorkRegistry
 func (r *Robot) WorkRegistry() *RobotWorkRegistry {
 	return r.workRegistry
@@ -149,7 +275,7 @@ func (r *Robot) Every(ctx context.Context, d time.Duration, f func()) *RobotWork
 						}
 					}()
 					f()
-					rw.tickCount++
+					rw.tick()
 				}()
 			}
 		}
@@ -205,7 +331,7 @@ func (r *Robot) After(ctx context.Context, d time.Duration, f func()) *RobotWork
 						}
 					}()
 					f()
-					rw.tickCount++
+					rw.tick()
 				}()
 				return // After only runs once
 			}
@@ -214,6 +340,194 @@ func (r *Robot) After(ctx context.Context, d time.Duration, f func()) *RobotWork
 	return rw
 }
 
+// Schedule calls the given function at the wall-clock times of the schedule, until there is no further run time
+// or the work is cancelled. Each run is delayed by a random duration, if a jitter is configured. Runs, which are
+// missed because the robot was not running or the system was suspended, are handled by the MissedRunPolicy. The
+// state of named work is restored from and saved to the work state file of the robot, see WithWorkStateFile.
+//
+// Usage:
+//
+//	quarterHourly, err := core.ParseCron("*/15 * * * *")
+//	if err != nil {
+//		return err
+//	}
+//	measure := myRobot.Schedule(context.Background(), quarterHourly, func() {
+//		sensor.Read()
+//	}, core.WithWorkName("measure"), core.WithJitter(30*time.Second))
+func (r *Robot) Schedule(ctx context.Context, schedule WorkSchedule, f func(), opts ...ScheduleOption) *RobotWork {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	rw := &RobotWork{kind: ScheduleWorkKind, function: f, schedule: schedule}
+	for _, opt := range opts {
+		opt(rw)
+	}
+	rw.restore(r.workState)
+	r.workRegistry.registerSchedule(ctx, r.Name, rw)
+
+	r.WorkScheduleWaitGroup.Add(1)
+	go func() {
+		defer r.WorkScheduleWaitGroup.Done()
+		defer r.workRegistry.remove(rw)
+
+		for {
+			next := rw.NextRun()
+			if next.IsZero() {
+				return
+			}
+
+			planned := next
+			if rw.jitter > 0 {
+				planned = planned.Add(rand.N(rw.jitter))
+			}
+
+			timer := time.NewTimer(time.Until(planned))
+			select {
+			case <-rw.ctx.Done():
+				timer.Stop()
+				return
+			case <-r.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			now := time.Now()
+			if now.Sub(planned) <= missedRunTolerance || rw.missedRunPolicy == MissedRunCatchUpOnce {
+				// Safe function execution with panic recovery
+				func() {
+					defer func() {
+						if r := recover(); r != nil {
+							// Log panic but don't crash the robot
+							fmt.Printf("Panic in scheduled work function: %v\n", r)
+						}
+					}()
+					f()
+					rw.tick()
+				}()
+			} else {
+				log.Printf("Missed run of work %s (planned %s) skipped", rw.description(), planned.Format(time.RFC3339))
+			}
+
+			rw.setNextRun(schedule.Next(now))
+			rw.persist(r.workState)
+		}
+	}()
+	return rw
+}
+
+// Cron calls the given function at the times of the cron expression, see ParseCron and Schedule
+//
+// Usage:
+//
+//	water, err := myRobot.Cron(context.Background(), "0 6 * * *", func() {
+//		pump.On()
+//	}, core.WithWorkName("water"), core.WithMissedRunPolicy(core.MissedRunCatchUpOnce))
+func (r *Robot) Cron(ctx context.Context, expr string, f func(), opts ...ScheduleOption) (*RobotWork, error) {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Schedule(ctx, schedule, f, opts...), nil
+}
+
+// At calls the given function once at the given wall-clock time, see Schedule
+func (r *Robot) At(ctx context.Context, at time.Time, f func(), opts ...ScheduleOption) *RobotWork {
+	return r.Schedule(ctx, OnceAt(at), f, opts...)
+}
+
+// tick counts a successful run
+func (rw *RobotWork) tick() {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+
+	rw.tickCount++
+	rw.lastRun = time.Now()
+}
+
+func (rw *RobotWork) setNextRun(next time.Time) {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+
+	rw.nextRun = next
+}
+
+func (rw *RobotWork) description() string {
+	if rw.name != "" {
+		return rw.name
+	}
+
+	return rw.id.String()
+}
+
+// restore computes the first run and takes over the counters and a missed run of named work from the state file
+func (rw *RobotWork) restore(state *workStateFile) {
+	rw.nextRun = rw.schedule.Next(time.Now())
+	if state == nil || rw.name == "" {
+		return
+	}
+
+	if entry, ok := state.get(rw.name); ok {
+		rw.tickCount = entry.TickCount
+		rw.lastRun = entry.LastRun
+		// a persisted run before the computed one was missed, unless the schedule has changed
+		if entry.Schedule == rw.schedule.String() && !entry.NextRun.IsZero() && entry.NextRun.Before(rw.nextRun) {
+			rw.nextRun = entry.NextRun
+		}
+	}
+	rw.persist(state)
+}
+
+// persist saves the state of named work to the state file
+func (rw *RobotWork) persist(state *workStateFile) {
+	if state == nil || rw.name == "" {
+		return
+	}
+
+	rw.mutex.RLock()
+	entry := workStateEntry{
+		Schedule:  rw.schedule.String(),
+		TickCount: rw.tickCount,
+		LastRun:   rw.lastRun,
+		NextRun:   rw.nextRun,
+	}
+	rw.mutex.RUnlock()
+
+	state.put(rw.name, entry)
+}
+
+// List returns all registered work, ordered by name and ID
+func (rwr *RobotWorkRegistry) List() []*RobotWork {
+	rwr.RLock()
+	defer rwr.RUnlock()
+
+	works := make([]*RobotWork, 0, len(rwr.r))
+	for _, rw := range rwr.r {
+		works = append(works, rw)
+	}
+	slices.SortFunc(works, func(a, b *RobotWork) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.id.String(), b.id.String())
+	})
+
+	return works
+}
+
+// Cancel cancels the RobotWork specified by the provided ID, it returns false for an unknown ID
+func (rwr *RobotWorkRegistry) Cancel(id uuid.UUID) bool {
+	rw := rwr.Get(id)
+	if rw == nil {
+		return false
+	}
+
+	rw.CallCancelFunc()
+	return true
+}
+
 // Get returns the RobotWork specified by the provided ID. To delete something from the registry, it's
 // necessary to call its context.CancelFunc, which will perform a goroutine-safe delete on the underlying
 // map.
@@ -230,6 +544,34 @@ func (rwr *RobotWorkRegistry) delete(id uuid.UUID) {
 	delete(rwr.r, id.String())
 }
 
+// remove deletes the given RobotWork, but not a newer work with the same ID
+func (rwr *RobotWorkRegistry) remove(rw *RobotWork) {
+	rwr.Lock()
+	defer rwr.Unlock()
+
+	if rwr.r[rw.id.String()] == rw {
+		delete(rwr.r, rw.id.String())
+	}
+}
+
+// registerSchedule registers the scheduled work and sets up its context/cancellation. The ID of named work is
+// derived from the names of the robot and the work, an already registered work with the same ID is cancelled.
+func (rwr *RobotWorkRegistry) registerSchedule(ctx context.Context, robot string, rw *RobotWork) {
+	rwr.Lock()
+	defer rwr.Unlock()
+
+	rw.id = uuid.New()
+	if rw.name != "" {
+		rw.id = uuid.NewSHA1(uuid.NameSpaceOID, []byte(robot+"/"+rw.name))
+	}
+	if previous, ok := rwr.r[rw.id.String()]; ok {
+		previous.cancelFunc()
+	}
+
+	rw.ctx, rw.cancelFunc = context.WithCancel(ctx)
+	rwr.r[rw.id.String()] = rw
+}
+
 // registerAfter creates a new unit of RobotWork and sets up its context/cancellation
 func (rwr *RobotWorkRegistry) registerAfter(ctx context.Context, d time.Duration, f func()) *RobotWork {
 	rwr.Lock()
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// workStateEntry is the persisted state of a named scheduled work
+type workStateEntry struct {
+	Schedule  string    `json:"schedule"`
+	TickCount int       `json:"tick_count"`
+	LastRun   time.Time `json:"last_run"`
+	NextRun   time.Time `json:"next_run"`
+}
+
+// workStateFile persists the state of the named scheduled work of a robot as JSON, so the counters and the
+// missed runs survive a restart
+type workStateFile struct {
+	path    string
+	mutex   sync.Mutex
+	entries map[string]workStateEntry
+}
+
+// WithWorkStateFile persists the state of the named scheduled work of the robot in the given file, see
+// Robot.Schedule and WithWorkName. Without this option, the state is held in memory only.
+func WithWorkStateFile(path string) RobotOption {
+	return func(r *Robot) {
+		r.workState = &workStateFile{path: path}
+	}
+}
+
+// get returns the persisted state of the named work
+func (s *workStateFile) get(name string) (workStateEntry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.load(); err != nil {
+		log.Printf("Loading the work state failed: %v", err)
+	}
+	entry, ok := s.entries[name]
+
+	return entry, ok
+}
+
+// put stores the state of the named work and writes the file
+func (s *workStateFile) put(name string, entry workStateEntry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.load(); err != nil {
+		log.Printf("Loading the work state failed: %v", err)
+	}
+	s.entries[name] = entry
+
+	if err := s.write(); err != nil {
+		log.Printf("Saving the work state failed: %v", err)
+	}
+}
+
+// load reads the file once, a missing file is not an error
+func (s *workStateFile) load() error {
+	if s.entries != nil {
+		return nil
+	}
+
+	s.entries = make(map[string]workStateEntry)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return fmt.Errorf("invalid work state file %s: %w", s.path, err)
+	}
+
+	return nil
+}
+
+// write replaces the file atomically, so a crash never leaves a partial file
+func (s *workStateFile) write() error {
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -2,11 +2,16 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRobotWork(t *testing.T) {
@@ -91,6 +96,144 @@ func TestRobotAutomationFunctions(t *testing.T) {
 	})
 }
 
+func TestRobotSchedule(t *testing.T) {
+	t.Run("At runs once", func(t *testing.T) {
+		robot := NewRobot("testbot")
+		var counter atomic.Int32
+
+		rw := robot.At(context.Background(), time.Now().Add(10*time.Millisecond), func() {
+			counter.Add(1)
+		}, WithJitter(10*time.Millisecond))
+
+		assert.Equal(t, ScheduleWorkKind, rw.Kind())
+		assert.False(t, rw.NextRun().IsZero())
+		robot.WorkScheduleWaitGroup.Wait()
+
+		assert.Equal(t, int32(1), counter.Load())
+		assert.Equal(t, 1, rw.TickCount())
+		assert.False(t, rw.LastRun().IsZero())
+		assert.True(t, rw.NextRun().IsZero())
+		assert.Empty(t, robot.WorkRegistry().List())
+	})
+
+	t.Run("Cron with cancel", func(t *testing.T) {
+		robot := NewRobot("testbot")
+
+		rw, err := robot.Cron(context.Background(), "0 6 * * *", func() {}, WithWorkName("water"))
+
+		require.NoError(t, err)
+		assert.Equal(t, []*RobotWork{rw}, robot.WorkRegistry().List())
+		assert.True(t, robot.WorkRegistry().Cancel(rw.ID()))
+		robot.WorkScheduleWaitGroup.Wait()
+		assert.Empty(t, robot.WorkRegistry().List())
+		assert.False(t, robot.WorkRegistry().Cancel(rw.ID()))
+	})
+
+	t.Run("Cron with invalid expression", func(t *testing.T) {
+		robot := NewRobot("testbot")
+
+		_, err := robot.Cron(context.Background(), "0 6 * *", func() {})
+
+		require.ErrorIs(t, err, ErrInvalidCron)
+	})
+
+	t.Run("named work has a stable ID and replaces the previous work", func(t *testing.T) {
+		robot := NewRobot("testbot")
+		schedule, _ := ParseCron("@daily")
+
+		first := robot.Schedule(context.Background(), schedule, func() {}, WithWorkName("calibrate"))
+		second := robot.Schedule(context.Background(), schedule, func() {}, WithWorkName("calibrate"))
+		other := NewRobot("otherbot").Schedule(context.Background(), schedule, func() {}, WithWorkName("calibrate"))
+		robot.WorkRegistry().Get(first.ID()).CallCancelFunc()
+		robot.WorkScheduleWaitGroup.Wait()
+
+		assert.Equal(t, first.ID(), second.ID())
+		assert.NotEqual(t, first.ID(), other.ID())
+		require.ErrorIs(t, first.ctx.Err(), context.Canceled)
+		assert.Empty(t, robot.WorkRegistry().List())
+		other.CallCancelFunc()
+	})
+
+	t.Run("Robot stop ends the work", func(t *testing.T) {
+		robot := NewRobot(WithAutoRun(false), WithWorkTimeout(time.Millisecond))
+		require.NoError(t, robot.Start())
+		robot.At(context.Background(), time.Now().Add(time.Hour), func() {})
+
+		require.NoError(t, robot.Stop())
+
+		robot.WorkScheduleWaitGroup.Wait()
+		assert.Empty(t, robot.WorkRegistry().List())
+	})
+}
+
+func TestRobotScheduleMissedRuns(t *testing.T) {
+	tests := map[string]struct {
+		policy        MissedRunPolicy
+		wantRun       bool
+		wantTickCount int
+	}{
+		"skip": {
+			policy:        MissedRunSkip,
+			wantTickCount: 3,
+		},
+		"catch_up_once": {
+			policy:        MissedRunCatchUpOnce,
+			wantRun:       true,
+			wantTickCount: 4,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange: the robot was down while the run was due an hour ago
+			path := filepath.Join(t.TempDir(), "work.json")
+			missed := time.Now().Add(-time.Hour).Truncate(time.Second)
+			data, err := json.Marshal(map[string]workStateEntry{
+				"water": {Schedule: "0 6 * * *", TickCount: 3, NextRun: missed},
+			})
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, data, 0o600))
+			robot := NewRobot("testbot", WithWorkStateFile(path))
+			ran := make(chan struct{}, 1)
+			// act
+			rw, err := robot.Cron(context.Background(), "0 6 * * *", func() { ran <- struct{}{} },
+				WithWorkName("water"), WithMissedRunPolicy(tc.policy))
+			// assert
+			require.NoError(t, err)
+			require.Eventually(t, func() bool { return rw.NextRun().After(time.Now()) }, time.Second,
+				time.Millisecond)
+			assert.Len(t, ran, map[bool]int{false: 0, true: 1}[tc.wantRun])
+			assert.Equal(t, tc.wantTickCount, rw.TickCount())
+			rw.CallCancelFunc()
+			robot.WorkScheduleWaitGroup.Wait()
+			// the state survives a restart
+			restarted := NewRobot("testbot", WithWorkStateFile(path))
+			rw, err = restarted.Cron(context.Background(), "0 6 * * *", func() {}, WithWorkName("water"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantTickCount, rw.TickCount())
+			assert.True(t, rw.NextRun().After(time.Now()))
+			rw.CallCancelFunc()
+		})
+	}
+}
+
+func TestNewJSONRobotWork(t *testing.T) {
+	// arrange
+	robot := NewRobot("testbot")
+	schedule, _ := ParseCron("0 6 * * *")
+	rw := robot.Schedule(context.Background(), schedule, func() {}, WithWorkName("water"))
+	defer rw.CallCancelFunc()
+	// act
+	jsonWork := NewJSONRobotWork(rw)
+	// assert
+	assert.Equal(t, rw.ID().String(), jsonWork.ID)
+	assert.Equal(t, "water", jsonWork.Name)
+	assert.Equal(t, ScheduleWorkKind, jsonWork.Kind)
+	assert.Equal(t, "0 6 * * *", jsonWork.Schedule)
+	assert.Nil(t, jsonWork.LastRun)
+	require.NotNil(t, jsonWork.NextRun)
+	assert.Equal(t, 6, jsonWork.NextRun.Hour())
+}
+
 func collectStringKeysFromWorkRegistry(rwr *RobotWorkRegistry) []string {
 	keys := make([]string, len(rwr.r))
 	var idx int
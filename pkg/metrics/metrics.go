@@ -0,0 +1,399 @@
+// Package metrics provides counters, gauges and histograms with labels, which are exposed in the Prometheus text
+// format or in the OpenMetrics text format without external dependencies.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// ContentType is the content type of the Prometheus text exposition format
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+	// OpenMetricsContentType is the content type of the OpenMetrics text format
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// DefaultBuckets are the upper bounds of the histogram buckets in seconds, which fit most latencies of robots
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultRegistry is the registry of the process wide metrics of gobot, e.g. of the buses, which is served by each
+// API together with its own registry
+var DefaultRegistry = NewRegistry()
+
+type metricType string
+
+const (
+	counterType   metricType = "counter"
+	gaugeType     metricType = "gauge"
+	histogramType metricType = "histogram"
+)
+
+// Registry holds the metric families by name
+type Registry struct {
+	mutex    sync.Mutex
+	families map[string]*family
+}
+
+// family is a metric with all its series, the series are identified by the values of the labels
+type family struct {
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+	buckets    []float64
+	mutex      sync.Mutex
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       atomicFloat
+	histogram   *Histogram
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Counter returns the counter family with the name and labels, the family is created on first call. The name of a
+// counter ends with "_total" by convention. A family with the same name but other type or labels panics, because
+// this is an error of the program.
+func (r *Registry) Counter(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{family: r.family(name, help, counterType, nil, labelNames)}
+}
+
+// Gauge returns the gauge family with the name and labels, the family is created on first call
+func (r *Registry) Gauge(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{family: r.family(name, help, gaugeType, nil, labelNames)}
+}
+
+// Histogram returns the histogram family with the name, bucket upper bounds and labels, the family is created on
+// first call. Without buckets, DefaultBuckets are used.
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &HistogramVec{family: r.family(name, help, histogramType, buckets, labelNames)}
+}
+
+func (r *Registry) family(name, help string, typ metricType, buckets []float64, labelNames []string) *family {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		if f.typ != typ || strings.Join(f.labelNames, ",") != strings.Join(labelNames, ",") {
+			panic(fmt.Sprintf("metric %s already registered as %s with labels %v", name, f.typ, f.labelNames))
+		}
+		return f
+	}
+
+	f := &family{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	r.families[name] = f
+
+	return f
+}
+
+// Write writes all metrics in the Prometheus text format or, if requested, in the OpenMetrics text format
+func (r *Registry) Write(w io.Writer, openMetrics bool) error {
+	return Write(w, openMetrics, r)
+}
+
+// Write writes the metrics of all registries in the Prometheus text format or, if requested, in the OpenMetrics
+// text format, e.g. the metrics of an application together with DefaultRegistry. The families are sorted by name,
+// the registries should not contain the same families.
+func Write(w io.Writer, openMetrics bool, registries ...*Registry) error {
+	var families []*family
+	for _, r := range registries {
+		r.mutex.Lock()
+		for _, f := range r.families {
+			families = append(families, f)
+		}
+		r.mutex.Unlock()
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b, openMetrics)
+	}
+	if openMetrics {
+		b.WriteString("# EOF\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// with returns the series of the label values, which is created on first call
+func (f *family) with(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s needs %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.typ == histogramType {
+			s.histogram = &Histogram{buckets: f.buckets, counts: make([]atomic.Uint64, len(f.buckets))}
+		}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+func (f *family) reset() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.series = make(map[string]*series)
+}
+
+// write writes the family, a family without series is omitted
+func (f *family) write(b *strings.Builder, openMetrics bool) {
+	f.mutex.Lock()
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	f.mutex.Unlock()
+	if len(all) == 0 {
+		return
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return strings.Join(all[i].labelValues, "\xff") < strings.Join(all[j].labelValues, "\xff")
+	})
+
+	// the OpenMetrics family name of a counter has no suffix, the samples have
+	name := f.name
+	if openMetrics && f.typ == counterType {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(b, "# HELP %s %s\n", name, escapeHelp(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, f.typ)
+
+	for _, s := range all {
+		labels := f.labels(s.labelValues)
+		switch f.typ {
+		case histogramType:
+			h := s.histogram
+			var cumulative uint64
+			for i, upper := range h.buckets {
+				cumulative += h.counts[i].Load()
+				writeSample(b, f.name+"_bucket", append(labels, "le", formatFloat(upper)), float64(cumulative))
+			}
+			writeSample(b, f.name+"_bucket", append(labels, "le", "+Inf"), float64(h.count.Load()))
+			writeSample(b, f.name+"_sum", labels, h.sum.load())
+			writeSample(b, f.name+"_count", labels, float64(h.count.Load()))
+		case counterType:
+			if !strings.HasSuffix(f.name, "_total") && openMetrics {
+				writeSample(b, f.name+"_total", labels, s.value.load())
+				continue
+			}
+			writeSample(b, f.name, labels, s.value.load())
+		default:
+			writeSample(b, f.name, labels, s.value.load())
+		}
+	}
+}
+
+// labels returns the pairs of names and values
+func (f *family) labels(values []string) []string {
+	labels := make([]string, 0, 2*len(values)+2)
+	for i, name := range f.labelNames {
+		labels = append(labels, name, values[i])
+	}
+
+	return labels
+}
+
+func writeSample(b *strings.Builder, name string, labels []string, value float64) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, `%s="%s"`, labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(value))
+	b.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// atomicFloat is a float64, which is updated atomically
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (a *atomicFloat) load() float64 {
+	return math.Float64frombits(a.bits.Load())
+}
+
+func (a *atomicFloat) store(v float64) {
+	a.bits.Store(math.Float64bits(v))
+}
+
+func (a *atomicFloat) add(v float64) {
+	for {
+		old := a.bits.Load()
+		if a.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// CounterVec is a counter family, see Registry.Counter
+type CounterVec struct {
+	family *family
+}
+
+// With returns the counter of the label values, which are given in the order of the label names
+func (v *CounterVec) With(labelValues ...string) *Counter {
+	return &Counter{series: v.family.with(labelValues)}
+}
+
+// Reset removes all counters of the family, e.g. before the counters are collected from their source again
+func (v *CounterVec) Reset() {
+	v.family.reset()
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	series *series
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	c.series.value.add(1)
+}
+
+// Add increases the counter by the given value, negative values are ignored
+func (c *Counter) Add(v float64) {
+	if v > 0 {
+		c.series.value.add(v)
+	}
+}
+
+// Set sets the counter to a value, which is counted by another source, e.g. the events of an eventer
+func (c *Counter) Set(v float64) {
+	c.series.value.store(v)
+}
+
+// Value returns the current value
+func (c *Counter) Value() float64 {
+	return c.series.value.load()
+}
+
+// GaugeVec is a gauge family, see Registry.Gauge
+type GaugeVec struct {
+	family *family
+}
+
+// With returns the gauge of the label values, which are given in the order of the label names
+func (v *GaugeVec) With(labelValues ...string) *Gauge {
+	return &Gauge{series: v.family.with(labelValues)}
+}
+
+// Reset removes all gauges of the family
+func (v *GaugeVec) Reset() {
+	v.family.reset()
+}
+
+// Gauge is a value, which can go up and down
+type Gauge struct {
+	series *series
+}
+
+// Set sets the gauge to the value
+func (g *Gauge) Set(v float64) {
+	g.series.value.store(v)
+}
+
+// Add adds the value, which can be negative
+func (g *Gauge) Add(v float64) {
+	g.series.value.add(v)
+}
+
+// Value returns the current value
+func (g *Gauge) Value() float64 {
+	return g.series.value.load()
+}
+
+// HistogramVec is a histogram family, see Registry.Histogram
+type HistogramVec struct {
+	family *family
+}
+
+// With returns the histogram of the label values, which are given in the order of the label names
+func (v *HistogramVec) With(labelValues ...string) *Histogram {
+	return v.family.with(labelValues).histogram
+}
+
+// Histogram counts the observed values in buckets
+type Histogram struct {
+	buckets []float64
+	counts  []atomic.Uint64
+	count   atomic.Uint64
+	sum     atomicFloat
+}
+
+// Observe adds the value to the first bucket with an upper bound greater than or equal to the value
+func (h *Histogram) Observe(v float64) {
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i].Add(1)
+	}
+	h.count.Add(1)
+	h.sum.add(v)
+}
+
+// Count returns the number of observed values
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	// arrange
+	r := NewRegistry()
+	r.Counter("gobot_test_total", "Test counter.", "robot").With("bot").Add(3)
+	r.Gauge("gobot_test_gauge", "Test gauge\nwith newline.", "robot").With(`a"b`).Set(-1.5)
+	h := r.Histogram("gobot_test_seconds", "Test histogram.", []float64{1, 0.1}, "robot")
+	h.With("bot").Observe(0.0625)
+	h.With("bot").Observe(0.5)
+	h.With("bot").Observe(4)
+	r.Counter("gobot_unused_total", "Counter without series.")
+	var b bytes.Buffer
+	// act
+	err := r.Write(&b, false)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP gobot_test_gauge Test gauge\nwith newline.
+# TYPE gobot_test_gauge gauge
+gobot_test_gauge{robot="a\"b"} -1.5
+# HELP gobot_test_seconds Test histogram.
+# TYPE gobot_test_seconds histogram
+gobot_test_seconds_bucket{robot="bot",le="0.1"} 1
+gobot_test_seconds_bucket{robot="bot",le="1"} 2
+gobot_test_seconds_bucket{robot="bot",le="+Inf"} 3
+gobot_test_seconds_sum{robot="bot"} 4.5625
+gobot_test_seconds_count{robot="bot"} 3
+# HELP gobot_test_total Test counter.
+# TYPE gobot_test_total counter
+gobot_test_total{robot="bot"} 3
+`, b.String())
+}
+
+func TestRegistryWriteOpenMetrics(t *testing.T) {
+	// arrange
+	r := NewRegistry()
+	r.Counter("gobot_test_total", "Test counter.").With().Inc()
+	var b bytes.Buffer
+	// act
+	err := r.Write(&b, true)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "# HELP gobot_test Test counter.\n# TYPE gobot_test counter\ngobot_test_total 1\n# EOF\n", b.String())
+}
+
+func TestWriteRegistries(t *testing.T) {
+	// arrange
+	r1 := NewRegistry()
+	r1.Gauge("gobot_b", "B.").With().Set(2)
+	r2 := NewRegistry()
+	r2.Gauge("gobot_a", "A.").With().Set(1)
+	var b bytes.Buffer
+	// act
+	err := Write(&b, true, r1, r2)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "# HELP gobot_a A.\n# TYPE gobot_a gauge\ngobot_a 1\n"+
+		"# HELP gobot_b B.\n# TYPE gobot_b gauge\ngobot_b 2\n# EOF\n", b.String())
+}
+
+func TestRegistryFamilies(t *testing.T) {
+	// arrange
+	r := NewRegistry()
+	c := r.Counter("gobot_test_total", "Test counter.", "robot")
+	c.With("bot").Inc()
+	// act & assert
+	assert.InDelta(t, 1.0, r.Counter("gobot_test_total", "Test counter.", "robot").With("bot").Value(), 0)
+	assert.Panics(t, func() { r.Gauge("gobot_test_total", "Test gauge.", "robot") })
+	assert.Panics(t, func() { r.Counter("gobot_test_total", "Test counter.", "device") })
+	assert.Panics(t, func() { c.With("bot", "led") })
+}
+
+func TestCounterVecReset(t *testing.T) {
+	// arrange
+	r := NewRegistry()
+	c := r.Counter("gobot_test_total", "Test counter.", "robot")
+	c.With("old").Set(7)
+	// act
+	c.Reset()
+	c.With("new").Add(2)
+	c.With("new").Add(-1)
+	// assert
+	var b bytes.Buffer
+	require.NoError(t, r.Write(&b, false))
+	assert.NotContains(t, b.String(), "old")
+	assert.Contains(t, b.String(), `gobot_test_total{robot="new"} 2`)
+}
+
+func TestCounterConcurrent(t *testing.T) {
+	// arrange
+	r := NewRegistry()
+	c := r.Counter("gobot_test_total", "Test counter.", "robot")
+	h := r.Histogram("gobot_test_seconds", "Test histogram.", nil, "robot")
+	var wg sync.WaitGroup
+	// act
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.With("bot").Inc()
+				h.With("bot").Observe(0.01)
+			}
+		}()
+	}
+	wg.Wait()
+	// assert
+	assert.InDelta(t, 1000.0, c.With("bot").Value(), 0)
+	assert.Equal(t, uint64(1000), h.With("bot").Count())
+}
@@ -1,9 +1,13 @@
 package robot
 
 import (
+	"context"
+	"errors"
 	"os"
 	"os/signal"
+	"sync"
 	"sync/atomic"
+	"time"
 	
 	"gobot.io/x/gobot/v2/pkg/core"
 )
@@ -20,6 +24,18 @@ var NewCommander = core.NewCommander
 var NewEventer = core.NewEventer
 var NewJSONRobot = core.NewJSONRobot
 
+// serviceShutdownTimeout limits the graceful shutdown of the services by Stop
+const serviceShutdownTimeout = 5 * time.Second
+
+// Service is a long running part of the application besides the robots, e.g. the API server. Services are
+// started before the robots and shut down after them, see Manager.AddService.
+type Service interface {
+	// Start starts the service in the background, errors of the startup are returned
+	Start(ctx context.Context) error
+	// Shutdown stops the service gracefully until the context is done
+	Shutdown(ctx context.Context) error
+}
+
 // JSONManager is a JSON representation of a Gobot Manager.
 type JSONManager struct {
 	Robots   []*JSONRobot `json:"robots"`
@@ -52,6 +68,10 @@ type Manager struct {
 	running atomic.Bool
 	Commander
 	Eventer
+
+	services        []Service
+	startedServices []Service
+	servicesMutex   sync.Mutex
 }
 
 // NewManager returns a new Gobot Manager
@@ -69,10 +89,14 @@ func NewManager() *Manager {
 	return m
 }
 
-// Start calls the Start method on each robot in its collection of robots. On
-// error, call Stop to ensure that all robots are returned to a sane, stopped
-// state.
+// Start starts the services and calls the Start method on each robot in its collection of robots. If a service
+// fails to start, the already started services are shut down and the robots are not started. On error of a
+// robot, call Stop to ensure that all robots and services are returned to a sane, stopped state.
 func (g *Manager) Start() error {
+	if err := g.startServices(); err != nil {
+		return err
+	}
+
 	if err := g.robots.Start(!g.AutoRun); err != nil {
 		return err
 	}
@@ -93,13 +117,60 @@ func (g *Manager) Start() error {
 	return g.Stop()
 }
 
-// Stop calls the Stop method on each robot in its collection of robots.
+// Stop calls the Stop method on each robot in its collection of robots and shuts down the services afterwards.
 func (g *Manager) Stop() error {
 	err := g.robots.Stop()
+	err = errors.Join(err, g.shutdownServices())
 	g.running.Store(false)
 	return err
 }
 
+// AddService adds a service, which is started and shut down together with the robots, e.g.
+//
+//	manager.AddService(api.NewAPI(manager))
+func (g *Manager) AddService(s Service) {
+	g.servicesMutex.Lock()
+	defer g.servicesMutex.Unlock()
+
+	g.services = append(g.services, s)
+}
+
+// startServices starts the services in the order of adding, on error the started ones are shut down
+func (g *Manager) startServices() error {
+	g.servicesMutex.Lock()
+	services := append([]Service(nil), g.services...)
+	g.servicesMutex.Unlock()
+
+	for _, s := range services {
+		if err := s.Start(context.Background()); err != nil {
+			return errors.Join(err, g.shutdownServices())
+		}
+
+		g.servicesMutex.Lock()
+		g.startedServices = append(g.startedServices, s)
+		g.servicesMutex.Unlock()
+	}
+
+	return nil
+}
+
+// shutdownServices shuts down the started services in reverse order
+func (g *Manager) shutdownServices() error {
+	g.servicesMutex.Lock()
+	services := g.startedServices
+	g.startedServices = nil
+	g.servicesMutex.Unlock()
+
+	var err error
+	for i := len(services) - 1; i >= 0; i-- {
+		ctx, cancel := context.WithTimeout(context.Background(), serviceShutdownTimeout)
+		err = errors.Join(err, services[i].Shutdown(ctx))
+		cancel()
+	}
+
+	return err
+}
+
 // Running returns if the Manager is currently started or not
 func (g *Manager) Running() bool {
 	return g.running.Load()
@@ -126,3 +197,16 @@ func (g *Manager) Robot(name string) *Robot {
 	}
 	return nil
 }
+
+// SchemaCommander returns the commander of the manager, if it is a SchemaCommander, see core.SchemaCommanderOf.
+func (g *Manager) SchemaCommander() core.SchemaCommander {
+	sc, _ := g.Commander.(core.SchemaCommander)
+	return sc
+}
+
+// SubscriptionEventer returns the eventer of the manager, if it is a SubscriptionEventer, see
+// core.SubscriptionEventerOf.
+func (g *Manager) SubscriptionEventer() core.SubscriptionEventer {
+	se, _ := g.Eventer.(core.SubscriptionEventer)
+	return se
+}
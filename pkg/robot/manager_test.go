@@ -1,15 +1,19 @@
 package robot
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2/pkg/core"
 )
 
 func initTestManager() *Manager {
@@ -67,6 +71,19 @@ func TestManagerToJSON(t *testing.T) {
 	assert.Len(t, json.Commands, len(g.Commands()))
 }
 
+func TestManagerAccessors(t *testing.T) {
+	// arrange
+	g := NewManager()
+	// act
+	sc, scOk := core.SchemaCommanderOf(g)
+	se, seOk := core.SubscriptionEventerOf(g)
+	// assert
+	assert.True(t, scOk)
+	assert.Equal(t, g.Commander, sc)
+	assert.True(t, seOk)
+	assert.Equal(t, g.Eventer, se)
+}
+
 func TestManagerStart(t *testing.T) {
 	g := initTestManager()
 	require.NoError(t, g.Start())
@@ -93,12 +110,13 @@ func TestManagerStartDriverErrors(t *testing.T) {
 		return e
 	}
 
-	var want error
-	want = AppendError(want, e)
-	want = AppendError(want, e)
-	want = AppendError(want, e)
+	err := g.Start()
 
-	assert.Equal(t, want, g.Start())
+	var lerr *core.LifecycleError
+	require.ErrorAs(t, err, &lerr)
+	assert.Equal(t, "start", lerr.Report.Operation)
+	assert.Len(t, lerr.Report.Failed(), 3)
+	require.ErrorIs(t, err, e)
 	require.NoError(t, g.Stop())
 
 	testDriverStart = func() error { return nil }
@@ -106,38 +124,41 @@ func TestManagerStartDriverErrors(t *testing.T) {
 
 func TestManagerHaltFromRobotDriverErrors(t *testing.T) {
 	g := initTestManager1Robot()
-	var ec int
+	var ec atomic.Int32 // the devices are halted in parallel
 	testDriverHalt = func() error {
-		ec++
-		return fmt.Errorf("driver halt error %d", ec)
+		return fmt.Errorf("driver halt error %d", ec.Add(1))
 	}
 	defer func() { testDriverHalt = func() error { return nil } }()
 
-	var want error
+	err := g.Start()
+
+	var lerr *core.LifecycleError
+	require.ErrorAs(t, err, &lerr)
+	assert.Equal(t, "stop", lerr.Report.Operation)
+	assert.Len(t, lerr.Report.Failed(), 3)
 	for i := 1; i <= 3; i++ {
-		e := fmt.Errorf("driver halt error %d", i)
-		want = AppendError(want, e)
+		require.ErrorContains(t, err, fmt.Sprintf("driver halt error %d", i))
 	}
-
-	assert.Equal(t, want, g.Start())
 }
 
 func TestManagerStartRobotAdaptorErrors(t *testing.T) {
 	g := initTestManager1Robot()
-	var ec int
+	var ec atomic.Int32 // the adaptors are connected in parallel
 	testAdaptorConnect = func() error {
-		ec++
-		return fmt.Errorf("adaptor start error %d", ec)
+		return fmt.Errorf("adaptor start error %d", ec.Add(1))
 	}
 	defer func() { testAdaptorConnect = func() error { return nil } }()
 
-	var want error
+	err := g.Start()
+
+	var lerr *core.LifecycleError
+	require.ErrorAs(t, err, &lerr)
+	// the devices are not started, because their connections failed
+	assert.Len(t, lerr.Report.Failed(), 6)
 	for i := 1; i <= 3; i++ {
-		e := fmt.Errorf("adaptor start error %d", i)
-		want = AppendError(want, e)
+		require.ErrorContains(t, err, fmt.Sprintf("adaptor start error %d", i))
 	}
-
-	assert.Equal(t, want, g.Start())
+	require.ErrorIs(t, err, core.ErrDependencyFailed)
 	require.NoError(t, g.Stop())
 
 	testAdaptorConnect = func() error { return nil }
@@ -145,18 +166,70 @@ func TestManagerStartRobotAdaptorErrors(t *testing.T) {
 
 func TestManagerFinalizeErrors(t *testing.T) {
 	g := initTestManager1Robot()
-	var ec int
+	var ec atomic.Int32 // the adaptors are finalized in parallel
 	testAdaptorFinalize = func() error {
-		ec++
-		return fmt.Errorf("adaptor finalize error %d", ec)
+		return fmt.Errorf("adaptor finalize error %d", ec.Add(1))
 	}
 	defer func() { testAdaptorFinalize = func() error { return nil } }()
 
-	var want error
+	err := g.Start()
+
+	var lerr *core.LifecycleError
+	require.ErrorAs(t, err, &lerr)
+	assert.Len(t, lerr.Report.Failed(), 3)
 	for i := 1; i <= 3; i++ {
-		e := fmt.Errorf("adaptor finalize error %d", i)
-		want = AppendError(want, e)
+		require.ErrorContains(t, err, fmt.Sprintf("adaptor finalize error %d", i))
 	}
+}
 
-	assert.Equal(t, want, g.Start())
+type testService struct {
+	name     string
+	calls    *[]string
+	startErr error
+}
+
+func (s *testService) Start(context.Context) error {
+	*s.calls = append(*s.calls, "start "+s.name)
+	return s.startErr
+}
+
+func (s *testService) Shutdown(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return fmt.Errorf("no deadline for the shutdown of %s", s.name)
+	}
+	*s.calls = append(*s.calls, "shutdown "+s.name)
+	return nil
+}
+
+func TestManagerServices(t *testing.T) {
+	// arrange
+	g := initTestManager1Robot()
+	g.AutoRun = false
+	var calls []string
+	g.AddService(&testService{name: "s1", calls: &calls})
+	g.AddService(&testService{name: "s2", calls: &calls})
+	// act
+	require.NoError(t, g.Start())
+	require.NoError(t, g.Stop())
+	// assert
+	assert.Equal(t, []string{"start s1", "start s2", "shutdown s2", "shutdown s1"}, calls)
+}
+
+func TestManagerServiceStartError(t *testing.T) {
+	// arrange
+	g := initTestManager1Robot()
+	g.AutoRun = false
+	var calls []string
+	g.AddService(&testService{name: "s1", calls: &calls})
+	g.AddService(&testService{name: "s2", calls: &calls, startErr: errors.New("port in use")})
+	g.AddService(&testService{name: "s3", calls: &calls})
+	// act
+	err := g.Start()
+	// assert
+	require.EqualError(t, err, "port in use")
+	assert.False(t, g.Running())
+	assert.False(t, g.Robot("Robot99").Running())
+	assert.Equal(t, []string{"start s1", "start s2", "shutdown s1"}, calls)
+	require.NoError(t, g.Stop())
+	assert.Len(t, calls, 3)
 }
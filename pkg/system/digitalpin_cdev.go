@@ -24,6 +24,9 @@ type digitalPinCdev struct {
 	*digitalPinConfig
 	line cdevLine
 	nativePin *digitalPinCdevNative // Use native implementation
+
+	reads  *ioCounters
+	writes *ioCounters
 }
 
 var digitalPinCdevReconfigure = digitalPinCdevReconfigureLine // to allow unit testing
@@ -76,6 +79,8 @@ func newDigitalPinCdev(chipName string, pin int, options ...func(gobot.DigitalPi
 		pin:              pin,
 		digitalPinConfig: cfg,
 		nativePin:        nativePin,
+		reads:            newIOCounters(gpioOperations, gpioErrors, chipName, strconv.Itoa(pin), "read"),
+		writes:           newIOCounters(gpioOperations, gpioErrors, chipName, strconv.Itoa(pin), "write"),
 	}
 	return d
 }
@@ -146,6 +151,16 @@ func (d *digitalPinCdev) Unexport() error {
 
 // Write writes the given value to the character device
 func (d *digitalPinCdev) Write(val int) error {
+	return d.writes.count(d.writeValue(val))
+}
+
+// Read reads the given value from character device
+func (d *digitalPinCdev) Read() (int, error) {
+	val, err := d.readValue()
+	return val, d.reads.count(err)
+}
+
+func (d *digitalPinCdev) writeValue(val int) error {
 	if val < 0 {
 		val = 0
 	}
@@ -170,8 +185,7 @@ func (d *digitalPinCdev) Write(val int) error {
 	return errors.New("no active line or native pin")
 }
 
-// Read reads the given value from character device
-func (d *digitalPinCdev) Read() (int, error) {
+func (d *digitalPinCdev) readValue() (int, error) {
 	// Prefer line interface for compatibility with tests
 	if d.line != nil {
 		val, err := d.line.Value()
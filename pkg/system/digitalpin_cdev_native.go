@@ -1,12 +1,15 @@
 package system
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"gobot.io/x/gobot/v2"
@@ -48,6 +51,12 @@ const (
 	_GPIO_V2_LINE_FLAG_BIAS_DISABLED  = 1 << 10
 )
 
+// GPIO line event ids
+const (
+	_GPIO_V2_LINE_EVENT_RISING_EDGE  = 1
+	_GPIO_V2_LINE_EVENT_FALLING_EDGE = 2
+)
+
 // Structs for GPIO ioctl operations
 type gpioChipInfo struct {
 	Name  [32]byte
@@ -91,6 +100,17 @@ type gpioV2LineAttribute struct {
 	Data [8]byte
 }
 
+// gpioV2LineEvent is the record, which is read from the line file descriptor for each detected edge. The
+// timestamp is taken by the kernel from CLOCK_MONOTONIC.
+type gpioV2LineEvent struct {
+	TimestampNs uint64
+	ID          uint32
+	Offset      uint32
+	Seqno       uint32
+	LineSeqno   uint32
+	_           [6]uint32 // padding
+}
+
 type gpioV2LineValues struct {
 	Bits uint64
 	Mask uint64
@@ -109,6 +129,7 @@ type nativeGpioLine struct {
 	offset uint32
 	fd     *os.File
 	config *digitalPinConfig
+	events chan struct{} // closed when the edge event reader has finished
 }
 
 // Native digital pin implementation
@@ -212,8 +233,8 @@ func (c *nativeGpioChip) requestLine(offset uint32, config *digitalPinConfig) (*
 		}
 	}
 	
-	// Set edge detection for inputs
-	if config.direction == IN {
+	// Set edge detection for inputs, unless discrete polling is used
+	if config.direction == IN && config.pollInterval <= 0 {
 		switch config.edge {
 		case digitalPinEventOnRisingEdge:
 			flags |= _GPIO_V2_LINE_FLAG_EDGE_RISING
@@ -235,6 +256,12 @@ func (c *nativeGpioChip) requestLine(offset uint32, config *digitalPinConfig) (*
 		return nil, errno
 	}
 	
+	// Use non-blocking mode, so closing the line interrupts a pending read of edge events
+	if err := syscall.SetNonblock(int(req.Fd), true); err != nil {
+		_ = syscall.Close(int(req.Fd))
+		return nil, err
+	}
+
 	// Create file descriptor for the line
 	lineFd := os.NewFile(uintptr(req.Fd), fmt.Sprintf("gpio-line-%d", offset))
 	if lineFd == nil {
@@ -259,26 +286,15 @@ func (l *nativeGpioLine) SetValue(value int) error {
 		values.Bits = 1
 	}
 	
-	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL,
-		uintptr(l.fd.Fd()),
-		_GPIO_V2_LINE_SET_VALUES_IOCTL,
-		uintptr(unsafe.Pointer(&values)))
-	if errno != 0 {
-		return errno
-	}
-	return nil
+	return l.ioctl(_GPIO_V2_LINE_SET_VALUES_IOCTL, unsafe.Pointer(&values))
 }
 
 func (l *nativeGpioLine) Value() (int, error) {
 	var values gpioV2LineValues
 	values.Mask = 1 // Set mask for line 0
 	
-	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL,
-		uintptr(l.fd.Fd()),
-		_GPIO_V2_LINE_GET_VALUES_IOCTL,
-		uintptr(unsafe.Pointer(&values)))
-	if errno != 0 {
-		return 0, errno
+	if err := l.ioctl(_GPIO_V2_LINE_GET_VALUES_IOCTL, unsafe.Pointer(&values)); err != nil {
+		return 0, err
 	}
 	
 	if values.Bits&1 != 0 {
@@ -287,13 +303,90 @@ func (l *nativeGpioLine) Value() (int, error) {
 	return 0, nil
 }
 
+// ioctl calls the request for the line. The descriptor is used by the raw connection, because Fd() would switch
+// the line back to blocking mode and a pending read of edge events would not be interrupted by Close() anymore.
+func (l *nativeGpioLine) ioctl(request uintptr, arg unsafe.Pointer) error {
+	rc, err := l.fd.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var errno syscall.Errno
+	if err := rc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Close releases the line and waits for the edge event reader to finish
 func (l *nativeGpioLine) Close() error {
+	var err error
 	if l.fd != nil {
-		return l.fd.Close()
+		err = l.fd.Close()
 	}
+	if l.events != nil {
+		<-l.events
+	}
+	return err
+}
+
+// startEdgeEvents reads the edge events of the line from the kernel and calls the handler for each event, until
+// the line is closed
+func (l *nativeGpioLine) startEdgeEvents(pinLabel string,
+	eventHandler func(offset int, t time.Duration, et string, sn uint32, lsn uint32),
+) error {
+	if eventHandler == nil {
+		return fmt.Errorf("an event handler is mandatory for edge events")
+	}
+	if l.events != nil {
+		return fmt.Errorf("edge events already started for pin %s", pinLabel)
+	}
+
+	l.events = make(chan struct{})
+	go func() {
+		defer close(l.events)
+		readEdgeEvents(pinLabel, l.fd, eventHandler)
+	}()
+
 	return nil
 }
 
+// readEdgeEvents decodes the gpio_v2_line_event records of the reader and calls the handler for each rising or
+// falling edge with the kernel timestamp and the sequence numbers, until the reader is closed
+func readEdgeEvents(pinLabel string, r io.Reader,
+	eventHandler func(offset int, t time.Duration, et string, sn uint32, lsn uint32),
+) {
+	for {
+		var evt gpioV2LineEvent
+		if err := binary.Read(r, binary.NativeEndian, &evt); err != nil {
+			if !errors.Is(err, os.ErrClosed) && !errors.Is(err, io.EOF) {
+				fmt.Printf("edge event error occurred while reading the pin %s: %v\n", pinLabel, err)
+			}
+			return
+		}
+
+		var detectedEdge string
+		switch evt.ID {
+		case _GPIO_V2_LINE_EVENT_RISING_EDGE:
+			detectedEdge = DigitalPinEventRisingEdge
+		case _GPIO_V2_LINE_EVENT_FALLING_EDGE:
+			detectedEdge = DigitalPinEventFallingEdge
+		default:
+			if systemCdevNativeDebug {
+				fmt.Printf("unknown edge event id %d for pin %s\n", evt.ID, pinLabel)
+			}
+			continue
+		}
+
+		eventHandler(int(evt.Offset), time.Duration(evt.TimestampNs), detectedEdge, evt.Seqno, evt.LineSeqno)
+	}
+}
+
 // Native digital pin implementation
 func newDigitalPinCdevNative(chipName string, pin int, options ...func(gobot.DigitalPinOptioner) bool) *digitalPinCdevNative {
 	if chipName == "" {
@@ -410,15 +503,16 @@ func (d *digitalPinCdevNative) reconfigure(forceInput bool) error {
 	
 	d.line = line
 
-	// start discrete polling function if configured
-	if (d.direction == IN || forceInput) && d.pollInterval > 0 {
-		if err := startEdgePolling(d.label, d.Read, d.pollInterval, d.edge, d.edgeEventHandler,
-			d.pollQuitChan); err != nil {
-			return err
-		}
+	if d.direction != IN || d.edge == digitalPinEventNone {
+		return nil
 	}
 
-	return nil
+	// start discrete polling function if configured, otherwise read the edge events detected by the kernel
+	if d.pollInterval > 0 {
+		return startEdgePolling(d.label, d.Read, d.pollInterval, d.edge, d.edgeEventHandler, d.pollQuitChan)
+	}
+
+	return line.startEdgeEvents(d.label, d.edgeEventHandler)
 }
 
 // Create alias for the new native implementation
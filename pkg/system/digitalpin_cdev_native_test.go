@@ -0,0 +1,124 @@
+package system
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEdgeEvent struct {
+	offset int
+	t      time.Duration
+	edge   string
+	seqno  uint32
+	lseqno uint32
+}
+
+func encodeTestLineEvents(t *testing.T, events ...gpioV2LineEvent) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	for _, evt := range events {
+		require.NoError(t, binary.Write(&buf, binary.NativeEndian, evt))
+	}
+	return buf.Bytes()
+}
+
+func TestGpioV2LineEventSize(t *testing.T) {
+	// the size of struct gpio_v2_line_event in the kernel header
+	assert.Equal(t, 48, binary.Size(gpioV2LineEvent{}))
+}
+
+func Test_readEdgeEvents(t *testing.T) {
+	// arrange
+	data := encodeTestLineEvents(t,
+		gpioV2LineEvent{TimestampNs: 1000, ID: _GPIO_V2_LINE_EVENT_RISING_EDGE, Offset: 17, Seqno: 1, LineSeqno: 1},
+		gpioV2LineEvent{TimestampNs: 1500, ID: 7, Offset: 17, Seqno: 2, LineSeqno: 2},
+		gpioV2LineEvent{TimestampNs: 2000, ID: _GPIO_V2_LINE_EVENT_FALLING_EDGE, Offset: 17, Seqno: 5, LineSeqno: 3},
+	)
+	var got []testEdgeEvent
+	handler := func(offset int, t time.Duration, et string, sn uint32, lsn uint32) {
+		got = append(got, testEdgeEvent{offset: offset, t: t, edge: et, seqno: sn, lseqno: lsn})
+	}
+	// act
+	readEdgeEvents("gobotio17", bytes.NewReader(data), handler)
+	// assert
+	want := []testEdgeEvent{
+		{offset: 17, t: 1000, edge: DigitalPinEventRisingEdge, seqno: 1, lseqno: 1},
+		{offset: 17, t: 2000, edge: DigitalPinEventFallingEdge, seqno: 5, lseqno: 3},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestNativeGpioLineEdgeEvents(t *testing.T) {
+	// arrange: the read end of a pipe stands in for the line file descriptor
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer w.Close()
+	line := &nativeGpioLine{offset: 4, fd: r}
+	received := make(chan testEdgeEvent, 10)
+	handler := func(offset int, t time.Duration, et string, sn uint32, lsn uint32) {
+		received <- testEdgeEvent{offset: offset, t: t, edge: et, seqno: sn, lseqno: lsn}
+	}
+	require.NoError(t, line.startEdgeEvents("gobotio4", handler))
+	require.ErrorContains(t, line.startEdgeEvents("gobotio4", handler), "already started")
+	// act
+	_, err = w.Write(encodeTestLineEvents(t,
+		gpioV2LineEvent{TimestampNs: 123456789, ID: _GPIO_V2_LINE_EVENT_RISING_EDGE, Offset: 4, Seqno: 8, LineSeqno: 1},
+	))
+	require.NoError(t, err)
+	// assert
+	select {
+	case evt := <-received:
+		assert.Equal(t, testEdgeEvent{offset: 4, t: 123456789, edge: DigitalPinEventRisingEdge, seqno: 8, lseqno: 1}, evt)
+	case <-time.After(time.Second):
+		require.Fail(t, "edge event not received")
+	}
+	// close interrupts the pending read and waits for the reader
+	require.NoError(t, line.Close())
+	select {
+	case <-line.events:
+	default:
+		require.Fail(t, "edge event reader not finished")
+	}
+}
+
+func TestNativeGpioLineValueKeepsNonblocking(t *testing.T) {
+	// arrange: the ioctl fails for a pipe, but the pipe would be switched to blocking mode by using Fd()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer w.Close()
+	line := &nativeGpioLine{offset: 4, fd: r}
+	received := make(chan string, 1)
+	require.NoError(t, line.startEdgeEvents("gobotio4", func(_ int, _ time.Duration, et string, _ uint32, _ uint32) {
+		received <- et
+	}))
+	_, err = line.Value()
+	require.Error(t, err)
+	require.Error(t, line.SetValue(1))
+	// the next read of the reader starts after the ioctl calls
+	_, err = w.Write(encodeTestLineEvents(t, gpioV2LineEvent{ID: _GPIO_V2_LINE_EVENT_FALLING_EDGE, Offset: 4}))
+	require.NoError(t, err)
+	assert.Equal(t, DigitalPinEventFallingEdge, <-received)
+	// act
+	closed := make(chan error)
+	go func() { closed <- line.Close() }()
+	// assert: close interrupts the pending read of edge events
+	select {
+	case err := <-closed:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "close blocked by the edge event reader")
+	}
+}
+
+func TestNativeGpioLineEdgeEventsNoHandler(t *testing.T) {
+	line := &nativeGpioLine{}
+	require.ErrorContains(t, line.startEdgeEvents("gobotio4", nil), "event handler is mandatory")
+	assert.Nil(t, line.events)
+}
@@ -28,6 +28,9 @@ type digitalPinSysfs struct {
 	dirFile       *sysfsFile
 	valFile       *sysfsFile
 	activeLowFile *sysfsFile
+
+	reads  *ioCounters
+	writes *ioCounters
 }
 
 // newDigitalPinSysfs returns a digital pin using for the given number. The name of the sysfs file will prepend "gpio"
@@ -42,6 +45,8 @@ func newDigitalPinSysfs(
 		pin:              pin,
 		digitalPinConfig: cfg,
 		sfa:              sfa,
+		reads:            newIOCounters(gpioOperations, gpioErrors, "sysfs", pin, "read"),
+		writes:           newIOCounters(gpioOperations, gpioErrors, "sysfs", pin, "write"),
 	}
 	return d
 }
@@ -108,6 +113,16 @@ func (d *digitalPinSysfs) Unexport() error {
 
 // Write writes the given value to the character device
 func (d *digitalPinSysfs) Write(b int) error {
+	return d.writes.count(d.writeValue(b))
+}
+
+// Read reads a value from character device
+func (d *digitalPinSysfs) Read() (int, error) {
+	val, err := d.readValue()
+	return val, d.reads.count(err)
+}
+
+func (d *digitalPinSysfs) writeValue(b int) error {
 	if d.valFile == nil {
 		return errNotExported
 	}
@@ -115,8 +130,7 @@ func (d *digitalPinSysfs) Write(b int) error {
 	return err
 }
 
-// Read reads a value from character device
-func (d *digitalPinSysfs) Read() (int, error) {
+func (d *digitalPinSysfs) readValue() (int, error) {
 	if d.valFile == nil {
 		return 0, errNotExported
 	}
@@ -63,6 +63,9 @@ type i2cDevice struct {
 	funcs       uint64 // adapter functionality mask
 	lastAddress int
 	mutex       sync.Mutex
+
+	reads  *ioCounters
+	writes *ioCounters
 }
 
 // NewI2cDevice returns a Linux Kernel access by ioctrl to the given i2c bus location (character device).
@@ -81,6 +84,8 @@ func (a *Accesser) NewI2cDevice(location string) (*i2cDevice, error) {
 		sys:         a.sys,
 		fs:          a.fs,
 		lastAddress: -1,
+		reads:       newIOCounters(i2cTransactions, i2cErrors, location, "read"),
+		writes:      newIOCounters(i2cTransactions, i2cErrors, location, "write"),
 	}
 	return d, nil
 }
@@ -322,7 +327,8 @@ func (d *i2cDevice) write(address int, b []byte) (int, error) {
 	if err := d.openFileLazy("Write"); err != nil {
 		return 0, err
 	}
-	return d.file.Write(b)
+	n, err := d.file.Write(b)
+	return n, d.writes.count(err)
 }
 
 func (d *i2cDevice) readAndCheckCount(address int, data []byte) error {
@@ -344,7 +350,8 @@ func (d *i2cDevice) read(address int, b []byte) (int, error) {
 		return 0, err
 	}
 
-	return d.file.Read(b)
+	n, err := d.file.Read(b)
+	return n, d.reads.count(err)
 }
 
 func (d *i2cDevice) queryFunctionality(requested uint64, sender string) error {
@@ -369,8 +376,13 @@ func (d *i2cDevice) smbusAccess(
 	protocol uint32,
 	dataStart unsafe.Pointer,
 ) error {
+	counters := d.writes
+	if readWrite == I2C_SMBUS_READ {
+		counters = d.reads
+	}
+
 	if err := d.setAddress(address); err != nil {
-		return err
+		return counters.count(err)
 	}
 
 	smbus := i2cSmbusIoctlData{
@@ -382,11 +394,7 @@ func (d *i2cDevice) smbusAccess(
 
 	sender := fmt.Sprintf("SMBus access r/w: %d, command: %d, protocol: %d, address: %d",
 		readWrite, command, protocol, d.lastAddress)
-	if err := d.syscallIoctl(I2C_SMBUS, unsafe.Pointer(&smbus), 0, sender); err != nil {
-		return err
-	}
-
-	return nil
+	return counters.count(d.syscallIoctl(I2C_SMBUS, unsafe.Pointer(&smbus), 0, sender))
 }
 
 // setAddress sets the address of the i2c device to use.
@@ -0,0 +1,46 @@
+package system
+
+import (
+	"gobot.io/x/gobot/v2/pkg/metrics"
+)
+
+// Metrics of the bus and pin I/O, the rate of errors is the ratio of the errors and the operations
+var (
+	i2cTransactions = metrics.DefaultRegistry.Counter("gobot_i2c_transactions_total",
+		"Number of I2C and SMBus transactions.", "bus", "op")
+	i2cErrors = metrics.DefaultRegistry.Counter("gobot_i2c_errors_total",
+		"Number of failed I2C and SMBus transactions.", "bus", "op")
+	spiTransfers = metrics.DefaultRegistry.Counter("gobot_spi_transfers_total",
+		"Number of SPI transfers.", "bus")
+	spiErrors = metrics.DefaultRegistry.Counter("gobot_spi_errors_total",
+		"Number of failed SPI transfers.", "bus")
+	gpioOperations = metrics.DefaultRegistry.Counter("gobot_gpio_operations_total",
+		"Number of reads and writes of digital pins.", "chip", "pin", "op")
+	gpioErrors = metrics.DefaultRegistry.Counter("gobot_gpio_errors_total",
+		"Number of failed reads and writes of digital pins.", "chip", "pin", "op")
+)
+
+// ioCounters counts the operations and the errors of a bus or pin. The counters are looked up once on creation
+// to keep the label lookup out of the I/O path.
+type ioCounters struct {
+	ops  *metrics.Counter
+	errs *metrics.Counter
+}
+
+func newIOCounters(ops, errs *metrics.CounterVec, labelValues ...string) *ioCounters {
+	return &ioCounters{ops: ops.With(labelValues...), errs: errs.With(labelValues...)}
+}
+
+// count counts an operation with its result and returns the error unchanged, nothing is counted for nil counters
+func (c *ioCounters) count(err error) error {
+	if c == nil {
+		return err
+	}
+
+	c.ops.Inc()
+	if err != nil {
+		c.errs.Inc()
+	}
+
+	return err
+}
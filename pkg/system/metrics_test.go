@@ -0,0 +1,54 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestI2cDeviceMetrics(t *testing.T) {
+	// arrange
+	const bus = "/dev/i2c-metrics"
+	a := NewAccesser()
+	msc := a.UseMockSyscall()
+	a.UseMockFilesystem([]string{bus})
+	d, err := a.NewI2cDevice(bus)
+	require.NoError(t, err)
+	msc.Impl = getSyscallFuncImpl(0)
+	// act
+	_, err = d.ReadByteData(2, 3)
+	require.NoError(t, err)
+	require.NoError(t, d.WriteByteData(2, 3, 4))
+	msc.Impl = getSyscallFuncImpl(0x04)
+	_, err = d.ReadWordData(2, 3)
+	require.Error(t, err)
+	// assert
+	assert.InDelta(t, 2.0, i2cTransactions.With(bus, "read").Value(), 0)
+	assert.InDelta(t, 1.0, i2cErrors.With(bus, "read").Value(), 0)
+	assert.InDelta(t, 1.0, i2cTransactions.With(bus, "write").Value(), 0)
+	assert.InDelta(t, 0.0, i2cErrors.With(bus, "write").Value(), 0)
+}
+
+func TestDigitalPinSysfsMetrics(t *testing.T) {
+	// arrange
+	mockPaths := []string{
+		"/sys/class/gpio/export",
+		"/sys/class/gpio/gpio77/value",
+		"/sys/class/gpio/gpio77/direction",
+	}
+	fs := newMockFilesystem(mockPaths)
+	sfa := sysfsFileAccess{fs: fs, readBufLen: 2}
+	pin := newDigitalPinSysfs(&sfa, "77")
+	// act
+	require.Error(t, pin.Write(1))
+	require.NoError(t, pin.Export())
+	require.NoError(t, pin.Write(1))
+	_, err := pin.Read()
+	require.NoError(t, err)
+	// assert
+	assert.InDelta(t, 2.0, gpioOperations.With("sysfs", "77", "write").Value(), 0)
+	assert.InDelta(t, 1.0, gpioErrors.With("sysfs", "77", "write").Value(), 0)
+	assert.InDelta(t, 1.0, gpioOperations.With("sysfs", "77", "read").Value(), 0)
+	assert.InDelta(t, 0.0, gpioErrors.With("sysfs", "77", "read").Value(), 0)
+}
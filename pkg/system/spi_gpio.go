@@ -25,11 +25,14 @@ type spiGpio struct {
 	ncsPin  gobot.DigitalPinner
 	sdoPin  gobot.DigitalPinner
 	sdiPin  gobot.DigitalPinner
+
+	transfers *ioCounters
 }
 
 // newSpiGpio creates and returns a new SPI connection based on given GPIO's.
 func newSpiGpio(cfg spiGpioConfig, maxSpeedHz int64) (*spiGpio, error) {
-	spi := &spiGpio{cfg: cfg}
+	bus := fmt.Sprintf("gpio:%s,%s,%s,%s", cfg.sclkPinID, cfg.ncsPinID, cfg.sdoPinID, cfg.sdiPinID)
+	spi := &spiGpio{cfg: cfg, transfers: newIOCounters(spiTransfers, spiErrors, bus)}
 	spi.initializeTime(maxSpeedHz)
 	return spi, spi.initializeGpios()
 }
@@ -51,6 +54,10 @@ func (s *spiGpio) initializeTime(maxSpeedHz int64) {
 
 // TxRx uses the SPI device to send/receive data. Implements gobot.SpiSystemDevicer.
 func (s *spiGpio) TxRx(tx []byte, rx []byte) error {
+	return s.transfers.count(s.txRx(tx, rx))
+}
+
+func (s *spiGpio) txRx(tx []byte, rx []byte) error {
 	var doRx bool
 	if rx != nil {
 		doRx = true
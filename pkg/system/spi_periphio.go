@@ -10,8 +10,9 @@ import (
 
 // spiPeriphIo is the implementation of the SPI interface using the periph.io sysfs implementation for Linux.
 type spiPeriphIo struct {
-	port xspi.PortCloser
-	dev  xspi.Conn
+	port      xspi.PortCloser
+	dev       xspi.Conn
+	transfers *ioCounters
 }
 
 // newSpiPeriphIo creates and returns a new connection to a specific SPI device on a bus/chip
@@ -25,11 +26,16 @@ func newSpiPeriphIo(busNum, chipNum, mode, bits int, maxSpeed int64) (*spiPeriph
 	if err != nil {
 		return nil, err
 	}
-	return &spiPeriphIo{port: p, dev: c}, nil
+	bus := fmt.Sprintf("/dev/spidev%d.%d", busNum, chipNum)
+	return &spiPeriphIo{port: p, dev: c, transfers: newIOCounters(spiTransfers, spiErrors, bus)}, nil
 }
 
 // TxRx uses the SPI device TX to send/receive data. Implements gobot.SpiSystemDevicer.
 func (c *spiPeriphIo) TxRx(tx []byte, rx []byte) error {
+	return c.transfers.count(c.txRx(tx, rx))
+}
+
+func (c *spiPeriphIo) txRx(tx []byte, rx []byte) error {
 	dataLen := len(rx)
 	if err := c.dev.Tx(tx, rx); err != nil {
 		return err
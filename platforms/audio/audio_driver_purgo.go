@@ -35,8 +35,9 @@ func NewPureGoDriver(a *PureGoAdaptor, filename string) *PureGoDriver {
 		filename:   filename,
 		halt:       make(chan bool),
 		Eventer:    gobot.NewEventer(),
-		Commander:  gobot.NewCommander(),
 	}
+	commander := gobot.NewCommander()
+	d.Commander = commander
 
 	// Add commander interface methods
 	d.AddCommand("play", func(params map[string]interface{}) interface{} {
@@ -50,13 +51,15 @@ func NewPureGoDriver(a *PureGoAdaptor, filename string) *PureGoDriver {
 		return []error{errors.New("invalid filename parameter")}
 	})
 
-	d.AddCommand("tone", func(params map[string]interface{}) interface{} {
-		freq, freqOk := params["frequency"].(float64)
-		dur, durOk := params["duration"].(time.Duration)
-		if freqOk && durOk {
-			return d.GenerateTone(freq, dur)
-		}
-		return []error{errors.New("invalid tone parameters")}
+	commander.AddTypedCommand("tone", gobot.CommandSchema{
+		Description: "Generates a pure tone",
+		Params: []gobot.CommandParam{
+			{Name: "frequency", Type: gobot.ParamNumber, Description: "frequency in Hz", Required: true},
+			{Name: "duration", Type: gobot.ParamDuration, Description: "duration, e.g. \"500ms\"", Required: true},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		//nolint:forcetypeassert // ensured by the schema
+		return d.GenerateTone(params["frequency"].(float64), params["duration"].(time.Duration))
 	})
 
 	return d
@@ -106,6 +109,12 @@ func (d *PureGoDriver) GenerateTone(frequency float64, duration time.Duration) [
 	return []error{errors.New("invalid connection type")}
 }
 
+// SchemaCommander returns the commander with the typed command "tone", see gobot.SchemaCommanderOf
+func (d *PureGoDriver) SchemaCommander() gobot.SchemaCommander {
+	sc, _ := d.Commander.(gobot.SchemaCommander)
+	return sc
+}
+
 // Start starts the Driver
 func (d *PureGoDriver) Start() error {
 	return nil
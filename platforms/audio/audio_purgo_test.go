@@ -10,6 +10,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
 )
 
 func TestPureGoAudioAdaptor(t *testing.T) {
@@ -138,11 +140,20 @@ func TestPureGoDriverCommands(t *testing.T) {
 	assert.True(t, ok)
 	assert.Len(t, errors, 0)
 	
+	// Test tone command with the params of a JSON request
+	result = driver.Command("tone")(map[string]interface{}{
+		"frequency": 440.0,
+		"duration":  "50ms",
+	})
+	errors, ok = result.([]error)
+	assert.True(t, ok)
+	assert.Len(t, errors, 0)
+
 	// Test invalid tone command
 	result = driver.Command("tone")(map[string]interface{}{
 		"frequency": "invalid",
 	})
-	errors, ok = result.([]error)
+	cmdErr, ok := result.(error)
 	assert.True(t, ok)
-	assert.Len(t, errors, 1)
+	require.ErrorIs(t, cmdErr, gobot.ErrInvalidCommandParams)
 }
\ No newline at end of file
@@ -10,14 +10,45 @@ import (
 	"gobot.io/x/gobot/v2/bluetooth"
 )
 
+const defaultMTU = 23 // minimum ATT MTU of Bluetooth LE
+
+const (
+	// ConnectedEvent is published by the Adaptor each time the connection to the device is (re-)established
+	ConnectedEvent = "connected"
+
+	// ConnectionLostEvent is published by the Adaptor when the connection to the device is lost unexpectedly,
+	// the event data contains the error
+	ConnectionLostEvent = "connection-lost"
+
+	// ReconnectingEvent is published by the Adaptor before each attempt to re-establish a lost connection
+	ReconnectingEvent = "reconnecting"
+
+	// DisconnectedEvent is published by the Adaptor when the connection was closed by Disconnect
+	DisconnectedEvent = "disconnected"
+)
+
+const (
+	defaultSupervisionInterval  = 1 * time.Second
+	defaultMinReconnectInterval = 1 * time.Second
+	defaultMaxReconnectInterval = 1 * time.Minute
+)
+
+// ErrConnectionLost is published with the ConnectionLostEvent, when the device is not connected anymore
+var ErrConnectionLost = fmt.Errorf("BLE connection lost")
+
 type configuration struct {
 	scanTimeout          time.Duration
 	sleepAfterDisconnect time.Duration
+	supervisionInterval  time.Duration
+	autoReconnect        bool
+	minReconnectInterval time.Duration
+	maxReconnectInterval time.Duration
 	debug                bool
 	testMode             bool
 }
 
-// Adaptor represents a Client Connection to a BLE Peripheral
+// Adaptor represents a Client Connection to a BLE Peripheral. The connection state changes are published as
+// events (ConnectedEvent, ConnectionLostEvent, ReconnectingEvent, DisconnectedEvent) by the embedded Eventer.
 type Adaptor struct {
 	name       string
 	identifier string
@@ -26,12 +57,17 @@ type Adaptor struct {
 	btAdpt          *btAdapter
 	btDevice        *btDevice
 	characteristics map[string]bluetoothExtCharacteristicer
+	subscriptions   map[string]func(data []byte) // restored after a reconnect
+	reconnected     []func() error
 
 	connected bool
 	rssi      int
 
-	btAdptCreator btAdptCreatorFunc
-	mutex         *sync.Mutex
+	btAdptCreator    btAdptCreatorFunc
+	mutex            *sync.Mutex // serializes connecting and disconnecting
+	stateMutex       *sync.RWMutex
+	cancelSupervisor context.CancelFunc
+	gobot.Eventer
 }
 
 // NewAdaptor returns a new Adaptor given an identifier. The identifier can be the address or the name.
@@ -40,10 +76,15 @@ type Adaptor struct {
 //
 //	"WithAdaptorDebug"
 //	"WithAdaptorScanTimeout"
+//	"WithSupervisionInterval"
+//	"WithAutoReconnect"
 func NewAdaptor(identifier string, opts ...optionApplier) *Adaptor {
 	cfg := configuration{
 		scanTimeout:          10 * time.Minute,
 		sleepAfterDisconnect: 500 * time.Millisecond,
+		supervisionInterval:  defaultSupervisionInterval,
+		minReconnectInterval: defaultMinReconnectInterval,
+		maxReconnectInterval: defaultMaxReconnectInterval,
 	}
 
 	a := Adaptor{
@@ -51,14 +92,22 @@ func NewAdaptor(identifier string, opts ...optionApplier) *Adaptor {
 		identifier:      identifier,
 		cfg:             &cfg,
 		characteristics: make(map[string]bluetoothExtCharacteristicer),
+		subscriptions:   make(map[string]func(data []byte)),
 		btAdptCreator:   newBtAdapter,
 		mutex:           &sync.Mutex{},
+		stateMutex:      &sync.RWMutex{},
+		Eventer:         gobot.NewEventer(),
 	}
 
 	for _, o := range opts {
 		o.apply(a.cfg)
 	}
 
+	a.AddEvent(ConnectedEvent)
+	a.AddEvent(ConnectionLostEvent)
+	a.AddEvent(ReconnectingEvent)
+	a.AddEvent(DisconnectedEvent)
+
 	return &a
 }
 
@@ -72,8 +121,23 @@ func WithScanTimeout(timeout time.Duration) scanTimeoutOption {
 	return scanTimeoutOption(timeout)
 }
 
+// WithSupervisionInterval substitute the default interval of 1 s, in which the connection state of the device is
+// checked. Zero switches off the supervision, so a lost connection is not detected.
+func WithSupervisionInterval(interval time.Duration) supervisionIntervalOption {
+	return supervisionIntervalOption(interval)
+}
+
+// WithAutoReconnect switch on the automatic re-establishment of a lost connection. The first attempt is made after
+// the given minimum interval, which is doubled for each further attempt up to the given maximum interval.
+func WithAutoReconnect(minInterval, maxInterval time.Duration) autoReconnectOption {
+	return autoReconnectOption{minInterval: minInterval, maxInterval: maxInterval}
+}
+
 // Name returns the name for the adaptor and after the connection is done, the name of the device
 func (a *Adaptor) Name() string {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
 	if a.btDevice != nil {
 		return a.btDevice.name()
 	}
@@ -85,6 +149,9 @@ func (a *Adaptor) SetName(n string) { a.name = n }
 
 // Address returns the Bluetooth LE address of the device if connected, otherwise the identifier
 func (a *Adaptor) Address() string {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
 	if a.btDevice != nil {
 		return a.btDevice.address()
 	}
@@ -93,18 +160,56 @@ func (a *Adaptor) Address() string {
 }
 
 // RSSI returns the Bluetooth LE RSSI value at the moment of connecting the adaptor
-func (a *Adaptor) RSSI() int { return a.rssi }
+func (a *Adaptor) RSSI() int {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
+	return a.rssi
+}
+
+// MTU returns the ATT MTU negotiated with the connected device, or the minimum ATT MTU of 23 bytes if not
+// connected. The payload of one write without response or notification is 3 bytes smaller.
+func (a *Adaptor) MTU() int {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
+	if !a.connected || a.btDevice == nil {
+		return defaultMTU
+	}
+	return int(a.btDevice.mtu())
+}
+
+// OnReconnect registers a handler, which is called after the connection was re-established automatically and the
+// previous notification subscriptions are restored. Drivers use it to re-run their initialization sequence. An
+// error of a handler fails the reconnect attempt.
+func (a *Adaptor) OnReconnect(handler func() error) {
+	a.stateMutex.Lock()
+	defer a.stateMutex.Unlock()
+
+	a.reconnected = append(a.reconnected, handler)
+}
 
 // WithoutResponses sets if the adaptor should expect responses after
 // writing characteristics for this device (has no effect at the moment).
 func (a *Adaptor) WithoutResponses(bool) {}
 
-// Connect initiates a connection to the BLE peripheral.
+// Connect initiates a connection to the BLE peripheral and starts the supervision of the connection.
 func (a *Adaptor) Connect() error {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
-	ctx := context.Background()
+	if err := a.connect(context.Background()); err != nil {
+		return err
+	}
+
+	a.startSupervisor()
+	a.Eventer.Publish(ConnectedEvent, nil)
+
+	return nil
+}
+
+// connect scans for the peripheral, connects to it and discovers all characteristics
+func (a *Adaptor) connect(ctx context.Context) error {
 	var err error
 
 	if a.cfg.debug {
@@ -153,16 +258,19 @@ func (a *Adaptor) Connect() error {
 		return err
 	}
 
+	a.stateMutex.Lock()
 	a.rssi = int(result.RSSI)
 	a.btDevice = dev
+	a.stateMutex.Unlock()
 
 	if a.cfg.debug {
 		fmt.Println("[Connect]: get all services/characteristics...")
 	}
-	services, err := a.btDevice.discoverServices(ctx, nil)
+	services, err := dev.discoverServices(ctx, nil)
 	if err != nil {
 		return err
 	}
+	characteristics := make(map[string]bluetoothExtCharacteristicer)
 	for _, service := range services {
 		if a.cfg.debug {
 			fmt.Printf("[Connect]: service found: %s\n", service.UUID().String())
@@ -172,21 +280,24 @@ func (a *Adaptor) Connect() error {
 			if a.cfg.debug {
 				fmt.Printf("[Connect]: characteristic found: %s\n", char.UUID().String())
 			}
-			a.characteristics[char.UUID().String()] = char
+			characteristics[char.UUID().String()] = char
 		}
 	}
 
 	if a.cfg.debug {
 		fmt.Println("[Connect]: connected")
 	}
+	a.stateMutex.Lock()
+	a.characteristics = characteristics
 	a.connected = true
+	a.stateMutex.Unlock()
 	return nil
 }
 
 // Reconnect attempts to reconnect to the BLE peripheral. If it has an active connection
 // it will first close that connection and then establish a new connection.
 func (a *Adaptor) Reconnect() error {
-	if a.connected {
+	if a.isConnected() {
 		if err := a.Disconnect(); err != nil {
 			return err
 		}
@@ -194,18 +305,28 @@ func (a *Adaptor) Reconnect() error {
 	return a.Connect()
 }
 
-// Disconnect terminates the connection to the BLE peripheral.
+// Disconnect stops the supervision and terminates the connection to the BLE peripheral.
 func (a *Adaptor) Disconnect() error {
+	a.stopSupervisor()
+
+	// wait for a running reconnect attempt
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
 	if a.cfg.debug {
 		fmt.Println("[Disconnect]: disconnect...")
 	}
 	ctx := context.Background()
-	err := a.btDevice.disconnect(ctx)
+	a.stateMutex.RLock()
+	dev := a.btDevice
+	a.stateMutex.RUnlock()
+	err := dev.disconnect(ctx)
 	time.Sleep(a.cfg.sleepAfterDisconnect)
-	a.connected = false
+	a.setConnected(false)
 	if a.cfg.debug {
 		fmt.Println("[Disconnect]: disconnected")
 	}
+	a.Eventer.Publish(DisconnectedEvent, nil)
 	return err
 }
 
@@ -217,7 +338,7 @@ func (a *Adaptor) Finalize() error {
 // ReadCharacteristic returns bytes from the BLE device for the requested characteristic UUID.
 // The UUID can be given as 16-bit or 128-bit (with or without dashes) value.
 func (a *Adaptor) ReadCharacteristic(cUUID string) ([]byte, error) {
-	if !a.connected {
+	if !a.isConnected() {
 		return nil, fmt.Errorf("cannot read from BLE device until connected")
 	}
 
@@ -226,7 +347,7 @@ func (a *Adaptor) ReadCharacteristic(cUUID string) ([]byte, error) {
 		return nil, err
 	}
 
-	if chara, ok := a.characteristics[cUUID]; ok {
+	if chara, ok := a.characteristic(cUUID); ok {
 		ctx := context.Background()
 		return readFromCharacteristic(ctx, chara)
 	}
@@ -237,7 +358,7 @@ func (a *Adaptor) ReadCharacteristic(cUUID string) ([]byte, error) {
 // WriteCharacteristic writes bytes to the BLE device for the requested characteristic UUID.
 // The UUID can be given as 16-bit or 128-bit (with or without dashes) value.
 func (a *Adaptor) WriteCharacteristic(cUUID string, data []byte) error {
-	if !a.connected {
+	if !a.isConnected() {
 		return fmt.Errorf("cannot write to BLE device until connected")
 	}
 
@@ -246,7 +367,7 @@ func (a *Adaptor) WriteCharacteristic(cUUID string, data []byte) error {
 		return err
 	}
 
-	if chara, ok := a.characteristics[cUUID]; ok {
+	if chara, ok := a.characteristic(cUUID); ok {
 		ctx := context.Background()
 		return writeToCharacteristicWithoutResponse(ctx, chara, data)
 	}
@@ -255,9 +376,12 @@ func (a *Adaptor) WriteCharacteristic(cUUID string, data []byte) error {
 }
 
 // Subscribe subscribes to notifications from the BLE device for the requested characteristic UUID.
-// The UUID can be given as 16-bit or 128-bit (with or without dashes) value.
+// The UUID can be given as 16-bit or 128-bit (with or without dashes) value. The subscription is restored
+// automatically after a reconnect.
+//
+// Note: this hides the Subscribe() of the embedded Eventer, use a.Eventer.Subscribe() for the events.
 func (a *Adaptor) Subscribe(cUUID string, f func(data []byte)) error {
-	if !a.connected {
+	if !a.isConnected() {
 		return fmt.Errorf("cannot subscribe to BLE device until connected")
 	}
 
@@ -266,10 +390,40 @@ func (a *Adaptor) Subscribe(cUUID string, f func(data []byte)) error {
 		return err
 	}
 
-	if chara, ok := a.characteristics[cUUID]; ok {
+	if chara, ok := a.characteristic(cUUID); ok {
 		ctx := context.Background()
-		return enableNotificationsForCharacteristic(ctx, chara, f)
+		if err := enableNotificationsForCharacteristic(ctx, chara, f); err != nil {
+			return err
+		}
+
+		a.stateMutex.Lock()
+		a.subscriptions[cUUID] = f
+		a.stateMutex.Unlock()
+
+		return nil
 	}
 
 	return fmt.Errorf("unknown characteristic: %s", cUUID)
 }
+
+func (a *Adaptor) isConnected() bool {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
+	return a.connected
+}
+
+func (a *Adaptor) setConnected(connected bool) {
+	a.stateMutex.Lock()
+	defer a.stateMutex.Unlock()
+
+	a.connected = connected
+}
+
+func (a *Adaptor) characteristic(cUUID string) (bluetoothExtCharacteristicer, bool) {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
+	chara, ok := a.characteristics[cUUID]
+	return chara, ok
+}
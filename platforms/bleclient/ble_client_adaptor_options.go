@@ -13,6 +13,15 @@ type debugOption bool
 // scanTimeoutOption is the type for applying another timeout than the default 10 min.
 type scanTimeoutOption time.Duration
 
+// supervisionIntervalOption is the type for applying another interval for the connection check than the default 1 s.
+type supervisionIntervalOption time.Duration
+
+// autoReconnectOption is the type for switching on the automatic reconnect with the given backoff intervals.
+type autoReconnectOption struct {
+	minInterval time.Duration
+	maxInterval time.Duration
+}
+
 func (o debugOption) String() string {
 	return "debug option for BLE client adaptors"
 }
@@ -21,6 +30,14 @@ func (o scanTimeoutOption) String() string {
 	return "scan timeout option for BLE client adaptors"
 }
 
+func (o supervisionIntervalOption) String() string {
+	return "connection supervision interval option for BLE client adaptors"
+}
+
+func (o autoReconnectOption) String() string {
+	return "auto reconnect option for BLE client adaptors"
+}
+
 func (o debugOption) apply(cfg *configuration) {
 	cfg.debug = bool(o)
 }
@@ -28,3 +45,13 @@ func (o debugOption) apply(cfg *configuration) {
 func (o scanTimeoutOption) apply(cfg *configuration) {
 	cfg.scanTimeout = time.Duration(o)
 }
+
+func (o supervisionIntervalOption) apply(cfg *configuration) {
+	cfg.supervisionInterval = time.Duration(o)
+}
+
+func (o autoReconnectOption) apply(cfg *configuration) {
+	cfg.autoReconnect = true
+	cfg.minReconnectInterval = o.minInterval
+	cfg.maxReconnectInterval = o.maxInterval
+}
@@ -25,3 +25,24 @@ func TestWithScanTimeout(t *testing.T) {
 	// assert
 	assert.Equal(t, newTimeout, cfg.scanTimeout)
 }
+
+func TestWithSupervisionInterval(t *testing.T) {
+	// arrange
+	newInterval := 100 * time.Millisecond
+	cfg := &configuration{supervisionInterval: time.Second}
+	// act
+	WithSupervisionInterval(newInterval).apply(cfg)
+	// assert
+	assert.Equal(t, newInterval, cfg.supervisionInterval)
+}
+
+func TestWithAutoReconnect(t *testing.T) {
+	// arrange
+	cfg := &configuration{}
+	// act
+	WithAutoReconnect(2*time.Second, time.Minute).apply(cfg)
+	// assert
+	assert.True(t, cfg.autoReconnect)
+	assert.Equal(t, 2*time.Second, cfg.minReconnectInterval)
+	assert.Equal(t, time.Minute, cfg.maxReconnectInterval)
+}
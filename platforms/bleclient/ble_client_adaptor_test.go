@@ -12,8 +12,9 @@ import (
 )
 
 var (
-	_ gobot.Adaptor      = (*Adaptor)(nil)
-	_ gobot.BLEConnector = (*Adaptor)(nil)
+	_ gobot.Adaptor        = (*Adaptor)(nil)
+	_ gobot.BLEConnector   = (*Adaptor)(nil)
+	_ gobot.BLEMTUProvider = (*Adaptor)(nil)
 )
 
 func TestNewAdaptor(t *testing.T) {
@@ -162,6 +163,16 @@ func TestConnect(t *testing.T) {
 	}
 }
 
+func TestMTU(t *testing.T) {
+	// arrange
+	a := NewAdaptor("")
+	// act & assert
+	assert.Equal(t, 23, a.MTU())
+	a.btDevice = newBtDevice(&btTestDevice{}, "", "")
+	a.connected = true
+	assert.Equal(t, 247, a.MTU())
+}
+
 func TestReconnect(t *testing.T) {
 	const (
 		scanTimeout   = 5 * time.Millisecond
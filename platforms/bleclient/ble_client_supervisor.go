@@ -0,0 +1,195 @@
+package bleclient
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// startSupervisor starts the cyclic check of the connection state of the current device, if not switched off
+func (a *Adaptor) startSupervisor() {
+	if a.cfg.supervisionInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	a.stateMutex.Lock()
+	if a.cancelSupervisor != nil {
+		a.cancelSupervisor()
+	}
+	a.cancelSupervisor = cancel
+	dev := a.btDevice
+	a.stateMutex.Unlock()
+
+	go a.supervise(ctx, dev)
+}
+
+// stopSupervisor stops the supervision and any reconnect attempts
+func (a *Adaptor) stopSupervisor() {
+	a.stateMutex.Lock()
+	defer a.stateMutex.Unlock()
+
+	if a.cancelSupervisor != nil {
+		a.cancelSupervisor()
+		a.cancelSupervisor = nil
+	}
+}
+
+// supervise checks the connection state of the device until the context is cancelled. A lost connection is
+// re-established, if auto reconnect is switched on, otherwise the supervision ends.
+func (a *Adaptor) supervise(ctx context.Context, dev *btDevice) {
+	ticker := time.NewTicker(a.cfg.supervisionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if dev.connected() {
+			continue
+		}
+
+		if !a.connectionLost(ctx, dev) || !a.cfg.autoReconnect {
+			return
+		}
+
+		var ok bool
+		if dev, ok = a.reconnect(ctx); !ok {
+			return
+		}
+	}
+}
+
+// connectionLost marks the adaptor as disconnected, releases the lost connection and publishes the
+// ConnectionLostEvent. It returns false, if the adaptor is disconnected meanwhile.
+func (a *Adaptor) connectionLost(ctx context.Context, dev *btDevice) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if a.cfg.debug {
+		fmt.Printf("[Supervise]: connection to %s lost\n", dev.address())
+	}
+
+	a.setConnected(false)
+	_ = dev.disconnect(context.Background())
+	a.Eventer.Publish(ConnectionLostEvent, fmt.Errorf("%w: %s", ErrConnectionLost, dev.address()))
+
+	return true
+}
+
+// reconnect tries to re-establish the connection with an exponential backoff until it succeeds or the context is
+// cancelled. The new device is returned on success.
+func (a *Adaptor) reconnect(ctx context.Context) (*btDevice, bool) {
+	interval := a.cfg.minReconnectInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(interval):
+		}
+
+		a.Eventer.Publish(ReconnectingEvent, nil)
+
+		dev, err := a.reconnectAttempt(ctx)
+		if err == nil {
+			a.Eventer.Publish(ConnectedEvent, nil)
+			return dev, true
+		}
+		if ctx.Err() != nil {
+			return nil, false
+		}
+
+		if a.cfg.debug {
+			fmt.Printf("[Reconnect]: attempt failed: %v\n", err)
+		}
+
+		interval = min(2*interval, a.cfg.maxReconnectInterval)
+	}
+}
+
+// reconnectAttempt connects to the device, restores the subscriptions and calls the reconnect handlers. On error
+// the connection is released again.
+func (a *Adaptor) reconnectAttempt(ctx context.Context) (*btDevice, error) {
+	a.mutex.Lock()
+	if err := ctx.Err(); err != nil {
+		a.mutex.Unlock()
+		return nil, err
+	}
+
+	err := a.connect(ctx)
+	if err == nil {
+		err = a.restoreSubscriptions(ctx)
+	}
+	a.mutex.Unlock()
+
+	if err == nil {
+		// the handlers are called without lock, because they usually use the adaptor
+		for _, handler := range a.reconnectHandlers() {
+			if err = handler(); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		a.releaseConnection()
+		return nil, err
+	}
+
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
+	return a.btDevice, nil
+}
+
+// restoreSubscriptions subscribes again to all characteristics of previous subscriptions
+func (a *Adaptor) restoreSubscriptions(ctx context.Context) error {
+	a.stateMutex.RLock()
+	subscriptions := maps.Clone(a.subscriptions)
+	a.stateMutex.RUnlock()
+
+	for cUUID, f := range subscriptions {
+		chara, ok := a.characteristic(cUUID)
+		if !ok {
+			return fmt.Errorf("can't restore subscription, unknown characteristic: %s", cUUID)
+		}
+
+		if err := enableNotificationsForCharacteristic(ctx, chara, f); err != nil {
+			return fmt.Errorf("can't restore subscription of characteristic %s: %w", cUUID, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Adaptor) reconnectHandlers() []func() error {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
+	return slices.Clone(a.reconnected)
+}
+
+// releaseConnection disconnects from the device after a failed reconnect attempt
+func (a *Adaptor) releaseConnection() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.stateMutex.Lock()
+	a.connected = false
+	dev := a.btDevice
+	a.stateMutex.Unlock()
+
+	if dev != nil {
+		_ = dev.disconnect(context.Background())
+	}
+}
@@ -0,0 +1,118 @@
+package bleclient
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+)
+
+var _ gobot.BLEReconnectNotifier = (*Adaptor)(nil)
+
+func initTestSupervisedAdaptor(conns *btTestConnections, opts ...optionApplier) *Adaptor {
+	const deviceAddress = "11:22:44:AA:BB:CC"
+	opts = append([]optionApplier{WithSupervisionInterval(time.Millisecond)}, opts...)
+	a := NewAdaptor(deviceAddress, opts...)
+	a.btAdptCreator = func(bluetoothExtAdapterer, bool) *btAdapter {
+		extAdapter := &btTestAdapter{deviceAddress: deviceAddress, payload: &btTestPayload{name: "hello"}}
+		return &btAdapter{extAdapter: extAdapter, btDeviceCreator: conns.newDevice}
+	}
+	a.cfg.scanTimeout = time.Second
+	a.cfg.sleepAfterDisconnect = 0 // to speed up test
+	a.cfg.testMode = true          // Enable test mode to skip real bluetooth
+	return a
+}
+
+func TestSupervisorReconnect(t *testing.T) {
+	const uuid = "00002a19-0000-1000-8000-00805f9b34fb"
+	// arrange
+	conns := &btTestConnections{charaUUID: uuid}
+	a := initTestSupervisedAdaptor(conns, WithAutoReconnect(time.Millisecond, 4*time.Millisecond))
+	events := a.Eventer.Subscribe()
+	defer a.Eventer.Unsubscribe(events)
+	require.NoError(t, a.Connect())
+	waitForEvent(t, events, ConnectedEvent)
+
+	received := make(chan []byte, 1)
+	require.NoError(t, a.Subscribe(uuid, func(data []byte) { received <- data }))
+	var handlerCalls int
+	a.OnReconnect(func() error {
+		handlerCalls++
+		if handlerCalls == 1 {
+			return fmt.Errorf("reinitialization error")
+		}
+		return nil
+	})
+	lostDevice, _ := conns.last()
+	// act
+	lostDevice.lost.Store(true)
+	// assert
+	evt := waitForEvent(t, events, ConnectionLostEvent)
+	require.ErrorIs(t, evt.Data.(error), ErrConnectionLost)
+	waitForEvent(t, events, ReconnectingEvent)
+	waitForEvent(t, events, ReconnectingEvent) // the first attempt fails by the handler
+	waitForEvent(t, events, ConnectedEvent)
+	assert.True(t, a.isConnected())
+	assert.Equal(t, 2, handlerCalls)
+	assert.Equal(t, 3, conns.count())
+	_, chara := conns.last()
+	require.NotNil(t, chara.notificationFunc)
+	chara.notificationFunc([]byte{42})
+	assert.Equal(t, []byte{42}, <-received)
+	// act & assert: disconnect stops the supervision of the new device
+	require.NoError(t, a.Disconnect())
+	waitForEvent(t, events, DisconnectedEvent)
+	assert.False(t, a.isConnected())
+}
+
+func TestSupervisorWithoutAutoReconnect(t *testing.T) {
+	// arrange
+	conns := &btTestConnections{charaUUID: "2a19"}
+	a := initTestSupervisedAdaptor(conns)
+	events := a.Eventer.Subscribe()
+	defer a.Eventer.Unsubscribe(events)
+	require.NoError(t, a.Connect())
+	waitForEvent(t, events, ConnectedEvent)
+	lostDevice, _ := conns.last()
+	// act
+	lostDevice.lost.Store(true)
+	// assert
+	waitForEvent(t, events, ConnectionLostEvent)
+	assert.False(t, a.isConnected())
+	require.ErrorContains(t, a.WriteCharacteristic("2a19", []byte{1}), "cannot write to BLE device until connected")
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 1, conns.count())
+	// act & assert: a manual reconnect restarts the supervision
+	require.NoError(t, a.Reconnect())
+	waitForEvent(t, events, ConnectedEvent)
+	assert.Equal(t, 2, conns.count())
+	require.NoError(t, a.Disconnect())
+	waitForEvent(t, events, DisconnectedEvent)
+}
+
+func TestSupervisorDisconnectStopsSupervision(t *testing.T) {
+	// arrange
+	conns := &btTestConnections{charaUUID: "2a19"}
+	a := initTestSupervisedAdaptor(conns, WithAutoReconnect(time.Millisecond, time.Millisecond))
+	events := a.Eventer.Subscribe()
+	defer a.Eventer.Unsubscribe(events)
+	require.NoError(t, a.Connect())
+	waitForEvent(t, events, ConnectedEvent)
+	// act
+	require.NoError(t, a.Disconnect())
+	dev, _ := conns.last()
+	dev.lost.Store(true)
+	// assert
+	waitForEvent(t, events, DisconnectedEvent)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 1, conns.count())
+	select {
+	case evt := <-events:
+		t.Fatalf("unexpected event '%s'", evt.Name)
+	default:
+	}
+}
@@ -17,6 +17,7 @@ type bluetoothExtDevicer interface {
 	Connected() bool
 	Address() bluetooth.Address
 	Name() string
+	GetMTU() uint16
 }
 
 // bluetoothExtAdapterer is the interface usually implemented by bluetooth.Central
@@ -139,6 +140,10 @@ func (btd *btDevice) name() string { return btd.devName }
 
 func (btd *btDevice) address() string { return btd.devAddress }
 
+func (btd *btDevice) mtu() uint16 { return btd.extDevice.GetMTU() }
+
+func (btd *btDevice) connected() bool { return btd.extDevice.Connected() }
+
 func (btd *btDevice) discoverServices(ctx context.Context, uuids []bluetooth.UUID) ([]bluetooth.Service, error) {
 	err := btd.extDevice.DiscoverServices(ctx, uuids)
 	if err != nil {
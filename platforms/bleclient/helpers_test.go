@@ -3,11 +3,68 @@ package bleclient
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
 	"time"
 
+	"gobot.io/x/gobot/v2"
 	"gobot.io/x/gobot/v2/bluetooth"
 )
 
+// btTestConnections creates a new device with one notifying characteristic for each connect
+type btTestConnections struct {
+	charaUUID string
+	mutex     sync.Mutex
+	devices   []*btTestDevice
+	charas    []*btTestChara
+}
+
+func (btc *btTestConnections) newDevice(_ bluetoothExtDevicer, address, name string) *btDevice {
+	u, err := bluetooth.NewUUID(btc.charaUUID)
+	if err != nil {
+		panic(err)
+	}
+	chara := &btTestChara{uuid: u}
+	dev := &btTestDevice{
+		services: []bluetooth.Service{&btTestService{characteristics: []bluetooth.Characteristic{chara}}},
+	}
+
+	btc.mutex.Lock()
+	defer btc.mutex.Unlock()
+	btc.devices = append(btc.devices, dev)
+	btc.charas = append(btc.charas, chara)
+
+	return newBtDevice(dev, address, name)
+}
+
+func (btc *btTestConnections) count() int {
+	btc.mutex.Lock()
+	defer btc.mutex.Unlock()
+	return len(btc.devices)
+}
+
+func (btc *btTestConnections) last() (*btTestDevice, *btTestChara) {
+	btc.mutex.Lock()
+	defer btc.mutex.Unlock()
+	return btc.devices[len(btc.devices)-1], btc.charas[len(btc.charas)-1]
+}
+
+func waitForEvent(t *testing.T, events chan *gobot.Event, name string) *gobot.Event {
+	t.Helper()
+
+	select {
+	case evt := <-events:
+		if evt.Name != name {
+			t.Fatalf("event '%s' published instead of '%s'", evt.Name, name)
+		}
+		return evt
+	case <-time.After(2 * time.Second):
+		t.Fatalf("event '%s' not published", name)
+		return nil
+	}
+}
+
 type btTestAdapter struct {
 	deviceAddress       string
 	rssi                int16
@@ -97,6 +154,7 @@ type btTestDevice struct {
 	simulateDiscoverServicesErr bool
 	simulateDisconnectErr       bool
 	services                    []bluetooth.Service
+	lost                        atomic.Bool
 }
 
 func (btd *btTestDevice) DiscoverServices(ctx context.Context, uuids []bluetooth.UUID) error {
@@ -127,7 +185,7 @@ func (btd *btTestDevice) Disconnect(ctx context.Context) error {
 }
 
 func (btd *btTestDevice) Connected() bool {
-	return true
+	return !btd.lost.Load()
 }
 
 func (btd *btTestDevice) Address() bluetooth.Address {
@@ -150,6 +208,26 @@ func (btd *btTestDevice) GetMTU() uint16 {
 	return 247
 }
 
+type btTestService struct {
+	uuid            bluetooth.UUID
+	characteristics []bluetooth.Characteristic
+}
+
+func (bts *btTestService) UUID() bluetooth.UUID { return bts.uuid }
+
+func (bts *btTestService) Primary() bool { return true }
+
+func (bts *btTestService) Characteristics() []bluetooth.Characteristic { return bts.characteristics }
+
+func (bts *btTestService) GetCharacteristic(uuid bluetooth.UUID) (bluetooth.Characteristic, error) {
+	for _, chara := range bts.characteristics {
+		if chara.UUID() == uuid {
+			return chara, nil
+		}
+	}
+	return nil, fmt.Errorf("characteristic not found")
+}
+
 type btTestChara struct {
 	readData         []byte
 	writtenData      []byte
@@ -184,3 +262,11 @@ func (btc *btTestChara) Unsubscribe(ctx context.Context) error {
 func (btc *btTestChara) UUID() bluetooth.UUID {
 	return btc.uuid
 }
+
+func (btc *btTestChara) Properties() bluetooth.CharacteristicProperty {
+	return bluetooth.CharacteristicNotify
+}
+
+func (btc *btTestChara) Descriptors() []bluetooth.Descriptor {
+	return nil
+}
@@ -0,0 +1,338 @@
+package mqtt
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+)
+
+const (
+	testPacketConnect     = 0x01
+	testPacketPublish     = 0x03
+	testPacketPubAck      = 0x04
+	testPacketPubRec      = 0x05
+	testPacketPubRel      = 0x06
+	testPacketPubComp     = 0x07
+	testPacketSubscribe   = 0x08
+	testPacketUnsubscribe = 0x0A
+	testPacketPingReq     = 0x0C
+	testPacketDisconnect  = 0x0E
+)
+
+type testPacket struct {
+	header  byte
+	payload []byte
+}
+
+func (p testPacket) packetType() byte { return p.header >> 4 }
+
+// testBroker is a minimal in-process stand-in for an MQTT broker. It acknowledges the packets of the client and
+// forwards every published message to all connected clients, so the topic matching is left to the client. The
+// acknowledges are sent in the format of the protocol level, which the client has requested by CONNECT.
+type testBroker struct {
+	t              *testing.T
+	listener       net.Listener
+	mtx            sync.Mutex
+	conns          []net.Conn
+	received       chan testPacket
+	sessionPresent atomic.Bool
+	connackCode    atomic.Uint32
+	connackProps   atomic.Pointer[Properties]
+	pubackCode     atomic.Uint32
+	subackCode     atomic.Uint32
+	ignorePings    atomic.Bool
+	ignorePublish  atomic.Bool
+
+	ignoreUnsubscribe atomic.Bool
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("test broker can not listen: %v", err)
+	}
+
+	b := &testBroker{
+		t:        t,
+		listener: listener,
+		received: make(chan testPacket, 100),
+	}
+	go b.serve()
+	t.Cleanup(b.close)
+
+	return b
+}
+
+func (b *testBroker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *testBroker) close() {
+	_ = b.listener.Close()
+	b.dropConnections()
+}
+
+// dropConnections closes all client connections without a DISCONNECT, like a crashed broker
+func (b *testBroker) dropConnections() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	for _, conn := range b.conns {
+		_ = conn.Close()
+	}
+	b.conns = nil
+}
+
+func (b *testBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		b.mtx.Lock()
+		b.conns = append(b.conns, conn)
+		b.mtx.Unlock()
+
+		go b.handle(conn)
+	}
+}
+
+func (b *testBroker) handle(conn net.Conn) {
+	var protocolVersion byte
+	for {
+		pkt, err := readTestPacket(conn)
+		if err != nil {
+			return
+		}
+
+		select {
+		case b.received <- pkt:
+		default:
+		}
+
+		var reply []byte
+		switch pkt.packetType() {
+		case testPacketConnect:
+			var flags byte
+			if b.sessionPresent.Load() {
+				flags = 0x01
+			}
+			protocolVersion = pkt.payload[6]
+			buf := []byte{flags, byte(b.connackCode.Load())}
+			if protocolVersion == ProtocolVersion5 {
+				buf = append(buf, b.connackProps.Load().encode()...)
+			}
+			reply = append([]byte{0x20}, encodeLength(len(buf))...)
+			reply = append(reply, buf...)
+		case testPacketPublish:
+			if qos := (pkt.header >> 1) & 0x03; qos > 0 && !b.ignorePublish.Load() {
+				topicLen := int(pkt.payload[0])<<8 | int(pkt.payload[1])
+				id := pkt.payload[2+topicLen : 4+topicLen]
+				reply = []byte{0x40, 0x02, id[0], id[1]}
+				if code := byte(b.pubackCode.Load()); code != 0 && protocolVersion == ProtocolVersion5 {
+					reply = []byte{0x40, 0x03, id[0], id[1], code}
+				}
+				if qos == 2 {
+					reply[0] = 0x50
+				}
+			}
+		case testPacketPubRel:
+			reply = []byte{0x70, 0x02, pkt.payload[0], pkt.payload[1]}
+		case testPacketSubscribe:
+			reply = []byte{0x90, 0x03, pkt.payload[0], pkt.payload[1], byte(b.subackCode.Load())}
+			if protocolVersion == ProtocolVersion5 {
+				reply = []byte{0x90, 0x04, pkt.payload[0], pkt.payload[1], 0x00, byte(b.subackCode.Load())}
+			}
+		case testPacketUnsubscribe:
+			if b.ignoreUnsubscribe.Load() {
+				break
+			}
+			reply = []byte{0xB0, 0x02, pkt.payload[0], pkt.payload[1]}
+			if protocolVersion == ProtocolVersion5 {
+				reply = []byte{0xB0, 0x04, pkt.payload[0], pkt.payload[1], 0x00, 0x00}
+			}
+		case testPacketPingReq:
+			if !b.ignorePings.Load() {
+				reply = []byte{0xD0, 0x00}
+			}
+		case testPacketDisconnect:
+			_ = conn.Close()
+			return
+		}
+
+		if reply != nil {
+			if _, err := conn.Write(reply); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// publish sends a QoS 0 PUBLISH packet to all connected clients
+func (b *testBroker) publish(topic string, payload []byte) {
+	b.t.Helper()
+	b.publishWithQoS(topic, 0, 0, false, payload)
+}
+
+// publishWithQoS sends a PUBLISH packet with the given QoS, packet ID and DUP flag to all connected clients
+func (b *testBroker) publishWithQoS(topic string, qos byte, packetID uint16, dup bool, payload []byte) {
+	b.t.Helper()
+
+	var buf []byte
+	buf = append(buf, byte(len(topic)>>8), byte(len(topic)))
+	buf = append(buf, []byte(topic)...)
+	if qos > 0 {
+		buf = append(buf, byte(packetID>>8), byte(packetID))
+	}
+	buf = append(buf, payload...)
+
+	header := 0x30 | qos<<1
+	if dup {
+		header |= 0x08
+	}
+	packet := []byte{header}
+	packet = append(packet, encodeLength(len(buf))...)
+	packet = append(packet, buf...)
+
+	b.send(packet)
+}
+
+// publishWithProperties sends a QoS 0 PUBLISH packet with MQTT 5 properties to all connected clients
+func (b *testBroker) publishWithProperties(topic string, props *Properties, payload []byte) {
+	b.t.Helper()
+
+	var buf []byte
+	buf = append(buf, byte(len(topic)>>8), byte(len(topic)))
+	buf = append(buf, []byte(topic)...)
+	buf = append(buf, props.encode()...)
+	buf = append(buf, payload...)
+
+	packet := []byte{0x30}
+	packet = append(packet, encodeLength(len(buf))...)
+	packet = append(packet, buf...)
+
+	b.send(packet)
+}
+
+// send writes the raw packet to all connected clients
+func (b *testBroker) send(packet []byte) {
+	b.t.Helper()
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	for _, conn := range b.conns {
+		if _, err := conn.Write(packet); err != nil {
+			b.t.Errorf("test broker can not publish: %v", err)
+		}
+	}
+}
+
+// waitForPacket waits until a packet of the given type was received from a client
+func (b *testBroker) waitForPacket(packetType byte) testPacket {
+	b.t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case pkt := <-b.received:
+			if pkt.packetType() == packetType {
+				return pkt
+			}
+		case <-timeout:
+			b.t.Fatalf("test broker has not received packet type %d", packetType)
+		}
+	}
+}
+
+func readTestPacket(r io.Reader) (testPacket, error) {
+	header := make([]byte, 1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return testPacket{}, err
+	}
+
+	length := 0
+	multiplier := 1
+	for {
+		b := make([]byte, 1)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return testPacket{}, err
+		}
+		length += int(b[0]&127) * multiplier
+		if b[0]&128 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return testPacket{}, err
+	}
+
+	return testPacket{header: header[0], payload: payload}, nil
+}
+
+// readTestPublish returns topic, properties and payload of a PUBLISH packet with QoS 0
+func readTestPublish(t *testing.T, pkt testPacket, protocolVersion uint) (string, *Properties, []byte) {
+	t.Helper()
+
+	topicLen := int(pkt.payload[0])<<8 | int(pkt.payload[1])
+	topic := string(pkt.payload[2 : 2+topicLen])
+	offset := 2 + topicLen
+	props := &Properties{}
+	if protocolVersion == ProtocolVersion5 {
+		var n int
+		var err error
+		props, n, err = decodeProperties(pkt.payload[offset:])
+		require.NoError(t, err)
+		offset += n
+	}
+	return topic, props, pkt.payload[offset:]
+}
+
+// waitForEvent waits until the event with the given name was published
+func waitForEvent(t *testing.T, events chan *gobot.Event, name string) *gobot.Event {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case evt := <-events:
+			if evt.Name == name {
+				return evt
+			}
+		case <-timeout:
+			t.Fatalf("event '%s' not published", name)
+			return nil
+		}
+	}
+}
+
+// testEventDevice is a device, which publishes events
+type testEventDevice struct {
+	name string
+	gobot.Eventer
+}
+
+func newTestEventDevice(name string, events ...string) *testEventDevice {
+	d := &testEventDevice{name: name, Eventer: gobot.NewEventer()}
+	for _, event := range events {
+		d.AddEvent(event)
+	}
+	return d
+}
+
+func (d *testEventDevice) Name() string                 { return d.name }
+func (d *testEventDevice) SetName(name string)          { d.name = name }
+func (d *testEventDevice) Start() error                 { return nil }
+func (d *testEventDevice) Halt() error                  { return nil }
+func (d *testEventDevice) Connection() gobot.Connection { return nil }
@@ -7,21 +7,78 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"gobot.io/x/gobot/v2"
 )
 
+const (
+	// ConnectedEvent is published by the Adaptor each time the connection to the broker is (re-)established
+	ConnectedEvent = "connected"
+
+	// ConnectionLostEvent is published by the Adaptor when the connection to the broker is lost unexpectedly,
+	// the event data contains the error
+	ConnectionLostEvent = "connection-lost"
+
+	// ReconnectingEvent is published by the Adaptor before each attempt to re-establish a lost connection
+	ReconnectingEvent = "reconnecting"
+
+	// DisconnectedEvent is published by the Adaptor when the connection was closed by Disconnect
+	DisconnectedEvent = "disconnected"
+)
+
+const (
+	defaultKeepAlive            = 60 * time.Second
+	defaultPingTimeout          = 10 * time.Second
+	defaultConnectTimeout       = 30 * time.Second
+	defaultWriteTimeout         = 10 * time.Second
+	defaultMinReconnectInterval = 1 * time.Second
+	defaultMaxReconnectInterval = 10 * time.Minute
+
+	// maxKeepAlive is the largest keep alive, which fits into the 16 bit field of the CONNECT packet
+	maxKeepAlive = 65535 * time.Second
+	// minSuperviseInterval limits the checks of the keep alive and the ping timeout for tiny values
+	minSuperviseInterval = time.Millisecond
+)
+
 // ErrNilClient is returned when a client action can't be taken because the struct has no client
 var ErrNilClient = fmt.Errorf("no MQTT client available")
 
-// Token represents an async operation result
+// ErrConnectionRefused is returned when the broker answers the CONNECT packet with a non zero return code
+var ErrConnectionRefused = fmt.Errorf("MQTT connection refused")
+
+// ErrPingTimeout is returned when the broker does not answer a PINGREQ in time
+var ErrPingTimeout = fmt.Errorf("MQTT ping response not received in time")
+
+// ErrTimeout is returned when the broker does not acknowledge an operation within the write timeout
+var ErrTimeout = fmt.Errorf("MQTT operation not acknowledged in time")
+
+// ErrDisconnected is returned by the token of a QoS 1 or QoS 2 publish, which was not acknowledged before the
+// client was disconnected
+var ErrDisconnected = fmt.Errorf("MQTT client disconnected before delivery was completed")
+
+// connackReturnCodes contains the MQTT 3.1.1 reasons for a refused connection
+var connackReturnCodes = map[byte]string{
+	0x01: "unacceptable protocol version",
+	0x02: "identifier rejected",
+	0x03: "server unavailable",
+	0x04: "bad user name or password",
+	0x05: "not authorized",
+}
+
+// Token represents an async operation result. For QoS 1 and QoS 2 publishing the token completes, when the
+// delivery was acknowledged by the broker.
 type Token interface {
 	Wait() bool
+	WaitTimeout(timeout time.Duration) bool
 	Error() error
 }
 
@@ -42,8 +99,26 @@ func (t *token) Wait() bool {
 	return t.err == nil
 }
 
+// WaitTimeout returns false if the timeout elapsed or the operation failed
+func (t *token) WaitTimeout(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-t.done:
+		return t.err == nil
+	case <-timer.C:
+		return false
+	}
+}
+
 func (t *token) Error() error {
-	return t.err
+	select {
+	case <-t.done:
+		return t.err
+	default:
+		return nil
+	}
 }
 
 func (t *token) complete(err error) {
@@ -57,61 +132,129 @@ type Message interface {
 	Payload() []byte
 	Qos() byte
 	Retained() bool
+	// Properties returns the MQTT 5 properties of the message, which are empty for MQTT 3.1.1
+	Properties() *Properties
 }
 
 // message implements Message interface
 type message struct {
-	topic    string
-	payload  []byte
-	qos      byte
-	retained bool
+	topic      string
+	payload    []byte
+	qos        byte
+	retained   bool
+	properties Properties
 }
 
-func (m *message) Topic() string   { return m.topic }
-func (m *message) Payload() []byte { return m.payload }
-func (m *message) Qos() byte       { return m.qos }
-func (m *message) Retained() bool  { return m.retained }
+func (m *message) Topic() string           { return m.topic }
+func (m *message) Payload() []byte         { return m.payload }
+func (m *message) Qos() byte               { return m.qos }
+func (m *message) Retained() bool          { return m.retained }
+func (m *message) Properties() *Properties { return &m.properties }
 
 // Client represents an MQTT client
 type Client interface {
 	Connect() Token
 	Disconnect(quiesce uint)
+	IsConnected() bool
 	Publish(topic string, qos byte, retained bool, payload any) Token
+	PublishWithProperties(topic string, qos byte, retained bool, payload any, props *Properties) Token
 	Subscribe(topic string, qos byte, callback func(Client, Message)) Token
+	Unsubscribe(topics ...string) Token
+}
+
+// will is the Last Will and Testament, which the broker publishes when the client disconnects unexpectedly
+type will struct {
+	topic      string
+	payload    []byte
+	qos        byte
+	retained   bool
+	properties *Properties
+}
+
+// subscription holds a registered topic filter, which is restored after a reconnect
+type subscription struct {
+	qos      byte
+	callback func(Client, Message)
 }
 
 // client implements Client interface using Go's standard library
 type client struct {
-	conn        net.Conn
-	clientID    string
-	username    string
-	password    string
-	host        string
-	tlsConfig   *tls.Config
-	connected   bool
-	mu          sync.RWMutex
-	subscribers map[string]func(Client, Message)
-	ctx         context.Context
-	cancel      context.CancelFunc
-	packetID    uint16
+	conn                 net.Conn
+	connCancel           context.CancelFunc
+	clientID             string
+	username             string
+	password             string
+	host                 string
+	tlsConfig            *tls.Config
+	will                 *will
+	protocolVersion      uint
+	sessionExpiry        time.Duration
+	topicAliasMaximum    uint16
+	keepAlive            time.Duration
+	pingTimeout          time.Duration
+	connectTimeout       time.Duration
+	autoReconnect        bool
+	cleanSession         bool
+	minReconnectInterval time.Duration
+	maxReconnectInterval time.Duration
+	onConnect            func(Client)
+	onConnectionLost     func(Client, error)
+	onReconnecting       func(Client)
+	store                Store
+	storeOpened          bool
+	inflight             map[uint16]*token // outbound QoS 1 and QoS 2 messages by packet ID
+	acks                 map[uint16]*token // outstanding SUBSCRIBE and UNSUBSCRIBE by packet ID
+	outAliasMaximum      uint16            // topic alias maximum of the broker for the current connection
+	outAliases           map[string]uint16 // topic aliases of the current connection for publishing
+	publishMu            *sync.Mutex       // serializes the alias assignment and the write of the current connection
+	inAliases            map[uint16]string // topic aliases of the current connection set by the broker
+	connected            bool
+	reconnecting         bool
+	mu                   sync.RWMutex
+	subscribers          map[string]subscription
+	ctx                  context.Context
+	cancel               context.CancelFunc
+	packetID             uint16
+	lastSent             atomic.Int64 // unix nano timestamp of the last packet sent
+	pingSent             atomic.Int64 // unix nano timestamp of the outstanding PINGREQ, 0 if none
 }
 
 // ClientOptions represents MQTT client options
 type ClientOptions struct {
-	brokers       []string
-	clientID      string
-	username      string
-	password      string
-	tlsConfig     *tls.Config
-	autoReconnect bool
-	cleanSession  bool
+	brokers              []string
+	clientID             string
+	username             string
+	password             string
+	tlsConfig            *tls.Config
+	will                 *will
+	willProperties       *Properties
+	protocolVersion      uint
+	sessionExpiry        time.Duration
+	topicAliasMaximum    uint16
+	keepAlive            time.Duration
+	pingTimeout          time.Duration
+	connectTimeout       time.Duration
+	autoReconnect        bool
+	cleanSession         bool
+	minReconnectInterval time.Duration
+	maxReconnectInterval time.Duration
+	onConnect            func(Client)
+	onConnectionLost     func(Client, error)
+	onReconnecting       func(Client)
+	store                Store
 }
 
 // NewClientOptions creates new client options
 func NewClientOptions() *ClientOptions {
 	return &ClientOptions{
-		autoReconnect: true,
-		cleanSession:  true,
+		protocolVersion:      ProtocolVersion311,
+		keepAlive:            defaultKeepAlive,
+		pingTimeout:          defaultPingTimeout,
+		connectTimeout:       defaultConnectTimeout,
+		autoReconnect:        true,
+		cleanSession:         true,
+		minReconnectInterval: defaultMinReconnectInterval,
+		maxReconnectInterval: defaultMaxReconnectInterval,
 	}
 }
 
@@ -140,18 +283,136 @@ func (o *ClientOptions) SetTLSConfig(config *tls.Config) {
 	o.tlsConfig = config
 }
 
+// SetWill sets the Last Will and Testament, which is published by the broker to the given topic, when the
+// connection is lost without a prior Disconnect
+func (o *ClientOptions) SetWill(topic string, payload []byte, qos byte, retained bool) {
+	o.will = &will{topic: topic, payload: payload, qos: qos, retained: retained}
+}
+
+// SetWillProperties sets the MQTT 5 properties of the Last Will and Testament, e.g. the will delay interval. The
+// properties are used for the will of SetWill, the order of both calls does not matter.
+func (o *ClientOptions) SetWillProperties(props *Properties) {
+	o.willProperties = props
+}
+
+// SetProtocolVersion sets the MQTT protocol level, ProtocolVersion311 (default) or ProtocolVersion5
+func (o *ClientOptions) SetProtocolVersion(version uint) {
+	o.protocolVersion = version
+}
+
+// SetSessionExpiryInterval sets the time the broker keeps the session after the connection is closed (MQTT 5 only)
+func (o *ClientOptions) SetSessionExpiryInterval(interval time.Duration) {
+	o.sessionExpiry = interval
+}
+
+// SetTopicAliasMaximum sets the number of topic aliases the broker is allowed to use for messages sent to the
+// client (MQTT 5 only). Zero (default) disables topic aliases in this direction.
+func (o *ClientOptions) SetTopicAliasMaximum(maximum uint16) {
+	o.topicAliasMaximum = maximum
+}
+
+// SetKeepAlive sets the maximum idle time, after which a PINGREQ is sent to the broker. Zero disables the keep
+// alive mechanism, values above 65535 s are limited to this maximum of the protocol.
+func (o *ClientOptions) SetKeepAlive(keepAlive time.Duration) {
+	o.keepAlive = min(keepAlive, maxKeepAlive)
+}
+
+// SetPingTimeout sets the time to wait for a PINGRESP before the connection is considered to be lost. A timeout
+// of zero or below means the default of 10 s.
+func (o *ClientOptions) SetPingTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultPingTimeout
+	}
+	o.pingTimeout = timeout
+}
+
+// SetConnectTimeout sets the time to wait for the CONNACK of the broker
+func (o *ClientOptions) SetConnectTimeout(timeout time.Duration) {
+	o.connectTimeout = timeout
+}
+
+// SetAutoReconnect sets whether a lost connection is re-established automatically
+func (o *ClientOptions) SetAutoReconnect(autoReconnect bool) {
+	o.autoReconnect = autoReconnect
+}
+
+// SetCleanSession sets whether the broker should discard the session state (subscriptions, queued messages)
+// of a previous connection with the same client ID
+func (o *ClientOptions) SetCleanSession(cleanSession bool) {
+	o.cleanSession = cleanSession
+}
+
+// SetMinReconnectInterval sets the wait time before the first reconnect attempt, which is doubled for each
+// further attempt
+func (o *ClientOptions) SetMinReconnectInterval(interval time.Duration) {
+	o.minReconnectInterval = interval
+}
+
+// SetMaxReconnectInterval sets the upper limit of the wait time between reconnect attempts
+func (o *ClientOptions) SetMaxReconnectInterval(interval time.Duration) {
+	o.maxReconnectInterval = interval
+}
+
+// SetOnConnectHandler sets the function called after each successful (re-)connect
+func (o *ClientOptions) SetOnConnectHandler(handler func(Client)) {
+	o.onConnect = handler
+}
+
+// SetConnectionLostHandler sets the function called when the connection is lost unexpectedly
+func (o *ClientOptions) SetConnectionLostHandler(handler func(Client, error)) {
+	o.onConnectionLost = handler
+}
+
+// SetReconnectingHandler sets the function called before each reconnect attempt
+func (o *ClientOptions) SetReconnectingHandler(handler func(Client)) {
+	o.onReconnecting = handler
+}
+
+// SetStore sets the store for in-flight QoS 1 and QoS 2 packets, by default a memory store is used
+func (o *ClientOptions) SetStore(store Store) {
+	o.store = store
+}
+
 // NewClient creates a new MQTT client
 func NewClient(opts *ClientOptions) Client {
 	ctx, cancel := context.WithCancel(context.Background())
+	store := opts.store
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	var lastWill *will
+	if opts.will != nil {
+		w := *opts.will
+		w.properties = opts.willProperties
+		lastWill = &w
+	}
 	return &client{
-		clientID:    opts.clientID,
-		username:    opts.username,
-		password:    opts.password,
-		host:        opts.brokers[0], // Use first broker
-		tlsConfig:   opts.tlsConfig,
-		subscribers: make(map[string]func(Client, Message)),
-		ctx:         ctx,
-		cancel:      cancel,
+		clientID:             opts.clientID,
+		username:             opts.username,
+		password:             opts.password,
+		host:                 opts.brokers[0], // Use first broker
+		tlsConfig:            opts.tlsConfig,
+		will:                 lastWill,
+		protocolVersion:      opts.protocolVersion,
+		sessionExpiry:        opts.sessionExpiry,
+		topicAliasMaximum:    opts.topicAliasMaximum,
+		keepAlive:            opts.keepAlive,
+		pingTimeout:          opts.pingTimeout,
+		connectTimeout:       opts.connectTimeout,
+		autoReconnect:        opts.autoReconnect,
+		cleanSession:         opts.cleanSession,
+		minReconnectInterval: opts.minReconnectInterval,
+		maxReconnectInterval: opts.maxReconnectInterval,
+		onConnect:            opts.onConnect,
+		onConnectionLost:     opts.onConnectionLost,
+		onReconnecting:       opts.onReconnecting,
+		store:                store,
+		inflight:             make(map[uint16]*token),
+		acks:                 make(map[uint16]*token),
+		subscribers:          make(map[string]subscription),
+		publishMu:            &sync.Mutex{},
+		ctx:                  ctx,
+		cancel:               cancel,
 	}
 }
 
@@ -190,69 +451,455 @@ func (c *client) parseURL(brokerURL string) (network, address string, useTLS boo
 func (c *client) Connect() Token {
 	token := newToken()
 	go func() {
-		network, address, useTLS, err := c.parseURL(c.host)
+		token.complete(c.connect())
+	}()
+	return token
+}
+
+// IsConnected returns true if the connection to the broker is currently established
+func (c *client) IsConnected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connected
+}
+
+// Disconnect sends a DISCONNECT packet after waiting the given milliseconds, closes the connection and stops
+// any reconnect attempts
+func (c *client) Disconnect(quiesce uint) {
+	c.cancel()
+
+	c.mu.Lock()
+	conn := c.conn
+	connCancel := c.connCancel
+	c.conn = nil
+	c.connected = false
+	c.reconnecting = false
+	storeOpened := c.storeOpened
+	c.mu.Unlock()
+
+	if conn != nil {
+		time.Sleep(time.Duration(quiesce) * time.Millisecond)
+		_ = c.write(conn, []byte{0xE0, 0x00}) // DISCONNECT
+		connCancel()
+		_ = conn.Close()
+	}
+
+	c.abortInflight(ErrDisconnected)
+	c.abortAcks(ErrDisconnected)
+	if storeOpened {
+		_ = c.store.Close()
+	}
+}
+
+// connect dials the broker, performs the CONNECT/CONNACK handshake and starts the packet reader and the keep
+// alive supervisor for the new connection. Subscriptions are restored, if the broker has no session present.
+func (c *client) connect() error {
+	network, address, useTLS, err := c.parseURL(c.host)
+	if err != nil {
+		return err
+	}
+
+	if c.protocolVersion != ProtocolVersion311 && c.protocolVersion != ProtocolVersion5 {
+		return fmt.Errorf("unsupported MQTT protocol version: %d", c.protocolVersion)
+	}
+
+	if c.will != nil {
+		if err := validateTopicName(c.will.topic); err != nil {
+			return err
+		}
+	}
+
+	if err := c.openStore(); err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{Timeout: c.connectTimeout}
+	var conn net.Conn
+	if useTLS {
+		conn, err = tls.DialWithDialer(dialer, network, address, c.tlsConfig)
+	} else {
+		conn, err = dialer.DialContext(c.ctx, network, address)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := c.sendConnect(conn); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	sessionPresent, props, err := c.readConnack(conn)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	keepAlive := c.keepAlive
+	if props.ServerKeepAlive != nil {
+		keepAlive = time.Duration(*props.ServerKeepAlive) * time.Second
+	}
+
+	c.mu.Lock()
+	if c.ctx.Err() != nil {
+		c.mu.Unlock()
+		_ = conn.Close()
+		return c.ctx.Err()
+	}
+	connCtx, connCancel := context.WithCancel(c.ctx)
+	c.conn = conn
+	c.connCancel = connCancel
+	c.connected = true
+	c.reconnecting = false
+	if props.AssignedClientID != "" {
+		c.clientID = props.AssignedClientID
+	}
+	c.outAliasMaximum = 0
+	if props.TopicAliasMaximum != nil {
+		c.outAliasMaximum = *props.TopicAliasMaximum
+	}
+	c.outAliases = make(map[string]uint16)
+	c.inAliases = make(map[uint16]string)
+	c.publishMu = &sync.Mutex{}
+	c.pingSent.Store(0)
+	c.mu.Unlock()
+
+	go c.readLoop(connCtx, conn)
+	go c.supervise(connCtx, conn, keepAlive)
+
+	if !sessionPresent {
+		c.resubscribe(conn)
+	}
+	c.resendInflight(conn, sessionPresent)
+
+	if c.onConnect != nil {
+		c.onConnect(c)
+	}
+
+	return nil
+}
+
+// readConnack waits for the CONNACK packet and returns the session present flag and the MQTT 5 properties,
+// which are empty for MQTT 3.1.1
+func (c *client) readConnack(conn net.Conn) (bool, *Properties, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(c.connectTimeout)); err != nil {
+		return false, nil, err
+	}
+	defer func() { _ = conn.SetReadDeadline(time.Time{}) }()
+
+	header := make([]byte, 1)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return false, nil, err
+	}
+	length, err := c.readRemainingLength(conn)
+	if err != nil {
+		return false, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return false, nil, err
+	}
+
+	if header[0]>>4 != 0x02 || len(payload) < 2 {
+		return false, nil, fmt.Errorf("%w: unexpected packet 0x%02X instead of CONNACK", ErrConnectionRefused,
+			header[0])
+	}
+
+	if c.protocolVersion == ProtocolVersion5 {
+		props, _, err := decodeProperties(payload[2:])
 		if err != nil {
-			token.complete(err)
-			return
+			return false, nil, err
+		}
+		if reasonCode := payload[1]; reasonCode >= 0x80 {
+			return false, nil, fmt.Errorf("%w: %w", ErrConnectionRefused,
+				&ReasonCodeError{Packet: "CONNACK", Code: reasonCode, Reason: props.ReasonString})
 		}
+		return payload[0]&0x01 != 0, props, nil
+	}
 
-		var conn net.Conn
-		if useTLS {
-			conn, err = tls.Dial(network, address, c.tlsConfig)
-		} else {
-			conn, err = net.Dial(network, address)
+	if returnCode := payload[1]; returnCode != 0 {
+		reason, ok := connackReturnCodes[returnCode]
+		if !ok {
+			reason = fmt.Sprintf("return code %d", returnCode)
 		}
-		if err != nil {
-			token.complete(err)
-			return
+		return false, nil, fmt.Errorf("%w: %s", ErrConnectionRefused, reason)
+	}
+
+	return payload[0]&0x01 != 0, &Properties{}, nil
+}
+
+// openStore opens the store on the first connect. Outbound packets of a previous run are registered as
+// in-flight, so their packet IDs are not reused. With a clean session, the store starts empty.
+func (c *client) openStore() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.storeOpened {
+		return nil
+	}
+	if err := c.store.Open(); err != nil {
+		return err
+	}
+	if c.cleanSession {
+		if err := c.store.Reset(); err != nil {
+			return err
 		}
+	}
 
-		c.mu.Lock()
-		c.conn = conn
-		c.connected = true
-		c.mu.Unlock()
+	keys, err := c.store.All()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if outbound, id, err := parseStoreKey(key); err == nil && outbound {
+			c.inflight[id] = newToken()
+		}
+	}
 
-		// Send CONNECT packet
-		err = c.sendConnect()
-		if err != nil {
-			token.complete(err)
+	c.storeOpened = true
+	return nil
+}
+
+// resendInflight retransmits the stored outbound PUBLISH packets with DUP flag and PUBREL packets in order of
+// their packet IDs. Without a session on broker side, the inbound QoS 2 states are obsolete and the broker knows
+// none of the packet IDs of the PUBREL packets. Their messages were already received by the broker (PUBREC), so
+// these flows are completed without PUBREL, the publishes start again by PUBLISH.
+func (c *client) resendInflight(conn net.Conn, sessionPresent bool) {
+	keys, err := c.store.All()
+	if err != nil {
+		return
+	}
+
+	type storedPacket struct {
+		key string
+		id  uint16
+	}
+	var outbound []storedPacket
+	for _, key := range keys {
+		isOutbound, id, err := parseStoreKey(key)
+		switch {
+		case err != nil:
+			continue
+		case isOutbound:
+			outbound = append(outbound, storedPacket{key: key, id: id})
+		case !sessionPresent:
+			_ = c.store.Del(key)
+		}
+	}
+	slices.SortFunc(outbound, func(a, b storedPacket) int { return int(a.id) - int(b.id) })
+
+	for _, sp := range outbound {
+		packet, err := c.store.Get(sp.key)
+		if err != nil || len(packet) == 0 {
+			continue
+		}
+		switch packet[0] >> 4 {
+		case 0x03: // PUBLISH
+			packet[0] |= 0x08
+		case 0x06: // PUBREL
+			if !sessionPresent {
+				c.completeInflight(sp.id)
+				continue
+			}
+		}
+		if err := c.write(conn, packet); err != nil {
 			return
 		}
+	}
+}
 
-		// Start reading messages
-		go c.readLoop()
+// abortAcks completes the tokens of all outstanding SUBSCRIBE and UNSUBSCRIBE with the given error
+func (c *client) abortAcks(err error) {
+	c.mu.Lock()
+	acks := c.acks
+	c.acks = make(map[uint16]*token)
+	c.mu.Unlock()
 
-		token.complete(nil)
-	}()
-	return token
+	for _, tok := range acks {
+		tok.complete(err)
+	}
 }
 
-// Disconnect closes the connection
-func (c *client) Disconnect(quiesce uint) {
+// abortInflight completes the tokens of all outbound QoS 1 and QoS 2 messages with the given error. The messages
+// remain in the store, so a persistent store retransmits them on the next start without a clean session.
+func (c *client) abortInflight(err error) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	inflight := c.inflight
+	c.inflight = make(map[uint16]*token)
+	c.mu.Unlock()
 
-	if c.conn != nil {
-		c.conn.Close()
-		c.conn = nil
+	for _, tok := range inflight {
+		tok.complete(err)
 	}
+}
+
+// completeInflight removes the acknowledged outbound message from the store and completes its token
+func (c *client) completeInflight(packetID uint16) {
+	_ = c.store.Del(outboundKey(packetID))
+
+	c.mu.Lock()
+	tok, ok := c.inflight[packetID]
+	delete(c.inflight, packetID)
+	c.mu.Unlock()
+
+	if ok {
+		tok.complete(nil)
+	}
+}
+
+// resubscribe sends a SUBSCRIBE packet for each registered topic filter
+func (c *client) resubscribe(conn net.Conn) {
+	c.mu.RLock()
+	subs := make(map[string]byte, len(c.subscribers))
+	for filter, sub := range c.subscribers {
+		subs[filter] = sub.qos
+	}
+	c.mu.RUnlock()
+
+	for filter, qos := range subs {
+		if err := c.sendSubscribe(conn, filter, qos, newToken()); err != nil {
+			return
+		}
+	}
+}
+
+// supervise sends a PINGREQ if nothing was sent for the keep alive interval and treats the connection as lost
+// if the PINGRESP is missing after the ping timeout
+func (c *client) supervise(ctx context.Context, conn net.Conn, keepAlive time.Duration) {
+	if keepAlive <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(max(min(keepAlive, c.pingTimeout)/2, minSuperviseInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if pingSent := c.pingSent.Load(); pingSent != 0 {
+				if now.Sub(time.Unix(0, pingSent)) >= c.pingTimeout {
+					c.connectionLost(conn, ErrPingTimeout)
+					return
+				}
+				continue
+			}
+
+			if now.Sub(time.Unix(0, c.lastSent.Load())) >= keepAlive {
+				c.pingSent.Store(now.UnixNano())
+				if err := c.write(conn, []byte{0xC0, 0x00}); err != nil { // PINGREQ
+					c.connectionLost(conn, err)
+					return
+				}
+			}
+		}
+	}
+}
+
+// connectionLost closes the given connection, if it is still the current one, and starts reconnecting
+func (c *client) connectionLost(conn net.Conn, err error) {
+	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
+	c.conn = nil
 	c.connected = false
-	c.cancel()
+	c.reconnecting = c.autoReconnect
+	c.outAliases = make(map[string]uint16)
+	c.connCancel()
+	c.mu.Unlock()
+
+	_ = conn.Close()
+	c.abortAcks(err)
+
+	if c.onConnectionLost != nil {
+		c.onConnectionLost(c, err)
+	}
+
+	if c.autoReconnect {
+		go c.reconnect()
+	} else {
+		c.abortInflight(err)
+	}
+}
+
+// reconnect tries to connect to the broker with an exponential backoff until it succeeds or the client is
+// disconnected
+func (c *client) reconnect() {
+	interval := c.minReconnectInterval
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		if c.onReconnecting != nil {
+			c.onReconnecting(c)
+		}
+
+		if err := c.connect(); err == nil || c.ctx.Err() != nil {
+			return
+		}
+
+		interval = min(2*interval, c.maxReconnectInterval)
+	}
+}
+
+// write sends the packet and remembers the time for the keep alive supervision
+func (c *client) write(conn net.Conn, packet []byte) error {
+	if _, err := conn.Write(packet); err != nil {
+		return err
+	}
+	c.lastSent.Store(time.Now().UnixNano())
+	return nil
 }
 
 // nextPacketID generates next packet ID
 func (c *client) nextPacketID() uint16 {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.packetID++
-	if c.packetID == 0 {
-		c.packetID = 1
+	return c.nextFreePacketIDLocked()
+}
+
+// reservePacketID generates the next packet ID and registers the token for the in-flight message
+func (c *client) reservePacketID(tok *token) uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.nextFreePacketIDLocked()
+	c.inflight[id] = tok
+	return id
+}
+
+// reserveAckPacketID generates the next packet ID and registers the token of a SUBSCRIBE or UNSUBSCRIBE
+func (c *client) reserveAckPacketID(tok *token) uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.nextFreePacketIDLocked()
+	c.acks[id] = tok
+	return id
+}
+
+// nextFreePacketIDLocked returns the next packet ID, which is not used by an in-flight message or an outstanding
+// SUBSCRIBE or UNSUBSCRIBE
+func (c *client) nextFreePacketIDLocked() uint16 {
+	for {
+		c.packetID++
+		if c.packetID == 0 {
+			c.packetID = 1
+		}
+		_, usedByPublish := c.inflight[c.packetID]
+		_, usedByAck := c.acks[c.packetID]
+		if !usedByPublish && !usedByAck {
+			return c.packetID
+		}
 	}
-	return c.packetID
 }
 
 // sendConnect sends MQTT CONNECT packet
-func (c *client) sendConnect() error {
+func (c *client) sendConnect(conn net.Conn) error {
 	// MQTT 3.1.1 CONNECT packet
 	var buf []byte
 
@@ -260,10 +907,19 @@ func (c *client) sendConnect() error {
 	protocolName := "MQTT"
 	buf = append(buf, byte(len(protocolName)>>8), byte(len(protocolName)))
 	buf = append(buf, []byte(protocolName)...)
-	buf = append(buf, 4) // Protocol level
+	buf = append(buf, byte(c.protocolVersion)) // Protocol level
 
 	// Connect flags
-	flags := byte(0x02) // Clean session
+	var flags byte
+	if c.cleanSession {
+		flags |= 0x02
+	}
+	if c.will != nil {
+		flags |= 0x04 | (c.will.qos&0x03)<<3
+		if c.will.retained {
+			flags |= 0x20
+		}
+	}
 	if c.username != "" {
 		flags |= 0x80
 		if c.password != "" {
@@ -272,13 +928,36 @@ func (c *client) sendConnect() error {
 	}
 	buf = append(buf, flags)
 
-	// Keep alive (60 seconds)
-	buf = append(buf, 0, 60)
+	// Keep alive in seconds, rounded up to not exceed the broker side timeout
+	keepAlive := (min(c.keepAlive, maxKeepAlive) + time.Second - 1) / time.Second
+	buf = append(buf, byte(keepAlive>>8), byte(keepAlive))
+
+	if c.protocolVersion == ProtocolVersion5 {
+		props := &Properties{}
+		if c.sessionExpiry > 0 {
+			seconds := uint32(min(c.sessionExpiry/time.Second, math.MaxUint32))
+			props.SessionExpiryInterval = &seconds
+		}
+		if c.topicAliasMaximum > 0 {
+			props.TopicAliasMaximum = &c.topicAliasMaximum
+		}
+		buf = append(buf, props.encode()...)
+	}
 
 	// Payload
 	buf = append(buf, byte(len(c.clientID)>>8), byte(len(c.clientID)))
 	buf = append(buf, []byte(c.clientID)...)
 
+	if c.will != nil {
+		if c.protocolVersion == ProtocolVersion5 {
+			buf = append(buf, c.will.properties.encode()...)
+		}
+		buf = append(buf, byte(len(c.will.topic)>>8), byte(len(c.will.topic)))
+		buf = append(buf, []byte(c.will.topic)...)
+		buf = append(buf, byte(len(c.will.payload)>>8), byte(len(c.will.payload)))
+		buf = append(buf, c.will.payload...)
+	}
+
 	if c.username != "" {
 		buf = append(buf, byte(len(c.username)>>8), byte(len(c.username)))
 		buf = append(buf, []byte(c.username)...)
@@ -293,8 +972,7 @@ func (c *client) sendConnect() error {
 	packet = append(packet, encodeLength(len(buf))...)
 	packet = append(packet, buf...)
 
-	_, err := c.conn.Write(packet)
-	return err
+	return c.write(conn, packet)
 }
 
 // encodeLength encodes remaining length for MQTT packets
@@ -314,33 +992,21 @@ func encodeLength(length int) []byte {
 	return encoded
 }
 
-// readLoop reads incoming MQTT packets
-func (c *client) readLoop() {
+// readLoop reads incoming MQTT packets until the connection fails or is closed
+func (c *client) readLoop(ctx context.Context, conn net.Conn) {
 	for {
-		select {
-		case <-c.ctx.Done():
-			return
-		default:
-		}
-
-		c.mu.RLock()
-		conn := c.conn
-		c.mu.RUnlock()
-
-		if conn == nil {
-			return
-		}
-
 		// Read fixed header
 		header := make([]byte, 1)
 		_, err := io.ReadFull(conn, header)
 		if err != nil {
+			c.readFailed(ctx, conn, err)
 			return
 		}
 
 		// Read remaining length
 		length, err := c.readRemainingLength(conn)
 		if err != nil {
+			c.readFailed(ctx, conn, err)
 			return
 		}
 
@@ -349,15 +1015,24 @@ func (c *client) readLoop() {
 		if length > 0 {
 			_, err = io.ReadFull(conn, payload)
 			if err != nil {
+				c.readFailed(ctx, conn, err)
 				return
 			}
 		}
 
 		// Handle packet
-		c.handlePacket(header[0], payload)
+		c.handlePacket(conn, header[0], payload)
 	}
 }
 
+// readFailed treats a read error as lost connection, unless the connection was closed intentionally
+func (c *client) readFailed(ctx context.Context, conn net.Conn, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	c.connectionLost(conn, err)
+}
+
 // readRemainingLength reads MQTT remaining length
 func (c *client) readRemainingLength(conn net.Conn) (int, error) {
 	length := 0
@@ -378,20 +1053,123 @@ func (c *client) readRemainingLength(conn net.Conn) (int, error) {
 }
 
 // handlePacket handles incoming MQTT packets
-func (c *client) handlePacket(header byte, payload []byte) {
+func (c *client) handlePacket(conn net.Conn, header byte, payload []byte) {
 	packetType := (header >> 4) & 0x0F
 	switch packetType {
 	case 0x02: // CONNACK
 		// Connection acknowledged
 	case 0x03: // PUBLISH
-		c.handlePublish(header, payload)
-	case 0x09: // SUBACK
-		// Subscribe acknowledged
+		c.handlePublish(conn, header, payload)
+	case 0x04, 0x07: // PUBACK (QoS 1), PUBCOMP (QoS 2)
+		packetID, err := c.decodeAck(packetType, payload)
+		if packetID == 0 {
+			return
+		}
+		if err != nil {
+			_ = c.store.Del(outboundKey(packetID))
+			c.completeInflightWithError(packetID, err)
+			return
+		}
+		c.completeInflight(packetID)
+	case 0x05: // PUBREC (QoS 2)
+		packetID, err := c.decodeAck(packetType, payload)
+		if packetID == 0 {
+			return
+		}
+		if err != nil {
+			// the flow ends without PUBREL
+			_ = c.store.Del(outboundKey(packetID))
+			c.completeInflightWithError(packetID, err)
+			return
+		}
+		pubrel := ackPacket(0x62, packetID)
+		if err := c.store.Put(outboundKey(packetID), pubrel); err != nil {
+			return
+		}
+		_ = c.write(conn, pubrel)
+	case 0x06: // PUBREL (QoS 2)
+		if len(payload) >= 2 {
+			packetID := binary.BigEndian.Uint16(payload[0:2])
+			_ = c.store.Del(inboundKey(packetID))
+			_ = c.write(conn, ackPacket(0x70, packetID)) // PUBCOMP
+		}
+	case 0x09, 0x0B: // SUBACK, UNSUBACK
+		packetID, err := c.decodeAck(packetType, payload)
+		if packetID == 0 {
+			return
+		}
+		c.mu.Lock()
+		tok, ok := c.acks[packetID]
+		delete(c.acks, packetID)
+		c.mu.Unlock()
+		if ok {
+			tok.complete(err)
+		}
+	case 0x0D: // PINGRESP
+		c.pingSent.Store(0)
+	case 0x0E: // DISCONNECT (MQTT 5 only)
+		err := &ReasonCodeError{Packet: "DISCONNECT", Code: 0x00}
+		if len(payload) > 0 {
+			err.Code = payload[0]
+		}
+		if len(payload) > 1 {
+			if props, _, perr := decodeProperties(payload[1:]); perr == nil {
+				err.Reason = props.ReasonString
+			}
+		}
+		c.connectionLost(conn, err)
 	}
 }
 
-// handlePublish handles PUBLISH packets
-func (c *client) handlePublish(header byte, payload []byte) {
+// decodeAck returns the packet ID of an acknowledge packet and an error for a failure reason code. For MQTT 5
+// the reason code of PUBACK, PUBREC and PUBCOMP is optional and the reason codes of SUBACK and UNSUBACK follow
+// the properties. MQTT 3.1.1 knows only the return codes of SUBACK.
+func (c *client) decodeAck(packetType byte, payload []byte) (uint16, error) {
+	if len(payload) < 2 {
+		return 0, nil
+	}
+	packetID := binary.BigEndian.Uint16(payload[0:2])
+	names := map[byte]string{0x04: "PUBACK", 0x05: "PUBREC", 0x07: "PUBCOMP", 0x09: "SUBACK", 0x0B: "UNSUBACK"}
+
+	var reasonCodes []byte
+	var reason string
+	switch {
+	case c.protocolVersion != ProtocolVersion5:
+		if packetType == 0x09 {
+			reasonCodes = payload[2:]
+		}
+	case packetType == 0x09 || packetType == 0x0B:
+		props, n, err := decodeProperties(payload[2:])
+		if err != nil {
+			return packetID, err
+		}
+		reasonCodes = payload[2+n:]
+		reason = props.ReasonString
+	case len(payload) > 2:
+		reasonCodes = payload[2:3]
+		if len(payload) > 3 {
+			if props, _, err := decodeProperties(payload[3:]); err == nil {
+				reason = props.ReasonString
+			}
+		}
+	}
+
+	for _, code := range reasonCodes {
+		if code >= 0x80 {
+			return packetID, &ReasonCodeError{Packet: names[packetType], Code: code, Reason: reason}
+		}
+	}
+	return packetID, nil
+}
+
+// ackPacket creates a packet, which consists only of the fixed header and the packet ID
+func ackPacket(header byte, packetID uint16) []byte {
+	return []byte{header, 0x02, byte(packetID >> 8), byte(packetID)}
+}
+
+// handlePublish handles PUBLISH packets. QoS 1 messages are acknowledged by PUBACK after the delivery. QoS 2
+// messages are delivered once, the packet ID is stored until the PUBREL of the broker arrives.
+func (c *client) handlePublish(conn net.Conn, header byte, payload []byte) {
 	if len(payload) < 2 {
 		return
 	}
@@ -406,8 +1184,27 @@ func (c *client) handlePublish(header byte, payload []byte) {
 	// Extract message payload
 	offset := 2 + topicLen
 	qos := (header >> 1) & 0x03
+	var packetID uint16
 	if qos > 0 {
-		offset += 2 // Skip packet ID for QoS > 0
+		if len(payload) < offset+2 {
+			return
+		}
+		packetID = binary.BigEndian.Uint16(payload[offset : offset+2])
+		offset += 2
+	}
+
+	// Extract MQTT 5 properties and resolve the topic alias
+	props := &Properties{}
+	if c.protocolVersion == ProtocolVersion5 {
+		var n int
+		var err error
+		if props, n, err = decodeProperties(payload[offset:]); err != nil {
+			return
+		}
+		offset += n
+		if topic, err = c.resolveInboundAlias(topic, props.TopicAlias); err != nil {
+			return
+		}
 	}
 
 	if offset > len(payload) {
@@ -415,31 +1212,99 @@ func (c *client) handlePublish(header byte, payload []byte) {
 	}
 	msgPayload := payload[offset:]
 
+	switch qos {
+	case 1:
+		defer func() { _ = c.write(conn, ackPacket(0x40, packetID)) }() // PUBACK
+	case 2:
+		defer func() { _ = c.write(conn, ackPacket(0x50, packetID)) }() // PUBREC
+		stored, err := c.store.Get(inboundKey(packetID))
+		if err != nil || stored != nil {
+			// duplicate, already delivered
+			return
+		}
+		if err := c.store.Put(inboundKey(packetID), []byte{header}); err != nil {
+			return
+		}
+	}
+
 	// Create message
 	msg := &message{
-		topic:    topic,
-		payload:  msgPayload,
-		qos:      qos,
-		retained: (header & 0x01) != 0,
+		topic:      topic,
+		payload:    msgPayload,
+		qos:        qos,
+		retained:   (header & 0x01) != 0,
+		properties: *props,
 	}
 
-	// Call subscriber
+	// Call all subscribers with a matching topic filter
 	c.mu.RLock()
-	if callback, exists := c.subscribers[topic]; exists {
-		go callback(c, msg)
+	for filter, sub := range c.subscribers {
+		if matchTopic(sharedSubscriptionFilter(filter), topic) {
+			go sub.callback(c, msg)
+		}
 	}
 	c.mu.RUnlock()
 }
 
+// resolveInboundAlias records the topic alias set by the broker or replaces an empty topic by the alias topic
+func (c *client) resolveInboundAlias(topic string, alias *uint16) (string, error) {
+	if alias == nil {
+		return topic, nil
+	}
+	if *alias == 0 || *alias > c.topicAliasMaximum {
+		return "", fmt.Errorf("%w: topic alias %d exceeds maximum %d", ErrInvalidTopic, *alias, c.topicAliasMaximum)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if topic != "" {
+		c.inAliases[*alias] = topic
+		return topic, nil
+	}
+	aliasTopic, ok := c.inAliases[*alias]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown topic alias %d", ErrInvalidTopic, *alias)
+	}
+	return aliasTopic, nil
+}
+
+// outboundAlias returns the topic alias for publishing and whether the alias is already known by the broker. An
+// alias of zero means, that no alias is used. The caller holds c.mu and writes the packet, which establishes a
+// new alias, before the next call for the same connection, see writePublish.
+func (c *client) outboundAlias(topic string) (uint16, bool) {
+	if alias, ok := c.outAliases[topic]; ok {
+		return alias, true
+	}
+	if len(c.outAliases) >= int(c.outAliasMaximum) {
+		return 0, false
+	}
+	alias := uint16(len(c.outAliases) + 1)
+	c.outAliases[topic] = alias
+	return alias, false
+}
+
 // Publish publishes a message
 func (c *client) Publish(topic string, qos byte, retained bool, payload any) Token {
+	return c.PublishWithProperties(topic, qos, retained, payload, nil)
+}
+
+// PublishWithProperties publishes a message with MQTT 5 properties, e.g. response topic and correlation data for
+// request-response. The properties are ignored for MQTT 3.1.1. If the broker allows topic aliases, they are used
+// automatically for repeated topics.
+func (c *client) PublishWithProperties(topic string, qos byte, retained bool, payload any, props *Properties) Token {
 	token := newToken()
 	go func() {
+		if err := validateTopicName(topic); err != nil {
+			token.complete(err)
+			return
+		}
+
 		c.mu.RLock()
 		conn := c.conn
+		reconnecting := c.reconnecting
 		c.mu.RUnlock()
 
-		if conn == nil {
+		if conn == nil && (qos == 0 || !reconnecting) {
 			token.complete(ErrNilClient)
 			return
 		}
@@ -455,45 +1320,139 @@ func (c *client) Publish(topic string, qos byte, retained bool, payload any) Tok
 			data = []byte(fmt.Sprintf("%v", p))
 		}
 
-		// Build PUBLISH packet
-		var buf []byte
-
-		// Topic
-		buf = append(buf, byte(len(topic)>>8), byte(len(topic)))
-		buf = append(buf, []byte(topic)...)
-
 		// Packet ID (for QoS > 0)
+		var packetID uint16
 		if qos > 0 {
-			packetID := c.nextPacketID()
-			buf = append(buf, byte(packetID>>8), byte(packetID))
+			packetID = c.reservePacketID(token)
 		}
 
-		// Payload
-		buf = append(buf, data...)
+		// The packet to store contains always the full topic, because topic aliases are valid only for one
+		// connection
+		packet := c.buildPublish(topic, qos, retained, packetID, props, data)
 
-		// Fixed header
-		header := byte(0x30) // PUBLISH
-		if retained {
-			header |= 0x01
+		if qos == 0 {
+			currentConn, err := c.writePublish(topic, qos, retained, packetID, props, data, packet)
+			if currentConn == nil && err == nil {
+				err = ErrNilClient
+			}
+			token.complete(err)
+			return
 		}
-		header |= (qos & 0x03) << 1
 
-		packet := []byte{header}
-		packet = append(packet, encodeLength(len(buf))...)
-		packet = append(packet, buf...)
-
-		_, err := conn.Write(packet)
-		token.complete(err)
+		// QoS 1 and QoS 2: the token is completed on acknowledge, the stored packet is sent again after a
+		// reconnect, if the connection is currently lost or fails while writing
+		if err := c.store.Put(outboundKey(packetID), packet); err != nil {
+			c.completeInflightWithError(packetID, err)
+			return
+		}
+		if currentConn, err := c.writePublish(topic, qos, retained, packetID, props, data, packet); err != nil {
+			c.connectionLost(currentConn, err)
+		}
 	}()
 	return token
 }
 
-// Subscribe subscribes to a topic
+// writePublish writes the PUBLISH packet to the current connection and returns the connection, nil if there is
+// none. For MQTT 5 a topic alias is used, if the broker allows it. The alias is assigned and the packet, which
+// establishes it, is written under the lock of the connection, so no other publish can send the alias without
+// topic before the broker knows the mapping. The aliases are reset together with the connection.
+func (c *client) writePublish(topic string, qos byte, retained bool, packetID uint16, props *Properties,
+	data []byte, packet []byte,
+) (net.Conn, error) {
+	c.mu.RLock()
+	publishMu := c.publishMu
+	c.mu.RUnlock()
+
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	c.mu.Lock()
+	conn := c.conn
+	if conn == nil || c.publishMu != publishMu {
+		// lost or replaced meanwhile, a stored packet is sent again after the reconnect
+		c.mu.Unlock()
+		return nil, nil
+	}
+	wirePacket := packet
+	if c.protocolVersion == ProtocolVersion5 && (props == nil || props.TopicAlias == nil) {
+		if alias, known := c.outboundAlias(topic); alias != 0 {
+			aliasProps := &Properties{}
+			if props != nil {
+				*aliasProps = *props
+			}
+			aliasProps.TopicAlias = &alias
+			aliasTopic := topic
+			if known {
+				aliasTopic = ""
+			}
+			wirePacket = c.buildPublish(aliasTopic, qos, retained, packetID, aliasProps, data)
+		}
+	}
+	c.mu.Unlock()
+
+	return conn, c.write(conn, wirePacket)
+}
+
+// buildPublish creates a PUBLISH packet, the properties are added for MQTT 5 only
+func (c *client) buildPublish(topic string, qos byte, retained bool, packetID uint16, props *Properties,
+	data []byte,
+) []byte {
+	var buf []byte
+
+	// Topic
+	buf = append(buf, byte(len(topic)>>8), byte(len(topic)))
+	buf = append(buf, []byte(topic)...)
+
+	// Packet ID (for QoS > 0)
+	if qos > 0 {
+		buf = append(buf, byte(packetID>>8), byte(packetID))
+	}
+
+	// Properties
+	if c.protocolVersion == ProtocolVersion5 {
+		buf = append(buf, props.encode()...)
+	}
+
+	// Payload
+	buf = append(buf, data...)
+
+	// Fixed header
+	header := byte(0x30) // PUBLISH
+	if retained {
+		header |= 0x01
+	}
+	header |= (qos & 0x03) << 1
+
+	packet := []byte{header}
+	packet = append(packet, encodeLength(len(buf))...)
+	return append(packet, buf...)
+}
+
+// completeInflightWithError removes the outbound message and completes its token with the error
+func (c *client) completeInflightWithError(packetID uint16, err error) {
+	c.mu.Lock()
+	tok, ok := c.inflight[packetID]
+	delete(c.inflight, packetID)
+	c.mu.Unlock()
+
+	if ok {
+		tok.complete(err)
+	}
+}
+
+// Subscribe subscribes to a topic filter, which may contain the wildcards '+' and '#' or be a shared subscription
+// "$share/<group>/<filter>". The token completes, when the SUBACK arrives. The subscription is restored
+// automatically after a reconnect.
 func (c *client) Subscribe(topic string, qos byte, callback func(Client, Message)) Token {
 	token := newToken()
 	go func() {
+		if err := validateTopicFilter(topic); err != nil {
+			token.complete(err)
+			return
+		}
+
 		c.mu.Lock()
-		c.subscribers[topic] = callback
+		c.subscribers[topic] = subscription{qos: qos, callback: callback}
 		conn := c.conn
 		c.mu.Unlock()
 
@@ -502,70 +1461,169 @@ func (c *client) Subscribe(topic string, qos byte, callback func(Client, Message
 			return
 		}
 
-		// Build SUBSCRIBE packet
-		packetID := c.nextPacketID()
-		var buf []byte
+		if err := c.sendSubscribe(conn, topic, qos, token); err != nil {
+			c.completeAckWithError(token, err)
+		}
+	}()
+	return token
+}
+
+// sendSubscribe sends a SUBSCRIBE packet for a single topic filter, the token is completed by the SUBACK
+func (c *client) sendSubscribe(conn net.Conn, topic string, qos byte, tok *token) error {
+	packetID := c.reserveAckPacketID(tok)
+	var buf []byte
 
-		// Packet ID
-		buf = append(buf, byte(packetID>>8), byte(packetID))
+	// Packet ID
+	buf = append(buf, byte(packetID>>8), byte(packetID))
+
+	// Properties
+	if c.protocolVersion == ProtocolVersion5 {
+		buf = append(buf, (*Properties)(nil).encode()...)
+	}
+
+	// Topic filter
+	buf = append(buf, byte(len(topic)>>8), byte(len(topic)))
+	buf = append(buf, []byte(topic)...)
+	buf = append(buf, qos)
+
+	// Fixed header
+	packet := []byte{0x82} // SUBSCRIBE
+	packet = append(packet, encodeLength(len(buf))...)
+	packet = append(packet, buf...)
+
+	return c.write(conn, packet)
+}
+
+// Unsubscribe removes the subscriptions of the given topic filters, which must match the filters used on
+// Subscribe. The token completes, when the UNSUBACK arrives. The filters are not restored on reconnect anymore.
+func (c *client) Unsubscribe(topics ...string) Token {
+	token := newToken()
+	go func() {
+		if len(topics) == 0 {
+			token.complete(fmt.Errorf("%w: no topic filter to unsubscribe", ErrInvalidTopic))
+			return
+		}
+		for _, topic := range topics {
+			if err := validateTopicFilter(topic); err != nil {
+				token.complete(err)
+				return
+			}
+		}
+
+		c.mu.Lock()
+		for _, topic := range topics {
+			delete(c.subscribers, topic)
+		}
+		conn := c.conn
+		c.mu.Unlock()
 
-		// Topic filter
-		buf = append(buf, byte(len(topic)>>8), byte(len(topic)))
-		buf = append(buf, []byte(topic)...)
-		buf = append(buf, qos)
+		if conn == nil {
+			token.complete(ErrNilClient)
+			return
+		}
+
+		// Build UNSUBSCRIBE packet
+		packetID := c.reserveAckPacketID(token)
+		buf := []byte{byte(packetID >> 8), byte(packetID)}
+		if c.protocolVersion == ProtocolVersion5 {
+			buf = append(buf, (*Properties)(nil).encode()...)
+		}
+		for _, topic := range topics {
+			buf = append(buf, byte(len(topic)>>8), byte(len(topic)))
+			buf = append(buf, []byte(topic)...)
+		}
 
 		// Fixed header
-		packet := []byte{0x82} // SUBSCRIBE
+		packet := []byte{0xA2} // UNSUBSCRIBE
 		packet = append(packet, encodeLength(len(buf))...)
 		packet = append(packet, buf...)
 
-		_, err := conn.Write(packet)
-		token.complete(err)
+		if err := c.write(conn, packet); err != nil {
+			c.completeAckWithError(token, err)
+		}
 	}()
 	return token
 }
 
-// Adaptor is the Gobot Adaptor for MQTT
+// completeAckWithError removes the outstanding SUBSCRIBE or UNSUBSCRIBE and completes its token with the error
+func (c *client) completeAckWithError(tok *token, err error) {
+	c.mu.Lock()
+	found := false
+	for id, t := range c.acks {
+		if t == tok {
+			delete(c.acks, id)
+			found = true
+		}
+	}
+	c.mu.Unlock()
+
+	if found {
+		tok.complete(err)
+	}
+}
+
+// Adaptor is the Gobot Adaptor for MQTT. The connection state changes are published as events
+// (ConnectedEvent, ConnectionLostEvent, ReconnectingEvent, DisconnectedEvent) by the embedded Eventer.
 type Adaptor struct {
-	name          string
-	Host          string
-	clientID      string
-	username      string
-	password      string
-	useSSL        bool
-	serverCert    string
-	clientCert    string
-	clientKey     string
-	autoReconnect bool
-	cleanSession  bool
-	client        Client
-	qos           int
+	name                 string
+	Host                 string
+	clientID             string
+	username             string
+	password             string
+	useSSL               bool
+	serverCert           string
+	clientCert           string
+	clientKey            string
+	autoReconnect        bool
+	cleanSession         bool
+	keepAlive            time.Duration
+	pingTimeout          time.Duration
+	writeTimeout         time.Duration
+	minReconnectInterval time.Duration
+	maxReconnectInterval time.Duration
+	store                Store
+	will                 *will
+	protocolVersion      uint
+	sessionExpiry        time.Duration
+	topicAliasMaximum    uint16
+	client               Client
+	qos                  int
+	gobot.Eventer
 }
 
 // NewAdaptor creates a new mqtt adaptor with specified host and client id
 func NewAdaptor(host string, clientID string) *Adaptor {
-	return &Adaptor{
-		name:          gobot.DefaultName("MQTT"),
-		Host:          host,
-		autoReconnect: false,
-		cleanSession:  true,
-		useSSL:        false,
-		clientID:      clientID,
+	a := &Adaptor{
+		name:                 gobot.DefaultName("MQTT"),
+		Host:                 host,
+		autoReconnect:        false,
+		cleanSession:         true,
+		useSSL:               false,
+		clientID:             clientID,
+		protocolVersion:      ProtocolVersion311,
+		keepAlive:            defaultKeepAlive,
+		pingTimeout:          defaultPingTimeout,
+		writeTimeout:         defaultWriteTimeout,
+		minReconnectInterval: defaultMinReconnectInterval,
+		maxReconnectInterval: defaultMaxReconnectInterval,
+		Eventer:              gobot.NewEventer(),
 	}
+
+	a.AddEvent(ConnectedEvent)
+	a.AddEvent(ConnectionLostEvent)
+	a.AddEvent(ReconnectingEvent)
+	a.AddEvent(DisconnectedEvent)
+
+	return a
 }
 
 // NewAdaptorWithAuth creates a new mqtt adaptor with specified host, client id, username, and password.
 func NewAdaptorWithAuth(host, clientID, username, password string) *Adaptor {
-	return &Adaptor{
-		name:          "MQTT",
-		Host:          host,
-		autoReconnect: false,
-		cleanSession:  true,
-		useSSL:        false,
-		clientID:      clientID,
-		username:      username,
-		password:      password,
-	}
+	a := NewAdaptor(host, clientID)
+	a.name = "MQTT"
+	a.username = username
+	a.password = password
+	return a
 }
 
 // Name returns the MQTT adaptors name
@@ -586,10 +1644,67 @@ func (a *Adaptor) SetAutoReconnect(val bool) { a.autoReconnect = val }
 // CleanSession returns the MQTT CleanSession setting
 func (a *Adaptor) CleanSession() bool { return a.cleanSession }
 
-// SetCleanSession sets the MQTT CleanSession setting. Should be false if reconnect is enabled.
-// Otherwise all subscriptions will be lost
+// SetCleanSession sets the MQTT CleanSession setting. If false, the broker keeps the subscriptions and queued
+// messages of the client ID between connections. Subscriptions registered by On/OnWithQOS are restored after a
+// reconnect in any case, if the broker has no session present.
 func (a *Adaptor) SetCleanSession(val bool) { a.cleanSession = val }
 
+// KeepAlive returns the maximum idle time, after which a ping is sent to the broker
+func (a *Adaptor) KeepAlive() time.Duration { return a.keepAlive }
+
+// SetKeepAlive sets the maximum idle time, after which a ping is sent to the broker. Zero disables pings, values
+// above 65535 s are limited to this maximum of the protocol.
+func (a *Adaptor) SetKeepAlive(val time.Duration) { a.keepAlive = val }
+
+// PingTimeout returns the time to wait for a ping response, before the connection is considered to be lost
+func (a *Adaptor) PingTimeout() time.Duration { return a.pingTimeout }
+
+// SetPingTimeout sets the time to wait for a ping response, before the connection is considered to be lost. A
+// timeout of zero or below means the default of 10 s.
+func (a *Adaptor) SetPingTimeout(val time.Duration) { a.pingTimeout = val }
+
+// WriteTimeout returns the time to wait for the acknowledge of an operation by the broker, e.g. the UNSUBACK on
+// halt of a driver
+func (a *Adaptor) WriteTimeout() time.Duration { return a.writeTimeout }
+
+// SetWriteTimeout sets the time to wait for the acknowledge of an operation by the broker
+func (a *Adaptor) SetWriteTimeout(val time.Duration) { a.writeTimeout = val }
+
+// SetReconnectInterval sets the wait time before the first reconnect attempt and the upper limit for the
+// exponential backoff of further attempts
+func (a *Adaptor) SetReconnectInterval(minInterval, maxInterval time.Duration) {
+	a.minReconnectInterval = minInterval
+	a.maxReconnectInterval = maxInterval
+}
+
+// SetWill sets the Last Will and Testament, e.g. a "robot offline" message, which is published by the broker to
+// the given topic, when the connection is lost without a prior Disconnect. Must be called before Connect.
+func (a *Adaptor) SetWill(topic string, payload []byte, qos int, retained bool) {
+	a.will = &will{topic: topic, payload: payload, qos: byte(qos), retained: retained}
+}
+
+// ProtocolVersion returns the MQTT protocol level
+func (a *Adaptor) ProtocolVersion() uint { return a.protocolVersion }
+
+// SetProtocolVersion sets the MQTT protocol level, ProtocolVersion311 (default) or ProtocolVersion5
+func (a *Adaptor) SetProtocolVersion(version uint) { a.protocolVersion = version }
+
+// SetSessionExpiryInterval sets the time the broker keeps the session after the connection is closed (MQTT 5 only)
+func (a *Adaptor) SetSessionExpiryInterval(val time.Duration) { a.sessionExpiry = val }
+
+// SetTopicAliasMaximum sets the number of topic aliases the broker is allowed to use for messages sent to the
+// adaptor (MQTT 5 only)
+func (a *Adaptor) SetTopicAliasMaximum(val uint16) { a.topicAliasMaximum = val }
+
+// SetStore sets the store for in-flight QoS 1 and QoS 2 messages, e.g. NewFileStore() to keep them over a
+// restart. By default the messages are kept in memory.
+func (a *Adaptor) SetStore(store Store) { a.store = store }
+
+// IsConnected returns true if the connection to the broker is currently established
+func (a *Adaptor) IsConnected() bool {
+	return a.client != nil && a.client.IsConnected()
+}
+
 // UseSSL returns the MQTT server SSL preference
 func (a *Adaptor) UseSSL() bool { return a.useSSL }
 
@@ -628,7 +1743,11 @@ func (a *Adaptor) Connect() error {
 // Disconnect returns true if connection to mqtt is closed
 func (a *Adaptor) Disconnect() error {
 	if a.client != nil {
+		wasConnected := a.client.IsConnected()
 		a.client.Disconnect(500)
+		if wasConnected {
+			a.Eventer.Publish(DisconnectedEvent, nil)
+		}
 	}
 	return nil
 }
@@ -664,6 +1783,27 @@ func (a *Adaptor) PublishWithQOS(topic string, qos int, message []byte) (Token,
 	return token, nil
 }
 
+// PublishWithProperties publishes a message with MQTT 5 properties, e.g. response topic, correlation data or user
+// properties, and returns a Token
+func (a *Adaptor) PublishWithProperties(topic string, qos int, message []byte, props *Properties) (Token, error) {
+	if a.client == nil {
+		return nil, ErrNilClient
+	}
+
+	token := a.client.PublishWithProperties(topic, byte(qos), false, message, props)
+	return token, nil
+}
+
+// ClearRetained removes the retained message of the topic on the broker, by publishing an empty retained message
+func (a *Adaptor) ClearRetained(topic string) (Token, error) {
+	if a.client == nil {
+		return nil, ErrNilClient
+	}
+
+	token := a.client.Publish(topic, byte(a.qos), true, []byte{})
+	return token, nil
+}
+
 // OnWithQOS allows per-subscribe QOS values to be set and returns a Token
 func (a *Adaptor) OnWithQOS(event string, qos int, f func(msg Message)) (Token, error) {
 	if a.client == nil {
@@ -677,12 +1817,23 @@ func (a *Adaptor) OnWithQOS(event string, qos int, f func(msg Message)) (Token,
 	return token, nil
 }
 
-// On subscribes to a topic, and then calls the message handler function when data is received
+// On subscribes to a topic, and then calls the message handler function when data is received.
+// The topic can be a filter with the wildcards '+' (single level) and '#' (multi level).
 func (a *Adaptor) On(event string, f func(msg Message)) bool {
 	_, err := a.OnWithQOS(event, a.qos, f)
 	return err == nil
 }
 
+// Unsubscribe removes the subscriptions of the given topics, which were registered by On or OnWithQOS. Note
+// that this hides the Unsubscribe() of the embedded Eventer, use a.Eventer.Unsubscribe() for event channels.
+func (a *Adaptor) Unsubscribe(topics ...string) (Token, error) {
+	if a.client == nil {
+		return nil, ErrNilClient
+	}
+
+	return a.client.Unsubscribe(topics...), nil
+}
+
 func (a *Adaptor) createClientOptions() *ClientOptions {
 	opts := NewClientOptions()
 	opts.AddBroker(a.Host)
@@ -691,7 +1842,28 @@ func (a *Adaptor) createClientOptions() *ClientOptions {
 		opts.SetPassword(a.password)
 		opts.SetUsername(a.username)
 	}
-	// Note: autoReconnect and cleanSession are handled internally
+	opts.SetAutoReconnect(a.autoReconnect)
+	opts.SetCleanSession(a.cleanSession)
+	opts.SetKeepAlive(a.keepAlive)
+	opts.SetPingTimeout(a.pingTimeout)
+	opts.SetMinReconnectInterval(a.minReconnectInterval)
+	opts.SetMaxReconnectInterval(a.maxReconnectInterval)
+	opts.SetStore(a.store)
+	opts.SetProtocolVersion(a.protocolVersion)
+	opts.SetSessionExpiryInterval(a.sessionExpiry)
+	opts.SetTopicAliasMaximum(a.topicAliasMaximum)
+	if a.will != nil {
+		opts.SetWill(a.will.topic, a.will.payload, a.will.qos, a.will.retained)
+	}
+	opts.SetOnConnectHandler(func(Client) {
+		a.Eventer.Publish(ConnectedEvent, nil)
+	})
+	opts.SetConnectionLostHandler(func(_ Client, err error) {
+		a.Eventer.Publish(ConnectionLostEvent, err)
+	})
+	opts.SetReconnectingHandler(func(Client) {
+		a.Eventer.Publish(ReconnectingEvent, nil)
+	})
 
 	if a.UseSSL() {
 		opts.SetTLSConfig(a.newTLSConfig())
@@ -1,9 +1,12 @@
 package mqtt
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -128,3 +131,910 @@ func TestMqttAdaptorQoS(t *testing.T) {
 	a.SetQoS(1)
 	assert.Equal(t, 1, a.qos)
 }
+
+func TestMqttAdaptorOnWildcards(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+
+	received := make(chan string, 10)
+	subscribe := func(filter string) {
+		token, err := a.OnWithQOS(filter, 0, func(msg Message) {
+			received <- filter + "=" + msg.Topic()
+		})
+		require.NoError(t, err)
+		require.True(t, token.Wait())
+		broker.waitForPacket(testPacketSubscribe)
+	}
+	subscribe("sensors/+/temp")
+	subscribe("sensors/#")
+	subscribe("sensors/1/temp")
+	subscribe("actors/#")
+	subscribe("#")
+	// act
+	broker.publish("sensors/1/temp", []byte("21.5"))
+	broker.publish("$SYS/broker/uptime", []byte("42"))
+	// assert
+	var got []string
+	for range 4 {
+		select {
+		case r := <-received:
+			got = append(got, r)
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "not all subscribers have been called", "got: %v", got)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"sensors/+/temp=sensors/1/temp",
+		"sensors/#=sensors/1/temp",
+		"sensors/1/temp=sensors/1/temp",
+		"#=sensors/1/temp",
+	}, got)
+	select {
+	case r := <-received:
+		assert.Fail(t, "unexpected message", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMqttAdaptorOnInvalidFilter(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+
+	token, err := a.OnWithQOS("sensors/#/temp", 0, func(msg Message) {})
+	require.NoError(t, err)
+	assert.False(t, token.Wait())
+	require.ErrorIs(t, token.Error(), ErrInvalidTopic)
+}
+
+func newTestClient(broker *testBroker, modify func(*ClientOptions)) *client {
+	opts := NewClientOptions()
+	opts.AddBroker(broker.url())
+	opts.SetClientID("client")
+	if modify != nil {
+		modify(opts)
+	}
+	return NewClient(opts).(*client) //nolint:forcetypeassert // ok here
+}
+
+func TestMqttClientConnectRefused(t *testing.T) {
+	broker := newTestBroker(t)
+	broker.connackCode.Store(0x05)
+	c := newTestClient(broker, nil)
+
+	token := c.Connect()
+
+	assert.False(t, token.Wait())
+	require.ErrorIs(t, token.Error(), ErrConnectionRefused)
+	require.ErrorContains(t, token.Error(), "not authorized")
+	assert.False(t, c.IsConnected())
+}
+
+func TestMqttClientCleanSessionFlag(t *testing.T) {
+	tests := map[string]struct {
+		cleanSession bool
+		wantFlags    byte
+	}{
+		"clean_session":      {cleanSession: true, wantFlags: 0x02},
+		"persistent_session": {cleanSession: false, wantFlags: 0x00},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			broker := newTestBroker(t)
+			c := newTestClient(broker, func(o *ClientOptions) {
+				o.SetCleanSession(tc.cleanSession)
+				o.SetKeepAlive(90 * time.Second)
+			})
+			require.True(t, c.Connect().Wait())
+			defer c.Disconnect(0)
+
+			pkt := broker.waitForPacket(testPacketConnect)
+
+			// protocol name (6 bytes), protocol level, flags, keep alive
+			assert.Equal(t, tc.wantFlags, pkt.payload[7])
+			assert.Equal(t, []byte{0x00, 90}, pkt.payload[8:10])
+		})
+	}
+}
+
+func TestMqttClientKeepAlivePing(t *testing.T) {
+	broker := newTestBroker(t)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetKeepAlive(50 * time.Millisecond)
+	})
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+
+	broker.waitForPacket(testPacketPingReq)
+	broker.waitForPacket(testPacketPingReq)
+	assert.True(t, c.IsConnected())
+}
+
+func TestMqttClientPingTimeout(t *testing.T) {
+	broker := newTestBroker(t)
+	broker.ignorePings.Store(true)
+	lost := make(chan error, 1)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetKeepAlive(50 * time.Millisecond)
+		o.SetPingTimeout(50 * time.Millisecond)
+		o.SetAutoReconnect(false)
+		o.SetConnectionLostHandler(func(_ Client, err error) { lost <- err })
+	})
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+
+	select {
+	case err := <-lost:
+		require.ErrorIs(t, err, ErrPingTimeout)
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "connection lost not detected")
+	}
+	assert.False(t, c.IsConnected())
+}
+
+func TestMqttClientKeepAliveLimits(t *testing.T) {
+	tests := map[string]struct {
+		keepAlive     time.Duration
+		pingTimeout   time.Duration
+		wantKeepAlive []byte
+		wantPing      bool
+	}{
+		"tiny_ping_timeout": {
+			keepAlive: 50 * time.Millisecond, pingTimeout: time.Nanosecond, wantKeepAlive: []byte{0x00, 1}, wantPing: true,
+		},
+		"zero_ping_timeout": {keepAlive: 50 * time.Millisecond, wantKeepAlive: []byte{0x00, 1}, wantPing: true},
+		"above_maximum":     {keepAlive: 70000 * time.Second, pingTimeout: time.Second, wantKeepAlive: []byte{0xFF, 0xFF}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			broker := newTestBroker(t)
+			c := newTestClient(broker, func(o *ClientOptions) {
+				o.SetKeepAlive(tc.keepAlive)
+				o.SetPingTimeout(tc.pingTimeout)
+			})
+			// act
+			require.True(t, c.Connect().Wait())
+			defer c.Disconnect(0)
+			// assert
+			pkt := broker.waitForPacket(testPacketConnect)
+			assert.Equal(t, tc.wantKeepAlive, pkt.payload[8:10])
+			if tc.wantPing {
+				broker.waitForPacket(testPacketPingReq)
+			}
+		})
+	}
+}
+
+func TestMqttAdaptorReconnectAndResubscribe(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	a.SetAutoReconnect(true)
+	a.SetReconnectInterval(10*time.Millisecond, 50*time.Millisecond)
+	events := a.Subscribe()
+	defer a.Eventer.Unsubscribe(events)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+
+	received := make(chan Message, 1)
+	require.True(t, a.On("sensors/+/temp", func(msg Message) { received <- msg }))
+	broker.waitForPacket(testPacketSubscribe)
+	waitForEvent(t, events, ConnectedEvent)
+	// act
+	broker.dropConnections()
+	// assert
+	waitForEvent(t, events, ConnectionLostEvent)
+	waitForEvent(t, events, ReconnectingEvent)
+	waitForEvent(t, events, ConnectedEvent)
+	assert.True(t, a.IsConnected())
+	pkt := broker.waitForPacket(testPacketSubscribe)
+	assert.Contains(t, string(pkt.payload), "sensors/+/temp")
+
+	broker.publish("sensors/1/temp", []byte("22"))
+	select {
+	case msg := <-received:
+		assert.Equal(t, "sensors/1/temp", msg.Topic())
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "message not received after reconnect")
+	}
+}
+
+func TestMqttAdaptorReconnectWithSessionPresent(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	a.SetAutoReconnect(true)
+	a.SetCleanSession(false)
+	a.SetReconnectInterval(10*time.Millisecond, 50*time.Millisecond)
+	events := a.Subscribe()
+	defer a.Eventer.Unsubscribe(events)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	require.True(t, a.On("sensors/#", func(msg Message) {}))
+	broker.waitForPacket(testPacketSubscribe)
+	broker.sessionPresent.Store(true)
+
+	broker.dropConnections()
+
+	waitForEvent(t, events, ConnectedEvent) // initial connect
+	waitForEvent(t, events, ConnectedEvent)
+	select {
+	case pkt := <-broker.received:
+		assert.NotEqual(t, byte(testPacketSubscribe), pkt.packetType(), "no resubscribe expected")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMqttAdaptorNoReconnect(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	a.SetReconnectInterval(10*time.Millisecond, 50*time.Millisecond)
+	events := a.Subscribe()
+	defer a.Eventer.Unsubscribe(events)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	waitForEvent(t, events, ConnectedEvent)
+
+	broker.dropConnections()
+
+	waitForEvent(t, events, ConnectionLostEvent)
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, a.IsConnected())
+}
+
+func TestMqttAdaptorDisconnectedEvent(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	events := a.Subscribe()
+	defer a.Eventer.Unsubscribe(events)
+	require.NoError(t, a.Connect())
+
+	require.NoError(t, a.Disconnect())
+
+	waitForEvent(t, events, DisconnectedEvent)
+	broker.waitForPacket(testPacketDisconnect)
+	assert.False(t, a.IsConnected())
+}
+
+func TestMqttClientPublishQoS1(t *testing.T) {
+	broker := newTestBroker(t)
+	store := NewMemoryStore()
+	c := newTestClient(broker, func(o *ClientOptions) { o.SetStore(store) })
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+
+	token := c.Publish("sensors/1/temp", 1, false, "21.5")
+
+	require.True(t, token.WaitTimeout(2*time.Second))
+	pkt := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, byte(0x32), pkt.header)
+	keys, err := store.All()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestMqttClientPublishQoS1NotAcknowledged(t *testing.T) {
+	broker := newTestBroker(t)
+	broker.ignorePublish.Store(true)
+	store := NewMemoryStore()
+	c := newTestClient(broker, func(o *ClientOptions) { o.SetStore(store) })
+	require.True(t, c.Connect().Wait())
+
+	token := c.Publish("sensors/1/temp", 1, false, "21.5")
+
+	broker.waitForPacket(testPacketPublish)
+	assert.False(t, token.WaitTimeout(50*time.Millisecond))
+	require.NoError(t, token.Error())
+	keys, err := store.All()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"o.1"}, keys)
+
+	c.Disconnect(0)
+	assert.False(t, token.Wait())
+	require.ErrorIs(t, token.Error(), ErrDisconnected)
+}
+
+func TestMqttClientPublishQoS2(t *testing.T) {
+	broker := newTestBroker(t)
+	c := newTestClient(broker, nil)
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+
+	token := c.Publish("actors/1/led", 2, false, []byte("on"))
+
+	require.True(t, token.WaitTimeout(2*time.Second))
+	pub := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, byte(0x34), pub.header)
+	rel := broker.waitForPacket(testPacketPubRel)
+	assert.Equal(t, byte(0x62), rel.header)
+	assert.Equal(t, pub.payload[len("actors/1/led")+2:len("actors/1/led")+4], rel.payload)
+}
+
+func TestMqttClientReceiveQoS1(t *testing.T) {
+	broker := newTestBroker(t)
+	c := newTestClient(broker, nil)
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	received := make(chan Message, 2)
+	require.True(t, c.Subscribe("sensors/#", 1, func(_ Client, msg Message) { received <- msg }).Wait())
+	broker.waitForPacket(testPacketSubscribe)
+
+	broker.publishWithQoS("sensors/1/temp", 1, 0x0105, false, []byte("22"))
+
+	ack := broker.waitForPacket(testPacketPubAck)
+	assert.Equal(t, []byte{0x01, 0x05}, ack.payload)
+	msg := <-received
+	assert.Equal(t, byte(1), msg.Qos())
+	assert.Equal(t, []byte("22"), msg.Payload())
+}
+
+func TestMqttClientReceiveQoS2ExactlyOnce(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	c := newTestClient(broker, nil)
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	received := make(chan Message, 3)
+	require.True(t, c.Subscribe("sensors/#", 2, func(_ Client, msg Message) { received <- msg }).Wait())
+	broker.waitForPacket(testPacketSubscribe)
+	// act
+	broker.publishWithQoS("sensors/1/temp", 2, 7, false, []byte("22"))
+	broker.waitForPacket(testPacketPubRec)
+	broker.publishWithQoS("sensors/1/temp", 2, 7, true, []byte("22"))
+	broker.waitForPacket(testPacketPubRec)
+	broker.send([]byte{0x62, 0x02, 0x00, 0x07})
+	comp := broker.waitForPacket(testPacketPubComp)
+	// assert
+	assert.Equal(t, []byte{0x00, 0x07}, comp.payload)
+	assert.Len(t, received, 1)
+	// after PUBREL the packet ID is free for a new message
+	broker.publishWithQoS("sensors/1/temp", 2, 7, false, []byte("23"))
+	broker.waitForPacket(testPacketPubRec)
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, received, 2)
+}
+
+func TestMqttClientRetransmitAfterReconnect(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	broker.ignorePublish.Store(true)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetMinReconnectInterval(10 * time.Millisecond)
+	})
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	token := c.Publish("sensors/1/temp", 1, false, "21.5")
+	first := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, byte(0x00), first.header&0x08)
+	// act
+	broker.ignorePublish.Store(false)
+	broker.dropConnections()
+	// assert
+	resent := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, byte(0x08), resent.header&0x08, "DUP flag expected")
+	assert.Equal(t, first.payload, resent.payload)
+	require.True(t, token.WaitTimeout(2*time.Second))
+}
+
+func TestMqttClientPublishWhileReconnecting(t *testing.T) {
+	broker := newTestBroker(t)
+	lost := make(chan error, 1)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetMinReconnectInterval(100 * time.Millisecond)
+		o.SetConnectionLostHandler(func(_ Client, err error) { lost <- err })
+	})
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	broker.dropConnections()
+	<-lost
+
+	token := c.Publish("sensors/1/temp", 2, false, "21.5")
+
+	pkt := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, byte(0x34), pkt.header&0xF6)
+	require.True(t, token.WaitTimeout(2*time.Second))
+}
+
+func TestMqttClientFileStoreResendOnStart(t *testing.T) {
+	// a message of a previous run remains in the store
+	dir := t.TempDir()
+	store := NewFileStore(dir)
+	require.NoError(t, store.Open())
+	require.NoError(t, store.Put(outboundKey(1), []byte{0x32, 0x07, 0x00, 0x01, 'a', 0x00, 0x01, 'x', 'y'}))
+	broker := newTestBroker(t)
+	broker.ignorePublish.Store(true)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetCleanSession(false)
+		o.SetStore(NewFileStore(dir))
+	})
+
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+
+	pkt := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, byte(0x3A), pkt.header)
+	// the ID of the restored message is not reused
+	assert.Equal(t, uint16(2), c.nextPacketID())
+	// the acknowledge removes the message from the store
+	broker.send([]byte{0x40, 0x02, 0x00, 0x01})
+	require.Eventually(t, func() bool {
+		keys, err := store.All()
+		return err == nil && len(keys) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestMqttClientResendPubrel(t *testing.T) {
+	tests := map[string]struct {
+		sessionPresent bool
+		wantPubrel     bool
+	}{
+		"session_present": {sessionPresent: true, wantPubrel: true},
+		"no_session":      {sessionPresent: false, wantPubrel: false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange: the flow of message 1 was at PUBREL, message 2 was not acknowledged
+			store := NewMemoryStore()
+			require.NoError(t, store.Put(outboundKey(1), ackPacket(0x62, 1)))
+			require.NoError(t, store.Put(outboundKey(2), []byte{0x32, 0x07, 0x00, 0x01, 'a', 0x00, 0x02, 'x', 'y'}))
+			broker := newTestBroker(t)
+			broker.sessionPresent.Store(tc.sessionPresent)
+			c := newTestClient(broker, func(o *ClientOptions) {
+				o.SetCleanSession(false)
+				o.SetStore(store)
+			})
+			// act
+			require.True(t, c.Connect().Wait())
+			defer c.Disconnect(0)
+			// assert
+			broker.waitForPacket(testPacketConnect)
+			if tc.wantPubrel {
+				assert.Equal(t, byte(testPacketPubRel), (<-broker.received).packetType())
+			}
+			assert.Equal(t, byte(testPacketPublish), (<-broker.received).packetType())
+			require.Eventually(t, func() bool {
+				keys, err := store.All()
+				return err == nil && len(keys) == 0
+			}, 2*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestMqttClientConnectWithWill(t *testing.T) {
+	broker := newTestBroker(t)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetWill("robots/r2d2/status", []byte("offline"), 1, true)
+	})
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+
+	pkt := broker.waitForPacket(testPacketConnect)
+
+	// will flag, will QoS 1, will retain, clean session
+	assert.Equal(t, byte(0x04|0x08|0x20|0x02), pkt.payload[7])
+	// client ID "client", will topic, will payload
+	want := []byte{0x00, 0x06}
+	want = append(want, "client"...)
+	want = append(want, 0x00, 0x12)
+	want = append(want, "robots/r2d2/status"...)
+	want = append(want, 0x00, 0x07)
+	want = append(want, "offline"...)
+	assert.Equal(t, want, pkt.payload[10:])
+}
+
+func TestMqttClientConnectWithWillPropertiesBeforeWill(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	delay := uint32(30)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetProtocolVersion(ProtocolVersion5)
+		o.SetWillProperties(&Properties{WillDelayInterval: &delay})
+		o.SetWill("robots/r2d2/status", []byte("offline"), 1, true)
+	})
+	// act
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	// assert
+	pkt := broker.waitForPacket(testPacketConnect)
+	assert.True(t, bytes.Contains(pkt.payload, []byte{propWillDelayInterval, 0x00, 0x00, 0x00, 0x1E}))
+}
+
+func TestMqttClientConnectWithInvalidWill(t *testing.T) {
+	broker := newTestBroker(t)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetWill("robots/+/status", []byte("offline"), 0, false)
+	})
+
+	token := c.Connect()
+
+	assert.False(t, token.Wait())
+	require.ErrorIs(t, token.Error(), ErrInvalidTopic)
+}
+
+func TestMqttAdaptorWill(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	a.SetWill("robots/r2d2/status", []byte("offline"), 0, true)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+
+	pkt := broker.waitForPacket(testPacketConnect)
+
+	assert.Equal(t, byte(0x04|0x20|0x02), pkt.payload[7])
+	assert.Contains(t, string(pkt.payload), "robots/r2d2/status")
+}
+
+func TestMqttAdaptorUnsubscribe(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	a.SetAutoReconnect(true)
+	a.SetReconnectInterval(10*time.Millisecond, 50*time.Millisecond)
+	events := a.Subscribe()
+	defer a.Eventer.Unsubscribe(events)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	received := make(chan string, 10)
+	require.True(t, a.On("sensors/#", func(msg Message) { received <- "all" }))
+	broker.waitForPacket(testPacketSubscribe)
+	require.True(t, a.On("sensors/+/temp", func(msg Message) { received <- "temp" }))
+	broker.waitForPacket(testPacketSubscribe)
+	// act
+	token, err := a.Unsubscribe("sensors/#")
+	// assert
+	require.NoError(t, err)
+	require.True(t, token.Wait())
+	pkt := broker.waitForPacket(testPacketUnsubscribe)
+	assert.Equal(t, append([]byte{0x00, 0x09}, "sensors/#"...), pkt.payload[2:])
+
+	broker.publish("sensors/1/temp", []byte("22"))
+	assert.Equal(t, "temp", <-received)
+	select {
+	case r := <-received:
+		assert.Fail(t, "unexpected message", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the removed subscription is not restored on reconnect
+	waitForEvent(t, events, ConnectedEvent)
+	broker.dropConnections()
+	waitForEvent(t, events, ConnectedEvent)
+	pkt = broker.waitForPacket(testPacketSubscribe)
+	assert.Contains(t, string(pkt.payload), "sensors/+/temp")
+	select {
+	case pkt := <-broker.received:
+		assert.NotEqual(t, byte(testPacketSubscribe), pkt.packetType(), "unexpected resubscribe")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMqttAdaptorUnsubscribeError(t *testing.T) {
+	a := initTestMqttAdaptor()
+	_, err := a.Unsubscribe("sensors/#")
+	require.ErrorIs(t, err, ErrNilClient)
+
+	broker := newTestBroker(t)
+	a = NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	token, err := a.Unsubscribe()
+	require.NoError(t, err)
+	assert.False(t, token.Wait())
+	require.ErrorIs(t, token.Error(), ErrInvalidTopic)
+}
+
+func TestMqttAdaptorClearRetained(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+
+	token, err := a.ClearRetained("robots/r2d2/status")
+
+	require.NoError(t, err)
+	require.True(t, token.Wait())
+	pkt := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, byte(0x31), pkt.header)
+	assert.Equal(t, append([]byte{0x00, 0x12}, "robots/r2d2/status"...), pkt.payload)
+}
+
+func TestMqttClientConnectV5(t *testing.T) {
+	broker := newTestBroker(t)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetProtocolVersion(ProtocolVersion5)
+		o.SetSessionExpiryInterval(time.Hour)
+		o.SetTopicAliasMaximum(10)
+	})
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+
+	pkt := broker.waitForPacket(testPacketConnect)
+
+	assert.Equal(t, byte(ProtocolVersion5), pkt.payload[6])
+	props, _, err := decodeProperties(pkt.payload[10:])
+	require.NoError(t, err)
+	require.NotNil(t, props.SessionExpiryInterval)
+	assert.Equal(t, uint32(3600), *props.SessionExpiryInterval)
+	require.NotNil(t, props.TopicAliasMaximum)
+	assert.Equal(t, uint16(10), *props.TopicAliasMaximum)
+}
+
+func TestMqttClientConnectV5Refused(t *testing.T) {
+	broker := newTestBroker(t)
+	broker.connackCode.Store(0x86)
+	broker.connackProps.Store(&Properties{ReasonString: "unknown user"})
+	c := newTestClient(broker, func(o *ClientOptions) { o.SetProtocolVersion(ProtocolVersion5) })
+
+	token := c.Connect()
+
+	assert.False(t, token.Wait())
+	require.ErrorIs(t, token.Error(), ErrConnectionRefused)
+	var reasonErr *ReasonCodeError
+	require.ErrorAs(t, token.Error(), &reasonErr)
+	assert.Equal(t, byte(0x86), reasonErr.Code)
+	assert.Equal(t, "unknown user", reasonErr.Reason)
+}
+
+func TestMqttClientConnectUnsupportedVersion(t *testing.T) {
+	broker := newTestBroker(t)
+	c := newTestClient(broker, func(o *ClientOptions) { o.SetProtocolVersion(3) })
+
+	token := c.Connect()
+
+	assert.False(t, token.Wait())
+	require.ErrorContains(t, token.Error(), "unsupported MQTT protocol version: 3")
+}
+
+func TestMqttClientPublishWithPropertiesV5(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	c := newTestClient(broker, func(o *ClientOptions) { o.SetProtocolVersion(ProtocolVersion5) })
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	props := &Properties{ResponseTopic: "robots/r2d2/reply", CorrelationData: []byte("42")}
+	props.AddUserProperty("unit", "celsius")
+	// act
+	token := c.PublishWithProperties("sensors/1/temp", 1, false, "21.5", props)
+	// assert
+	require.True(t, token.WaitTimeout(2*time.Second))
+	require.NoError(t, token.Error())
+	pkt := broker.waitForPacket(testPacketPublish)
+	offset := 2 + len("sensors/1/temp") + 2
+	got, n, err := decodeProperties(pkt.payload[offset:])
+	require.NoError(t, err)
+	assert.Equal(t, props, got)
+	assert.Equal(t, []byte("21.5"), pkt.payload[offset+n:])
+}
+
+func TestMqttClientPublishPropertiesIgnoredV311(t *testing.T) {
+	broker := newTestBroker(t)
+	c := newTestClient(broker, nil)
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+
+	token := c.PublishWithProperties("sensors/1/temp", 0, false, "21.5", &Properties{ContentType: "text/plain"})
+
+	require.True(t, token.WaitTimeout(2*time.Second))
+	pkt := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, append(append([]byte{0x00, 0x0E}, "sensors/1/temp"...), "21.5"...), pkt.payload)
+}
+
+func TestMqttClientReceivePropertiesV5(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	c := newTestClient(broker, func(o *ClientOptions) { o.SetProtocolVersion(ProtocolVersion5) })
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	received := make(chan Message, 1)
+	require.True(t, c.Subscribe("robots/+/command", 0, func(_ Client, msg Message) { received <- msg }).Wait())
+	props := &Properties{ResponseTopic: "robots/r2d2/reply", CorrelationData: []byte("42")}
+	props.AddUserProperty("sender", "c3po")
+	// act
+	broker.publishWithProperties("robots/r2d2/command", props, []byte("beep"))
+	// assert
+	msg := <-received
+	assert.Equal(t, "robots/r2d2/command", msg.Topic())
+	assert.Equal(t, []byte("beep"), msg.Payload())
+	assert.Equal(t, "robots/r2d2/reply", msg.Properties().ResponseTopic)
+	assert.Equal(t, []byte("42"), msg.Properties().CorrelationData)
+	sender, ok := msg.Properties().UserProperty("sender")
+	assert.True(t, ok)
+	assert.Equal(t, "c3po", sender)
+}
+
+func TestMqttClientPubackReasonCodeV5(t *testing.T) {
+	broker := newTestBroker(t)
+	broker.pubackCode.Store(0x87)
+	store := NewMemoryStore()
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetProtocolVersion(ProtocolVersion5)
+		o.SetStore(store)
+	})
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+
+	token := c.Publish("sensors/1/temp", 1, false, "21.5")
+
+	assert.False(t, token.Wait())
+	var reasonErr *ReasonCodeError
+	require.ErrorAs(t, token.Error(), &reasonErr)
+	assert.Equal(t, "PUBACK", reasonErr.Packet)
+	assert.Equal(t, byte(0x87), reasonErr.Code)
+	keys, err := store.All()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestMqttClientSubackFailure(t *testing.T) {
+	for _, version := range []uint{ProtocolVersion311, ProtocolVersion5} {
+		t.Run(fmt.Sprintf("version_%d", version), func(t *testing.T) {
+			broker := newTestBroker(t)
+			broker.subackCode.Store(0x80)
+			c := newTestClient(broker, func(o *ClientOptions) { o.SetProtocolVersion(version) })
+			require.True(t, c.Connect().Wait())
+			defer c.Disconnect(0)
+
+			token := c.Subscribe("sensors/#", 0, func(Client, Message) {})
+
+			assert.False(t, token.Wait())
+			var reasonErr *ReasonCodeError
+			require.ErrorAs(t, token.Error(), &reasonErr)
+			assert.Equal(t, "SUBACK", reasonErr.Packet)
+			assert.Equal(t, byte(0x80), reasonErr.Code)
+		})
+	}
+}
+
+func TestMqttClientOutboundTopicAliasV5(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	aliasMaximum := uint16(1)
+	broker.connackProps.Store(&Properties{TopicAliasMaximum: &aliasMaximum})
+	c := newTestClient(broker, func(o *ClientOptions) { o.SetProtocolVersion(ProtocolVersion5) })
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	// act
+	require.True(t, c.Publish("sensors/1/temp", 0, false, "21.5").Wait())
+	require.True(t, c.Publish("sensors/1/temp", 0, false, "21.6").Wait())
+	require.True(t, c.Publish("sensors/2/temp", 0, false, "19.0").Wait())
+	// assert
+	first := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, append([]byte{0x00, 0x0E}, "sensors/1/temp"...), first.payload[:16])
+	props, _, err := decodeProperties(first.payload[16:])
+	require.NoError(t, err)
+	require.NotNil(t, props.TopicAlias)
+	assert.Equal(t, uint16(1), *props.TopicAlias)
+
+	second := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, []byte{0x00, 0x00}, second.payload[:2])
+	props, _, err = decodeProperties(second.payload[2:])
+	require.NoError(t, err)
+	require.NotNil(t, props.TopicAlias)
+	assert.Equal(t, uint16(1), *props.TopicAlias)
+
+	// no alias left for the second topic
+	third := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, append([]byte{0x00, 0x0E}, "sensors/2/temp"...), third.payload[:16])
+	props, _, err = decodeProperties(third.payload[16:])
+	require.NoError(t, err)
+	assert.Nil(t, props.TopicAlias)
+}
+
+func TestMqttClientOutboundTopicAliasV5Concurrent(t *testing.T) {
+	// arrange
+	const publishes = 20
+	broker := newTestBroker(t)
+	aliasMaximum := uint16(1)
+	broker.connackProps.Store(&Properties{TopicAliasMaximum: &aliasMaximum})
+	c := newTestClient(broker, func(o *ClientOptions) { o.SetProtocolVersion(ProtocolVersion5) })
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	// act
+	var wg sync.WaitGroup
+	for range publishes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Publish("sensors/1/temp", 0, false, "21.5").Wait()
+		}()
+	}
+	wg.Wait()
+	// assert: the alias is established by the first packet, all others use the alias only
+	first := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, append([]byte{0x00, 0x0E}, "sensors/1/temp"...), first.payload[:16])
+	for range publishes - 1 {
+		pkt := broker.waitForPacket(testPacketPublish)
+		assert.Equal(t, []byte{0x00, 0x00}, pkt.payload[:2])
+	}
+}
+
+func TestMqttClientInboundTopicAliasV5(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetProtocolVersion(ProtocolVersion5)
+		o.SetTopicAliasMaximum(5)
+	})
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	received := make(chan string, 3)
+	require.True(t, c.Subscribe("sensors/#", 0, func(_ Client, msg Message) { received <- msg.Topic() }).Wait())
+	alias := uint16(3)
+	// act
+	broker.publishWithProperties("sensors/1/temp", &Properties{TopicAlias: &alias}, []byte("21.5"))
+	assert.Equal(t, "sensors/1/temp", <-received)
+	broker.publishWithProperties("", &Properties{TopicAlias: &alias}, []byte("21.6"))
+	// assert
+	assert.Equal(t, "sensors/1/temp", <-received)
+}
+
+func TestMqttClientSharedSubscription(t *testing.T) {
+	broker := newTestBroker(t)
+	c := newTestClient(broker, func(o *ClientOptions) { o.SetProtocolVersion(ProtocolVersion5) })
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	received := make(chan Message, 1)
+
+	require.True(t, c.Subscribe("$share/robots/sensors/+/temp", 0, func(_ Client, msg Message) {
+		received <- msg
+	}).Wait())
+	pkt := broker.waitForPacket(testPacketSubscribe)
+	assert.Contains(t, string(pkt.payload), "$share/robots/sensors/+/temp")
+	broker.publishWithProperties("sensors/1/temp", nil, []byte("21.5"))
+
+	msg := <-received
+	assert.Equal(t, "sensors/1/temp", msg.Topic())
+}
+
+func TestMqttClientServerDisconnectV5(t *testing.T) {
+	broker := newTestBroker(t)
+	lost := make(chan error, 1)
+	c := newTestClient(broker, func(o *ClientOptions) {
+		o.SetProtocolVersion(ProtocolVersion5)
+		o.SetConnectionLostHandler(func(_ Client, err error) { lost <- err })
+	})
+	require.True(t, c.Connect().Wait())
+	defer c.Disconnect(0)
+	broker.waitForPacket(testPacketConnect)
+
+	reason := (&Properties{ReasonString: "maintenance"}).encode()
+	broker.send(append([]byte{0xE0, byte(1 + len(reason)), 0x8B}, reason...))
+
+	err := <-lost
+	var reasonErr *ReasonCodeError
+	require.ErrorAs(t, err, &reasonErr)
+	assert.Equal(t, byte(0x8B), reasonErr.Code)
+	assert.Equal(t, "maintenance", reasonErr.Reason)
+	assert.False(t, c.IsConnected())
+}
+
+func TestMqttAdaptorProtocolVersion5(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	assert.Equal(t, uint(ProtocolVersion311), a.ProtocolVersion())
+	a.SetProtocolVersion(ProtocolVersion5)
+	a.SetSessionExpiryInterval(time.Minute)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+
+	token, err := a.PublishWithProperties("sensors/1/temp", 0, []byte("21.5"), &Properties{ContentType: "text/plain"})
+
+	require.NoError(t, err)
+	require.True(t, token.Wait())
+	pkt := broker.waitForPacket(testPacketConnect)
+	assert.Equal(t, byte(ProtocolVersion5), pkt.payload[6])
+	pkt = broker.waitForPacket(testPacketPublish)
+	props, _, err := decodeProperties(pkt.payload[16:])
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", props.ContentType)
+}
+
+func TestMqttAdaptorPublishWithPropertiesError(t *testing.T) {
+	a := initTestMqttAdaptor()
+	_, err := a.PublishWithProperties("sensors/1/temp", 0, []byte("21.5"), nil)
+	require.ErrorIs(t, err, ErrNilClient)
+}
@@ -0,0 +1,136 @@
+package mqtt
+
+import (
+	"strings"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/api"
+	"gobot.io/x/gobot/v2/pkg/metrics"
+)
+
+const (
+	// DefaultCommandPrefix is the first topic level of the command bridge, if no prefix is given
+	DefaultCommandPrefix = "gobot"
+
+	// AuthorizationProperty is the user property of a command request with the bearer token of the caller
+	AuthorizationProperty = "authorization"
+
+	commandReplyLevel = "reply"
+)
+
+// commandFilters are the subscriptions of the command bridge below the prefix, which mirror the command routes
+// of the API
+var commandFilters = []string{
+	"commands",
+	"commands/+",
+	"robots/+/commands",
+	"robots/+/commands/+",
+	"robots/+/devices/+/commands",
+	"robots/+/devices/+/commands/+",
+}
+
+// CommandBridge serves the commands of the manager and its robots and devices over MQTT, the same commands
+// which are served by the API over HTTP. A request is published to "<prefix>/<path>", e.g.
+// "gobot/robots/r2d2/devices/led/commands/Toggle", with the params as JSON object in the payload. The JSON
+// response, e.g. {"result": true} or {"error": "Unknown Command"}, is published to the response topic of the
+// request (MQTT 5), together with its correlation data. If the request has no response topic, or one below the
+// prefix but outside of "<prefix>/reply", the response is published to "<prefix>/reply/<path>".
+//
+// Without SetAuth the bridge does not authenticate the publishers of the requests, everyone who can publish to
+// the command topics on the broker can execute the commands. With SetAuth each request needs the bearer token
+// of its caller in the user property AuthorizationProperty (MQTT 5), which is verified and checked by the
+// policy, requests without valid token are rejected.
+type CommandBridge struct {
+	adaptor  *Adaptor
+	prefix   string
+	executor *api.CommandExecutor
+}
+
+// NewCommandBridge creates a bridge for the commands of the manager. An empty prefix means DefaultCommandPrefix.
+func NewCommandBridge(a *Adaptor, manager *gobot.Manager, prefix string) *CommandBridge {
+	if prefix == "" {
+		prefix = DefaultCommandPrefix
+	}
+	return &CommandBridge{
+		adaptor: a,
+		prefix:  prefix,
+		executor: &api.CommandExecutor{
+			Manager:   manager,
+			Transport: "mqtt",
+		},
+	}
+}
+
+// SetAuth sets the verifier of the tokens of the callers, e.g. api.NewTokenAuth, and the policy, which grants
+// the commands to their roles. All commands of authenticated callers are allowed without policy.
+func (b *CommandBridge) SetAuth(auth api.TokenVerifier, policy *api.Policy) {
+	b.executor.Auth = auth
+	b.executor.Policy = policy
+}
+
+// SetAuditLog sets the logger, which records each command invocation with the caller
+func (b *CommandBridge) SetAuditLog(auditLog api.AuditLogger) { b.executor.AuditLog = auditLog }
+
+// SetMetrics sets the registry, which counts the command invocations, e.g. the registry of the API
+func (b *CommandBridge) SetMetrics(registry *metrics.Registry) { b.executor.Metrics = registry }
+
+// Prefix returns the first topic level(s) of all command topics
+func (b *CommandBridge) Prefix() string { return b.prefix }
+
+// Start subscribes to the command topics, the adaptor needs to be connected
+func (b *CommandBridge) Start() error {
+	for _, filter := range commandFilters {
+		token, err := b.adaptor.OnWithQOS(b.prefix+topicLevelSeparator+filter, b.adaptor.qos, b.handle)
+		if err != nil {
+			return err
+		}
+		if !token.Wait() {
+			return token.Error()
+		}
+	}
+
+	return nil
+}
+
+// Halt removes the subscriptions of the command topics
+func (b *CommandBridge) Halt() error {
+	filters := make([]string, len(commandFilters))
+	for i, filter := range commandFilters {
+		filters[i] = b.prefix + topicLevelSeparator + filter
+	}
+
+	token, err := b.adaptor.Unsubscribe(filters...)
+	if err != nil {
+		return err
+	}
+	token.Wait()
+	return token.Error()
+}
+
+// handle executes the requested command and publishes the response
+func (b *CommandBridge) handle(msg Message) {
+	path := strings.TrimPrefix(msg.Topic(), b.prefix+topicLevelSeparator)
+	token, _ := msg.Properties().UserProperty(AuthorizationProperty)
+	response := b.executor.Execute(token, strings.Split(path, topicLevelSeparator), msg.Payload())
+
+	props := &Properties{CorrelationData: msg.Properties().CorrelationData}
+	replyTopic := msg.Properties().ResponseTopic
+	if !b.validResponseTopic(replyTopic) {
+		replyTopic = b.prefix + topicLevelSeparator + commandReplyLevel + topicLevelSeparator + path
+	}
+
+	_, _ = b.adaptor.PublishWithProperties(replyTopic, int(msg.Qos()), response, props)
+}
+
+// validResponseTopic returns whether the response topic of a request can be used for the response. A response
+// topic below the prefix is only allowed below the reply level, because the bridge would execute its response
+// as a new command on the command topics.
+func (b *CommandBridge) validResponseTopic(topic string) bool {
+	if topic == "" {
+		return false
+	}
+	if topic == b.prefix || strings.HasPrefix(topic, b.prefix+topicLevelSeparator) {
+		return strings.HasPrefix(topic, b.prefix+topicLevelSeparator+commandReplyLevel+topicLevelSeparator)
+	}
+	return true
+}
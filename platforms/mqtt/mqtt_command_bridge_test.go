@@ -0,0 +1,159 @@
+package mqtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/api"
+)
+
+func newTestCommandManager() *gobot.Manager {
+	m := gobot.NewManager()
+	r := gobot.NewRobot("r2d2")
+	r.AddCommand("beep", func(params map[string]interface{}) interface{} {
+		return params["times"]
+	})
+	m.AddRobot(r)
+	return m
+}
+
+func TestMqttCommandBridge(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	b := NewCommandBridge(a, newTestCommandManager(), "")
+	assert.Equal(t, DefaultCommandPrefix, b.Prefix())
+	require.NoError(t, b.Start())
+	// act
+	broker.publish("gobot/robots/r2d2/commands/beep", []byte(`{"times":3}`))
+	// assert
+	topic, _, payload := readTestPublish(t, broker.waitForPacket(testPacketPublish), ProtocolVersion311)
+	assert.Equal(t, "gobot/reply/robots/r2d2/commands/beep", topic)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(payload, &response))
+	assert.Equal(t, map[string]interface{}{"result": float64(3)}, response)
+
+	broker.publish("gobot/robots/c3po/commands", nil)
+	topic, _, payload = readTestPublish(t, broker.waitForPacket(testPacketPublish), ProtocolVersion311)
+	assert.Equal(t, "gobot/reply/robots/c3po/commands", topic)
+	assert.JSONEq(t, `{"error":"No Robot found with the name c3po"}`, string(payload))
+
+	require.NoError(t, b.Halt())
+	pkt := broker.waitForPacket(testPacketUnsubscribe)
+	assert.Contains(t, string(pkt.payload), "gobot/robots/+/devices/+/commands/+")
+}
+
+func TestMqttCommandBridgeAuth(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	a.SetProtocolVersion(ProtocolVersion5)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	b := NewCommandBridge(a, newTestCommandManager(), "")
+	auth := api.NewTokenAuth([]byte("secret"))
+	b.SetAuth(auth, api.NewPolicy(api.Rule{Role: "operator", Action: api.ActionRead}))
+	auditLog := &bytes.Buffer{}
+	b.SetAuditLog(api.NewAuditLog(auditLog))
+	require.NoError(t, b.Start())
+	token, err := auth.Issue(api.Identity{Subject: "alice", Roles: []string{"operator"}}, 0)
+	require.NoError(t, err)
+	props := &Properties{}
+	props.AddUserProperty(AuthorizationProperty, "Bearer "+token)
+	// act
+	broker.publishWithProperties("gobot/robots/r2d2/commands/beep", props, []byte(`{"times":3}`))
+	// assert
+	_, _, payload := readTestPublish(t, broker.waitForPacket(testPacketPublish), ProtocolVersion5)
+	assert.JSONEq(t, `{"error":"Forbidden"}`, string(payload))
+	var entry api.AuditEntry
+	require.NoError(t, json.Unmarshal(auditLog.Bytes(), &entry))
+	assert.Equal(t, "alice", entry.Subject)
+	assert.Equal(t, []string{"operator"}, entry.Roles)
+	assert.Equal(t, "mqtt", entry.Transport)
+	assert.Equal(t, "r2d2", entry.Robot)
+	assert.Equal(t, "beep", entry.Command)
+	assert.False(t, entry.Allowed)
+
+	broker.publishWithProperties("gobot/robots/r2d2/commands", props, nil)
+	_, _, payload = readTestPublish(t, broker.waitForPacket(testPacketPublish), ProtocolVersion5)
+	assert.JSONEq(t, `{"commands":["beep"]}`, string(payload))
+
+	broker.publishWithProperties("gobot/robots/r2d2/commands", &Properties{}, nil)
+	_, _, payload = readTestPublish(t, broker.waitForPacket(testPacketPublish), ProtocolVersion5)
+	assert.JSONEq(t, `{"error":"missing bearer token"}`, string(payload))
+}
+
+func TestMqttCommandBridgeSubscribeFailure(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	broker.subackCode.Store(0x80)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	b := NewCommandBridge(a, newTestCommandManager(), "")
+	// act
+	err := b.Start()
+	// assert
+	var reasonErr *ReasonCodeError
+	require.ErrorAs(t, err, &reasonErr)
+	assert.Equal(t, "SUBACK", reasonErr.Packet)
+}
+
+func TestMqttCommandBridgeResponseTopicV5(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	a.SetProtocolVersion(ProtocolVersion5)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	b := NewCommandBridge(a, newTestCommandManager(), "factory/line1")
+	require.NoError(t, b.Start())
+	// act
+	broker.publishWithProperties("factory/line1/robots/r2d2/commands",
+		&Properties{ResponseTopic: "cloud/replies/42", CorrelationData: []byte("42")}, nil)
+	// assert
+	topic, props, payload := readTestPublish(t, broker.waitForPacket(testPacketPublish), ProtocolVersion5)
+	assert.Equal(t, "cloud/replies/42", topic)
+	assert.Equal(t, []byte("42"), props.CorrelationData)
+	assert.JSONEq(t, `{"commands":["beep"]}`, string(payload))
+}
+
+func TestMqttCommandBridgeResponseTopicInPrefixV5(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	a.SetProtocolVersion(ProtocolVersion5)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	b := NewCommandBridge(a, newTestCommandManager(), "")
+	require.NoError(t, b.Start())
+	tests := map[string]string{
+		"gobot/robots/r2d2/commands/beep": "gobot/reply/robots/r2d2/commands",
+		"gobot/status":                    "gobot/reply/robots/r2d2/commands",
+		"gobot/reply/42":                  "gobot/reply/42",
+	}
+	for responseTopic, want := range tests {
+		t.Run(responseTopic, func(t *testing.T) {
+			// act
+			broker.publishWithProperties("gobot/robots/r2d2/commands", &Properties{ResponseTopic: responseTopic}, nil)
+			// assert
+			topic, _, payload := readTestPublish(t, broker.waitForPacket(testPacketPublish), ProtocolVersion5)
+			assert.Equal(t, want, topic)
+			assert.JSONEq(t, `{"commands":["beep"]}`, string(payload))
+		})
+	}
+}
+
+func TestMqttCommandBridgeNotConnected(t *testing.T) {
+	b := NewCommandBridge(initTestMqttAdaptor(), newTestCommandManager(), "")
+
+	require.ErrorIs(t, b.Start(), ErrNilClient)
+	require.ErrorIs(t, b.Halt(), ErrNilClient)
+}
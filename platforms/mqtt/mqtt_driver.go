@@ -14,6 +14,7 @@ const (
 type Driver struct {
 	name       string
 	topic      string
+	subscribed bool
 	connection gobot.Connection
 	gobot.Eventer
 	gobot.Commander
@@ -56,9 +57,12 @@ func (m *Driver) Start() error {
 	return nil
 }
 
-// Halt halts the Driver
+// Halt halts the Driver and removes the subscription of the device topic, if any
 func (m *Driver) Halt() error {
-	return nil
+	if !m.subscribed {
+		return nil
+	}
+	return m.UnsubscribeTopic()
 }
 
 // Topic returns the current topic for the Driver
@@ -81,6 +85,37 @@ func (m *Driver) On(n string, f func(msg any)) error {
 	f1 := func(msg Message) {
 		f(msg)
 	}
-	m.adaptor().On(m.topic, f1)
+	m.subscribed = m.adaptor().On(m.topic, f1)
 	return nil
 }
+
+// UnsubscribeTopic removes the subscription of the current device topic, which was registered by On. The name
+// differs from Adaptor.Unsubscribe, because Unsubscribe() is part of the embedded Eventer.
+func (m *Driver) UnsubscribeTopic() error {
+	token, err := m.adaptor().Unsubscribe(m.topic)
+	if err != nil {
+		return err
+	}
+
+	m.subscribed = false
+	return m.wait(token)
+}
+
+// ClearRetained removes the retained message of the current device topic on the broker
+func (m *Driver) ClearRetained() error {
+	token, err := m.adaptor().ClearRetained(m.topic)
+	if err != nil {
+		return err
+	}
+
+	return m.wait(token)
+}
+
+// wait waits for the completion of the token up to the write timeout of the adaptor, so a broker, which never
+// acknowledges, e.g. while disconnected, does not block the halt of the robot
+func (m *Driver) wait(token Token) error {
+	if !token.WaitTimeout(m.adaptor().WriteTimeout()) && token.Error() == nil {
+		return ErrTimeout
+	}
+	return token.Error()
+}
@@ -3,6 +3,7 @@ package mqtt
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -52,3 +53,85 @@ func TestMqttDriverPublishError(t *testing.T) {
 	defer func() { _ = d.Halt() }()
 	assert.False(t, d.Publish([]byte{0x01, 0x02, 0x03}))
 }
+
+func TestMqttDriverOnWildcard(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	d := NewDriver(a, "robots/#")
+	received := make(chan Message, 1)
+	require.NoError(t, d.On(Data, func(msg any) {
+		received <- msg.(Message) //nolint:forcetypeassert // ok here
+	}))
+	broker.waitForPacket(testPacketSubscribe)
+
+	broker.publish("robots/r2d2/status", []byte("online"))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "robots/r2d2/status", msg.Topic())
+		assert.Equal(t, []byte("online"), msg.Payload())
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "message not received")
+	}
+}
+
+func TestMqttDriverUnsubscribeTopic(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	d := NewDriver(a, "robots/r2d2/cmd")
+	require.NoError(t, d.On(Data, func(msg any) {}))
+	broker.waitForPacket(testPacketSubscribe)
+
+	require.NoError(t, d.UnsubscribeTopic())
+
+	pkt := broker.waitForPacket(testPacketUnsubscribe)
+	assert.Contains(t, string(pkt.payload), "robots/r2d2/cmd")
+}
+
+func TestMqttDriverHaltUnsubscribes(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	d := NewDriver(a, "robots/r2d2/cmd")
+	require.NoError(t, d.Start())
+	require.NoError(t, d.On(Data, func(msg any) {}))
+	broker.waitForPacket(testPacketSubscribe)
+
+	require.NoError(t, d.Halt())
+
+	broker.waitForPacket(testPacketUnsubscribe)
+}
+
+func TestMqttDriverHaltTimeout(t *testing.T) {
+	broker := newTestBroker(t)
+	broker.ignoreUnsubscribe.Store(true)
+	a := NewAdaptor(broker.url(), "client")
+	a.SetWriteTimeout(50 * time.Millisecond)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	d := NewDriver(a, "robots/r2d2/cmd")
+	require.NoError(t, d.On(Data, func(msg any) {}))
+	broker.waitForPacket(testPacketSubscribe)
+
+	err := d.Halt()
+
+	require.ErrorIs(t, err, ErrTimeout)
+}
+
+func TestMqttDriverClearRetained(t *testing.T) {
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	d := NewDriver(a, "robots/r2d2/status")
+
+	require.NoError(t, d.ClearRetained())
+
+	pkt := broker.waitForPacket(testPacketPublish)
+	assert.Equal(t, byte(0x31), pkt.header)
+}
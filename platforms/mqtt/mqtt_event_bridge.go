@@ -0,0 +1,64 @@
+package mqtt
+
+import (
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/bridge"
+)
+
+// DefaultEventNaming is the topic template of the event bridge, if no other naming is given by
+// bridge.WithEventNaming
+const DefaultEventNaming = DefaultCommandPrefix + "/robots/{robot}/devices/{device}/events/{event}"
+
+// EventBridge publishes the events of devices to MQTT topics, which are created from a template like
+// DefaultEventNaming. The events are published with the QoS of the adaptor. With bridge.WithEventInjection(), a
+// message published to "<event topic>/inject" is published as event by the device.
+type EventBridge struct {
+	*bridge.EventBridge
+	adaptor *Adaptor
+}
+
+// NewEventBridge creates a bridge for the device events of the manager's robots, see the options of the bridge
+// package for the selection of events, the encoding and the rate limit.
+func NewEventBridge(a *Adaptor, manager *gobot.Manager, opts ...bridge.EventBridgeOption) *EventBridge {
+	b := &EventBridge{adaptor: a}
+	publish := func(topic string, payload []byte) error {
+		_, err := a.PublishWithQOS(topic, a.qos, payload)
+		return err
+	}
+	opts = append([]bridge.EventBridgeOption{bridge.WithEventNaming(DefaultEventNaming, topicLevelSeparator)}, opts...)
+	b.EventBridge = bridge.NewEventBridge(manager, publish, opts...)
+	return b
+}
+
+// Start subscribes to the device events and, if enabled, to the injection topics. The adaptor needs to be
+// connected.
+func (b *EventBridge) Start() error {
+	if b.InjectionEnabled() {
+		token, err := b.adaptor.OnWithQOS(b.InjectionFilter(singleLevelWildcard), b.adaptor.qos, func(msg Message) {
+			_ = b.Inject(msg.Topic(), msg.Payload())
+		})
+		if err != nil {
+			return err
+		}
+		if !token.Wait() {
+			return token.Error()
+		}
+	}
+
+	return b.EventBridge.Start()
+}
+
+// Halt removes the subscriptions of the device events and the injection topics
+func (b *EventBridge) Halt() error {
+	err := b.EventBridge.Halt()
+	if b.InjectionEnabled() {
+		token, uerr := b.adaptor.Unsubscribe(b.InjectionFilter(singleLevelWildcard))
+		if uerr != nil {
+			return gobot.AppendError(err, uerr)
+		}
+		token.Wait()
+		err = gobot.AppendError(err, token.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,66 @@
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/bridge"
+)
+
+func TestMqttEventBridge(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	d := newTestEventDevice("button", "pushed", "stuck")
+	m := gobot.NewManager()
+	m.AddRobot(gobot.NewRobot("r2d2", []gobot.Device{d}))
+	b := NewEventBridge(a, m, bridge.WithEventInjection())
+	require.NoError(t, b.Start())
+	pkt := broker.waitForPacket(testPacketSubscribe)
+	assert.Contains(t, string(pkt.payload), "gobot/robots/+/devices/+/events/+/inject")
+	// act
+	d.Publish("pushed", true)
+	// assert
+	topic, _, payload := readTestPublish(t, broker.waitForPacket(testPacketPublish), ProtocolVersion311)
+	assert.Equal(t, "gobot/robots/r2d2/devices/button/events/pushed", topic)
+	assert.JSONEq(t, `true`, string(payload))
+
+	// inject
+	received := make(chan interface{}, 1)
+	require.NoError(t, d.Once("stuck", func(data interface{}) { received <- data }))
+	broker.publish("gobot/robots/r2d2/devices/button/events/stuck/inject", []byte(`"left"`))
+	select {
+	case data := <-received:
+		assert.Equal(t, "left", data)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not injected")
+	}
+
+	require.NoError(t, b.Halt())
+	pkt = broker.waitForPacket(testPacketUnsubscribe)
+	assert.Contains(t, string(pkt.payload), "gobot/robots/+/devices/+/events/+/inject")
+}
+
+func TestMqttEventBridgeSubscribeFailure(t *testing.T) {
+	// arrange
+	broker := newTestBroker(t)
+	broker.subackCode.Store(0x80)
+	a := NewAdaptor(broker.url(), "client")
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	m := gobot.NewManager()
+	m.AddRobot(gobot.NewRobot("r2d2", []gobot.Device{newTestEventDevice("button", "pushed")}))
+	b := NewEventBridge(a, m, bridge.WithEventInjection())
+	// act
+	err := b.Start()
+	// assert
+	var reasonErr *ReasonCodeError
+	require.ErrorAs(t, err, &reasonErr)
+	assert.Equal(t, "SUBACK", reasonErr.Packet)
+}
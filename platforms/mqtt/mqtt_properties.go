@@ -0,0 +1,426 @@
+package mqtt
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// ProtocolVersion311 is the protocol level of MQTT 3.1.1, which is used by default
+	ProtocolVersion311 = 4
+
+	// ProtocolVersion5 is the protocol level of MQTT 5.0
+	ProtocolVersion5 = 5
+)
+
+// ErrMalformedPacket is returned when a received MQTT 5 packet can not be decoded
+var ErrMalformedPacket = fmt.Errorf("malformed MQTT packet")
+
+// MQTT 5 property identifiers
+const (
+	propPayloadFormat          = 0x01
+	propMessageExpiry          = 0x02
+	propContentType            = 0x03
+	propResponseTopic          = 0x08
+	propCorrelationData        = 0x09
+	propSubscriptionIdentifier = 0x0B
+	propSessionExpiryInterval  = 0x11
+	propAssignedClientID       = 0x12
+	propServerKeepAlive        = 0x13
+	propAuthMethod             = 0x15
+	propAuthData               = 0x16
+	propRequestProblemInfo     = 0x17
+	propWillDelayInterval      = 0x18
+	propRequestResponseInfo    = 0x19
+	propResponseInfo           = 0x1A
+	propServerReference        = 0x1C
+	propReasonString           = 0x1F
+	propReceiveMaximum         = 0x21
+	propTopicAliasMaximum      = 0x22
+	propTopicAlias             = 0x23
+	propMaximumQoS             = 0x24
+	propRetainAvailable        = 0x25
+	propUserProperty           = 0x26
+	propMaximumPacketSize      = 0x27
+	propWildcardSubAvailable   = 0x28
+	propSubIDAvailable         = 0x29
+	propSharedSubAvailable     = 0x2A
+)
+
+// reasonCodeNames contains the MQTT 5 reason codes used in acknowledges and DISCONNECT
+var reasonCodeNames = map[byte]string{
+	0x00: "success",
+	0x01: "granted QoS 1",
+	0x02: "granted QoS 2",
+	0x04: "disconnect with will message",
+	0x10: "no matching subscribers",
+	0x11: "no subscription existed",
+	0x80: "unspecified error",
+	0x81: "malformed packet",
+	0x82: "protocol error",
+	0x83: "implementation specific error",
+	0x84: "unsupported protocol version",
+	0x85: "client identifier not valid",
+	0x86: "bad user name or password",
+	0x87: "not authorized",
+	0x88: "server unavailable",
+	0x89: "server busy",
+	0x8A: "banned",
+	0x8B: "server shutting down",
+	0x8C: "bad authentication method",
+	0x8D: "keep alive timeout",
+	0x8E: "session taken over",
+	0x8F: "topic filter invalid",
+	0x90: "topic name invalid",
+	0x91: "packet identifier in use",
+	0x92: "packet identifier not found",
+	0x93: "receive maximum exceeded",
+	0x94: "topic alias invalid",
+	0x95: "packet too large",
+	0x96: "message rate too high",
+	0x97: "quota exceeded",
+	0x98: "administrative action",
+	0x99: "payload format invalid",
+	0x9A: "retain not supported",
+	0x9B: "QoS not supported",
+	0x9C: "use another server",
+	0x9D: "server moved",
+	0x9E: "shared subscriptions not supported",
+	0x9F: "connection rate exceeded",
+	0xA0: "maximum connect time",
+	0xA1: "subscription identifiers not supported",
+	0xA2: "wildcard subscriptions not supported",
+}
+
+// ReasonCodeError is the error of a token, when the broker answers with a failure reason code (>= 0x80). For
+// MQTT 3.1.1 this is only the case for a failed subscription (return code 0x80).
+type ReasonCodeError struct {
+	Packet string // name of the acknowledge packet, e.g. "PUBACK"
+	Code   byte
+	Reason string // the reason string property of the broker, if any
+}
+
+func (e *ReasonCodeError) Error() string {
+	name, ok := reasonCodeNames[e.Code]
+	if !ok {
+		name = "unknown reason"
+	}
+	msg := fmt.Sprintf("MQTT %s reason code 0x%02X (%s)", e.Packet, e.Code, name)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// UserProperty is a name-value pair, which is transmitted unchanged by the broker
+type UserProperty struct {
+	Key   string
+	Value string
+}
+
+// Properties contains the MQTT 5 properties of a packet. Unset optional values are nil. For MQTT 3.1.1 all
+// properties are ignored.
+type Properties struct {
+	// PUBLISH and will properties
+	PayloadFormat   *byte
+	MessageExpiry   *uint32
+	ContentType     string
+	ResponseTopic   string
+	CorrelationData []byte
+	TopicAlias      *uint16
+	// SubscriptionIdentifiers contains the identifiers of all matching subscriptions of a received message
+	SubscriptionIdentifiers []int
+	WillDelayInterval       *uint32
+
+	// CONNECT and CONNACK properties
+	SessionExpiryInterval *uint32
+	AssignedClientID      string
+	ServerKeepAlive       *uint16
+	AuthMethod            string
+	AuthData              []byte
+	RequestProblemInfo    *byte
+	RequestResponseInfo   *byte
+	ResponseInfo          string
+	ServerReference       string
+	ReceiveMaximum        *uint16
+	TopicAliasMaximum     *uint16
+	MaximumQoS            *byte
+	RetainAvailable       *byte
+	MaximumPacketSize     *uint32
+	WildcardSubAvailable  *byte
+	SubIDAvailable        *byte
+	SharedSubAvailable    *byte
+
+	// common properties
+	ReasonString   string
+	UserProperties []UserProperty
+}
+
+// AddUserProperty appends a name-value pair to the user properties
+func (p *Properties) AddUserProperty(key, value string) {
+	p.UserProperties = append(p.UserProperties, UserProperty{Key: key, Value: value})
+}
+
+// UserProperty returns the value of the first user property with the given key
+func (p *Properties) UserProperty(key string) (string, bool) {
+	for _, up := range p.UserProperties {
+		if up.Key == key {
+			return up.Value, true
+		}
+	}
+	return "", false
+}
+
+// encode serializes the properties including the leading property length
+func (p *Properties) encode() []byte {
+	var buf []byte
+	if p != nil {
+		buf = appendByteProp(buf, propPayloadFormat, p.PayloadFormat)
+		buf = appendUint32Prop(buf, propMessageExpiry, p.MessageExpiry)
+		buf = appendStringProp(buf, propContentType, p.ContentType)
+		buf = appendStringProp(buf, propResponseTopic, p.ResponseTopic)
+		buf = appendBinaryProp(buf, propCorrelationData, p.CorrelationData)
+		for _, id := range p.SubscriptionIdentifiers {
+			buf = append(buf, propSubscriptionIdentifier)
+			buf = append(buf, encodeLength(id)...)
+		}
+		buf = appendUint32Prop(buf, propSessionExpiryInterval, p.SessionExpiryInterval)
+		buf = appendStringProp(buf, propAssignedClientID, p.AssignedClientID)
+		buf = appendUint16Prop(buf, propServerKeepAlive, p.ServerKeepAlive)
+		buf = appendStringProp(buf, propAuthMethod, p.AuthMethod)
+		buf = appendBinaryProp(buf, propAuthData, p.AuthData)
+		buf = appendByteProp(buf, propRequestProblemInfo, p.RequestProblemInfo)
+		buf = appendUint32Prop(buf, propWillDelayInterval, p.WillDelayInterval)
+		buf = appendByteProp(buf, propRequestResponseInfo, p.RequestResponseInfo)
+		buf = appendStringProp(buf, propResponseInfo, p.ResponseInfo)
+		buf = appendStringProp(buf, propServerReference, p.ServerReference)
+		buf = appendStringProp(buf, propReasonString, p.ReasonString)
+		buf = appendUint16Prop(buf, propReceiveMaximum, p.ReceiveMaximum)
+		buf = appendUint16Prop(buf, propTopicAliasMaximum, p.TopicAliasMaximum)
+		buf = appendUint16Prop(buf, propTopicAlias, p.TopicAlias)
+		buf = appendByteProp(buf, propMaximumQoS, p.MaximumQoS)
+		buf = appendByteProp(buf, propRetainAvailable, p.RetainAvailable)
+		for _, up := range p.UserProperties {
+			buf = append(buf, propUserProperty)
+			buf = appendString(buf, up.Key)
+			buf = appendString(buf, up.Value)
+		}
+		buf = appendUint32Prop(buf, propMaximumPacketSize, p.MaximumPacketSize)
+		buf = appendByteProp(buf, propWildcardSubAvailable, p.WildcardSubAvailable)
+		buf = appendByteProp(buf, propSubIDAvailable, p.SubIDAvailable)
+		buf = appendByteProp(buf, propSharedSubAvailable, p.SharedSubAvailable)
+	}
+
+	return append(encodeLength(len(buf)), buf...)
+}
+
+// decodeProperties parses the properties including the leading property length and returns the number of
+// consumed bytes
+func decodeProperties(data []byte) (*Properties, int, error) {
+	length, n, err := decodeLength(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if n+length > len(data) {
+		return nil, 0, fmt.Errorf("%w: property length %d exceeds packet", ErrMalformedPacket, length)
+	}
+
+	p := &Properties{}
+	r := propertyReader{data: data[n : n+length]}
+	for r.err == nil && r.pos < len(r.data) {
+		id := r.byte()
+		switch id {
+		case propPayloadFormat:
+			p.PayloadFormat = r.bytePtr()
+		case propMessageExpiry:
+			p.MessageExpiry = r.uint32Ptr()
+		case propContentType:
+			p.ContentType = r.string()
+		case propResponseTopic:
+			p.ResponseTopic = r.string()
+		case propCorrelationData:
+			p.CorrelationData = r.binary()
+		case propSubscriptionIdentifier:
+			p.SubscriptionIdentifiers = append(p.SubscriptionIdentifiers, r.varint())
+		case propSessionExpiryInterval:
+			p.SessionExpiryInterval = r.uint32Ptr()
+		case propAssignedClientID:
+			p.AssignedClientID = r.string()
+		case propServerKeepAlive:
+			p.ServerKeepAlive = r.uint16Ptr()
+		case propAuthMethod:
+			p.AuthMethod = r.string()
+		case propAuthData:
+			p.AuthData = r.binary()
+		case propRequestProblemInfo:
+			p.RequestProblemInfo = r.bytePtr()
+		case propWillDelayInterval:
+			p.WillDelayInterval = r.uint32Ptr()
+		case propRequestResponseInfo:
+			p.RequestResponseInfo = r.bytePtr()
+		case propResponseInfo:
+			p.ResponseInfo = r.string()
+		case propServerReference:
+			p.ServerReference = r.string()
+		case propReasonString:
+			p.ReasonString = r.string()
+		case propReceiveMaximum:
+			p.ReceiveMaximum = r.uint16Ptr()
+		case propTopicAliasMaximum:
+			p.TopicAliasMaximum = r.uint16Ptr()
+		case propTopicAlias:
+			p.TopicAlias = r.uint16Ptr()
+		case propMaximumQoS:
+			p.MaximumQoS = r.bytePtr()
+		case propRetainAvailable:
+			p.RetainAvailable = r.bytePtr()
+		case propUserProperty:
+			key := r.string()
+			p.UserProperties = append(p.UserProperties, UserProperty{Key: key, Value: r.string()})
+		case propMaximumPacketSize:
+			p.MaximumPacketSize = r.uint32Ptr()
+		case propWildcardSubAvailable:
+			p.WildcardSubAvailable = r.bytePtr()
+		case propSubIDAvailable:
+			p.SubIDAvailable = r.bytePtr()
+		case propSharedSubAvailable:
+			p.SharedSubAvailable = r.bytePtr()
+		default:
+			return nil, 0, fmt.Errorf("%w: unknown property identifier 0x%02X", ErrMalformedPacket, id)
+		}
+	}
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+
+	return p, n + length, nil
+}
+
+// decodeLength decodes a variable byte integer and returns the number of consumed bytes
+func decodeLength(data []byte) (int, int, error) {
+	length := 0
+	multiplier := 1
+	for i := range min(len(data), 4) {
+		length += int(data[i]&127) * multiplier
+		if data[i]&128 == 0 {
+			return length, i + 1, nil
+		}
+		multiplier *= 128
+	}
+	return 0, 0, fmt.Errorf("%w: invalid variable byte integer", ErrMalformedPacket)
+}
+
+// propertyReader reads the values of properties and remembers the first error
+type propertyReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *propertyReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("%w: property exceeds property length", ErrMalformedPacket)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *propertyReader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *propertyReader) bytePtr() *byte {
+	v := r.byte()
+	return &v
+}
+
+func (r *propertyReader) uint16Ptr() *uint16 {
+	var v uint16
+	if b := r.next(2); b != nil {
+		v = binary.BigEndian.Uint16(b)
+	}
+	return &v
+}
+
+func (r *propertyReader) uint32Ptr() *uint32 {
+	var v uint32
+	if b := r.next(4); b != nil {
+		v = binary.BigEndian.Uint32(b)
+	}
+	return &v
+}
+
+func (r *propertyReader) varint() int {
+	if r.err != nil {
+		return 0
+	}
+	v, n, err := decodeLength(r.data[r.pos:])
+	if err != nil {
+		r.err = err
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *propertyReader) binary() []byte {
+	b := r.next(2)
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, r.next(int(binary.BigEndian.Uint16(b)))...)
+}
+
+func (r *propertyReader) string() string {
+	return string(r.binary())
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = append(buf, byte(len(s)>>8), byte(len(s)))
+	return append(buf, s...)
+}
+
+func appendByteProp(buf []byte, id byte, v *byte) []byte {
+	if v == nil {
+		return buf
+	}
+	return append(buf, id, *v)
+}
+
+func appendUint16Prop(buf []byte, id byte, v *uint16) []byte {
+	if v == nil {
+		return buf
+	}
+	return append(buf, id, byte(*v>>8), byte(*v))
+}
+
+func appendUint32Prop(buf []byte, id byte, v *uint32) []byte {
+	if v == nil {
+		return buf
+	}
+	buf = append(buf, id)
+	return binary.BigEndian.AppendUint32(buf, *v)
+}
+
+func appendStringProp(buf []byte, id byte, v string) []byte {
+	if v == "" {
+		return buf
+	}
+	return appendString(append(buf, id), v)
+}
+
+func appendBinaryProp(buf []byte, id byte, v []byte) []byte {
+	if v == nil {
+		return buf
+	}
+	buf = append(buf, id, byte(len(v)>>8), byte(len(v)))
+	return append(buf, v...)
+}
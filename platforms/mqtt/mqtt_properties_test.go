@@ -0,0 +1,70 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertiesEncodeDecode(t *testing.T) {
+	// arrange
+	format := byte(1)
+	expiry := uint32(3600)
+	alias := uint16(7)
+	want := &Properties{
+		PayloadFormat:           &format,
+		MessageExpiry:           &expiry,
+		ContentType:             "application/json",
+		ResponseTopic:           "robots/r2d2/reply",
+		CorrelationData:         []byte{0x01, 0x02},
+		TopicAlias:              &alias,
+		SubscriptionIdentifiers: []int{1, 300},
+		ReasonString:            "all fine",
+	}
+	want.AddUserProperty("robot", "r2d2")
+	want.AddUserProperty("robot", "c3po")
+	// act
+	data := want.encode()
+	got, n, err := decodeProperties(data)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	assert.Equal(t, want, got)
+	val, ok := got.UserProperty("robot")
+	assert.True(t, ok)
+	assert.Equal(t, "r2d2", val)
+	_, ok = got.UserProperty("unknown")
+	assert.False(t, ok)
+}
+
+func TestPropertiesEncodeNil(t *testing.T) {
+	var p *Properties
+	assert.Equal(t, []byte{0x00}, p.encode())
+}
+
+func Test_decodePropertiesMalformed(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":               {},
+		"length_exceeds":      {0x05, 0x01},
+		"unknown_identifier":  {0x01, 0x7F},
+		"truncated_uint16":    {0x02, propTopicAlias, 0x00},
+		"truncated_string":    {0x04, propContentType, 0x00, 0x05, 'a'},
+		"truncated_user_prop": {0x04, propUserProperty, 0x00, 0x01, 'a'},
+		"unterminated_varint": {0x03, propSubscriptionIdentifier, 0x80, 0x80},
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := decodeProperties(data)
+			require.ErrorIs(t, err, ErrMalformedPacket)
+		})
+	}
+}
+
+func TestReasonCodeError(t *testing.T) {
+	err := &ReasonCodeError{Packet: "PUBACK", Code: 0x87}
+	assert.Equal(t, "MQTT PUBACK reason code 0x87 (not authorized)", err.Error())
+
+	err = &ReasonCodeError{Packet: "SUBACK", Code: 0xFF, Reason: "quota"}
+	assert.Equal(t, "MQTT SUBACK reason code 0xFF (unknown reason): quota", err.Error())
+}
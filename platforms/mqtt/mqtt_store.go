@@ -0,0 +1,207 @@
+package mqtt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	outboundKeyPrefix = "o."
+	inboundKeyPrefix  = "i."
+	fileStoreSuffix   = ".msg"
+)
+
+// Store persists the packets of the QoS 1 and QoS 2 flows, which are in-flight. Outbound packets are
+// retransmitted after a reconnect, inbound packet identifiers are used to suppress duplicate deliveries.
+// Keys are of the form "o.<packet id>" for outbound and "i.<packet id>" for inbound packets.
+type Store interface {
+	// Open prepares the store for use, it is called before the first connect
+	Open() error
+	// Put stores the packet under the given key, an existing packet is replaced
+	Put(key string, packet []byte) error
+	// Get returns the packet stored under the given key or nil, if there is none
+	Get(key string) ([]byte, error)
+	// All returns the keys of all stored packets
+	All() ([]string, error)
+	// Del removes the packet stored under the given key, a missing key is not an error
+	Del(key string) error
+	// Reset removes all stored packets
+	Reset() error
+	// Close releases the store, it is called on disconnect
+	Close() error
+}
+
+// memoryStore implements Store by a map, so in-flight packets survive a reconnect but not a restart
+type memoryStore struct {
+	mtx     sync.Mutex
+	packets map[string][]byte
+}
+
+// NewMemoryStore creates a Store, which holds the in-flight packets in memory. This is the default store.
+func NewMemoryStore() Store {
+	return &memoryStore{packets: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Open() error { return nil }
+
+func (s *memoryStore) Put(key string, packet []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.packets[key] = slices.Clone(packet)
+	return nil
+}
+
+func (s *memoryStore) Get(key string) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return slices.Clone(s.packets[key]), nil
+}
+
+func (s *memoryStore) All() ([]string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	keys := make([]string, 0, len(s.packets))
+	for key := range s.packets {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *memoryStore) Del(key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.packets, key)
+	return nil
+}
+
+func (s *memoryStore) Reset() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.packets = make(map[string][]byte)
+	return nil
+}
+
+func (s *memoryStore) Close() error { return nil }
+
+// fileStore implements Store by one file per packet, so in-flight packets survive also a restart
+type fileStore struct {
+	mtx       sync.Mutex
+	directory string
+}
+
+// NewFileStore creates a Store, which persists the in-flight packets in the given directory. The directory is
+// created on open, if not exist.
+func NewFileStore(directory string) Store {
+	return &fileStore{directory: directory}
+}
+
+func (s *fileStore) Open() error {
+	return os.MkdirAll(s.directory, 0o750)
+}
+
+// Put writes the packet to a temporary file first, so a crash never leaves a partially written packet
+func (s *fileStore) Put(key string, packet []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	tmpPath := s.path(key) + ".tmp"
+	if err := os.WriteFile(tmpPath, packet, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path(key))
+}
+
+func (s *fileStore) Get(key string) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	packet, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return packet, err
+}
+
+func (s *fileStore) All() ([]string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	entries, err := os.ReadDir(s.directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && strings.HasSuffix(name, fileStoreSuffix) {
+			keys = append(keys, strings.TrimSuffix(name, fileStoreSuffix))
+		}
+	}
+	return keys, nil
+}
+
+func (s *fileStore) Del(key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) Reset() error {
+	keys, err := s.All()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := s.Del(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fileStore) Close() error { return nil }
+
+func (s *fileStore) path(key string) string {
+	return filepath.Join(s.directory, key+fileStoreSuffix)
+}
+
+func outboundKey(packetID uint16) string {
+	return outboundKeyPrefix + strconv.Itoa(int(packetID))
+}
+
+func inboundKey(packetID uint16) string {
+	return inboundKeyPrefix + strconv.Itoa(int(packetID))
+}
+
+// parseStoreKey returns the packet identifier of an outbound or inbound store key
+func parseStoreKey(key string) (outbound bool, packetID uint16, err error) {
+	var idStr string
+	switch {
+	case strings.HasPrefix(key, outboundKeyPrefix):
+		outbound = true
+		idStr = strings.TrimPrefix(key, outboundKeyPrefix)
+	case strings.HasPrefix(key, inboundKeyPrefix):
+		idStr = strings.TrimPrefix(key, inboundKeyPrefix)
+	default:
+		return false, 0, fmt.Errorf("unknown MQTT store key '%s'", key)
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 16)
+	if err != nil {
+		return false, 0, fmt.Errorf("invalid packet id in MQTT store key '%s': %w", key, err)
+	}
+
+	return outbound, uint16(id), nil
+}
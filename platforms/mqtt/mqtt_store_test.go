@@ -0,0 +1,84 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStores(t *testing.T) {
+	tests := map[string]struct {
+		store func(t *testing.T) Store
+	}{
+		"memory": {store: func(*testing.T) Store { return NewMemoryStore() }},
+		"file":   {store: func(t *testing.T) Store { return NewFileStore(t.TempDir() + "/store") }},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			s := tc.store(t)
+			require.NoError(t, s.Open())
+			defer func() { _ = s.Close() }()
+			// act & assert
+			require.NoError(t, s.Put("o.1", []byte{0x32, 0x01}))
+			require.NoError(t, s.Put("o.2", []byte{0x34, 0x02}))
+			require.NoError(t, s.Put("i.1", []byte{0x34}))
+			require.NoError(t, s.Put("o.2", []byte{0x62, 0x02}))
+
+			got, err := s.Get("o.2")
+			require.NoError(t, err)
+			assert.Equal(t, []byte{0x62, 0x02}, got)
+			got, err = s.Get("o.3")
+			require.NoError(t, err)
+			assert.Nil(t, got)
+
+			keys, err := s.All()
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{"o.1", "o.2", "i.1"}, keys)
+
+			require.NoError(t, s.Del("o.1"))
+			require.NoError(t, s.Del("o.1"))
+			keys, err = s.All()
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{"o.2", "i.1"}, keys)
+
+			require.NoError(t, s.Reset())
+			keys, err = s.All()
+			require.NoError(t, err)
+			assert.Empty(t, keys)
+		})
+	}
+}
+
+func TestFileStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileStore(dir)
+	require.NoError(t, s.Open())
+	require.NoError(t, s.Put("o.7", []byte{0x32, 0x07}))
+	require.NoError(t, s.Close())
+
+	s = NewFileStore(dir)
+	require.NoError(t, s.Open())
+	got, err := s.Get("o.7")
+
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x32, 0x07}, got)
+}
+
+func Test_parseStoreKey(t *testing.T) {
+	outbound, id, err := parseStoreKey(outboundKey(513))
+	require.NoError(t, err)
+	assert.True(t, outbound)
+	assert.Equal(t, uint16(513), id)
+
+	outbound, id, err = parseStoreKey(inboundKey(7))
+	require.NoError(t, err)
+	assert.False(t, outbound)
+	assert.Equal(t, uint16(7), id)
+
+	_, _, err = parseStoreKey("x.1")
+	require.ErrorContains(t, err, "unknown MQTT store key")
+	_, _, err = parseStoreKey("o.70000")
+	require.ErrorContains(t, err, "invalid packet id")
+}
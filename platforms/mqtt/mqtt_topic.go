@@ -0,0 +1,97 @@
+package mqtt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidTopic is returned when a topic name or topic filter violates the MQTT rules
+var ErrInvalidTopic = fmt.Errorf("invalid MQTT topic")
+
+const (
+	topicLevelSeparator = "/"
+	singleLevelWildcard = "+"
+	multiLevelWildcard  = "#"
+	sharedSubscription  = "$share/"
+)
+
+// validateTopicFilter checks a subscription filter, where '+' must occupy a whole level and '#' must occupy
+// the last level. A shared subscription "$share/<group>/<filter>" needs a group name without wildcards.
+func validateTopicFilter(filter string) error {
+	if filter == "" {
+		return fmt.Errorf("%w: empty topic filter", ErrInvalidTopic)
+	}
+
+	if strings.HasPrefix(filter, sharedSubscription) {
+		group, sharedFilter, found := strings.Cut(strings.TrimPrefix(filter, sharedSubscription), topicLevelSeparator)
+		if !found || group == "" || sharedFilter == "" {
+			return fmt.Errorf("%w: shared subscription '%s' needs a group and a filter", ErrInvalidTopic, filter)
+		}
+		if strings.ContainsAny(group, singleLevelWildcard+multiLevelWildcard) {
+			return fmt.Errorf("%w: wildcards are not allowed in group of shared subscription '%s'", ErrInvalidTopic,
+				filter)
+		}
+		return validateTopicFilter(sharedFilter)
+	}
+
+	levels := strings.Split(filter, topicLevelSeparator)
+	for i, level := range levels {
+		if strings.Contains(level, multiLevelWildcard) && (level != multiLevelWildcard || i != len(levels)-1) {
+			return fmt.Errorf("%w: '%s' must be the last level in filter '%s'", ErrInvalidTopic, multiLevelWildcard, filter)
+		}
+		if strings.Contains(level, singleLevelWildcard) && level != singleLevelWildcard {
+			return fmt.Errorf("%w: '%s' must occupy an entire level in filter '%s'", ErrInvalidTopic, singleLevelWildcard,
+				filter)
+		}
+	}
+
+	return nil
+}
+
+// validateTopicName checks a topic name used for publishing, which must not contain any wildcard.
+func validateTopicName(topic string) error {
+	if topic == "" {
+		return fmt.Errorf("%w: empty topic name", ErrInvalidTopic)
+	}
+	if strings.ContainsAny(topic, singleLevelWildcard+multiLevelWildcard) {
+		return fmt.Errorf("%w: wildcards are not allowed in topic name '%s'", ErrInvalidTopic, topic)
+	}
+
+	return nil
+}
+
+// sharedSubscriptionFilter returns the topic filter of a shared subscription "$share/<group>/<filter>", other
+// filters are returned unchanged
+func sharedSubscriptionFilter(filter string) string {
+	if !strings.HasPrefix(filter, sharedSubscription) {
+		return filter
+	}
+	_, sharedFilter, _ := strings.Cut(strings.TrimPrefix(filter, sharedSubscription), topicLevelSeparator)
+	return sharedFilter
+}
+
+// matchTopic reports whether the topic name matches the topic filter, according to the MQTT 3.1.1 rules:
+// '+' matches exactly one level, '#' matches the parent level and any number of child levels and topics
+// starting with '$' (e.g. "$SYS/...") are not matched by filters starting with a wildcard.
+func matchTopic(filter, topic string) bool {
+	if strings.HasPrefix(topic, "$") &&
+		(strings.HasPrefix(filter, singleLevelWildcard) || strings.HasPrefix(filter, multiLevelWildcard)) {
+		return false
+	}
+
+	filterLevels := strings.Split(filter, topicLevelSeparator)
+	topicLevels := strings.Split(topic, topicLevelSeparator)
+	for i, level := range filterLevels {
+		if level == multiLevelWildcard {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != singleLevelWildcard && level != topicLevels[i] {
+			return false
+		}
+	}
+
+	return len(filterLevels) == len(topicLevels)
+}
@@ -0,0 +1,84 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_matchTopic(t *testing.T) {
+	tests := map[string]struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		"exact":                         {filter: "sensors/1/temp", topic: "sensors/1/temp", want: true},
+		"exact_mismatch":                {filter: "sensors/1/temp", topic: "sensors/2/temp", want: false},
+		"single_level":                  {filter: "sensors/+/temp", topic: "sensors/1/temp", want: true},
+		"single_level_empty":            {filter: "sensors/+/temp", topic: "sensors//temp", want: true},
+		"single_level_not_multi":        {filter: "sensors/+/temp", topic: "sensors/1/a/temp", want: false},
+		"single_level_not_parent":       {filter: "sensors/+", topic: "sensors", want: false},
+		"single_level_only":             {filter: "+", topic: "sensors", want: true},
+		"single_level_leading_slash":    {filter: "+", topic: "/sensors", want: false},
+		"single_level_twice":            {filter: "+/+", topic: "/sensors", want: true},
+		"multi_level":                   {filter: "sensors/#", topic: "sensors/1/temp", want: true},
+		"multi_level_parent":            {filter: "sensors/#", topic: "sensors", want: true},
+		"multi_level_only":              {filter: "#", topic: "sensors/1/temp", want: true},
+		"multi_level_mismatch":          {filter: "sensors/#", topic: "actors/1", want: false},
+		"mixed":                         {filter: "sensors/+/#", topic: "sensors/1/temp/raw", want: true},
+		"longer_filter":                 {filter: "sensors/1/temp", topic: "sensors/1", want: false},
+		"sys_not_multi_level_wildcard":  {filter: "#", topic: "$SYS/broker/uptime", want: false},
+		"sys_not_single_level_wildcard": {filter: "+/broker/uptime", topic: "$SYS/broker/uptime", want: false},
+		"sys_explicit":                  {filter: "$SYS/#", topic: "$SYS/broker/uptime", want: true},
+		"sys_explicit_single_level":     {filter: "$SYS/+/uptime", topic: "$SYS/broker/uptime", want: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, matchTopic(tc.filter, tc.topic))
+		})
+	}
+}
+
+func Test_validateTopicFilter(t *testing.T) {
+	tests := map[string]struct {
+		filter  string
+		wantErr string
+	}{
+		"plain":                  {filter: "sensors/1/temp"},
+		"wildcards":              {filter: "sensors/+/temp/#"},
+		"multi_level_only":       {filter: "#"},
+		"error_empty":            {filter: "", wantErr: "empty topic filter"},
+		"error_multi_not_last":   {filter: "sensors/#/temp", wantErr: "must be the last level"},
+		"error_multi_in_level":   {filter: "sensors#", wantErr: "must be the last level"},
+		"error_single_in_level":  {filter: "sensors/a+/temp", wantErr: "must occupy an entire level"},
+		"shared":                 {filter: "$share/robots/sensors/+/temp"},
+		"error_shared_no_group":  {filter: "$share//sensors", wantErr: "needs a group and a filter"},
+		"error_shared_no_filter": {filter: "$share/robots", wantErr: "needs a group and a filter"},
+		"error_shared_wildcard":  {filter: "$share/+/sensors", wantErr: "wildcards are not allowed in group"},
+		"error_shared_filter":    {filter: "$share/robots/sensors#", wantErr: "must be the last level"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateTopicFilter(tc.filter)
+			if tc.wantErr != "" {
+				require.ErrorIs(t, err, ErrInvalidTopic)
+				require.ErrorContains(t, err, tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_sharedSubscriptionFilter(t *testing.T) {
+	assert.Equal(t, "sensors/+/temp", sharedSubscriptionFilter("$share/robots/sensors/+/temp"))
+	assert.Equal(t, "sensors/+/temp", sharedSubscriptionFilter("sensors/+/temp"))
+}
+
+func Test_validateTopicName(t *testing.T) {
+	require.NoError(t, validateTopicName("sensors/1/temp"))
+	require.ErrorIs(t, validateTopicName(""), ErrInvalidTopic)
+	require.ErrorIs(t, validateTopicName("sensors/+/temp"), ErrInvalidTopic)
+	require.ErrorIs(t, validateTopicName("sensors/#"), ErrInvalidTopic)
+}
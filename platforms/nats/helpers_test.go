@@ -0,0 +1,211 @@
+package nats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gobot.io/x/gobot/v2"
+)
+
+// testServer is a minimal in-process stand-in for a NATS server, which speaks the core text protocol with
+// headers but without JetStream. Messages are routed to all subscriptions with a matching subject.
+type testServer struct {
+	t        *testing.T
+	listener net.Listener
+	mtx      sync.Mutex
+	subs     map[*testServerConn]map[string]string // subscription ID to subject
+}
+
+type testServerConn struct {
+	conn net.Conn
+	mtx  sync.Mutex
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("test server can not listen: %v", err)
+	}
+
+	s := &testServer{
+		t:        t,
+		listener: listener,
+		subs:     make(map[*testServerConn]map[string]string),
+	}
+	go s.serve()
+	t.Cleanup(s.close)
+
+	return s
+}
+
+func (s *testServer) url() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *testServer) close() {
+	_ = s.listener.Close()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for c := range s.subs {
+		_ = c.conn.Close()
+	}
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &testServerConn{conn: conn}
+		s.mtx.Lock()
+		s.subs[c] = make(map[string]string)
+		s.mtx.Unlock()
+
+		go s.handle(c)
+	}
+}
+
+func (s *testServer) handle(c *testServerConn) {
+	defer func() {
+		s.mtx.Lock()
+		delete(s.subs, c)
+		s.mtx.Unlock()
+		_ = c.conn.Close()
+	}()
+
+	info := `INFO {"server_id":"test","version":"2.10.0","proto":1,"max_payload":1048576,"headers":true}`
+	if c.write(info+"\r\n") != nil {
+		return
+	}
+
+	r := bufio.NewReader(c.conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			err = c.write("PONG\r\n")
+		case "SUB":
+			// SUB <subject> [queue group] <sid>
+			s.mtx.Lock()
+			s.subs[c][fields[len(fields)-1]] = fields[1]
+			s.mtx.Unlock()
+		case "UNSUB":
+			s.mtx.Lock()
+			delete(s.subs[c], fields[1])
+			s.mtx.Unlock()
+		case "PUB", "HPUB":
+			// PUB <subject> [reply-to] <#bytes>, HPUB <subject> [reply-to] <#header bytes> <#total bytes>
+			args := fields[1:]
+			headerSize := 0
+			if strings.ToUpper(fields[0]) == "HPUB" {
+				if headerSize, err = strconv.Atoi(args[len(args)-2]); err != nil {
+					return
+				}
+				args = append(args[:len(args)-2], args[len(args)-1])
+			}
+			size, perr := strconv.Atoi(args[len(args)-1])
+			if perr != nil {
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			var reply string
+			if len(args) == 3 {
+				reply = args[1]
+			}
+			s.route(args[0], reply, headerSize, payload[:size])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// route sends the message to all subscriptions with a matching subject, the payload starts with the headers
+// of the given size
+func (s *testServer) route(subject, reply string, headerSize int, payload []byte) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for c, subs := range s.subs {
+		for sid, filter := range subs {
+			if !matchTestSubject(filter, subject) {
+				continue
+			}
+			header := "MSG " + subject + " " + sid
+			if headerSize > 0 {
+				header = "H" + header
+			}
+			if reply != "" {
+				header += " " + reply
+			}
+			if headerSize > 0 {
+				header += " " + strconv.Itoa(headerSize)
+			}
+			_ = c.write(fmt.Sprintf("%s %d\r\n%s\r\n", header, len(payload), payload))
+		}
+	}
+}
+
+func (c *testServerConn) write(data string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	_, err := c.conn.Write([]byte(data))
+	return err
+}
+
+// matchTestSubject reports whether the subject matches the filter with the wildcards '*' and '>'
+func matchTestSubject(filter, subject string) bool {
+	filterTokens := strings.Split(filter, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, token := range filterTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(filterTokens) == len(subjectTokens)
+}
+
+// testEventDevice is a device, which publishes events
+type testEventDevice struct {
+	name string
+	gobot.Eventer
+}
+
+func newTestEventDevice(name string, events ...string) *testEventDevice {
+	d := &testEventDevice{name: name, Eventer: gobot.NewEventer()}
+	for _, event := range events {
+		d.AddEvent(event)
+	}
+	return d
+}
+
+func (d *testEventDevice) Name() string                 { return d.name }
+func (d *testEventDevice) SetName(name string)          { d.name = name }
+func (d *testEventDevice) Start() error                 { return nil }
+func (d *testEventDevice) Halt() error                  { return nil }
+func (d *testEventDevice) Connection() gobot.Connection { return nil }
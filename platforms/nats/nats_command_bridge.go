@@ -0,0 +1,125 @@
+package nats
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/api"
+	"gobot.io/x/gobot/v2/pkg/metrics"
+)
+
+// DefaultCommandPrefix is the first subject token of the command bridge, if no prefix is given
+const DefaultCommandPrefix = "gobot"
+
+// AuthorizationHeader is the header of a command request with the bearer token of the caller
+const AuthorizationHeader = "Authorization"
+
+// ErrNotConnected is returned when the adaptor has no connection to the NATS server
+var ErrNotConnected = errors.New("NATS adaptor not connected")
+
+const subjectTokenSeparator = "."
+
+// commandSubjects are the subscriptions of the command bridge below the prefix, which mirror the command routes
+// of the API
+var commandSubjects = []string{
+	"commands",
+	"commands.*",
+	"robots.*.commands",
+	"robots.*.commands.*",
+	"robots.*.devices.*.commands",
+	"robots.*.devices.*.commands.*",
+}
+
+// CommandBridge serves the commands of the manager and its robots and devices as NATS request/reply subjects,
+// the same commands which are served by the API over HTTP. A request is sent to "<prefix>.<path>", e.g.
+// "gobot.robots.r2d2.devices.led.commands.Toggle", with the params as JSON object in the payload. The reply is
+// the JSON response, e.g. {"result": true} or {"error": "Unknown Command"}. A request without reply subject
+// executes the command only. Robots, devices and commands with a '.' in the name can not be addressed.
+//
+// Without SetAuth the bridge does not authenticate the senders of the requests, everyone who can publish to the
+// command subjects on the server can execute the commands. With SetAuth each request needs the bearer token of
+// its caller in the header AuthorizationHeader, which is verified and checked by the policy, requests without
+// valid token are rejected.
+type CommandBridge struct {
+	adaptor  *Adaptor
+	prefix   string
+	executor *api.CommandExecutor
+	subs     []*nats.Subscription
+}
+
+// NewCommandBridge creates a bridge for the commands of the manager. An empty prefix means DefaultCommandPrefix.
+func NewCommandBridge(a *Adaptor, manager *gobot.Manager, prefix string) *CommandBridge {
+	if prefix == "" {
+		prefix = DefaultCommandPrefix
+	}
+	return &CommandBridge{
+		adaptor: a,
+		prefix:  prefix,
+		executor: &api.CommandExecutor{
+			Manager:   manager,
+			Transport: "nats",
+		},
+	}
+}
+
+// SetAuth sets the verifier of the tokens of the callers, e.g. api.NewTokenAuth, and the policy, which grants
+// the commands to their roles. All commands of authenticated callers are allowed without policy.
+func (b *CommandBridge) SetAuth(auth api.TokenVerifier, policy *api.Policy) {
+	b.executor.Auth = auth
+	b.executor.Policy = policy
+}
+
+// SetAuditLog sets the logger, which records each command invocation with the caller
+func (b *CommandBridge) SetAuditLog(auditLog api.AuditLogger) { b.executor.AuditLog = auditLog }
+
+// SetMetrics sets the registry, which counts the command invocations, e.g. the registry of the API
+func (b *CommandBridge) SetMetrics(registry *metrics.Registry) { b.executor.Metrics = registry }
+
+// Prefix returns the first subject token(s) of all command subjects
+func (b *CommandBridge) Prefix() string { return b.prefix }
+
+// Start subscribes to the command subjects, the adaptor needs to be connected. The subscriptions use the core
+// NATS protocol, because JetStream consumers can not reply.
+func (b *CommandBridge) Start() error {
+	if b.adaptor.client == nil {
+		return ErrNotConnected
+	}
+
+	for _, subject := range commandSubjects {
+		sub, err := b.adaptor.client.Subscribe(b.prefix+subjectTokenSeparator+subject, b.handle)
+		if err != nil {
+			_ = b.Halt()
+			return err
+		}
+		b.subs = append(b.subs, sub)
+	}
+
+	return nil
+}
+
+// Halt removes the subscriptions of the command subjects
+func (b *CommandBridge) Halt() error {
+	var err error
+	for _, sub := range b.subs {
+		if uerr := sub.Unsubscribe(); uerr != nil && !errors.Is(uerr, nats.ErrConnectionClosed) {
+			err = gobot.AppendError(err, uerr)
+		}
+	}
+	b.subs = nil
+
+	return err
+}
+
+// handle executes the requested command and sends the reply
+func (b *CommandBridge) handle(msg *nats.Msg) {
+	path := strings.TrimPrefix(msg.Subject, b.prefix+subjectTokenSeparator)
+	token := msg.Header.Get(AuthorizationHeader)
+	response := b.executor.Execute(token, strings.Split(path, subjectTokenSeparator), msg.Data)
+
+	if msg.Reply != "" {
+		_ = msg.Respond(response)
+	}
+}
@@ -0,0 +1,120 @@
+package nats
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/api"
+)
+
+func newTestCommandManager() *gobot.Manager {
+	m := gobot.NewManager()
+	r := gobot.NewRobot("r2d2")
+	r.AddCommand("beep", func(params map[string]interface{}) interface{} {
+		return params["times"]
+	})
+	m.AddRobot(r)
+	return m
+}
+
+func TestNatsCommandBridge(t *testing.T) {
+	// arrange
+	server := newTestServer(t)
+	a := NewAdaptor(server.url(), 1)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	b := NewCommandBridge(a, newTestCommandManager(), "")
+	assert.Equal(t, DefaultCommandPrefix, b.Prefix())
+	require.NoError(t, b.Start())
+	requester, err := nats.Connect(server.url())
+	require.NoError(t, err)
+	defer requester.Close()
+	// act
+	reply, err := requester.Request("gobot.robots.r2d2.commands.beep", []byte(`{"times":3}`), 2*time.Second)
+	// assert
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"result":3}`, string(reply.Data))
+
+	reply, err = requester.Request("gobot.robots.r2d2.commands", nil, 2*time.Second)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"commands":["beep"]}`, string(reply.Data))
+
+	reply, err = requester.Request("gobot.robots.r2d2.devices.led.commands.Toggle", nil, 2*time.Second)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"error":"No Device found with the name led"}`, string(reply.Data))
+
+	require.NoError(t, b.Halt())
+	_, err = requester.Request("gobot.robots.r2d2.commands", nil, 100*time.Millisecond)
+	require.ErrorIs(t, err, nats.ErrTimeout)
+}
+
+func TestNatsCommandBridgeAuth(t *testing.T) {
+	// arrange
+	server := newTestServer(t)
+	a := NewAdaptor(server.url(), 1)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	b := NewCommandBridge(a, newTestCommandManager(), "")
+	auth := api.NewTokenAuth([]byte("secret"))
+	b.SetAuth(auth, api.NewPolicy(api.Rule{Role: "operator", Action: api.ActionCommand, Name: "beep"}))
+	auditLog := &bytes.Buffer{}
+	b.SetAuditLog(api.NewAuditLog(auditLog))
+	require.NoError(t, b.Start())
+	requester, err := nats.Connect(server.url())
+	require.NoError(t, err)
+	defer requester.Close()
+	token, err := auth.Issue(api.Identity{Subject: "alice", Roles: []string{"operator"}}, 0)
+	require.NoError(t, err)
+	request := func(subject string, data []byte, token string) string {
+		msg := nats.NewMsg(subject)
+		msg.Data = data
+		if token != "" {
+			msg.Header.Set(AuthorizationHeader, "Bearer "+token)
+		}
+		reply, err := requester.RequestMsg(msg, 2*time.Second)
+		require.NoError(t, err)
+		return string(reply.Data)
+	}
+	// act
+	reply := request("gobot.robots.r2d2.commands.beep", []byte(`{"times":3}`), token)
+	// assert
+	assert.JSONEq(t, `{"result":3}`, reply)
+	var entry api.AuditEntry
+	require.NoError(t, json.Unmarshal(auditLog.Bytes(), &entry))
+	assert.Equal(t, "alice", entry.Subject)
+	assert.Equal(t, "nats", entry.Transport)
+	assert.Equal(t, map[string]interface{}{"times": float64(3)}, entry.Params)
+	assert.True(t, entry.Allowed)
+
+	assert.JSONEq(t, `{"error":"Forbidden"}`, request("gobot.robots.r2d2.commands", nil, token))
+	assert.JSONEq(t, `{"error":"missing bearer token"}`, request("gobot.robots.r2d2.commands.beep", nil, ""))
+}
+
+func TestNatsCommandBridgePrefix(t *testing.T) {
+	server := newTestServer(t)
+	a := NewAdaptor(server.url(), 1)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	require.NoError(t, NewCommandBridge(a, newTestCommandManager(), "factory.line1").Start())
+	requester, err := nats.Connect(server.url())
+	require.NoError(t, err)
+	defer requester.Close()
+
+	reply, err := requester.Request("factory.line1.robots.r2d2.commands.beep", []byte(`{"times":1}`), 2*time.Second)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"result":1}`, string(reply.Data))
+}
+
+func TestNatsCommandBridgeNotConnected(t *testing.T) {
+	b := NewCommandBridge(NewAdaptor("localhost:4222", 1), newTestCommandManager(), "")
+
+	require.ErrorIs(t, b.Start(), ErrNotConnected)
+}
@@ -0,0 +1,71 @@
+package nats
+
+import (
+	"errors"
+
+	"github.com/nats-io/nats.go"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/bridge"
+)
+
+// DefaultEventNaming is the subject template of the event bridge, if no other naming is given by
+// bridge.WithEventNaming
+const DefaultEventNaming = DefaultCommandPrefix + ".robots.{robot}.devices.{device}.events.{event}"
+
+// EventBridge publishes the events of devices to NATS subjects, which are created from a template like
+// DefaultEventNaming. The events are published by core NATS, not JetStream. With bridge.WithEventInjection(), a
+// message sent to "<event subject>.inject" is published as event by the device.
+type EventBridge struct {
+	*bridge.EventBridge
+	adaptor *Adaptor
+	sub     *nats.Subscription
+}
+
+// NewEventBridge creates a bridge for the device events of the manager's robots, see the options of the bridge
+// package for the selection of events, the encoding and the rate limit.
+func NewEventBridge(a *Adaptor, manager *gobot.Manager, opts ...bridge.EventBridgeOption) *EventBridge {
+	b := &EventBridge{adaptor: a}
+	publish := func(subject string, payload []byte) error {
+		if a.client == nil {
+			return ErrNotConnected
+		}
+		return a.client.Publish(subject, payload)
+	}
+	opts = append([]bridge.EventBridgeOption{bridge.WithEventNaming(DefaultEventNaming, subjectTokenSeparator)}, opts...)
+	b.EventBridge = bridge.NewEventBridge(manager, publish, opts...)
+	return b
+}
+
+// Start subscribes to the device events and, if enabled, to the injection subjects. The adaptor needs to be
+// connected.
+func (b *EventBridge) Start() error {
+	if b.adaptor.client == nil {
+		return ErrNotConnected
+	}
+
+	if b.InjectionEnabled() {
+		sub, err := b.adaptor.client.Subscribe(b.InjectionFilter("*"), func(msg *nats.Msg) {
+			_ = b.Inject(msg.Subject, msg.Data)
+		})
+		if err != nil {
+			return err
+		}
+		b.sub = sub
+	}
+
+	return b.EventBridge.Start()
+}
+
+// Halt removes the subscriptions of the device events and the injection subjects
+func (b *EventBridge) Halt() error {
+	err := b.EventBridge.Halt()
+	if b.sub != nil {
+		if uerr := b.sub.Unsubscribe(); uerr != nil && !errors.Is(uerr, nats.ErrConnectionClosed) {
+			err = gobot.AppendError(err, uerr)
+		}
+		b.sub = nil
+	}
+
+	return err
+}
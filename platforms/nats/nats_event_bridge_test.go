@@ -0,0 +1,57 @@
+package nats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/pkg/bridge"
+)
+
+func TestNatsEventBridge(t *testing.T) {
+	// arrange
+	server := newTestServer(t)
+	a := NewAdaptor(server.url(), 1)
+	require.NoError(t, a.Connect())
+	defer func() { _ = a.Finalize() }()
+	d := newTestEventDevice("button", "push")
+	m := gobot.NewManager()
+	m.AddRobot(gobot.NewRobot("r2d2", []gobot.Device{d}))
+	b := NewEventBridge(a, m, bridge.WithEventInjection(), bridge.WithEventEncoding(bridge.EventEncodingRaw))
+	require.NoError(t, b.Start())
+	defer func() { _ = b.Halt() }()
+	client, err := nats.Connect(server.url())
+	require.NoError(t, err)
+	defer client.Close()
+	sub, err := client.SubscribeSync("gobot.robots.r2d2.devices.button.events.>")
+	require.NoError(t, err)
+	require.NoError(t, client.Flush())
+	// act
+	d.Publish("push", []byte("1"))
+	// assert
+	msg, err := sub.NextMsg(2 * time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "gobot.robots.r2d2.devices.button.events.push", msg.Subject)
+	assert.Equal(t, []byte("1"), msg.Data)
+
+	// inject
+	received := make(chan interface{}, 1)
+	require.NoError(t, d.Once("push", func(data interface{}) { received <- data }))
+	require.NoError(t, client.Publish("gobot.robots.r2d2.devices.button.events.push.inject", []byte("2")))
+	select {
+	case data := <-received:
+		assert.Equal(t, []byte("2"), data)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not injected")
+	}
+}
+
+func TestNatsEventBridgeNotConnected(t *testing.T) {
+	b := NewEventBridge(NewAdaptor("localhost:4222", 1), gobot.NewManager())
+
+	require.ErrorIs(t, b.Start(), ErrNotConnected)
+}